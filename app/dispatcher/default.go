@@ -275,6 +275,7 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 			result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 			if err == nil {
 				content.Protocol = result.Protocol()
+				setTLSAttributes(content, result)
 			}
 			if err == nil && d.shouldOverride(ctx, result, sniffingRequest, destination) {
 				domain := result.Domain()
@@ -329,6 +330,7 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		result, err := sniffer(ctx, cReader, sniffingRequest.MetadataOnly, destination.Network)
 		if err == nil {
 			content.Protocol = result.Protocol()
+			setTLSAttributes(content, result)
 		}
 		if err == nil && d.shouldOverride(ctx, result, sniffingRequest, destination) {
 			domain := result.Domain()
@@ -354,6 +356,17 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 	return nil
 }
 
+// setTLSAttributes keeps the server name and ALPN of sniffed TLS in content.
+func setTLSAttributes(content *session.Content, result SniffResult) {
+	if result.Protocol() != "tls" {
+		return
+	}
+	content.SetAttribute(session.AttributeSNI, result.Domain())
+	if r, ok := result.(interface{ ALPN() []string }); ok && len(r.ALPN()) > 0 {
+		content.SetAttribute(session.AttributeALPN, strings.Join(r.ALPN(), ","))
+	}
+}
+
 func sniffer(ctx context.Context, cReader *cachedReader, metadataOnly bool, network net.Network) (SniffResult, error) {
 	payload := buf.New()
 	defer payload.Release()
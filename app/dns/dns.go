@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/HZ-PRE/XrarCore/app/router"
 	"github.com/HZ-PRE/XrarCore/common"
@@ -13,6 +14,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/session"
 	"github.com/HZ-PRE/XrarCore/common/strmatcher"
+	"github.com/HZ-PRE/XrarCore/common/task"
 	"github.com/HZ-PRE/XrarCore/features/dns"
 )
 
@@ -29,6 +31,8 @@ type DNS struct {
 	ctx                    context.Context
 	domainMatcher          strmatcher.IndexMatcher
 	matcherInfos           []*DomainMatcherInfo
+	records                map[string]*recordCacheItem
+	recordsCleanup         *task.Periodic
 }
 
 // DomainMatcherInfo contains information attached to index returned by Server.domainMatcher
@@ -120,7 +124,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 		clients = append(clients, NewLocalDNSClient())
 	}
 
-	return &DNS{
+	s := &DNS{
 		tag:                    tag,
 		hosts:                  hosts,
 		ipOption:               ipOption,
@@ -131,7 +135,13 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 		disableCache:           config.DisableCache,
 		disableFallback:        config.DisableFallback,
 		disableFallbackIfMatch: config.DisableFallbackIfMatch,
-	}, nil
+		records:                make(map[string]*recordCacheItem),
+	}
+	s.recordsCleanup = &task.Periodic{
+		Interval: time.Minute,
+		Execute:  s.cleanupRecords,
+	}
+	return s, nil
 }
 
 // Type implements common.HasType.
@@ -146,7 +156,7 @@ func (s *DNS) Start() error {
 
 // Close implements common.Closable.
 func (s *DNS) Close() error {
-	return nil
+	return s.recordsCleanup.Close()
 }
 
 // IsOwnLink implements proxy.dns.ownLinkVerifier
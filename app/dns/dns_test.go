@@ -1,6 +1,7 @@
 package dns_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -59,6 +60,10 @@ func (*staticHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 			rr, _ := dns.NewRR("v2.api.google.com. IN A 8.8.7.8")
 			ans.Answer = append(ans.Answer, rr)
 
+		case q.Name == "google.com." && q.Qtype == dns.TypeTXT:
+			rr, _ := dns.NewRR(`google.com. IN TXT "v=spf1 -all"`)
+			ans.Answer = append(ans.Answer, rr)
+
 		case q.Name == "facebook.com." && q.Qtype == dns.TypeA:
 			rr, _ := dns.NewRR("facebook.com. IN A 9.9.9.9")
 			ans.Answer = append(ans.Answer, rr)
@@ -1061,3 +1066,65 @@ func TestMultiMatchPrioritizedDomain(t *testing.T) {
 		t.Error("DNS query doesn't finish in 2 seconds.")
 	}
 }
+
+func TestUDPServerRecords(t *testing.T) {
+	port := udp.PickPort()
+
+	dnsServer := dns.Server{
+		Addr:    "127.0.0.1:" + port.String(),
+		Net:     "udp",
+		Handler: &staticHandler{},
+		UDPSize: 1200,
+	}
+
+	go dnsServer.ListenAndServe()
+	time.Sleep(time.Second)
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&Config{
+				NameServer: []*NameServer{
+					{
+						Address: &net.Endpoint{
+							Network: net.Network_UDP,
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{127, 0, 0, 1},
+								},
+							},
+							Port: uint32(port),
+						},
+					},
+				},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{
+			{
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			},
+		},
+	}
+
+	v, err := core.New(config)
+	common.Must(err)
+
+	client := v.GetFeature(feature_dns.ClientType()).(feature_dns.RecordLookup)
+
+	records, err := client.LookupRecords(context.Background(), "google.com", dns.TypeTXT)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if len(records) != 1 {
+		t.Fatal("expected 1 record, but got ", len(records))
+	}
+	if r := cmp.Diff(records[0].(*dns.TXT).Txt, []string{"v=spf1 -all"}); r != "" {
+		t.Fatal(r)
+	}
+
+	if _, err := client.LookupRecords(context.Background(), "facebook.com", dns.TypeTXT); err == nil {
+		t.Fatal("nil error")
+	}
+}
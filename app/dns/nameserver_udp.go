@@ -28,6 +28,7 @@ type ClassicNameServer struct {
 	address       *net.Destination
 	ips           map[string]*record
 	requests      map[uint16]*dnsRequest
+	rawRequests   map[uint16]chan []byte
 	pub           *pubsub.Service
 	udpServer     *udp.Dispatcher
 	cleanup       *task.Periodic
@@ -46,6 +47,7 @@ func NewClassicNameServer(address net.Destination, dispatcher routing.Dispatcher
 		address:       &address,
 		ips:           make(map[string]*record),
 		requests:      make(map[uint16]*dnsRequest),
+		rawRequests:   make(map[uint16]chan []byte),
 		pub:           pubsub.NewService(),
 		name:          strings.ToUpper(address.String()),
 		queryStrategy: queryStrategy,
@@ -109,6 +111,10 @@ func (s *ClassicNameServer) Cleanup() error {
 
 // HandleResponse handles udp response packet from remote DNS server.
 func (s *ClassicNameServer) HandleResponse(ctx context.Context, packet *udp_proto.Packet) {
+	if s.handleRawResponse(packet.Payload.Bytes()) {
+		return
+	}
+
 	ipRec, err := parseResponse(packet.Payload.Bytes())
 	if err != nil {
 		errors.LogError(ctx, s.name, " fail to parse responded DNS udp")
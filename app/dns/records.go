@@ -0,0 +1,344 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	gonet "net"
+	"strings"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/log"
+	"github.com/HZ-PRE/XrarCore/common/session"
+	dns_feature "github.com/HZ-PRE/XrarCore/features/dns"
+	mdns "github.com/miekg/dns"
+)
+
+// recordServer is implemented by name servers that can answer queries of any type.
+type recordServer interface {
+	queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error)
+}
+
+// rawExchanger sends a packed DNS query and returns the packed response.
+type rawExchanger func(ctx context.Context, msg []byte) ([]byte, error)
+
+// recordCacheItem is a cached answer of LookupRecords.
+type recordCacheItem struct {
+	records []mdns.RR
+	expire  time.Time
+}
+
+// minRecordTTL is the lower bound for caching answers of LookupRecords.
+const minRecordTTL = 10
+
+// cleanupRecords removes expired answers of LookupRecords. It stops the periodic cleanup when there
+// is nothing left to clean up.
+func (s *DNS) cleanupRecords() error {
+	now := time.Now()
+	s.Lock()
+	defer s.Unlock()
+
+	for key, item := range s.records {
+		if !item.expire.After(now) {
+			delete(s.records, key)
+		}
+	}
+	if len(s.records) == 0 {
+		return errors.New("no cached records. stopping...")
+	}
+	return nil
+}
+
+// queryRecordsWith builds a query for domain and qType, exchanges it, and returns the matching answer
+// records along with the TTL for caching them.
+func queryRecordsWith(ctx context.Context, domain string, qType uint16, exchange rawExchanger) ([]mdns.RR, uint32, error) {
+	req := new(mdns.Msg)
+	req.SetQuestion(mdns.Fqdn(domain), qType)
+	req.SetEdns0(1232, false)
+	b, err := req.Pack()
+	if err != nil {
+		return nil, 0, errors.New("failed to pack record query").Base(err)
+	}
+
+	var deadline time.Time
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	} else {
+		deadline = time.Now().Add(time.Second * 5)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	respBytes, err := exchange(ctx, b)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := new(mdns.Msg)
+	if err := resp.Unpack(respBytes); err != nil {
+		return nil, 0, errors.New("failed to parse record response").Base(err)
+	}
+	if resp.Id != req.Id {
+		return nil, 0, errors.New("mismatched record response ID")
+	}
+	if resp.Rcode != mdns.RcodeSuccess {
+		return nil, 0, dns_feature.RCodeError(resp.Rcode)
+	}
+
+	var records []mdns.RR
+	ttl := uint32(600)
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qType {
+			continue
+		}
+		records = append(records, rr)
+		ttl = min(ttl, rr.Header().Ttl)
+	}
+	if len(records) == 0 {
+		return nil, 0, dns_feature.ErrEmptyResponse
+	}
+	return records, ttl, nil
+}
+
+// exchangeStream exchanges a DNS message over a stream connection, with the 2-byte length prefix (RFC 1035 4.2.2).
+func exchangeStream(conn io.ReadWriter, msg []byte) ([]byte, error) {
+	req := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(req, uint16(len(msg)))
+	copy(req[2:], msg)
+	if _, err := conn.Write(req); err != nil {
+		return nil, errors.New("failed to send query").Base(err)
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, errors.New("failed to read response length").Base(err)
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, errors.New("failed to read response").Base(err)
+	}
+	return resp, nil
+}
+
+// queryContext returns the context for sending record queries with the given protocol.
+func queryContext(ctx context.Context, protocol string) context.Context {
+	return session.ContextWithContent(ctx, &session.Content{
+		Protocol:       protocol,
+		SkipDNSResolve: true,
+	})
+}
+
+// queryRecords implements recordServer.
+func (s *ClassicNameServer) queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error) {
+	return queryRecordsWith(ctx, domain, qType, func(ctx context.Context, msg []byte) ([]byte, error) {
+		// Replace the ID to avoid conflicts with pending IP queries.
+		origID := binary.BigEndian.Uint16(msg)
+		id := s.newReqID()
+		binary.BigEndian.PutUint16(msg, id)
+
+		ch := make(chan []byte, 1)
+		s.Lock()
+		s.rawRequests[id] = ch
+		s.Unlock()
+		defer func() {
+			s.Lock()
+			delete(s.rawRequests, id)
+			s.Unlock()
+		}()
+
+		s.udpServer.Dispatch(toDnsContext(queryContext(ctx, "dns"), s.address.String()), *s.address, buf.FromBytes(msg))
+		select {
+		case resp := <-ch:
+			binary.BigEndian.PutUint16(resp, origID)
+			return resp, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+// handleRawResponse delivers the response to a pending record query. It returns false if the
+// response doesn't belong to any record query.
+func (s *ClassicNameServer) handleRawResponse(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	id := binary.BigEndian.Uint16(payload)
+	s.Lock()
+	ch, found := s.rawRequests[id]
+	delete(s.rawRequests, id)
+	s.Unlock()
+	if !found {
+		return false
+	}
+	ch <- append([]byte(nil), payload...)
+	return true
+}
+
+// queryRecords implements recordServer.
+func (s *TCPNameServer) queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error) {
+	return queryRecordsWith(ctx, domain, qType, func(ctx context.Context, msg []byte) ([]byte, error) {
+		conn, err := s.dial(queryContext(ctx, "dns"))
+		if err != nil {
+			return nil, errors.New("failed to dial nameserver").Base(err)
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		return exchangeStream(conn, msg)
+	})
+}
+
+// queryRecords implements recordServer.
+func (s *QUICNameServer) queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error) {
+	return queryRecordsWith(ctx, domain, qType, func(ctx context.Context, msg []byte) ([]byte, error) {
+		// RFC 9250 4.2.1: the message ID must be 0 in DNS over QUIC.
+		origID := binary.BigEndian.Uint16(msg)
+		binary.BigEndian.PutUint16(msg, 0)
+
+		stream, err := s.openStream(queryContext(ctx, "quic"))
+		if err != nil {
+			return nil, errors.New("failed to open quic connection").Base(err)
+		}
+		defer stream.CancelRead(0)
+		if deadline, ok := ctx.Deadline(); ok {
+			stream.SetDeadline(deadline)
+		}
+		resp, err := exchangeStream(&quicStreamWriter{stream}, msg)
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(resp, origID)
+		return resp, nil
+	})
+}
+
+// quicStreamWriter closes the send direction of the stream after writing the query, as DNS over QUIC requires.
+type quicStreamWriter struct {
+	io.ReadWriteCloser
+}
+
+func (w *quicStreamWriter) Write(b []byte) (int, error) {
+	n, err := w.ReadWriteCloser.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, w.ReadWriteCloser.Close()
+}
+
+// queryRecords implements recordServer.
+func (s *DoHNameServer) queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error) {
+	if s.name+"." == "DOH//"+mdns.Fqdn(domain) {
+		return nil, 0, errors.New(s.name, " tries to resolve itself! Use IP or set \"hosts\" instead.")
+	}
+	return queryRecordsWith(ctx, domain, qType, func(ctx context.Context, msg []byte) ([]byte, error) {
+		return s.dohHTTPSContext(queryContext(ctx, "https"), msg)
+	})
+}
+
+// queryRecords implements recordServer. Only SRV and TXT records are supported by the system resolver.
+func (s *LocalNameServer) queryRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, uint32, error) {
+	fqdn := mdns.Fqdn(domain)
+	var records []mdns.RR
+	switch qType {
+	case mdns.TypeSRV:
+		_, srvs, err := gonet.DefaultResolver.LookupSRV(ctx, "", "", domain)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, srv := range srvs {
+			records = append(records, &mdns.SRV{
+				Hdr:      mdns.RR_Header{Name: fqdn, Rrtype: mdns.TypeSRV, Class: mdns.ClassINET, Ttl: 600},
+				Priority: srv.Priority,
+				Weight:   srv.Weight,
+				Port:     srv.Port,
+				Target:   srv.Target,
+			})
+		}
+	case mdns.TypeTXT:
+		txts, err := gonet.DefaultResolver.LookupTXT(ctx, domain)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, txt := range txts {
+			records = append(records, &mdns.TXT{
+				Hdr: mdns.RR_Header{Name: fqdn, Rrtype: mdns.TypeTXT, Class: mdns.ClassINET, Ttl: 600},
+				Txt: []string{txt},
+			})
+		}
+	default:
+		return nil, 0, errors.New("record type ", mdns.Type(qType).String(), " is not supported by the local DNS")
+	}
+	if len(records) == 0 {
+		return nil, 0, dns_feature.ErrEmptyResponse
+	}
+	return records, 600, nil
+}
+
+// LookupRecords implements dns.RecordLookup.
+func (s *DNS) LookupRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if domain == "" {
+		return nil, errors.New("empty domain name")
+	}
+	typeName := mdns.Type(qType).String()
+
+	key := domain + "/" + typeName
+	if !s.disableCache {
+		s.Lock()
+		item, found := s.records[key]
+		s.Unlock()
+		if found && item.expire.After(time.Now()) {
+			errors.LogDebug(s.ctx, "record cache HIT ", domain, " ", typeName)
+			return item.records, nil
+		}
+	}
+
+	// Queries are canceled along with ctx, but carry the values of s.ctx, so that they are routed
+	// as queries of the DNS, instead of as the request ctx belongs to.
+	queryCtx, cancel := context.WithCancel(session.ContextWithInbound(s.ctx, &session.Inbound{Tag: s.tag}))
+	defer cancel()
+	defer context.AfterFunc(ctx, cancel)()
+	if deadline, ok := ctx.Deadline(); ok {
+		queryCtx, cancel = context.WithDeadline(queryCtx, deadline)
+		defer cancel()
+	}
+
+	errs := []error{}
+	for _, client := range s.sortClients(domain) {
+		server, ok := client.server.(recordServer)
+		if !ok {
+			errors.LogDebug(s.ctx, "skip ", typeName, " query for domain ", domain, " at server ", client.Name())
+			continue
+		}
+		start := time.Now()
+		records, ttl, err := server.queryRecords(queryCtx, domain, qType)
+		if len(records) > 0 {
+			errors.LogInfo(s.ctx, client.Name(), " got ", len(records), " ", typeName, " record(s) for ", domain, " ", time.Since(start))
+			if !s.disableCache {
+				s.Lock()
+				s.records[key] = &recordCacheItem{
+					records: records,
+					expire:  time.Now().Add(time.Duration(max(ttl, minRecordTTL)) * time.Second),
+				}
+				s.Unlock()
+				common.Must(s.recordsCleanup.Start())
+			}
+			return records, nil
+		}
+		if err != nil {
+			errors.LogInfoInner(s.ctx, err, "failed to lookup ", typeName, " records for domain ", domain, " at server ", client.Name())
+			log.Record(&log.DNSLog{Server: client.Name(), Domain: domain, Status: log.DNSQueried, Elapsed: time.Since(start), Error: err})
+			errs = append(errs, err)
+		}
+		// 5 for RcodeRefused in miekg/dns, hardcode to reduce binary size
+		if err != context.Canceled && err != context.DeadlineExceeded && err != dns_feature.ErrEmptyResponse && dns_feature.RCodeFromError(err) != 5 {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("returning nil ", typeName, " records for domain ", domain).Base(errors.Combine(errs...))
+}
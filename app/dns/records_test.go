@@ -0,0 +1,30 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCleanupRecords(t *testing.T) {
+	s := &DNS{records: map[string]*recordCacheItem{
+		"expired.example.com/TXT": {expire: time.Now().Add(-time.Second)},
+		"example.com/TXT":         {expire: time.Now().Add(time.Minute)},
+	}}
+	if err := s.cleanupRecords(); err != nil {
+		t.Fatal("expect the cleanup to continue, but got ", err)
+	}
+	if _, found := s.records["expired.example.com/TXT"]; found {
+		t.Error("expired records are not removed")
+	}
+	if _, found := s.records["example.com/TXT"]; !found {
+		t.Error("unexpired records are removed")
+	}
+
+	s.records["example.com/TXT"].expire = time.Now()
+	if err := s.cleanupRecords(); err == nil {
+		t.Error("expect the cleanup to stop without records")
+	}
+	if len(s.records) != 0 {
+		t.Error("expect no records, but got ", len(s.records))
+	}
+}
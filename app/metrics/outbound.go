@@ -8,6 +8,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/net/cnc"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/common/signal/done"
 	"github.com/HZ-PRE/XrarCore/transport"
 )
@@ -92,6 +93,16 @@ func (co *Outbound) Tag() string {
 	return co.tag
 }
 
+// SenderSettings implements outbound.Handler.
+func (co *Outbound) SenderSettings() *serial.TypedMessage {
+	return nil
+}
+
+// ProxySettings implements outbound.Handler.
+func (co *Outbound) ProxySettings() *serial.TypedMessage {
+	return nil
+}
+
 // Start implements common.Runnable.
 func (co *Outbound) Start() error {
 	co.access.Lock()
@@ -6,11 +6,13 @@ import (
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/core"
 	"github.com/HZ-PRE/XrarCore/features/inbound"
 	"github.com/HZ-PRE/XrarCore/features/outbound"
 	"github.com/HZ-PRE/XrarCore/proxy"
 	grpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // InboundOperation is the interface for operations that applies to inbound handlers.
@@ -139,6 +141,59 @@ func (s *handlerServer) GetInboundUsersCount(ctx context.Context, request *GetIn
 	return &GetInboundUsersCountResponse{Count: um.GetUsersCount(ctx)}, nil
 }
 
+func (s *handlerServer) ListInbounds(ctx context.Context, request *ListInboundsRequest) (*ListInboundsResponse, error) {
+	handlers := s.ihm.ListHandlers(ctx)
+	result := make([]*core.InboundHandlerConfig, 0, len(handlers))
+	for _, handler := range handlers {
+		proxySettings := handler.ProxySettings()
+		if proxySettings == nil {
+			continue
+		}
+		// The users may be altered after the handler is created.
+		if p, err := getInbound(handler); err == nil {
+			if um, ok := p.(proxy.UserManager); ok {
+				settings, err := withUsers(proxySettings, um.GetUsers(ctx))
+				if err != nil {
+					return nil, errors.New("failed to get settings of inbound: ", handler.Tag()).Base(err)
+				}
+				proxySettings = settings
+			}
+		}
+		result = append(result, &core.InboundHandlerConfig{
+			Tag:              handler.Tag(),
+			ReceiverSettings: handler.ReceiverSettings(),
+			ProxySettings:    proxySettings,
+		})
+	}
+	return &ListInboundsResponse{Inbounds: result}, nil
+}
+
+// withUsers returns the proxy settings with the users replaced.
+func withUsers(settings *serial.TypedMessage, users []*protocol.MemoryUser) (*serial.TypedMessage, error) {
+	config, err := settings.GetInstance()
+	if err != nil {
+		return nil, err
+	}
+	m := config.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !fd.IsList() || fd.Message() == nil || fd.Message().FullName() != "xray.common.protocol.User" {
+			continue
+		}
+		list := m.Mutable(fd).List()
+		list.Truncate(0)
+		for _, u := range users {
+			list.Append(protoreflect.ValueOfMessage(protocol.ToProtoUser(u).ProtoReflect()))
+		}
+		if list.Len() == 0 {
+			m.Clear(fd)
+		}
+		return serial.ToTypedMessage(config), nil
+	}
+	return settings, nil
+}
+
 func (s *handlerServer) AddOutbound(ctx context.Context, request *AddOutboundRequest) (*AddOutboundResponse, error) {
 	if err := core.AddOutboundHandler(s.s, request.Outbound); err != nil {
 		return nil, err
@@ -164,6 +219,23 @@ func (s *handlerServer) AlterOutbound(ctx context.Context, request *AlterOutboun
 	return &AlterOutboundResponse{}, operation.ApplyOutbound(ctx, handler)
 }
 
+func (s *handlerServer) ListOutbounds(ctx context.Context, request *ListOutboundsRequest) (*ListOutboundsResponse, error) {
+	handlers := s.ohm.ListHandlers(ctx)
+	result := make([]*core.OutboundHandlerConfig, 0, len(handlers))
+	for _, handler := range handlers {
+		proxySettings := handler.ProxySettings()
+		if proxySettings == nil {
+			continue
+		}
+		result = append(result, &core.OutboundHandlerConfig{
+			Tag:            handler.Tag(),
+			SenderSettings: handler.SenderSettings(),
+			ProxySettings:  proxySettings,
+		})
+	}
+	return &ListOutboundsResponse{Outbounds: result}, nil
+}
+
 func (s *handlerServer) mustEmbedUnimplementedHandlerServiceServer() {}
 
 type service struct {
@@ -2,6 +2,7 @@ package inbound
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/HZ-PRE/XrarCore/app/proxyman"
@@ -69,6 +70,24 @@ func (m *Manager) GetHandler(ctx context.Context, tag string) (inbound.Handler,
 	return handler, nil
 }
 
+// ListHandlers implements inbound.Manager.
+func (m *Manager) ListHandlers(ctx context.Context) []inbound.Handler {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	tags := make([]string, 0, len(m.taggedHandlers))
+	for tag := range m.taggedHandlers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	handlers := make([]inbound.Handler, 0, len(m.taggedHandlers)+len(m.untaggedHandler))
+	for _, tag := range tags {
+		handlers = append(handlers, m.taggedHandlers[tag])
+	}
+	return append(handlers, m.untaggedHandler...)
+}
+
 // RemoveHandler implements inbound.Manager.
 func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 	if tag == "" {
@@ -16,6 +16,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/mux"
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/net/cnc"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/common/session"
 	"github.com/HZ-PRE/XrarCore/core"
 	"github.com/HZ-PRE/XrarCore/features/outbound"
@@ -27,6 +28,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 	"github.com/HZ-PRE/XrarCore/transport/pipe"
+	"google.golang.org/protobuf/proto"
 )
 
 func getStatCounter(v *core.Instance, tag string) (stats.Counter, stats.Counter) {
@@ -60,10 +62,12 @@ type Handler struct {
 	senderSettings  *proxyman.SenderConfig
 	streamSettings  *internet.MemoryStreamConfig
 	proxy           proxy.Outbound
+	proxyConfig     proto.Message
 	outboundManager outbound.Manager
 	mux             *mux.ClientManager
 	xudp            *mux.ClientManager
 	udp443          string
+	pool            *connPool
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
 }
@@ -101,6 +105,7 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 	if err != nil {
 		return nil, err
 	}
+	h.proxyConfig = proxyConfig
 
 	rawProxyHandler, err := common.CreateObject(ctx, proxyConfig)
 	if err != nil {
@@ -160,10 +165,26 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 		}
 	}
 
+	if h.senderSettings != nil && h.senderSettings.ConnectionPool.GetSize() > 0 {
+		if h.senderSettings.ProxySettings.HasTag() || h.viaOrigin() {
+			errors.LogWarning(ctx, "connection pool of outbound ", h.tag, " is disabled, as it doesn't work with proxySettings or sendThrough origin")
+		} else {
+			h.pool = newConnPool(ctx, h.senderSettings.ConnectionPool, h.streamSettings, h.dialPooled)
+		}
+	}
+
 	h.proxy = proxyHandler
 	return h, nil
 }
 
+func (h *Handler) viaOrigin() bool {
+	if h.senderSettings == nil || h.senderSettings.Via == nil || h.senderSettings.ViaCidr != "" {
+		return false
+	}
+	via := h.senderSettings.Via.AsAddress()
+	return via.Family().IsDomain() && via.Domain() == "origin"
+}
+
 // Tag implements outbound.Handler.
 func (h *Handler) Tag() string {
 	return h.tag
@@ -259,7 +280,7 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 				conn := cnc.NewConnection(cnc.ConnectionInputMulti(uplinkWriter), cnc.ConnectionOutputMulti(downlinkReader))
 
 				if config := tls.ConfigFromStreamSettings(h.streamSettings); config != nil {
-					tlsConfig := config.GetTLSConfig(tls.WithDestination(dest))
+					tlsConfig := config.GetTLSConfigContext(ctx, tls.WithDestination(dest))
 					conn = tls.Client(conn, tlsConfig)
 				}
 
@@ -293,6 +314,17 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 		return conn, err
 	}
 
+	if h.pool != nil && dest.Network == net.Network_TCP {
+		if conn := h.pool.Get(ctx, dest); conn != nil {
+			errors.LogDebug(ctx, "using pooled connection to ", dest)
+			conn = h.getStatCouterConnection(conn)
+			outbounds := session.OutboundsFromContext(ctx)
+			ob := outbounds[len(outbounds)-1]
+			ob.Conn = conn
+			return conn, nil
+		}
+	}
+
 	conn, err := internet.Dial(ctx, dest, h.streamSettings)
 	conn = h.getStatCouterConnection(conn)
 	outbounds := session.OutboundsFromContext(ctx)
@@ -301,6 +333,22 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 	return conn, err
 }
 
+// dialPooled dials a connection for the connection pool, outside of any request.
+func (h *Handler) dialPooled(ctx context.Context, dest net.Destination) (stat.Connection, error) {
+	ob := &session.Outbound{
+		Target: dest,
+		Tag:    h.tag,
+	}
+	if via := h.senderSettings.Via; via != nil {
+		if h.senderSettings.ViaCidr == "" {
+			ob.Gateway = via.AsAddress()
+		} else {
+			ob.Gateway = ParseRandomIPv6(via.AsAddress(), h.senderSettings.ViaCidr)
+		}
+	}
+	return internet.Dial(session.ContextWithOutbounds(ctx, []*session.Outbound{ob}), dest, h.streamSettings)
+}
+
 func (h *Handler) getStatCouterConnection(conn stat.Connection) stat.Connection {
 	if h.uplinkCounter != nil || h.downlinkCounter != nil {
 		return &stat.CounterConnection{
@@ -317,14 +365,33 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 	return h.proxy
 }
 
+// SenderSettings implements outbound.Handler.
+func (h *Handler) SenderSettings() *serial.TypedMessage {
+	if h.senderSettings == nil {
+		return nil
+	}
+	return serial.ToTypedMessage(h.senderSettings)
+}
+
+// ProxySettings implements outbound.Handler.
+func (h *Handler) ProxySettings() *serial.TypedMessage {
+	return serial.ToTypedMessage(h.proxyConfig)
+}
+
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
+	if h.pool != nil {
+		return h.pool.Start()
+	}
 	return nil
 }
 
 // Close implements common.Closable.
 func (h *Handler) Close() error {
 	common.Close(h.mux)
+	if h.pool != nil {
+		h.pool.Close()
+	}
 	return nil
 }
 
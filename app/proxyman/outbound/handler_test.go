@@ -3,6 +3,8 @@ package outbound_test
 import (
 	"context"
 	"fmt"
+	"io"
+	gonet "net"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/app/proxyman"
 	. "github.com/HZ-PRE/XrarCore/app/proxyman/outbound"
 	"github.com/HZ-PRE/XrarCore/app/stats"
+	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/common/session"
@@ -19,6 +22,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/features/outbound"
 	"github.com/HZ-PRE/XrarCore/proxy/freedom"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+	_ "github.com/HZ-PRE/XrarCore/transport/internet/tcp"
 )
 
 func TestInterfaces(t *testing.T) {
@@ -174,3 +178,139 @@ func TestTagsCache(t *testing.T) {
 	stop_get = true
 	wg_get.Wait()
 }
+
+func TestOutboundConnectionPool(t *testing.T) {
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	var access sync.Mutex
+	var accepted []gonet.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			access.Lock()
+			accepted = append(accepted, conn)
+			access.Unlock()
+			go io.Copy(conn, conn)
+		}
+	}()
+	acceptedAddrs := func() map[string]bool {
+		access.Lock()
+		defer access.Unlock()
+		addrs := make(map[string]bool)
+		for _, conn := range accepted {
+			addrs[conn.RemoteAddr().String()] = true
+		}
+		return addrs
+	}
+	dest := net.DestinationFromAddr(listener.Addr())
+
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ConnectionPool: &proxyman.ConnectionPoolConfig{Size: 2},
+		}),
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+	common.Must(h.Start())
+	defer h.Close()
+
+	echo := func(conn stat.Connection) {
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 4)
+		if _, err := io.ReadFull(conn, b); err != nil || string(b) != "ping" {
+			t.Fatal("unexpected echo ", string(b), " ", err)
+		}
+	}
+
+	conn, err := h.(*Handler).Dial(ctx, dest)
+	common.Must(err)
+	echo(conn)
+	conn.Close()
+	for i := 0; len(acceptedAddrs()) < 3; i++ {
+		if i > 100 {
+			t.Fatal("expect 2 idle connections to be dialed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	idle := acceptedAddrs()
+	conn, err = h.(*Handler).Dial(ctx, dest)
+	common.Must(err)
+	if !idle[conn.LocalAddr().String()] {
+		t.Error("expect an idle connection, but got a new one")
+	}
+	echo(conn)
+	conn.Close()
+
+	// Idle connections closed by the server are not handed out.
+	access.Lock()
+	for _, conn := range accepted {
+		conn.Close()
+	}
+	access.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	conn, err = h.(*Handler).Dial(ctx, dest)
+	common.Must(err)
+	echo(conn)
+	conn.Close()
+}
+
+func TestOutboundConnectionPoolExpiry(t *testing.T) {
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+	dest := net.DestinationFromAddr(listener.Addr())
+
+	v, _ := core.New(&core.Config{})
+	v.AddFeature((outbound.Manager)(new(Manager)))
+	ctx := context.WithValue(context.Background(), xrayKey, v)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{}})
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag: "tag",
+		SenderSettings: serial.ToTypedMessage(&proxyman.SenderConfig{
+			ConnectionPool: &proxyman.ConnectionPoolConfig{Size: 1, IdleTimeout: 1},
+		}),
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+	common.Must(h.Start())
+	defer h.Close()
+
+	conn, err := h.(*Handler).Dial(ctx, dest)
+	common.Must(err)
+	conn.Close()
+	for i := 0; accepted.Load() < 2; i++ {
+		if i > 100 {
+			t.Fatal("expect an idle connection to be dialed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Expired idle connections are closed, but not replaced until the pool is used again.
+	time.Sleep(2500 * time.Millisecond)
+	if n := accepted.Load(); n != 2 {
+		t.Error("expect no redial of expired idle connections, but got ", n, " connections")
+	}
+}
@@ -99,6 +99,33 @@ func (m *Manager) GetHandler(tag string) outbound.Handler {
 	return nil
 }
 
+// ListHandlers implements outbound.Manager.
+func (m *Manager) ListHandlers(ctx context.Context) []outbound.Handler {
+	m.access.RLock()
+	defer m.access.RUnlock()
+
+	var handlers []outbound.Handler
+	if m.defaultHandler != nil {
+		handlers = append(handlers, m.defaultHandler)
+	}
+	tags := make([]string, 0, len(m.taggedHandler))
+	for tag, handler := range m.taggedHandler {
+		if handler != m.defaultHandler {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		handlers = append(handlers, m.taggedHandler[tag])
+	}
+	for _, handler := range m.untaggedHandlers {
+		if handler != m.defaultHandler {
+			handlers = append(handlers, handler)
+		}
+	}
+	return handlers
+}
+
 // AddHandler implements outbound.Manager.
 func (m *Manager) AddHandler(ctx context.Context, handler outbound.Handler) error {
 	m.access.Lock()
@@ -147,15 +174,14 @@ func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 
 // Select implements outbound.HandlerSelector.
 func (m *Manager) Select(selectors []string) []string {
+	m.access.RLock()
+	defer m.access.RUnlock()
 
 	key := strings.Join(selectors, ",")
 	if cache, ok := m.tagsCache.Load(key); ok {
 		return cache.([]string)
 	}
 
-	m.access.RLock()
-	defer m.access.RUnlock()
-
 	tags := make([]string, 0, len(selectors))
 
 	for tag := range m.taggedHandler {
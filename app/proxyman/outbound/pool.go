@@ -0,0 +1,388 @@
+package outbound
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/task"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+)
+
+const (
+	// defaultPoolIdleTimeout is below the handshake timeout of Xray servers, 4 seconds, after
+	// which they close idle connections.
+	defaultPoolIdleTimeout = 3 * time.Second
+	// maxUnprobedIdleTimeout caps the idle timeout of the transports that can't be probed.
+	maxUnprobedIdleTimeout = 2 * time.Second
+	// poolProbeTimeout is how long a health check waits for the peer to close the connection.
+	poolProbeTimeout = time.Millisecond
+	// poolReplaySize is the most data written to an unprobed pooled connection that is kept to be
+	// sent again over a fresh connection.
+	poolReplaySize = 64 * 1024
+)
+
+// probeableProtocols are the transports whose connections survive a read timeout, so that idle
+// connections of them can be probed for liveness. Others are kept shortly, and fall back to fresh
+// connections if they turn out to be closed.
+var probeableProtocols = map[string]bool{
+	"tcp":         true,
+	"httpupgrade": true,
+}
+
+type idleConn struct {
+	conn    stat.Connection
+	created time.Time
+}
+
+type poolEntry struct {
+	idle    []idleConn
+	dialing int
+	// checking is the number of idle connections being probed by check.
+	checking int
+}
+
+// connPool keeps transport connections established in advance for the destinations dialed by a
+// Handler.
+type connPool struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	dial        func(ctx context.Context, dest net.Destination) (stat.Connection, error)
+	size        int
+	idleTimeout time.Duration
+	probe       bool
+	checker     *task.Periodic
+
+	access  sync.Mutex
+	entries map[net.Destination]*poolEntry
+	closed  bool
+}
+
+func newConnPool(ctx context.Context, config *proxyman.ConnectionPoolConfig, streamSettings *internet.MemoryStreamConfig, dial func(ctx context.Context, dest net.Destination) (stat.Connection, error)) *connPool {
+	p := &connPool{
+		dial:        dial,
+		size:        int(config.Size),
+		idleTimeout: defaultPoolIdleTimeout,
+		probe:       streamSettings == nil || probeableProtocols[streamSettings.ProtocolName],
+		entries:     make(map[net.Destination]*poolEntry),
+	}
+	if config.IdleTimeout > 0 {
+		p.idleTimeout = time.Duration(config.IdleTimeout) * time.Second
+	}
+	if !p.probe {
+		p.idleTimeout = min(p.idleTimeout, maxUnprobedIdleTimeout)
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.checker = &task.Periodic{
+		Interval: time.Second,
+		Execute:  p.check,
+	}
+	return p
+}
+
+// Start implements common.Runnable.
+func (p *connPool) Start() error {
+	return p.checker.Start()
+}
+
+// Close implements common.Closable.
+func (p *connPool) Close() error {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.cancel()
+	for _, e := range p.entries {
+		for _, c := range e.idle {
+			c.conn.Close()
+		}
+	}
+	p.entries = nil
+	return p.checker.Close()
+}
+
+// Get returns an idle connection to dest, or nil if there is none. In both cases the pool starts
+// to refill the idle connections of dest. Connections that can't be probed are replaced by ones
+// dialed with ctx, if they fail before anything is read from them.
+func (p *connPool) Get(ctx context.Context, dest net.Destination) stat.Connection {
+	for {
+		c, ok := p.take(dest)
+		if !ok {
+			return nil
+		}
+		if !p.alive(c) {
+			c.conn.Close()
+			continue
+		}
+		if p.probe {
+			return c.conn
+		}
+		return &pooledConn{
+			current: c.conn,
+			redial: func() (stat.Connection, error) {
+				return p.dial(ctx, dest)
+			},
+		}
+	}
+}
+
+func (p *connPool) take(dest net.Destination) (idleConn, bool) {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	if p.closed {
+		return idleConn{}, false
+	}
+	e := p.entries[dest]
+	if e == nil {
+		e = &poolEntry{}
+		p.entries[dest] = e
+	}
+	defer p.fill(dest, e)
+	if len(e.idle) == 0 {
+		return idleConn{}, false
+	}
+	c := e.idle[0]
+	e.idle = e.idle[1:]
+	return c, true
+}
+
+// alive returns true if c isn't expired nor closed by the peer. Servers shouldn't send anything
+// before the request, so anything read also means the connection is broken.
+func (p *connPool) alive(c idleConn) bool {
+	if time.Since(c.created) > p.idleTimeout {
+		return false
+	}
+	if !p.probe {
+		return true
+	}
+	if err := c.conn.SetReadDeadline(time.Now().Add(poolProbeTimeout)); err != nil {
+		return false
+	}
+	var b [1]byte
+	_, err := c.conn.Read(b[:])
+	if e, ok := err.(net.Error); !ok || !e.Timeout() {
+		return false
+	}
+	return c.conn.SetReadDeadline(time.Time{}) == nil
+}
+
+// fill dials the missing idle connections of e. It must be called with access held.
+func (p *connPool) fill(dest net.Destination, e *poolEntry) {
+	for n := p.size - len(e.idle) - e.dialing - e.checking; n > 0; n-- {
+		e.dialing++
+		go p.dialIdle(dest, e)
+	}
+}
+
+func (p *connPool) dialIdle(dest net.Destination, e *poolEntry) {
+	conn, err := p.dial(p.ctx, dest)
+
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	e.dialing--
+	if err != nil {
+		errors.LogInfoInner(p.ctx, err, "failed to dial idle connection to ", dest)
+		return
+	}
+	if p.closed || p.entries[dest] != e {
+		conn.Close()
+		return
+	}
+	e.idle = append(e.idle, idleConn{conn: conn, created: time.Now()})
+}
+
+// check closes broken and expired idle connections. They aren't replaced until the next Get, so
+// that an unused destination doesn't keep dialing. The connections are probed without access
+// held, as probes read from the network.
+func (p *connPool) check() error {
+	p.access.Lock()
+	if p.closed {
+		p.access.Unlock()
+		return nil
+	}
+	checking := make(map[net.Destination][]idleConn, len(p.entries))
+	for dest, e := range p.entries {
+		checking[dest] = e.idle
+		e.checking = len(e.idle)
+		e.idle = nil
+	}
+	p.access.Unlock()
+
+	for dest, idle := range checking {
+		alive := idle[:0]
+		for _, c := range idle {
+			if p.alive(c) {
+				alive = append(alive, c)
+			} else {
+				c.conn.Close()
+			}
+		}
+		clear(idle[len(alive):])
+		checking[dest] = alive
+	}
+
+	p.access.Lock()
+	defer p.access.Unlock()
+	for dest, idle := range checking {
+		e := p.entries[dest]
+		if p.closed || e == nil {
+			for _, c := range idle {
+				c.conn.Close()
+			}
+			continue
+		}
+		e.checking = 0
+		e.idle = append(idle, e.idle...)
+		if len(e.idle) == 0 && e.dialing == 0 {
+			delete(p.entries, dest)
+		}
+	}
+	return nil
+}
+
+// pooledConn is an idle connection of a transport that can't be probed, which may have been closed
+// by the peer. If it fails before anything is read from it, the data written to it is sent again
+// over a fresh connection, which replaces it.
+type pooledConn struct {
+	redial func() (stat.Connection, error)
+
+	access  sync.Mutex
+	current stat.Connection
+	// written is the data written before anything is read, nil if it's too much to be replayed.
+	written    []byte
+	overflowed bool
+	// replaced is whether the pooled connection is replaced already, which is done only once.
+	replaced bool
+	closed   bool
+	settled  atomic.Bool
+}
+
+func (c *pooledConn) conn() stat.Connection {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.current
+}
+
+// fallback replaces failed, the current connection, with a fresh one, unless it's replaced
+// already. It returns the connection to use, or nil if it can't be replaced.
+func (c *pooledConn) fallback(failed stat.Connection) stat.Connection {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	if c.current != failed {
+		return c.current
+	}
+	if c.closed || c.overflowed || c.replaced || c.settled.Load() {
+		return nil
+	}
+	c.replaced = true
+	conn, err := c.redial()
+	if err != nil {
+		errors.LogInfoInner(context.Background(), err, "failed to replace a closed pooled connection")
+		return nil
+	}
+	if len(c.written) > 0 {
+		if _, err := conn.Write(c.written); err != nil {
+			conn.Close()
+			return nil
+		}
+	}
+	errors.LogDebug(context.Background(), "replaced a closed pooled connection")
+	failed.Close()
+	c.current = conn
+	return conn
+}
+
+// Read implements net.Conn.
+func (c *pooledConn) Read(b []byte) (int, error) {
+	if c.settled.Load() {
+		return c.current.Read(b)
+	}
+	conn := c.conn()
+	n, err := conn.Read(b)
+	if n > 0 || err == nil {
+		c.access.Lock()
+		c.settled.Store(true)
+		c.written = nil
+		c.access.Unlock()
+		return n, err
+	}
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return n, err
+	}
+	if conn = c.fallback(conn); conn == nil {
+		return n, err
+	}
+	return c.Read(b)
+}
+
+// Write implements net.Conn.
+func (c *pooledConn) Write(b []byte) (int, error) {
+	if c.settled.Load() {
+		return c.current.Write(b)
+	}
+	c.access.Lock()
+	conn := c.current
+	if !c.overflowed {
+		if len(c.written)+len(b) > poolReplaySize {
+			c.overflowed = true
+			c.written = nil
+		} else {
+			c.written = append(c.written, b...)
+		}
+	}
+	c.access.Unlock()
+
+	n, err := conn.Write(b)
+	if err == nil {
+		return n, nil
+	}
+	// The data is written again by the replacement.
+	if c.fallback(conn) == nil {
+		return n, err
+	}
+	return len(b), nil
+}
+
+// Close implements net.Conn.
+func (c *pooledConn) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.closed = true
+	return c.current.Close()
+}
+
+// LocalAddr implements net.Conn.
+func (c *pooledConn) LocalAddr() net.Addr {
+	return c.conn().LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (c *pooledConn) RemoteAddr() net.Addr {
+	return c.conn().RemoteAddr()
+}
+
+// SetDeadline implements net.Conn.
+func (c *pooledConn) SetDeadline(t time.Time) error {
+	return c.conn().SetDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *pooledConn) SetReadDeadline(t time.Time) error {
+	return c.conn().SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn.
+func (c *pooledConn) SetWriteDeadline(t time.Time) error {
+	return c.conn().SetWriteDeadline(t)
+}
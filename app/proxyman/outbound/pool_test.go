@@ -0,0 +1,98 @@
+package outbound
+
+import (
+	"context"
+	"io"
+	gonet "net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+)
+
+func TestConnPoolFallback(t *testing.T) {
+	dest := net.TCPDestination(net.LocalHostIP, 443)
+	for _, c := range []struct {
+		name string
+		// broken makes the server side of a pooled connection closed.
+		broken func(server gonet.Conn)
+	}{
+		{"write", func(server gonet.Conn) {
+			server.Close()
+		}},
+		{"read", func(server gonet.Conn) {
+			go func() {
+				io.ReadFull(server, make([]byte, 4))
+				server.Close()
+			}()
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var dialed atomic.Int32
+			servers := make(chan gonet.Conn, 8)
+			dial := func(ctx context.Context, dest net.Destination) (stat.Connection, error) {
+				client, server := gonet.Pipe()
+				if dialed.Add(1) > 1 {
+					go io.Copy(server, server)
+				} else {
+					servers <- server
+				}
+				return client, nil
+			}
+			p := newConnPool(context.Background(), &proxyman.ConnectionPoolConfig{Size: 1}, &internet.MemoryStreamConfig{ProtocolName: "websocket"}, dial)
+			defer p.Close()
+
+			if conn := p.Get(context.Background(), dest); conn != nil {
+				t.Fatal("expect no idle connection")
+			}
+			c.broken(<-servers)
+			var conn stat.Connection
+			for i := 0; conn == nil; i++ {
+				if i > 100 {
+					t.Fatal("expect an idle connection")
+				}
+				time.Sleep(10 * time.Millisecond)
+				conn = p.Get(context.Background(), dest)
+			}
+			defer conn.Close()
+
+			if _, err := conn.Write([]byte("ping")); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 4)
+			if _, err := io.ReadFull(conn, b); err != nil || string(b) != "ping" {
+				t.Fatal("unexpected echo ", string(b), " ", err)
+			}
+		})
+	}
+}
+
+func TestConnPoolNoFallbackAfterRead(t *testing.T) {
+	client, server := gonet.Pipe()
+	var redialed atomic.Bool
+	conn := &pooledConn{
+		current: client,
+		redial: func() (stat.Connection, error) {
+			redialed.Store(true)
+			c, _ := gonet.Pipe()
+			return c, nil
+		},
+	}
+	go func() {
+		server.Write([]byte("pong"))
+		server.Close()
+	}()
+	b := make([]byte, 4)
+	common.Must2(io.ReadFull(conn, b))
+	if _, err := conn.Read(b); err != io.EOF {
+		t.Error("expect EOF, but got ", err)
+	}
+	if redialed.Load() {
+		t.Error("expect no fallback after a response is read")
+	}
+}
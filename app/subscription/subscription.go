@@ -0,0 +1,255 @@
+// Package subscription keeps outbounds in sync with subscriptions.
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	v2net "github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/platform/filesystem"
+	"github.com/HZ-PRE/XrarCore/common/task"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/features/outbound"
+	"github.com/HZ-PRE/XrarCore/features/routing"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tagged"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultInterval = time.Hour
+	fetchTimeout    = 30 * time.Second
+	// maxContentSize is the maximum size of subscriptions.
+	maxContentSize = 16 * 1024 * 1024
+)
+
+// ParseOutbounds parses the content of a subscription, either share links or outbounds in JSON, into
+// outbounds. It's set by the config loader, as the formats of configs are not known here.
+var ParseOutbounds func(content []byte) ([]*core.OutboundHandlerConfig, error)
+
+// Subscription fetches subscriptions periodically, and adds their outbounds to the outbound manager,
+// or removes them. Balancers and observatory can select them with the tag prefixes.
+type Subscription struct {
+	ctx        context.Context
+	instance   *core.Instance
+	ohm        outbound.Manager
+	dispatcher routing.Dispatcher
+	providers  []*provider
+}
+
+type provider struct {
+	config *Provider
+	s      *Subscription
+	task   *task.Periodic
+
+	access sync.Mutex
+	// outbounds are the current outbounds of the provider, keyed by tags, with the serialized configs.
+	outbounds map[string][]byte
+}
+
+// New creates a Subscription.
+func New(ctx context.Context, config *Config) (*Subscription, error) {
+	s := &Subscription{
+		ctx:      ctx,
+		instance: core.MustFromContext(ctx),
+	}
+	if err := core.RequireFeatures(ctx, func(om outbound.Manager, d routing.Dispatcher) {
+		s.ohm = om
+		s.dispatcher = d
+	}); err != nil {
+		return nil, errors.New("failed to get depended features").Base(err)
+	}
+
+	for _, c := range config.Providers {
+		if c.Url == "" && c.File == "" {
+			return nil, errors.New("no url or file of subscription ", c.Tag)
+		}
+		p := &provider{
+			config:    c,
+			s:         s,
+			outbounds: make(map[string][]byte),
+		}
+		interval := time.Duration(c.Interval)
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+		p.task = &task.Periodic{
+			Interval: interval,
+			Execute:  p.refresh,
+		}
+		s.providers = append(s.providers, p)
+	}
+	return s, nil
+}
+
+// Type implements common.HasType.
+func (s *Subscription) Type() interface{} {
+	return (*Subscription)(nil)
+}
+
+// Start implements common.Runnable.
+func (s *Subscription) Start() error {
+	for _, p := range s.providers {
+		// Don't block the startup with fetching.
+		go p.task.Start()
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (s *Subscription) Close() error {
+	var errs []error
+	for _, p := range s.providers {
+		errs = append(errs, p.task.Close())
+	}
+	return errors.Combine(errs...)
+}
+
+// refresh fetches the subscription and synchronizes the outbounds. Errors are logged, and the current
+// outbounds are kept, so that it's retried in the next interval.
+func (p *provider) refresh() error {
+	if err := p.sync(); err != nil {
+		errors.LogWarningInner(p.s.ctx, err, "failed to refresh subscription ", p.config.Tag)
+	}
+	return nil
+}
+
+func (p *provider) sync() error {
+	if ParseOutbounds == nil {
+		return errors.New("subscriptions are not supported by the config loader")
+	}
+	content, err := p.fetch()
+	if err != nil {
+		return err
+	}
+	configs, err := ParseOutbounds(content)
+	if err != nil {
+		return errors.New("failed to parse subscription").Base(err)
+	}
+	if len(configs) == 0 {
+		return errors.New("no outbound in subscription")
+	}
+
+	outbounds := make(map[string]*core.OutboundHandlerConfig, len(configs))
+	serialized := make(map[string][]byte, len(configs))
+	for i, config := range configs {
+		name := config.Tag
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		tag := p.config.TagPrefix + name
+		for n := 2; outbounds[tag] != nil; n++ {
+			tag = p.config.TagPrefix + name + "-" + strconv.Itoa(n)
+		}
+		config.Tag = tag
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(config)
+		if err != nil {
+			return errors.New("failed to serialize outbound ", tag).Base(err)
+		}
+		outbounds[tag] = config
+		serialized[tag] = b
+	}
+
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	var added, removed int
+	for tag, b := range p.outbounds {
+		if nb, found := serialized[tag]; found && bytes.Equal(nb, b) {
+			continue
+		}
+		p.removeOutbound(tag)
+		delete(p.outbounds, tag)
+		removed++
+	}
+	for tag, config := range outbounds {
+		if _, found := p.outbounds[tag]; found {
+			continue
+		}
+		if err := core.AddOutboundHandler(p.s.instance, config); err != nil {
+			errors.LogWarningInner(p.s.ctx, err, "failed to add outbound ", tag, " of subscription ", p.config.Tag)
+			continue
+		}
+		p.outbounds[tag] = serialized[tag]
+		added++
+	}
+	errors.LogInfo(p.s.ctx, "subscription ", p.config.Tag, " refreshed: ", len(p.outbounds), " outbounds, ", added, " added, ", removed, " removed")
+	return nil
+}
+
+func (p *provider) removeOutbound(tag string) {
+	handler := p.s.ohm.GetHandler(tag)
+	if err := p.s.ohm.RemoveHandler(p.s.ctx, tag); err != nil {
+		errors.LogWarningInner(p.s.ctx, err, "failed to remove outbound ", tag, " of subscription ", p.config.Tag)
+		return
+	}
+	if handler != nil {
+		common.Close(handler)
+	}
+}
+
+func (p *provider) fetch() ([]byte, error) {
+	if p.config.Url == "" {
+		content, err := filesystem.ReadFile(p.config.File)
+		if err != nil {
+			return nil, errors.New("failed to read subscription file ", p.config.File).Base(err)
+		}
+		return content, nil
+	}
+
+	transport := &http.Transport{
+		Proxy: func(*http.Request) (*url.URL, error) {
+			return nil, nil
+		},
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if tag := p.config.OutboundTag; tag != "" {
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dest, err := v2net.ParseDestination(network + ":" + addr)
+			if err != nil {
+				return nil, errors.New("cannot understand address").Base(err)
+			}
+			return tagged.Dialer(p.s.ctx, p.s.dispatcher, dest, tag)
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   fetchTimeout,
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(p.s.ctx, http.MethodGet, p.config.Url, nil)
+	if err != nil {
+		return nil, errors.New("invalid subscription url").Base(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.New("failed to fetch subscription").Base(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status of subscription: ", resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxContentSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read subscription").Base(err)
+	}
+	if len(content) > maxContentSize {
+		return nil, errors.New("subscription is too large")
+	}
+	return content, nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
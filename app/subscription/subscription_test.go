@@ -0,0 +1,89 @@
+package subscription_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/app/dispatcher"
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	_ "github.com/HZ-PRE/XrarCore/app/proxyman/outbound"
+	. "github.com/HZ-PRE/XrarCore/app/subscription"
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/features/outbound"
+	"github.com/HZ-PRE/XrarCore/proxy/freedom"
+)
+
+func TestSubscription(t *testing.T) {
+	// A subscription of freedom outbounds, one per line, named with the lines.
+	ParseOutbounds = func(content []byte) ([]*core.OutboundHandlerConfig, error) {
+		var outbounds []*core.OutboundHandlerConfig
+		for _, line := range strings.Fields(string(content)) {
+			outbounds = append(outbounds, &core.OutboundHandlerConfig{
+				Tag:           line,
+				ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+			})
+		}
+		return outbounds, nil
+	}
+	defer func() {
+		ParseOutbounds = nil
+	}()
+
+	file := filepath.Join(t.TempDir(), "sub.txt")
+	common.Must(os.WriteFile(file, []byte("a b b"), 0o644))
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&Config{
+				Providers: []*Provider{{
+					Tag:       "test",
+					File:      file,
+					TagPrefix: "sub-",
+					Interval:  int64(100 * time.Millisecond),
+				}},
+			}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{{
+			Tag:           "direct",
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		}},
+	}
+	server, err := core.New(config)
+	common.Must(err)
+	common.Must(server.Start())
+	defer server.Close()
+
+	ohm := server.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	waitFor := func(expected ...string) {
+		t.Helper()
+		var tags []string
+		for i := 0; i < 50; i++ {
+			tags = ohm.(outbound.HandlerSelector).Select([]string{"sub-"})
+			if strings.Join(tags, ",") == strings.Join(expected, ",") {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatal("unexpected outbounds: ", tags, ", expected ", expected)
+	}
+	waitFor("sub-a", "sub-b", "sub-b-2")
+
+	common.Must(os.WriteFile(file, []byte("b c"), 0o644))
+	waitFor("sub-b", "sub-c")
+
+	// Outbounds are kept if the subscription is broken.
+	common.Must(os.Remove(file))
+	time.Sleep(300 * time.Millisecond)
+	waitFor("sub-b", "sub-c")
+
+	if ohm.GetHandler("direct") == nil {
+		t.Error("static outbound is removed")
+	}
+}
@@ -74,14 +74,23 @@ func (p *IncrementalWorkerPicker) cleanup() {
 	p.workers = activeWorkers
 }
 
+// findAvailable returns the available worker with the lowest throughput, and then the fewest active
+// connections, so that new sessions avoid busy connections.
 func (p *IncrementalWorkerPicker) findAvailable() int {
-	for idx, w := range p.workers {
-		if !w.IsFull() {
-			return idx
+	idx := -1
+	var throughput uint64
+	var active uint32
+	for i, w := range p.workers {
+		if w.IsFull() {
+			continue
+		}
+		t, a := w.Throughput(), w.ActiveConnections()
+		if idx < 0 || t < throughput || (t == throughput && a < active) {
+			idx, throughput, active = i, t, a
 		}
 	}
 
-	return -1
+	return idx
 }
 
 func (p *IncrementalWorkerPicker) pickInternal() (*ClientWorker, bool, error) {
@@ -90,10 +99,6 @@ func (p *IncrementalWorkerPicker) pickInternal() (*ClientWorker, bool, error) {
 
 	idx := p.findAvailable()
 	if idx >= 0 {
-		n := len(p.workers)
-		if n > 1 && idx != n-1 {
-			p.workers[n-1], p.workers[idx] = p.workers[idx], p.workers[n-1]
-		}
 		return p.workers[idx], false, nil
 	}
 
@@ -172,6 +177,8 @@ type ClientStrategy struct {
 type ClientWorker struct {
 	sessionManager *SessionManager
 	link           transport.Link
+	output         buf.Writer
+	meter          throughputMeter
 	done           *done.Instance
 	strategy       ClientStrategy
 }
@@ -189,6 +196,7 @@ func NewClientWorker(stream transport.Link, s ClientStrategy) (*ClientWorker, er
 		done:           done.New(),
 		strategy:       s,
 	}
+	c.output = &meteredWriter{Writer: stream.Writer, meter: &c.meter}
 
 	go c.fetchOutput()
 	go c.monitor()
@@ -204,6 +212,11 @@ func (m *ClientWorker) ActiveConnections() uint32 {
 	return uint32(m.sessionManager.Size())
 }
 
+// Throughput returns the recent throughput of both directions, in bytes per second.
+func (m *ClientWorker) Throughput() uint64 {
+	return m.meter.Rate()
+}
+
 // Closed returns true if this Client is closed.
 func (m *ClientWorker) Closed() bool {
 	return m.done.Done()
@@ -251,6 +264,7 @@ func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
 	}
 	s.transferType = transferType
 	writer := NewWriter(s.ID, ob.Target, output, transferType, xudp.GetGlobalID(ctx))
+	writer.flow = s.flow
 	defer s.Close(false)
 	defer writer.Close()
 
@@ -300,7 +314,14 @@ func (m *ClientWorker) Dispatch(ctx context.Context, link *transport.Link) bool
 	}
 	s.input = link.Reader
 	s.output = link.Writer
-	go fetchInput(ctx, s, m.link.Writer)
+	outbounds := session.OutboundsFromContext(ctx)
+	if outbounds[len(outbounds)-1].Target.Network != net.Network_UDP {
+		// Offer flow control, which takes effect once the server grants credits. Until then, the
+		// server may be an old one that ignores credits, so the session keeps blocking the reading of
+		// the connection as backpressure.
+		s.flow = newFlowControl()
+	}
+	go fetchInput(ctx, s, m.output)
 	return true
 }
 
@@ -319,6 +340,17 @@ func (m *ClientWorker) handleStatusNew(meta *FrameMetadata, reader *buf.Buffered
 }
 
 func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionFlowControl) {
+		if s := grantCredits(m.sessionManager, meta); s != nil {
+			// The server supports flow control, which bounds the data it sends from now on. It grants
+			// credits before sending any data, so all the data is counted.
+			m.sessionManager.Lock()
+			if !s.closed {
+				s.receiveWithFlowControl(m.output)
+			}
+			m.sessionManager.Unlock()
+		}
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -326,7 +358,7 @@ func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	s, found := m.sessionManager.Get(meta.SessionID)
 	if !found {
 		// Notify remote peer to close this session.
-		closingWriter := NewResponseWriter(meta.SessionID, m.link.Writer, protocol.TransferTypeStream)
+		closingWriter := NewResponseWriter(meta.SessionID, m.output, protocol.TransferTypeStream)
 		closingWriter.Close()
 
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
@@ -358,7 +390,7 @@ func (m *ClientWorker) fetchOutput() {
 		common.Must(m.done.Close())
 	}()
 
-	reader := &buf.BufferedReader{Reader: m.link.Reader}
+	reader := &buf.BufferedReader{Reader: &meteredReader{Reader: m.link.Reader, meter: &m.meter}}
 
 	var meta FrameMetadata
 	for {
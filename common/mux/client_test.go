@@ -2,13 +2,16 @@ package mux_test
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/buf"
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/mux"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
 	"github.com/HZ-PRE/XrarCore/common/session"
 	"github.com/HZ-PRE/XrarCore/testing/mocks"
 	"github.com/HZ-PRE/XrarCore/transport"
@@ -114,3 +117,62 @@ func TestClientWorkerClose(t *testing.T) {
 
 	common.Must(w2.Close())
 }
+
+func TestClientWorkerOldServerBackpressure(t *testing.T) {
+	// An old server ignores flow control, so it never grants credits.
+	fromServerReader, fromServerWriter := pipe.New(pipe.WithSizeLimit(1024))
+	toServerReader, toServerWriter := pipe.New(pipe.WithoutSizeLimit())
+	defer toServerReader.Interrupt()
+	worker, err := mux.NewClientWorker(transport.Link{Reader: fromServerReader, Writer: toServerWriter}, mux.ClientStrategy{})
+	common.Must(err)
+
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+	}})
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithSizeLimit(1024))
+	uplinkReader, _ := pipe.New(pipe.WithoutSizeLimit())
+	if !worker.Dispatch(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}) {
+		t.Fatal("failed to dispatch")
+	}
+	defer downlinkReader.Interrupt()
+	// Wait for the request, after which the server responds.
+	if _, err := toServerReader.ReadMultiBuffer(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The local reader is slow.
+	go func() {
+		for {
+			if _, err := downlinkReader.ReadMultiBuffer(); err != nil {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	const total = 8 * 1024 * 1024
+	var sent int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer := mux.NewResponseWriter(1, fromServerWriter, protocol.TransferTypeStream)
+		for atomic.LoadInt32(&sent) < total {
+			b := buf.New()
+			b.Extend(buf.Size)
+			if writer.WriteMultiBuffer(buf.MultiBuffer{b}) != nil {
+				return
+			}
+			atomic.AddInt32(&sent, buf.Size)
+		}
+	}()
+	defer fromServerWriter.Interrupt()
+
+	select {
+	case <-done:
+		t.Fatal("the download is buffered without limit")
+	case <-time.After(time.Second):
+	}
+	if n := atomic.LoadInt32(&sent); n > 256*1024 {
+		t.Error("too much data is buffered: ", n)
+	}
+}
@@ -0,0 +1,218 @@
+package mux
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/signal"
+	"github.com/HZ-PRE/XrarCore/common/signal/done"
+	"github.com/HZ-PRE/XrarCore/transport/pipe"
+)
+
+const (
+	// flowWindow is the initial window of each direction of a session, in bytes.
+	flowWindow = 1024 * 1024
+	// flowUpdateThreshold is the number of consumed bytes that triggers a window update.
+	flowUpdateThreshold = flowWindow / 4
+)
+
+// flowControl is the credit-based flow control of a session. The sender may send as many bytes as the
+// credits granted by the receiver, which grants more as its output consumes them. Before the peer
+// grants any credit, it's unknown whether the peer supports flow control, so sending is unlimited.
+type flowControl struct {
+	access   sync.Mutex
+	enabled  bool
+	credit   int64
+	consumed int64
+	update   *signal.Notifier
+	done     *done.Instance
+}
+
+func newFlowControl() *flowControl {
+	return &flowControl{
+		update: signal.NewNotifier(),
+		done:   done.New(),
+	}
+}
+
+// grant adds credits from the peer, and returns true if they are the first ones.
+func (f *flowControl) grant(n uint32) bool {
+	f.access.Lock()
+	first := !f.enabled
+	f.enabled = true
+	f.credit += int64(n)
+	f.access.Unlock()
+	f.update.Signal()
+	return first
+}
+
+// acquire waits for credits, and returns the number of bytes to send, which is at most size.
+func (f *flowControl) acquire(size int32) (int32, error) {
+	for {
+		f.access.Lock()
+		if !f.enabled || f.credit > 0 {
+			n := size
+			if f.enabled {
+				n = int32(min(int64(size), f.credit))
+			}
+			f.credit -= int64(n)
+			f.access.Unlock()
+			return n, nil
+		}
+		f.access.Unlock()
+
+		select {
+		case <-f.update.Wait():
+		case <-f.done.Wait():
+			return 0, io.ErrClosedPipe
+		}
+	}
+}
+
+// consume records n bytes consumed by the output, and returns the credits to grant to the peer, if
+// it's time.
+func (f *flowControl) consume(n int32) uint32 {
+	f.access.Lock()
+	defer f.access.Unlock()
+
+	if !f.enabled {
+		return 0
+	}
+	f.consumed += int64(n)
+	if f.consumed < flowUpdateThreshold {
+		return 0
+	}
+	c := f.consumed
+	f.consumed = 0
+	return uint32(c)
+}
+
+// Close implements common.Closable.
+func (f *flowControl) Close() error {
+	return f.done.Close()
+}
+
+// writeWindowUpdate grants n bytes of credits of session id to the peer.
+func writeWindowUpdate(writer buf.Writer, id uint16, n uint32) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusKeep,
+		Option:        OptionFlowControl,
+		Window:        n,
+	}
+	frame := buf.New()
+	common.Must(meta.WriteTo(frame))
+	return writer.WriteMultiBuffer(buf.MultiBuffer{frame})
+}
+
+// receiveWithFlowControl buffers the data received by s, so that a slow output doesn't block other
+// sessions, and grants credits to the peer through link as the output consumes data. The buffered
+// data is bounded by the credits, so it must be called only after the peer is known to respect
+// them, that is, after the peer grants credits.
+func (s *Session) receiveWithFlowControl(link buf.Writer) {
+	reader, writer := pipe.New(pipe.WithoutSizeLimit())
+	output := s.output
+	s.output = writer
+	go func() {
+		err := buf.Copy(reader, &grantingWriter{
+			Writer: output,
+			flow:   s.flow,
+			link:   link,
+			id:     s.ID,
+		})
+		if err != nil {
+			common.Interrupt(reader)
+			common.Interrupt(output)
+			return
+		}
+		common.Close(output)
+	}()
+}
+
+type grantingWriter struct {
+	buf.Writer
+	flow *flowControl
+	link buf.Writer
+	id   uint16
+}
+
+func (w *grantingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	n := mb.Len()
+	if err := w.Writer.WriteMultiBuffer(mb); err != nil {
+		return err
+	}
+	if c := w.flow.consume(n); c > 0 {
+		return writeWindowUpdate(w.link, w.id, c)
+	}
+	return nil
+}
+
+// throughputMeterDecay is the time constant of throughputMeter.
+const throughputMeterDecay = 5 * time.Second
+
+// throughputMeter measures the recent throughput with exponential decay.
+type throughputMeter struct {
+	access sync.Mutex
+	rate   float64
+	last   time.Time
+}
+
+func (m *throughputMeter) decay(now time.Time) {
+	if !m.last.IsZero() {
+		m.rate *= math.Exp(-float64(now.Sub(m.last)) / float64(throughputMeterDecay))
+	}
+	m.last = now
+}
+
+func (m *throughputMeter) add(n int32) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	m.decay(time.Now())
+	m.rate += float64(n) / throughputMeterDecay.Seconds()
+}
+
+// Rate returns the throughput in bytes per second.
+func (m *throughputMeter) Rate() uint64 {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	m.decay(time.Now())
+	return uint64(m.rate)
+}
+
+type meteredReader struct {
+	buf.Reader
+	meter *throughputMeter
+}
+
+func (r *meteredReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	r.meter.add(mb.Len())
+	return mb, err
+}
+
+type meteredWriter struct {
+	buf.Writer
+	meter *throughputMeter
+}
+
+func (w *meteredWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.meter.add(mb.Len())
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+// grantCredits adds the credits in meta to its session, if any, and returns the session if they are
+// its first credits.
+func grantCredits(m *SessionManager, meta *FrameMetadata) *Session {
+	if s, found := m.Get(meta.SessionID); found && s.flow != nil && meta.Window > 0 {
+		if s.flow.grant(meta.Window) {
+			return s
+		}
+	}
+	return nil
+}
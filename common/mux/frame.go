@@ -25,8 +25,16 @@ const (
 const (
 	OptionData  bitmask.Byte = 0x01
 	OptionError bitmask.Byte = 0x02
+	// OptionFlowControl marks frames of the flow control extension. A New frame with it offers flow
+	// control, and a Keep frame with it grants credits to the sender of the session.
+	OptionFlowControl bitmask.Byte = 0x04
 )
 
+// FlowControlVersion is the version of the flow control extension. The extension follows the address
+// of New frames, and the status and option of Keep frames, where peers without the extension check
+// TargetNetworkUDP, so the version must never be that.
+const FlowControlVersion byte = 0x01
+
 type TargetNetwork byte
 
 const (
@@ -52,6 +60,10 @@ Frame format
 2 bytes - port
 n bytes - address
 
+Flow control extension (OptionFlowControl)
+1 byte - version
+4 bytes - window
+
 */
 
 type FrameMetadata struct {
@@ -60,6 +72,8 @@ type FrameMetadata struct {
 	Option        bitmask.Byte
 	SessionStatus SessionStatus
 	GlobalID      [8]byte
+	// Window is the credits granted to the peer, if Option has OptionFlowControl.
+	Window uint32
 }
 
 func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
@@ -85,10 +99,14 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 		}
 		if b.UDP != nil { // make sure it's user's proxy request
 			b.Write(f.GlobalID[:]) // no need to check whether it's empty
+		} else if f.Option.Has(OptionFlowControl) {
+			f.writeFlowControl(b)
 		}
 	} else if b.UDP != nil {
 		b.WriteByte(byte(TargetNetworkUDP))
 		addrParser.WriteAddressPort(b, b.UDP.Address, b.UDP.Port)
+	} else if f.Option.Has(OptionFlowControl) {
+		f.writeFlowControl(b)
 	}
 
 	len1 := b.Len()
@@ -96,6 +114,18 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 	return nil
 }
 
+func (f FrameMetadata) writeFlowControl(b *buf.Buffer) {
+	common.Must(b.WriteByte(FlowControlVersion))
+	binary.BigEndian.PutUint32(b.Extend(4), f.Window)
+}
+
+// readFlowControl reads the flow control extension from the start of b, if any.
+func (f *FrameMetadata) readFlowControl(b *buf.Buffer) {
+	if f.Option.Has(OptionFlowControl) && b.Len() >= 5 && b.Byte(0) == FlowControlVersion {
+		f.Window = binary.BigEndian.Uint32(b.BytesRange(1, 5))
+	}
+}
+
 // Unmarshal reads FrameMetadata from the given reader.
 func (f *FrameMetadata) Unmarshal(reader io.Reader) error {
 	metaLen, err := serial.ReadUint16(reader)
@@ -126,6 +156,7 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer) error {
 	f.SessionStatus = SessionStatus(b.Byte(2))
 	f.Option = bitmask.Byte(b.Byte(3))
 	f.Target.Network = net.Network_Unknown
+	f.Window = 0
 
 	if f.SessionStatus == SessionStatusNew || (f.SessionStatus == SessionStatusKeep && b.Len() > 4 &&
 		TargetNetwork(b.Byte(4)) == TargetNetworkUDP) { // MUST check the flag first
@@ -148,6 +179,12 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer) error {
 		default:
 			return errors.New("unknown network type: ", network)
 		}
+		if network == TargetNetworkTCP {
+			f.readFlowControl(b)
+		}
+	} else if f.SessionStatus == SessionStatusKeep {
+		b.Advance(4)
+		f.readFlowControl(b)
 	}
 
 	// Application data is essential, to test whether the pipe is closed.
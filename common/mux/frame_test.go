@@ -23,3 +23,31 @@ func BenchmarkFrameWrite(b *testing.B) {
 		writer.Clear()
 	}
 }
+
+func TestFrameFlowControl(t *testing.T) {
+	for _, frame := range []mux.FrameMetadata{
+		{
+			Target:        net.TCPDestination(net.DomainAddress("www.example.com"), net.Port(80)),
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusNew,
+			Option:        mux.OptionFlowControl,
+			Window:        1 << 20,
+		},
+		{
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusKeep,
+			Option:        mux.OptionFlowControl,
+			Window:        4096,
+		},
+	} {
+		b := buf.New()
+		common.Must(frame.WriteTo(b))
+
+		var meta mux.FrameMetadata
+		common.Must(meta.Unmarshal(b))
+		if meta != frame {
+			t.Error("unexpected frame: ", meta, ", expected ", frame)
+		}
+		b.Release()
+	}
+}
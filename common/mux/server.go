@@ -82,6 +82,9 @@ type ServerWorker struct {
 }
 
 func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.Link) (*ServerWorker, error) {
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		inbound.Shared = true
+	}
 	worker := &ServerWorker{
 		dispatcher:     d,
 		link:           link,
@@ -93,6 +96,7 @@ func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.
 
 func handle(ctx context.Context, s *Session, output buf.Writer) {
 	writer := NewResponseWriter(s.ID, output, s.transferType)
+	writer.flow = s.flow
 	if err := buf.Copy(s.input, writer); err != nil {
 		errors.LogInfoInner(ctx, err, "session ", s.ID, " ends.")
 		writer.hasError = true
@@ -226,6 +230,15 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 	if meta.Target.Network == net.Network_UDP {
 		s.transferType = protocol.TransferTypePacket
 	}
+	if s.transferType == protocol.TransferTypeStream && meta.Window > 0 {
+		// The client offers flow control, so accept it by granting the initial window.
+		s.flow = newFlowControl()
+		s.flow.grant(meta.Window)
+		if err := writeWindowUpdate(w.link.Writer, s.ID, flowWindow); err != nil {
+			return err
+		}
+		s.receiveWithFlowControl(w.link.Writer)
+	}
 	w.sessionManager.Add(s)
 	go handle(ctx, s, w.link.Writer)
 	if !meta.Option.Has(OptionData) {
@@ -241,6 +254,9 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 }
 
 func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionFlowControl) {
+		grantCredits(w.sessionManager, meta)
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -3,6 +3,7 @@ package mux_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/buf"
@@ -122,3 +123,71 @@ func TestRegressionOutboundLeak(t *testing.T) {
 		t.Error("outbound target got leaked: ", outbounds[0].Target.String())
 	}
 }
+
+func TestFlowControlHeadOfLine(t *testing.T) {
+	slowUplink, slowDownlink := newLinkPair()
+	fastUplink, fastDownlink := newLinkPair()
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			if dest.Port == 1 {
+				return slowDownlink, nil
+			}
+			return fastDownlink, nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	_, err := mux.NewServerWorker(context.Background(), &dispatcher, muxServerUplink)
+	common.Must(err)
+	client, err := mux.NewClientWorker(*muxServerDownlink, mux.ClientStrategy{})
+	common.Must(err)
+
+	dispatch := func(port net.Port, link *transport.Link) {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress("www.example.com"), port),
+		}})
+		if !client.Dispatch(ctx, link) {
+			t.Fatal("failed to dispatch")
+		}
+	}
+
+	// The slow session never reads its download, which fills a small pipe.
+	slowReader, slowWriter := pipe.New(pipe.WithSizeLimit(1024))
+	uplinkReader, _ := pipe.New(pipe.WithoutSizeLimit())
+	dispatch(1, &transport.Link{Reader: uplinkReader, Writer: slowWriter})
+	defer slowReader.Interrupt()
+	go func() {
+		for {
+			b := buf.New()
+			b.Extend(buf.Size)
+			if slowUplink.Writer.WriteMultiBuffer(buf.MultiBuffer{b}) != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(500 * time.Millisecond)
+
+	muxClientUplink, muxClientDownlink := newLinkPair()
+	dispatch(2, muxClientUplink)
+	common.Must(muxClientDownlink.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+	mb, err := fastUplink.Reader.ReadMultiBuffer()
+	common.Must(err)
+	if s := mb.String(); s != "hello" {
+		t.Error("upload: ", s)
+	}
+
+	common.Must(fastUplink.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("world"))}))
+	done := make(chan string)
+	go func() {
+		mb, _ := muxClientDownlink.Reader.ReadMultiBuffer()
+		done <- mb.String()
+	}()
+	select {
+	case s := <-done:
+		if s != "world" {
+			t.Error("download: ", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("download is blocked by the slow session")
+	}
+}
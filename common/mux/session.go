@@ -155,6 +155,8 @@ type Session struct {
 	transferType protocol.TransferType
 	closed       bool
 	XUDP         *XUDP
+	// flow is the flow control of stream sessions, if the peer may support it.
+	flow *flowControl
 }
 
 // Close closes all resources associated with this session.
@@ -168,6 +170,9 @@ func (s *Session) Close(locked bool) error {
 		return nil
 	}
 	s.closed = true
+	if s.flow != nil {
+		s.flow.Close()
+	}
 	if s.XUDP == nil {
 		common.Interrupt(s.input)
 		common.Close(s.output)
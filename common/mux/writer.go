@@ -16,6 +16,7 @@ type Writer struct {
 	hasError     bool
 	transferType protocol.TransferType
 	globalID     [8]byte
+	flow         *flowControl
 }
 
 func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType protocol.TransferType, globalID [8]byte) *Writer {
@@ -50,6 +51,10 @@ func (w *Writer) getNextFrameMeta() FrameMetadata {
 	} else {
 		w.followup = true
 		meta.SessionStatus = SessionStatusNew
+		if w.flow != nil {
+			meta.Option.Set(OptionFlowControl)
+			meta.Window = flowWindow
+		}
 	}
 
 	return meta
@@ -100,7 +105,14 @@ func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
 	for !mb.IsEmpty() {
 		var chunk buf.MultiBuffer
 		if w.transferType == protocol.TransferTypeStream {
-			mb, chunk = buf.SplitSize(mb, 8*1024)
+			size := min(mb.Len(), 8*1024)
+			if w.flow != nil {
+				var err error
+				if size, err = w.flow.acquire(size); err != nil {
+					return err
+				}
+			}
+			mb, chunk = buf.SplitSize(mb, size)
 		} else {
 			mb2, b := buf.SplitFirst(mb)
 			mb = mb2
@@ -26,6 +26,9 @@ var FileConn = net.FileConn
 // ParseIP is an alias of net.ParseIP
 var ParseIP = net.ParseIP
 
+// ParseCIDR is an alias of net.ParseCIDR
+var ParseCIDR = net.ParseCIDR
+
 var SplitHostPort = net.SplitHostPort
 
 var CIDRMask = net.CIDRMask
@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+)
+
+// PasswordAccount is an account with a username and a password, such as those of Socks and HTTP.
+type PasswordAccount interface {
+	Account
+	GetUsername() string
+	GetPassword() string
+}
+
+// PasswordValidator stores valid users of PasswordAccount.
+type PasswordValidator struct {
+	email sync.Map
+	users sync.Map
+}
+
+// Add a user. Username must be unique, and Email must be empty or unique.
+func (v *PasswordValidator) Add(u *MemoryUser) error {
+	account, ok := u.Account.(PasswordAccount)
+	if !ok {
+		return errors.New("account has no username and password")
+	}
+	if _, loaded := v.users.LoadOrStore(account.GetUsername(), u); loaded {
+		return errors.New("User ", account.GetUsername(), " already exists.")
+	}
+	if u.Email != "" {
+		if _, loaded := v.email.LoadOrStore(strings.ToLower(u.Email), u); loaded {
+			v.users.Delete(account.GetUsername())
+			return errors.New("User ", u.Email, " already exists.")
+		}
+	}
+	return nil
+}
+
+// Del a user with a non-empty Email.
+func (v *PasswordValidator) Del(e string) error {
+	if e == "" {
+		return errors.New("Email must not be empty.")
+	}
+	le := strings.ToLower(e)
+	u, _ := v.email.Load(le)
+	if u == nil {
+		return errors.New("User ", e, " not found.")
+	}
+	v.email.Delete(le)
+	v.users.Delete(u.(*MemoryUser).Account.(PasswordAccount).GetUsername())
+	return nil
+}
+
+// Get a user with matching username and password, nil if there is no such user.
+func (v *PasswordValidator) Get(username, password string) *MemoryUser {
+	u, _ := v.users.Load(username)
+	if u == nil {
+		return nil
+	}
+	user := u.(*MemoryUser)
+	if user.Account.(PasswordAccount).GetPassword() != password {
+		return nil
+	}
+	return user
+}
+
+// GetByEmail gets a user with email, nil if user doesn't exist.
+func (v *PasswordValidator) GetByEmail(email string) *MemoryUser {
+	u, _ := v.email.Load(strings.ToLower(email))
+	if u != nil {
+		return u.(*MemoryUser)
+	}
+	return nil
+}
+
+// GetAll gets all users with an email.
+func (v *PasswordValidator) GetAll() []*MemoryUser {
+	var u = make([]*MemoryUser, 0, 100)
+	v.email.Range(func(key, value interface{}) bool {
+		u = append(u, value.(*MemoryUser))
+		return true
+	})
+	return u
+}
+
+// GetCount gets the count of users with an email.
+func (v *PasswordValidator) GetCount() int64 {
+	var c int64 = 0
+	v.email.Range(func(key, value interface{}) bool {
+		c++
+		return true
+	})
+	return c
+}
+
+// GetAllUsers gets all users, including those without an email.
+func (v *PasswordValidator) GetAllUsers() []*MemoryUser {
+	var u []*MemoryUser
+	v.users.Range(func(key, value interface{}) bool {
+		u = append(u, value.(*MemoryUser))
+		return true
+	})
+	return u
+}
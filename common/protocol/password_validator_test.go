@@ -0,0 +1,42 @@
+package protocol_test
+
+import (
+	"testing"
+
+	. "github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/proxy/http"
+	"github.com/HZ-PRE/XrarCore/proxy/socks"
+)
+
+func TestPasswordValidator(t *testing.T) {
+	v := new(PasswordValidator)
+	user := &MemoryUser{
+		Account: &socks.Account{Username: "user", Password: "pass"},
+		Email:   "user@example.com",
+		Level:   1,
+	}
+	if err := v.Add(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Add(&MemoryUser{Account: &http.Account{Username: "user", Password: "other"}}); err == nil {
+		t.Error("expect error for duplicated username")
+	}
+	if u := v.Get("user", "pass"); u != user {
+		t.Error("failed to get user by username and password")
+	}
+	if u := v.Get("user", "wrong"); u != nil {
+		t.Error("expect nil for wrong password, but got ", u.Email)
+	}
+	if u := v.GetByEmail("USER@example.com"); u != user {
+		t.Error("failed to get user by email")
+	}
+	if c := v.GetCount(); c != 1 {
+		t.Error("expect 1 user, but got ", c)
+	}
+	if err := v.Del("user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if u := v.Get("user", "pass"); u != nil {
+		t.Error("expect nil for removed user")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 type SniffHeader struct {
 	domain string
+	alpn   []string
 }
 
 func (h *SniffHeader) Protocol() string {
@@ -20,6 +21,11 @@ func (h *SniffHeader) Domain() string {
 	return h.domain
 }
 
+// ALPN returns the application protocols offered by the client hello.
+func (h *SniffHeader) ALPN() []string {
+	return h.alpn
+}
+
 var (
 	errNotTLS         = errors.New("not TLS header")
 	errNotClientHello = errors.New("not client hello")
@@ -29,7 +35,7 @@ func IsValidTLSVersion(major, minor byte) bool {
 	return major == 3
 }
 
-// ReadClientHello returns server name (if any) from TLS client hello message.
+// ReadClientHello returns server name (if any) and ALPN from TLS client hello message.
 // https://github.com/golang/go/blob/master/src/crypto/tls/handshake_messages.go#L300
 func ReadClientHello(data []byte, h *SniffHeader) error {
 	if len(data) < 42 {
@@ -72,36 +78,44 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 		return errNotClientHello
 	}
 
+	// Extensions after the server name are parsed for ALPN only, so errors in them are ignored.
+	fail := func(err error) error {
+		if h.domain != "" {
+			return nil
+		}
+		return err
+	}
 	for len(data) != 0 {
 		if len(data) < 4 {
-			return errNotClientHello
+			return fail(errNotClientHello)
 		}
 		extension := uint16(data[0])<<8 | uint16(data[1])
 		length := int(data[2])<<8 | int(data[3])
 		data = data[4:]
 		if len(data) < length {
-			return errNotClientHello
+			return fail(errNotClientHello)
 		}
 
-		if extension == 0x00 { /* extensionServerName */
+		switch extension {
+		case 0x00: /* extensionServerName */
 			d := data[:length]
 			if len(d) < 2 {
-				return errNotClientHello
+				return fail(errNotClientHello)
 			}
 			namesLen := int(d[0])<<8 | int(d[1])
 			d = d[2:]
 			if len(d) != namesLen {
-				return errNotClientHello
+				return fail(errNotClientHello)
 			}
 			for len(d) > 0 {
 				if len(d) < 3 {
-					return errNotClientHello
+					return fail(errNotClientHello)
 				}
 				nameType := d[0]
 				nameLen := int(d[1])<<8 | int(d[2])
 				d = d[3:]
 				if len(d) < nameLen {
-					return errNotClientHello
+					return fail(errNotClientHello)
 				}
 				if nameType == 0 {
 					serverName := string(d[:nameLen])
@@ -112,14 +126,27 @@ func ReadClientHello(data []byte, h *SniffHeader) error {
 						return errNotClientHello
 					}
 					h.domain = serverName
-					return nil
+					break
 				}
 				d = d[nameLen:]
 			}
+		case 0x10: /* extensionALPN */
+			d := data[:length]
+			if len(d) < 2 {
+				break
+			}
+			d = d[2:]
+			for len(d) > 0 && len(d) >= 1+int(d[0]) {
+				h.alpn = append(h.alpn, string(d[1:1+d[0]]))
+				d = d[1+d[0]:]
+			}
 		}
 		data = data[length:]
 	}
 
+	if h.domain != "" {
+		return nil
+	}
 	return errNotTLS
 }
 
@@ -1,6 +1,7 @@
 package tls_test
 
 import (
+	"slices"
 	"testing"
 
 	. "github.com/HZ-PRE/XrarCore/common/protocol/tls"
@@ -10,6 +11,7 @@ func TestTLSHeaders(t *testing.T) {
 	cases := []struct {
 		input  []byte
 		domain string
+		alpn   []string
 		err    bool
 	}{
 		{
@@ -42,6 +44,7 @@ func TestTLSHeaders(t *testing.T) {
 				0xaa, 0xaa, 0x00, 0x01, 0x00,
 			},
 			domain: "c.s-microsoft.com",
+			alpn:   []string{"h2", "http/1.1"},
 			err:    false,
 		},
 		{
@@ -156,6 +159,9 @@ func TestTLSHeaders(t *testing.T) {
 			if header.Domain() != test.domain {
 				t.Error("expect domain ", test.domain, " but got ", header.Domain())
 			}
+			if test.alpn != nil && !slices.Equal(header.ALPN(), test.alpn) {
+				t.Error("expect ALPN ", test.alpn, " but got ", header.ALPN())
+			}
 		}
 	}
 }
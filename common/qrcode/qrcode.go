@@ -0,0 +1,528 @@
+// Package qrcode encodes data into QR codes (ISO/IEC 18004) in byte mode, and renders them for
+// terminals.
+package qrcode
+
+import (
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+)
+
+// ecLevel is an error correction level.
+type ecLevel int
+
+const (
+	levelLow ecLevel = iota
+	levelMedium
+)
+
+// formatBits returns the bits of the level in format information.
+func (l ecLevel) formatBits() int {
+	if l == levelLow {
+		return 1
+	}
+	return 0
+}
+
+// eccCodewordsPerBlock and numErrorCorrectionBlocks are indexed by the level and the version.
+var eccCodewordsPerBlock = [2][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+}
+
+var numErrorCorrectionBlocks = [2][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+}
+
+// Code is a QR code. Dark modules are true.
+type Code struct {
+	Size       int
+	version    int
+	level      ecLevel
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode returns the smallest QR code of data. The medium error correction level is used if it doesn't
+// need a larger version than the low level.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if dataBits(data, v) <= numDataCodewords(v, levelLow)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("data is too long for a QR code: ", len(data), " bytes")
+	}
+	level := levelLow
+	if dataBits(data, version) <= numDataCodewords(version, levelMedium)*8 {
+		level = levelMedium
+	}
+
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := numDataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	c := &Code{
+		Size:    version*4 + 17,
+		version: version,
+		level:   level,
+	}
+	c.modules = newGrid(c.Size)
+	c.isFunction = newGrid(c.Size)
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.addECCAndInterleave(codewords))
+
+	mask, minPenalty := 0, -1
+	for i := 0; i < 8; i++ {
+		c.applyMask(i)
+		c.drawFormatBits(i)
+		if penalty := c.penalty(); minPenalty < 0 || penalty < minPenalty {
+			mask, minPenalty = i, penalty
+		}
+		c.applyMask(i)
+	}
+	c.applyMask(mask)
+	c.drawFormatBits(mask)
+	return c, nil
+}
+
+// Module returns whether the module at (x, y) is dark. Modules out of the code are light.
+func (c *Code) Module(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
+
+// Terminal renders c with half blocks, two rows of modules per line, for terminals with dark
+// backgrounds. Light modules and the quiet zone are drawn, so that it's also scannable on them.
+func (c *Code) Terminal() string {
+	const border = 2
+	var sb strings.Builder
+	for y := -border; y < c.Size+border; y += 2 {
+		for x := -border; x < c.Size+border; x++ {
+			top, bottom := !c.Module(x, y), !c.Module(x, y+1)
+			if y+1 >= c.Size+border {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func newGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	return grid
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+func dataBits(data []byte, version int) int {
+	return 4 + charCountBits(version) + len(data)*8
+}
+
+// numRawDataModules returns the number of modules for data and error correction codewords, including
+// remainder bits.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level ecLevel) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (value>>i)&1 != 0)
+	}
+}
+
+func (c *Code) setFunctionModule(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunctionModule(6, i, i%2 == 0)
+		c.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	positions := c.alignmentPatternPositions()
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the areas of format information.
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < c.Size && yy >= 0 && yy < c.Size {
+				dist := max(abs(dx), abs(dy))
+				c.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+			}
+		}
+	}
+}
+
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunctionModule(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (c *Code) alignmentPatternPositions() []int {
+	if c.version == 1 {
+		return nil
+	}
+	numAlign := c.version/7 + 2
+	step := (c.version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, c.Size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	data := c.level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunctionModule(8, i, bit(bits, i))
+	}
+	c.setFunctionModule(8, 7, bit(bits, 6))
+	c.setFunctionModule(8, 8, bit(bits, 7))
+	c.setFunctionModule(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunctionModule(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunctionModule(c.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunctionModule(8, c.Size-15+i, bit(bits, i))
+	}
+	c.setFunctionModule(8, c.Size-8, true)
+}
+
+func (c *Code) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+	rem := c.version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		c.setFunctionModule(a, b, bit(bits, i))
+		c.setFunctionModule(b, a, bit(bits, i))
+	}
+}
+
+// addECCAndInterleave splits data into blocks, appends the error correction codewords of each block,
+// and interleaves them.
+func (c *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[c.level][c.version]
+	blockECCLen := eccCodewordsPerBlock[c.level][c.version]
+	rawCodewords := numRawDataModules(c.version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			// Skip the padding of short blocks.
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords draws data in the zigzag order.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunction[y][x] && i < len(data)*8 {
+					c.modules[y][x] = bit(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask XORs the data modules with mask. Applying it twice undoes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			c.modules[y][x] = c.modules[y][x] != (invert && !c.isFunction[y][x])
+		}
+	}
+}
+
+// Penalty weights of the mask evaluation.
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// penalty returns the penalty score of the current modules, for choosing a mask.
+func (c *Code) penalty() int {
+	result := 0
+	for _, horizontal := range []bool{true, false} {
+		for a := 0; a < c.Size; a++ {
+			runColor, run := false, 0
+			var history finderHistory
+			for b := 0; b < c.Size; b++ {
+				color := c.modules[a][b]
+				if !horizontal {
+					color = c.modules[b][a]
+				}
+				if color == runColor {
+					run++
+					if run == 5 {
+						result += penaltyN1
+					} else if run > 5 {
+						result++
+					}
+				} else {
+					history.add(run, c.Size)
+					if !runColor {
+						result += history.count() * penaltyN3
+					}
+					runColor, run = color, 1
+				}
+			}
+			result += history.terminate(runColor, run, c.Size) * penaltyN3
+		}
+	}
+
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			color := c.modules[y][x]
+			if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+				result += penaltyN2
+			}
+		}
+	}
+
+	dark := 0
+	for _, row := range c.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyN4
+	return result
+}
+
+// finderHistory holds the lengths of the recent runs of a line, for finding finder-like patterns.
+type finderHistory [7]int
+
+func (h *finderHistory) add(run, size int) {
+	if h[0] == 0 {
+		// The light border before the line.
+		run += size
+	}
+	copy(h[1:], h[:6])
+	h[0] = run
+}
+
+func (h *finderHistory) count() int {
+	n := h[1]
+	core := n > 0 && h[2] == n && h[3] == n*3 && h[4] == n && h[5] == n
+	result := 0
+	if core && h[0] >= n*4 && h[6] >= n {
+		result++
+	}
+	if core && h[6] >= n*4 && h[0] >= n {
+		result++
+	}
+	return result
+}
+
+func (h *finderHistory) terminate(runColor bool, run, size int) int {
+	if runColor {
+		h.add(run, size)
+		run = 0
+	}
+	h.add(run+size, size)
+	return h.count()
+}
+
+// reedSolomonDivisor returns the generator polynomial of degree, without the leading term.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMul(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) with the polynomial 0x11D.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
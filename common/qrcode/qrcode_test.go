@@ -0,0 +1,167 @@
+package qrcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// decode reads the data of c back, checking the format information and the error correction
+// codewords on the way.
+func decode(t *testing.T, c *Code) []byte {
+	t.Helper()
+
+	var format int
+	for i := 0; i <= 5; i++ {
+		if c.modules[i][8] {
+			format |= 1 << i
+		}
+	}
+	for i, m := range []bool{c.modules[7][8], c.modules[8][8], c.modules[8][7]} {
+		if m {
+			format |= 1 << (6 + i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if c.modules[8][14-i] {
+			format |= 1 << i
+		}
+	}
+	data := (format ^ 0x5412) >> 10
+	if level := data >> 3; level != c.level.formatBits() {
+		t.Fatal("unexpected level in format information: ", level)
+	}
+	mask := data & 7
+
+	c.applyMask(mask)
+	defer c.applyMask(mask)
+
+	var bits []bool
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunction[y][x] {
+					bits = append(bits, c.modules[y][x])
+				}
+			}
+		}
+	}
+	interleaved := make([]byte, len(bits)/8)
+	for i := range interleaved {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				interleaved[i] |= 1 << (7 - j)
+			}
+		}
+	}
+
+	numBlocks := numErrorCorrectionBlocks[c.level][c.version]
+	eccLen := eccCodewordsPerBlock[c.level][c.version]
+	numShortBlocks := numBlocks - len(interleaved)%numBlocks
+	shortDataLen := len(interleaved)/numBlocks - eccLen
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortDataLen+1; i++ {
+		for j := range blocks {
+			if i < shortDataLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], interleaved[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], interleaved[k])
+			k++
+		}
+	}
+
+	var codewords []byte
+	for i, block := range blocks {
+		// Evaluate the block at the roots of the generator polynomial.
+		root := byte(1)
+		for r := 0; r < eccLen; r++ {
+			var syndrome byte
+			for _, b := range block {
+				syndrome = gfMul(syndrome, root) ^ b
+			}
+			if syndrome != 0 {
+				t.Fatal("non-zero syndrome ", r, " of block ", i)
+			}
+			root = gfMul(root, 0x02)
+		}
+		codewords = append(codewords, block[:len(block)-eccLen]...)
+	}
+
+	read := func(offset, length int) int {
+		v := 0
+		for i := offset; i < offset+length; i++ {
+			v = v<<1 | int(codewords[i>>3]>>(7-i&7)&1)
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		t.Fatal("unexpected mode: ", mode)
+	}
+	n := read(4, charCountBits(c.version))
+	result := make([]byte, n)
+	for i := range result {
+		result[i] = byte(read(4+charCountBits(c.version)+i*8, 8))
+	}
+	return result
+}
+
+func TestEncode(t *testing.T) {
+	for _, tc := range []struct {
+		data    string
+		version int
+	}{
+		{"", 1},
+		{"hello", 1},
+		{strings.Repeat("a", 17), 1},
+		{strings.Repeat("a", 18), 2},
+		{"vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?security=reality&sni=www.example.com&fp=chrome&pbk=SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA&sid=6ba85179e30d4fc2&type=tcp&flow=xtls-rprx-vision#example", 0},
+		{strings.Repeat("x", 2953), 40},
+	} {
+		c, err := Encode([]byte(tc.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.version != 0 && c.version != tc.version {
+			t.Error("unexpected version: ", c.version, ", expected ", tc.version)
+		}
+		if c.Size != c.version*4+17 {
+			t.Error("unexpected size: ", c.Size)
+		}
+		if got := decode(t, c); !bytes.Equal(got, []byte(tc.data)) {
+			t.Errorf("decoded %q, expected %q", got, tc.data)
+		}
+	}
+
+	if _, err := Encode(make([]byte, 2954)); err == nil {
+		t.Error("expected error of too long data")
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := Encode([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(), "\n"), "\n")
+	if len(lines) != (c.Size+4+1)/2 {
+		t.Error("unexpected number of lines: ", len(lines))
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n != c.Size+4 {
+			t.Error("unexpected width: ", n)
+		}
+	}
+}
@@ -51,6 +51,9 @@ type Inbound struct {
 	// CanSpliceCopy is a property for this connection
 	// 1 = can, 2 = after processing protocol info should be able to, 3 = cannot
 	CanSpliceCopy int
+	// Shared is true if Conn carries multiple requests, such as those of Mux, so that it mustn't
+	// be torn down for one of them.
+	Shared bool
 }
 
 // Outbound is the metadata of an outbound connection.
@@ -99,6 +102,12 @@ type Sockopt struct {
 	Mark int32
 }
 
+// Attributes of the server name and ALPN sniffed from TLS. ALPN is separated by commas.
+const (
+	AttributeSNI  = ":sni"
+	AttributeALPN = ":alpn"
+)
+
 // SetAttribute attaches additional string attributes to content.
 func (c *Content) SetAttribute(name string, value string) {
 	if c.Attributes == nil {
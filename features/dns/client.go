@@ -1,10 +1,13 @@
 package dns
 
 import (
+	"context"
+
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/features"
+	"github.com/miekg/dns"
 )
 
 // IPOption is an object for IP query options.
@@ -24,6 +27,16 @@ type Client interface {
 	LookupIP(domain string, option IPOption) ([]net.IP, error)
 }
 
+// RecordLookup is an optional interface of Client, for querying records other than A and AAAA,
+// such as SRV, TXT and HTTPS.
+//
+// xray:api:beta
+type RecordLookup interface {
+	// LookupRecords returns the answer records of type qType for the given domain. The lookup is
+	// canceled when ctx is done.
+	LookupRecords(ctx context.Context, domain string, qType uint16) ([]dns.RR, error)
+}
+
 type HostsLookup interface {
 	LookupHosts(domain string) *net.Address
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/features"
 )
 
@@ -18,6 +19,13 @@ type Handler interface {
 
 	// Deprecated: Do not use in new code.
 	GetRandomInboundProxy() (interface{}, net.Port, int)
+
+	// ReceiverSettings returns the receiver settings of this handler, or nil if it's not
+	// created from configs.
+	ReceiverSettings() *serial.TypedMessage
+	// ProxySettings returns the proxy settings of this handler, or nil if it's not created
+	// from configs.
+	ProxySettings() *serial.TypedMessage
 }
 
 // Manager is a feature that manages InboundHandlers.
@@ -32,6 +40,9 @@ type Manager interface {
 
 	// RemoveHandler removes a handler from Manager.
 	RemoveHandler(ctx context.Context, tag string) error
+
+	// ListHandlers returns all the handlers, ordered by their tags.
+	ListHandlers(ctx context.Context) []Handler
 }
 
 // ManagerType returns the type of Manager interface. Can be used for implementing common.HasType.
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/features"
 	"github.com/HZ-PRE/XrarCore/transport"
 )
@@ -15,6 +16,13 @@ type Handler interface {
 	common.Runnable
 	Tag() string
 	Dispatch(ctx context.Context, link *transport.Link)
+
+	// SenderSettings returns the sender settings of this handler, or nil if it has none or
+	// it's not created from configs.
+	SenderSettings() *serial.TypedMessage
+	// ProxySettings returns the proxy settings of this handler, or nil if it's not created
+	// from configs.
+	ProxySettings() *serial.TypedMessage
 }
 
 type HandlerSelector interface {
@@ -35,6 +43,10 @@ type Manager interface {
 
 	// RemoveHandler removes a handler from outbound.Manager.
 	RemoveHandler(ctx context.Context, tag string) error
+
+	// ListHandlers returns all the handlers, with the default one first and the others
+	// ordered by their tags.
+	ListHandlers(ctx context.Context) []Handler
 }
 
 // ManagerType returns the type of Manager interface. Can be used to implement common.HasType.
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/platform/filesystem"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/proxy/blackhole"
 	"google.golang.org/protobuf/proto"
@@ -15,10 +16,58 @@ func (*NoneResponse) Build() (proto.Message, error) {
 	return new(blackhole.NoneResponse), nil
 }
 
-type HTTPResponse struct{}
+type HTTPResponse struct {
+	StatusCode uint32            `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	BodyFile   string            `json:"bodyFile"`
+}
+
+func (c *HTTPResponse) Build() (proto.Message, error) {
+	if c.StatusCode != 0 && (c.StatusCode < 100 || c.StatusCode > 999) {
+		return nil, errors.New("Config: invalid HTTP status code ", c.StatusCode)
+	}
+	if c.Body != "" && c.BodyFile != "" {
+		return nil, errors.New("Config: body and bodyFile cannot be used together in blackhole HTTP response.")
+	}
+	response := &blackhole.HTTPResponse{
+		StatusCode: c.StatusCode,
+		Header:     c.Headers,
+		Body:       []byte(c.Body),
+	}
+	if c.BodyFile != "" {
+		body, err := filesystem.ReadFile(c.BodyFile)
+		if err != nil {
+			return nil, errors.New("Config: failed to read blackhole HTTP response body from ", c.BodyFile).Base(err)
+		}
+		response.Body = body
+	}
+	if len(response.Body) == 0 {
+		response.Body = nil
+	}
+	return response, nil
+}
+
+type RSTResponse struct{}
+
+func (*RSTResponse) Build() (proto.Message, error) {
+	return new(blackhole.RSTResponse), nil
+}
+
+type TarpitResponse struct {
+	Duration uint32 `json:"duration"`
+}
+
+func (c *TarpitResponse) Build() (proto.Message, error) {
+	return &blackhole.TarpitResponse{
+		Duration: c.Duration,
+	}, nil
+}
+
+type NXDomainResponse struct{}
 
-func (*HTTPResponse) Build() (proto.Message, error) {
-	return new(blackhole.HTTPResponse), nil
+func (*NXDomainResponse) Build() (proto.Message, error) {
+	return new(blackhole.NXDomainResponse), nil
 }
 
 type BlackholeConfig struct {
@@ -44,8 +93,11 @@ func (v *BlackholeConfig) Build() (proto.Message, error) {
 
 var configLoader = NewJSONConfigLoader(
 	ConfigCreatorCache{
-		"none": func() interface{} { return new(NoneResponse) },
-		"http": func() interface{} { return new(HTTPResponse) },
+		"none":     func() interface{} { return new(NoneResponse) },
+		"http":     func() interface{} { return new(HTTPResponse) },
+		"rst":      func() interface{} { return new(RSTResponse) },
+		"tarpit":   func() interface{} { return new(TarpitResponse) },
+		"nxdomain": func() interface{} { return new(NXDomainResponse) },
 	},
 	"type",
 	"")
@@ -25,6 +25,53 @@ func TestHTTPResponseJSON(t *testing.T) {
 				Response: serial.ToTypedMessage(&blackhole.HTTPResponse{}),
 			},
 		},
+		{
+			Input: `{
+				"response": {
+					"type": "http",
+					"statusCode": 451,
+					"headers": {
+						"Content-Type": "text/html"
+					},
+					"body": "blocked"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &blackhole.Config{
+				Response: serial.ToTypedMessage(&blackhole.HTTPResponse{
+					StatusCode: 451,
+					Header: map[string]string{
+						"Content-Type": "text/html",
+					},
+					Body: []byte("blocked"),
+				}),
+			},
+		},
+		{
+			Input: `{
+				"response": {
+					"type": "tarpit",
+					"duration": 30
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &blackhole.Config{
+				Response: serial.ToTypedMessage(&blackhole.TarpitResponse{
+					Duration: 30,
+				}),
+			},
+		},
+		{
+			Input: `{
+				"response": {
+					"type": "nxdomain"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &blackhole.Config{
+				Response: serial.ToTypedMessage(&blackhole.NXDomainResponse{}),
+			},
+		},
 		{
 			Input:  `{}`,
 			Parser: loadJSON(creator),
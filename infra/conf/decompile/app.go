@@ -0,0 +1,470 @@
+package decompile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/app/commander"
+	"github.com/HZ-PRE/XrarCore/app/dispatcher"
+	"github.com/HZ-PRE/XrarCore/app/dns"
+	"github.com/HZ-PRE/XrarCore/app/dns/fakedns"
+	"github.com/HZ-PRE/XrarCore/app/log"
+	loggerservice "github.com/HZ-PRE/XrarCore/app/log/command"
+	"github.com/HZ-PRE/XrarCore/app/metrics"
+	"github.com/HZ-PRE/XrarCore/app/observatory"
+	"github.com/HZ-PRE/XrarCore/app/observatory/burst"
+	observatoryservice "github.com/HZ-PRE/XrarCore/app/observatory/command"
+	"github.com/HZ-PRE/XrarCore/app/policy"
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	handlerservice "github.com/HZ-PRE/XrarCore/app/proxyman/command"
+	"github.com/HZ-PRE/XrarCore/app/reverse"
+	"github.com/HZ-PRE/XrarCore/app/router"
+	routerservice "github.com/HZ-PRE/XrarCore/app/router/command"
+	"github.com/HZ-PRE/XrarCore/app/stats"
+	statsservice "github.com/HZ-PRE/XrarCore/app/stats/command"
+	"github.com/HZ-PRE/XrarCore/app/subscription"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	clog "github.com/HZ-PRE/XrarCore/common/log"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"google.golang.org/protobuf/proto"
+)
+
+// app converts the app config into the corresponding section of c.
+func (d *Decompiler) app(c object, tm *serial.TypedMessage) error {
+	a, err := instance(tm)
+	if err != nil {
+		return err
+	}
+	switch a := a.(type) {
+	case *dispatcher.Config, *proxyman.InboundConfig, *proxyman.OutboundConfig:
+		// These are added to every config.
+	case *log.Config:
+		if !proto.Equal(a, conf.DefaultLogConfig()) {
+			c["log"] = logConfig(a)
+		}
+	case *commander.Config:
+		api := object{"tag": a.Tag}
+		put(api, "listen", a.Listen)
+		var services []string
+		for _, s := range a.Service {
+			name, err := apiService(s)
+			if err != nil {
+				return err
+			}
+			services = append(services, name)
+		}
+		put(api, "services", services)
+		c["api"] = api
+	case *metrics.Config:
+		m := object{}
+		put(m, "tag", a.Tag)
+		put(m, "listen", a.Listen)
+		c["metrics"] = m
+	case *stats.Config:
+		c["stats"] = object{}
+	case *router.Config:
+		routing, err := d.routing(a)
+		if err != nil {
+			return err
+		}
+		c["routing"] = routing
+	case *dns.Config:
+		dnsConfig, err := d.dns(a)
+		if err != nil {
+			return err
+		}
+		c["dns"] = dnsConfig
+	case *policy.Config:
+		c["policy"] = policyConfig(a)
+	case *reverse.Config:
+		r := object{}
+		bridges := make([]interface{}, len(a.BridgeConfig))
+		for i, b := range a.BridgeConfig {
+			bridges[i] = object{"tag": b.Tag, "domain": b.Domain}
+		}
+		put(r, "bridges", bridges)
+		portals := make([]interface{}, len(a.PortalConfig))
+		for i, p := range a.PortalConfig {
+			portals[i] = object{"tag": p.Tag, "domain": p.Domain}
+		}
+		put(r, "portals", portals)
+		c["reverse"] = r
+	case *fakedns.FakeDnsPoolMulti:
+		pools := make([]interface{}, len(a.Pools))
+		for i, p := range a.Pools {
+			pools[i] = object{"ipPool": p.IpPool, "poolSize": p.LruSize}
+		}
+		c["fakeDns"] = pools
+	case *observatory.Config:
+		o := object{}
+		put(o, "subjectSelector", a.SubjectSelector)
+		put(o, "probeURL", a.ProbeUrl)
+		put(o, "probeInterval", durationString(a.ProbeInterval))
+		put(o, "enableConcurrency", a.EnableConcurrency)
+		c["observatory"] = o
+	case *burst.Config:
+		o := object{}
+		put(o, "subjectSelector", a.SubjectSelector)
+		if p := a.PingConfig; p != nil {
+			ping := object{}
+			put(ping, "destination", p.Destination)
+			put(ping, "connectivity", p.Connectivity)
+			put(ping, "interval", durationString(p.Interval))
+			put(ping, "sampling", p.SamplingCount)
+			put(ping, "timeout", durationString(p.Timeout))
+			o["pingConfig"] = ping
+		}
+		c["burstObservatory"] = o
+	case *subscription.Config:
+		providers := make([]interface{}, len(a.Providers))
+		for i, p := range a.Providers {
+			provider := object{"tag": p.Tag}
+			put(provider, "url", p.Url)
+			put(provider, "file", p.File)
+			if p.TagPrefix != p.Tag+"-" {
+				put(provider, "tagPrefix", p.TagPrefix)
+			}
+			put(provider, "interval", durationString(p.Interval))
+			put(provider, "outboundTag", p.OutboundTag)
+			providers[i] = provider
+		}
+		c["providers"] = providers
+	default:
+		return errors.New("unsupported app")
+	}
+	return nil
+}
+
+// durationString returns the duration in nanoseconds as a string such as "1m0s".
+func durationString(d int64) string {
+	if d == 0 {
+		return ""
+	}
+	return time.Duration(d).String()
+}
+
+var logLevels = map[clog.Severity]string{
+	clog.Severity_Debug: "debug",
+	clog.Severity_Info:  "info",
+	clog.Severity_Error: "error",
+}
+
+func logConfig(c *log.Config) object {
+	l := object{}
+	if c.AccessLogType == log.LogType_None && c.ErrorLogType == log.LogType_None && c.ErrorLogLevel == clog.Severity_Unknown {
+		l["loglevel"] = "none"
+	} else {
+		put(l, "access", logPath(c.AccessLogType, c.AccessLogPath))
+		put(l, "error", logPath(c.ErrorLogType, c.ErrorLogPath))
+		put(l, "loglevel", logLevels[c.ErrorLogLevel])
+	}
+	put(l, "dnsLog", c.EnableDnsLog)
+	put(l, "maskAddress", c.MaskAddress)
+	return l
+}
+
+// logPath returns the path of the log, which is empty for the console.
+func logPath(t log.LogType, path string) string {
+	switch t {
+	case log.LogType_None:
+		return "none"
+	case log.LogType_File:
+		return path
+	default:
+		return ""
+	}
+}
+
+func apiService(tm *serial.TypedMessage) (string, error) {
+	s, err := instance(tm)
+	if err != nil {
+		return "", err
+	}
+	switch s.(type) {
+	case *commander.ReflectionConfig:
+		return "ReflectionService", nil
+	case *handlerservice.Config:
+		return "HandlerService", nil
+	case *loggerservice.Config:
+		return "LoggerService", nil
+	case *statsservice.Config:
+		return "StatsService", nil
+	case *observatoryservice.Config:
+		return "ObservatoryService", nil
+	case *routerservice.Config:
+		return "RoutingService", nil
+	default:
+		return "", errors.New("unsupported API service ", tm.Type)
+	}
+}
+
+var (
+	routingDomainStrategies = map[router.Config_DomainStrategy]string{
+		router.Config_UseIp:        "AlwaysIP",
+		router.Config_IpIfNonMatch: "IPIfNonMatch",
+		router.Config_IpOnDemand:   "IPOnDemand",
+	}
+	domainPrefixes = map[router.Domain_Type]string{
+		router.Domain_Plain:  "keyword:",
+		router.Domain_Regex:  "regexp:",
+		router.Domain_Domain: "domain:",
+		router.Domain_Full:   "full:",
+	}
+)
+
+func (d *Decompiler) routing(c *router.Config) (object, error) {
+	r := object{}
+	put(r, "domainStrategy", routingDomainStrategies[c.DomainStrategy])
+	rules := make([]interface{}, len(c.Rule))
+	for i, rule := range c.Rule {
+		rules[i] = d.rule(rule)
+	}
+	put(r, "rules", rules)
+	balancers := make([]interface{}, len(c.BalancingRule))
+	for i, b := range c.BalancingRule {
+		balancer := object{"tag": b.Tag, "selector": b.OutboundSelector}
+		strategy := object{"type": b.Strategy}
+		settings, err := strategySettings(b.StrategySettings)
+		if err != nil {
+			return nil, err
+		}
+		put(strategy, "settings", settings)
+		balancer["strategy"] = strategy
+		put(balancer, "fallbackTag", b.FallbackTag)
+		balancers[i] = balancer
+	}
+	put(r, "balancers", balancers)
+	return r, nil
+}
+
+func (d *Decompiler) rule(rule *router.RoutingRule) object {
+	r := object{}
+	put(r, "ruleTag", rule.RuleTag)
+	switch t := rule.TargetTag.(type) {
+	case *router.RoutingRule_Tag:
+		r["outboundTag"] = t.Tag
+	case *router.RoutingRule_BalancingTag:
+		r["balancerTag"] = t.BalancingTag
+	}
+	put(r, "domainMatcher", rule.DomainMatcher)
+	domains := make([]string, len(rule.Domain))
+	for i, domain := range rule.Domain {
+		domains[i] = domainPrefixes[domain.Type] + domain.Value
+	}
+	put(r, "domain", domains)
+	put(r, "ip", d.ipList(rule.Geoip))
+	put(r, "port", portList(rule.PortList))
+	if len(rule.Networks) > 0 {
+		names := make([]string, len(rule.Networks))
+		for i, n := range rule.Networks {
+			names[i] = n.SystemString()
+		}
+		r["network"] = strings.Join(names, ",")
+	}
+	put(r, "source", d.ipList(rule.SourceGeoip))
+	put(r, "sourcePort", portList(rule.SourcePortList))
+	put(r, "user", rule.UserEmail)
+	put(r, "inboundTag", rule.InboundTag)
+	put(r, "protocol", rule.Protocol)
+	put(r, "attrs", rule.Attributes)
+	return r
+}
+
+// ipList returns the IP rules, where the GeoIP lists are referred by their country codes and the
+// others are expanded into CIDRs.
+func (d *Decompiler) ipList(list []*router.GeoIP) []string {
+	var ips []string
+	for _, geoip := range list {
+		if geoip.CountryCode != "" && !strings.Contains(geoip.CountryCode, "_") {
+			code := strings.ToLower(geoip.CountryCode)
+			if geoip.ReverseMatch {
+				code = "!" + code
+			}
+			ips = append(ips, "geoip:"+code)
+			continue
+		}
+		if geoip.ReverseMatch {
+			d.warn("skipped reverse matching of IP list ", geoip.CountryCode)
+		}
+		for _, cidr := range geoip.Cidr {
+			s := ipString(cidr.Ip)
+			if s == "" {
+				continue
+			}
+			if int(cidr.Prefix) != len(cidr.Ip)*8 {
+				s = fmt.Sprint(s, "/", cidr.Prefix)
+			}
+			ips = append(ips, s)
+		}
+	}
+	return ips
+}
+
+func strategySettings(tm *serial.TypedMessage) (object, error) {
+	s, err := instance(tm)
+	if err != nil {
+		return nil, err
+	}
+	switch s := s.(type) {
+	case nil:
+		return nil, nil
+	case *router.StrategyLeastLoadConfig:
+		settings := object{}
+		costs := make([]interface{}, len(s.Costs))
+		for i, c := range s.Costs {
+			cost := object{"match": c.Match, "value": c.Value}
+			put(cost, "regexp", c.Regexp)
+			costs[i] = cost
+		}
+		put(settings, "costs", costs)
+		baselines := make([]string, len(s.Baselines))
+		for i, b := range s.Baselines {
+			baselines[i] = durationString(b)
+		}
+		put(settings, "baselines", baselines)
+		put(settings, "expected", s.Expected)
+		put(settings, "maxRTT", durationString(s.MaxRTT))
+		put(settings, "tolerance", s.Tolerance)
+		return settings, nil
+	default:
+		return nil, errors.New("unsupported strategy settings ", tm.Type)
+	}
+}
+
+var (
+	dnsDomainPrefixes = map[dns.DomainMatchingType]string{
+		dns.DomainMatchingType_Subdomain: "domain:",
+		dns.DomainMatchingType_Keyword:   "keyword:",
+		dns.DomainMatchingType_Regex:     "regexp:",
+		dns.DomainMatchingType_Full:      "full:",
+	}
+	queryStrategies = map[dns.QueryStrategy]string{
+		dns.QueryStrategy_USE_IP4: "UseIPv4",
+		dns.QueryStrategy_USE_IP6: "UseIPv6",
+	}
+)
+
+func (d *Decompiler) dns(c *dns.Config) (object, error) {
+	s := object{}
+	servers := make([]interface{}, len(c.NameServer))
+	for i, ns := range c.NameServer {
+		servers[i] = d.nameServer(ns)
+	}
+	put(s, "servers", servers)
+	if len(c.StaticHosts) > 0 {
+		hosts := object{}
+		for _, h := range c.StaticHosts {
+			key := h.Domain
+			if h.Type != dns.DomainMatchingType_Full {
+				key = dnsDomainPrefixes[h.Type] + h.Domain
+			}
+			switch {
+			case h.ProxiedDomain != "":
+				hosts[key] = h.ProxiedDomain
+			case len(h.Ip) == 1:
+				hosts[key] = ipString(h.Ip[0])
+			default:
+				ips := make([]string, len(h.Ip))
+				for i, ip := range h.Ip {
+					ips[i] = ipString(ip)
+				}
+				hosts[key] = ips
+			}
+		}
+		s["hosts"] = hosts
+	}
+	put(s, "clientIp", ipString(c.ClientIp))
+	put(s, "tag", c.Tag)
+	put(s, "queryStrategy", queryStrategies[c.QueryStrategy])
+	put(s, "disableCache", c.DisableCache)
+	put(s, "disableFallback", c.DisableFallback)
+	put(s, "disableFallbackIfMatch", c.DisableFallbackIfMatch)
+	return s, nil
+}
+
+// nameServer returns the name server, which is a plain address if it has no other settings.
+func (d *Decompiler) nameServer(ns *dns.NameServer) interface{} {
+	s := object{}
+	put(s, "address", address(ns.Address.GetAddress()))
+	put(s, "port", ns.Address.GetPort())
+	put(s, "clientIp", ipString(ns.ClientIp))
+	put(s, "skipFallback", ns.SkipFallback)
+	var size uint32
+	for _, rule := range ns.OriginalRules {
+		size += rule.Size
+	}
+	var domains []string
+	if len(ns.OriginalRules) > 0 && int(size) == len(ns.PrioritizedDomain) {
+		for _, rule := range ns.OriginalRules {
+			domains = append(domains, rule.Rule)
+		}
+	} else {
+		for _, domain := range ns.PrioritizedDomain {
+			domains = append(domains, dnsDomainPrefixes[domain.Type]+domain.Domain)
+		}
+	}
+	put(s, "domains", domains)
+	put(s, "expectIps", d.ipList(ns.Geoip))
+	put(s, "queryStrategy", queryStrategies[ns.QueryStrategy])
+	if len(s) == 1 && s["address"] != nil {
+		return s["address"]
+	}
+	return s
+}
+
+func policyConfig(c *policy.Config) object {
+	p := object{}
+	if len(c.Level) > 0 {
+		levels := make([]uint32, 0, len(c.Level))
+		for level := range c.Level {
+			levels = append(levels, level)
+		}
+		sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+		l := object{}
+		for _, level := range levels {
+			l[fmt.Sprint(level)] = levelPolicy(c.Level[level])
+		}
+		p["levels"] = l
+	}
+	if s := c.System.GetStats(); s != nil {
+		system := object{}
+		put(system, "statsInboundUplink", s.InboundUplink)
+		put(system, "statsInboundDownlink", s.InboundDownlink)
+		put(system, "statsOutboundUplink", s.OutboundUplink)
+		put(system, "statsOutboundDownlink", s.OutboundDownlink)
+		p["system"] = system
+	}
+	return p
+}
+
+func levelPolicy(p *policy.Policy) object {
+	l := object{}
+	if t := p.Timeout; t != nil {
+		if t.Handshake != nil {
+			l["handshake"] = t.Handshake.Value
+		}
+		if t.ConnectionIdle != nil {
+			l["connIdle"] = t.ConnectionIdle.Value
+		}
+		if t.UplinkOnly != nil {
+			l["uplinkOnly"] = t.UplinkOnly.Value
+		}
+		if t.DownlinkOnly != nil {
+			l["downlinkOnly"] = t.DownlinkOnly.Value
+		}
+	}
+	put(l, "statsUserUplink", p.Stats.GetUserUplink())
+	put(l, "statsUserDownlink", p.Stats.GetUserDownlink())
+	put(l, "statsUserOnline", p.Stats.GetUserOnline())
+	if p.Buffer != nil {
+		size := p.Buffer.Connection
+		if size > 0 {
+			size /= 1024
+		}
+		l["bufferSize"] = size
+	}
+	return l
+}
@@ -0,0 +1,158 @@
+// Package decompile converts protobuf configs back into JSON configs that can be decoded into
+// conf.Config, so that the configs of nodes altered via the API can be inspected and exported.
+package decompile
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/core"
+	"google.golang.org/protobuf/proto"
+)
+
+type object = map[string]interface{}
+
+// Decompiler converts protobuf configs into JSON configs. The settings that have no JSON
+// equivalent are skipped, with warnings recorded.
+type Decompiler struct {
+	Warnings []string
+}
+
+func (d *Decompiler) warn(v ...interface{}) {
+	d.Warnings = append(d.Warnings, serial.Concat(v...))
+}
+
+// Config converts the config. The inbounds, outbounds and apps that fail to be converted are
+// skipped with warnings.
+func (d *Decompiler) Config(config *core.Config) (map[string]interface{}, error) {
+	c := object{}
+	var inbounds, outbounds []interface{}
+	for _, in := range config.Inbound {
+		v, err := d.Inbound(in)
+		if err != nil {
+			d.warn("skipped inbound ", in.Tag, ": ", err)
+			continue
+		}
+		inbounds = append(inbounds, v)
+	}
+	for _, out := range config.Outbound {
+		v, err := d.Outbound(out)
+		if err != nil {
+			d.warn("skipped outbound ", out.Tag, ": ", err)
+			continue
+		}
+		outbounds = append(outbounds, v)
+	}
+	put(c, "inbounds", inbounds)
+	put(c, "outbounds", outbounds)
+
+	for _, app := range config.App {
+		if err := d.app(c, app); err != nil {
+			d.warn("skipped app ", app.Type, ": ", err)
+		}
+	}
+	for _, ext := range config.Extension {
+		d.warn("skipped extension ", ext.Type)
+	}
+	return c, nil
+}
+
+// put sets the value of the key, unless the value is zero or empty.
+func put(o object, key string, value interface{}) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.IsZero() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return
+		}
+	}
+	o[key] = value
+}
+
+// instance returns the message of the typed message, which is nil if the typed message is nil.
+func instance(tm *serial.TypedMessage) (proto.Message, error) {
+	if tm == nil {
+		return nil, nil
+	}
+	v, err := tm.GetInstance()
+	if err != nil {
+		return nil, errors.New("unknown message ", tm.Type).Base(err)
+	}
+	return v, nil
+}
+
+func address(a *net.IPOrDomain) string {
+	if a == nil {
+		return ""
+	}
+	addr := a.AsAddress()
+	if addr.Family().IsDomain() {
+		return addr.Domain()
+	}
+	return addr.IP().String()
+}
+
+// ipString returns the IP in bytes as a string, which is empty if the IP is invalid.
+func ipString(ip []byte) string {
+	if len(ip) != 4 && len(ip) != 16 {
+		return ""
+	}
+	return net.IPAddress(ip).IP().String()
+}
+
+// portList returns a number for a single port, or a string such as "1000-2000,3000".
+func portList(list *net.PortList) interface{} {
+	if list == nil || len(list.Range) == 0 {
+		return nil
+	}
+	if len(list.Range) == 1 && list.Range[0].From == list.Range[0].To {
+		return list.Range[0].From
+	}
+	var s string
+	for i, r := range list.Range {
+		if i > 0 {
+			s += ","
+		}
+		s += rangeString(int64(r.From), int64(r.To))
+	}
+	return s
+}
+
+func rangeString(from, to int64) string {
+	if from == to {
+		return fmt.Sprint(from)
+	}
+	return fmt.Sprint(from, "-", to)
+}
+
+// networks returns the names of the networks, or nil for the default TCP only.
+func networks(list []net.Network) interface{} {
+	if len(list) == 1 && list[0] == net.Network_TCP {
+		return nil
+	}
+	names := make([]string, len(list))
+	for i, n := range list {
+		names[i] = n.SystemString()
+	}
+	return names
+}
+
+// domainStrategies are the names of domain strategies in the order of their values.
+var domainStrategies = []string{
+	"AsIs", "UseIP", "UseIPv4", "UseIPv6", "UseIPv4v6", "UseIPv6v4",
+	"ForceIP", "ForceIPv4", "ForceIPv6", "ForceIPv4v6", "ForceIPv6v4",
+}
+
+// domainStrategy returns the name of the domain strategy, or "" for AsIs.
+func domainStrategy(v int32) string {
+	if v <= 0 || int(v) >= len(domainStrategies) {
+		return ""
+	}
+	return domainStrategies[v]
+}
@@ -0,0 +1,207 @@
+package decompile_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/core"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/decompile"
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
+	"google.golang.org/protobuf/proto"
+)
+
+func load(t *testing.T, s string) *core.Config {
+	t.Helper()
+	config, err := serial.LoadJSONConfig(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := []string{
+		`{
+			"log": {"loglevel": "debug", "access": "none"},
+			"api": {"tag": "api", "services": ["HandlerService", "StatsService"]},
+			"stats": {},
+			"policy": {
+				"levels": {"0": {"handshake": 2, "connIdle": 100, "statsUserUplink": true}},
+				"system": {"statsInboundDownlink": true}
+			},
+			"routing": {
+				"domainStrategy": "IPIfNonMatch",
+				"rules": [
+					{"inboundTag": ["api"], "outboundTag": "api"},
+					{"domain": ["domain:example.com", "full:www.example.org", "keyword:ads"], "outboundTag": "block"},
+					{"ip": ["10.0.0.0/8", "1.1.1.1", "fc00::/7"], "port": "53,443,1000-2000", "network": "tcp,udp", "outboundTag": "direct"},
+					{"protocol": ["bittorrent"], "balancerTag": "balancer"}
+				],
+				"balancers": [{"tag": "balancer", "selector": ["proxy"]}]
+			},
+			"dns": {
+				"servers": ["1.1.1.1", {"address": "8.8.8.8", "port": 5353, "domains": ["domain:example.com"]}],
+				"hosts": {"domain:example.com": "127.0.0.1", "domain:example.org": ["10.0.0.1", "10.0.0.2"]},
+				"queryStrategy": "UseIPv4"
+			},
+			"inbounds": [
+				{
+					"tag": "vless-in",
+					"port": 443,
+					"protocol": "vless",
+					"settings": {
+						"clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "flow": "xtls-rprx-vision", "email": "a@example.com"}],
+						"decryption": "none",
+						"fallbacks": [{"dest": 80}, {"path": "/ws", "dest": "/dev/shm/ws.sock", "xver": 1}]
+					},
+					"streamSettings": {
+						"network": "tcp",
+						"security": "reality",
+						"realitySettings": {
+							"dest": "example.com:443",
+							"serverNames": ["example.com"],
+							"privateKey": "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8",
+							"shortIds": ["", "0123456789abcdef"]
+						}
+					},
+					"sniffing": {"enabled": true, "destOverride": ["http", "tls"]}
+				},
+				{
+					"tag": "vmess-in",
+					"listen": "127.0.0.1",
+					"port": "10000-10010",
+					"protocol": "vmess",
+					"settings": {"clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "level": 1}]},
+					"streamSettings": {"network": "ws", "wsSettings": {"path": "/ws", "host": "example.com"}}
+				},
+				{
+					"tag": "ss-in",
+					"port": 8388,
+					"protocol": "shadowsocks",
+					"settings": {"method": "aes-128-gcm", "password": "password", "network": "tcp,udp"}
+				},
+				{
+					"tag": "socks-in",
+					"port": 1080,
+					"protocol": "socks",
+					"settings": {"auth": "password", "accounts": [{"user": "user", "pass": "pass"}], "udp": true}
+				},
+				{
+					"tag": "api",
+					"listen": "127.0.0.1",
+					"port": 8080,
+					"protocol": "dokodemo-door",
+					"settings": {"address": "127.0.0.1"}
+				}
+			],
+			"outbounds": [
+				{
+					"tag": "direct",
+					"protocol": "freedom",
+					"settings": {
+						"domainStrategy": "UseIPv4",
+						"fragment": {"packets": "tlshello", "length": "100-200", "interval": "10-20"},
+						"noises": [{"type": "rand", "packet": "10-20", "delay": "10-16"}]
+					}
+				},
+				{"tag": "block", "protocol": "blackhole", "settings": {"response": {"type": "http"}}},
+				{
+					"tag": "proxy",
+					"protocol": "vless",
+					"settings": {
+						"vnext": [{
+							"address": "example.com",
+							"port": 443,
+							"users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"}]
+						}]
+					},
+					"streamSettings": {
+						"network": "xhttp",
+						"xhttpSettings": {"path": "/xhttp", "mode": "packet-up"},
+						"security": "tls",
+						"tlsSettings": {"serverName": "example.com", "fingerprint": "chrome", "alpn": ["h2"]}
+					},
+					"mux": {"enabled": true, "concurrency": 8}
+				},
+				{
+					"tag": "trojan",
+					"protocol": "trojan",
+					"settings": {"servers": [{"address": "1.2.3.4", "port": 443, "password": "password"}]},
+					"streamSettings": {"sockopt": {"tcpFastOpen": true, "mark": 255}},
+					"proxySettings": {"tag": "proxy"}
+				},
+				{
+					"tag": "wg",
+					"protocol": "wireguard",
+					"settings": {
+						"secretKey": "QEFCQ0RFRkdISUpLTE1OT1BRUlNUVVZXWFlaW1xdXl8=",
+						"address": ["10.0.0.2/32"],
+						"peers": [{"publicKey": "YGFiY2RlZmdoaWprbG1ub3BxcnN0dXZ3eHl6e3x9fn8=", "endpoint": "1.2.3.4:51820"}]
+					}
+				}
+			]
+		}`,
+		`{
+			"inbounds": [{
+				"port": 1234,
+				"protocol": "shadowsocks",
+				"settings": {
+					"method": "2022-blake3-aes-128-gcm",
+					"password": "QEFCQ0RFRkdISUpLTE1OTw==",
+					"clients": [{"password": "YGFiY2RlZmdoaWprbG1ub3A=", "email": "a@example.com"}]
+				}
+			}],
+			"outbounds": [{
+				"protocol": "vmess",
+				"settings": {
+					"vnext": [{
+						"address": "2001:db8::1",
+						"port": 443,
+						"users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "security": "aes-128-gcm"}]
+					}]
+				},
+				"streamSettings": {"network": "grpc", "grpcSettings": {"serviceName": "grpc", "multiMode": true}}
+			}]
+		}`,
+	}
+
+	for i, c := range cases {
+		config := load(t, c)
+		d := &Decompiler{}
+		v, err := d.Config(config)
+		if err != nil {
+			t.Fatal("case ", i, ": ", err)
+		}
+		if len(d.Warnings) != 0 {
+			t.Error("case ", i, ": unexpected warnings: ", d.Warnings)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("case ", i, ": ", err)
+		}
+		if got := load(t, string(b)); !proto.Equal(config, got) {
+			t.Error("case ", i, ": config changed after round trip: ", string(b))
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	config := load(t, `{
+		"inbounds": [{
+			"port": 1080,
+			"protocol": "socks",
+			"streamSettings": {"sockopt": {"tproxy": "redirect"}},
+			"settings": {}
+		}]
+	}`)
+	config.Extension = append(config.Extension, config.App[0])
+
+	d := &Decompiler{}
+	if _, err := d.Config(config); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Warnings) != 1 || !strings.Contains(d.Warnings[0], "extension") {
+		t.Error("unexpected warnings: ", d.Warnings)
+	}
+}
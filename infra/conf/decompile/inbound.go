@@ -0,0 +1,378 @@
+package decompile
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/proxy/dokodemo"
+	"github.com/HZ-PRE/XrarCore/proxy/http"
+	"github.com/HZ-PRE/XrarCore/proxy/shadowsocks"
+	"github.com/HZ-PRE/XrarCore/proxy/shadowsocks_2022"
+	"github.com/HZ-PRE/XrarCore/proxy/socks"
+	"github.com/HZ-PRE/XrarCore/proxy/trojan"
+	"github.com/HZ-PRE/XrarCore/proxy/vless"
+	vlessinbound "github.com/HZ-PRE/XrarCore/proxy/vless/inbound"
+	"github.com/HZ-PRE/XrarCore/proxy/vmess"
+	vmessinbound "github.com/HZ-PRE/XrarCore/proxy/vmess/inbound"
+	"github.com/HZ-PRE/XrarCore/proxy/wireguard"
+	"google.golang.org/protobuf/proto"
+)
+
+var allocationStrategies = map[proxyman.AllocationStrategy_Type]string{
+	proxyman.AllocationStrategy_Always:   "always",
+	proxyman.AllocationStrategy_Random:   "random",
+	proxyman.AllocationStrategy_External: "external",
+}
+
+// Inbound converts the inbound handler config.
+func (d *Decompiler) Inbound(config *core.InboundHandlerConfig) (map[string]interface{}, error) {
+	in := object{}
+	put(in, "tag", config.Tag)
+
+	p, err := instance(config.ProxySettings)
+	if err != nil {
+		return nil, err
+	}
+	protocol, settings, err := d.inboundProxy(p)
+	if err != nil {
+		return nil, err
+	}
+	in["protocol"] = protocol
+	put(in, "settings", settings)
+
+	r, err := instance(config.ReceiverSettings)
+	if err != nil {
+		return nil, err
+	}
+	receiver, ok := r.(*proxyman.ReceiverConfig)
+	if !ok {
+		if r != nil {
+			return nil, errors.New("unknown receiver settings ", config.ReceiverSettings.Type)
+		}
+		return in, nil
+	}
+	put(in, "port", portList(receiver.PortList))
+	put(in, "listen", address(receiver.Listen))
+	if a := receiver.AllocationStrategy; a != nil {
+		allocate := object{"strategy": allocationStrategies[a.Type]}
+		if a.Concurrency != nil {
+			allocate["concurrency"] = a.Concurrency.Value
+		}
+		if a.Refresh != nil {
+			allocate["refresh"] = a.Refresh.Value
+		}
+		in["allocate"] = allocate
+	}
+	if receiver.StreamSettings != nil {
+		stream, err := d.stream(receiver.StreamSettings)
+		if err != nil {
+			return nil, err
+		}
+		in["streamSettings"] = stream
+	}
+	if s := receiver.SniffingSettings; s != nil {
+		sniffing := object{"enabled": s.Enabled}
+		put(sniffing, "destOverride", s.DestinationOverride)
+		put(sniffing, "domainsExcluded", s.DomainsExcluded)
+		put(sniffing, "metadataOnly", s.MetadataOnly)
+		put(sniffing, "routeOnly", s.RouteOnly)
+		in["sniffing"] = sniffing
+	}
+	if receiver.ReceiveOriginalDestination && protocol != "dokodemo-door" {
+		d.warn("skipped receiving original destinations of inbound ", config.Tag)
+	}
+	return in, nil
+}
+
+func (d *Decompiler) inboundProxy(p proto.Message) (string, object, error) {
+	switch c := p.(type) {
+	case *dokodemo.Config:
+		s := object{}
+		put(s, "address", address(c.Address))
+		put(s, "port", c.Port)
+		put(s, "network", networks(c.Networks))
+		put(s, "followRedirect", c.FollowRedirect)
+		put(s, "userLevel", c.UserLevel)
+		return "dokodemo-door", s, nil
+	case *http.ServerConfig:
+		s := object{}
+		accounts, err := d.accounts(c.Accounts, c.Users)
+		if err != nil {
+			return "", nil, err
+		}
+		put(s, "accounts", accounts)
+		put(s, "allowTransparent", c.AllowTransparent)
+		put(s, "userLevel", c.UserLevel)
+		return "http", s, nil
+	case *socks.ServerConfig:
+		s := object{}
+		if c.AuthType == socks.AuthType_PASSWORD {
+			s["auth"] = "password"
+		}
+		accounts, err := d.accounts(c.Accounts, c.Users)
+		if err != nil {
+			return "", nil, err
+		}
+		put(s, "accounts", accounts)
+		put(s, "udp", c.UdpEnabled)
+		put(s, "ip", address(c.Address))
+		put(s, "userLevel", c.UserLevel)
+		put(s, "bind", c.BindEnabled)
+		return "socks", s, nil
+	case *shadowsocks.ServerConfig:
+		s, err := d.shadowsocksServer(c)
+		return "shadowsocks", s, err
+	case *shadowsocks_2022.ServerConfig:
+		s := object{}
+		put(s, "method", c.Method)
+		put(s, "password", c.Key)
+		put(s, "email", c.Email)
+		put(s, "network", networks(c.Network))
+		return "shadowsocks", s, nil
+	case *shadowsocks_2022.MultiUserServerConfig:
+		s := object{}
+		put(s, "method", c.Method)
+		put(s, "password", c.Key)
+		clients, err := d.users(c.Users)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(clients) == 0 {
+			d.warn("multi-user shadowsocks 2022 inbound has no users, which is converted into a single-user one")
+		}
+		put(s, "clients", clients)
+		put(s, "network", networks(c.Network))
+		return "shadowsocks", s, nil
+	case *shadowsocks_2022.RelayServerConfig:
+		s := object{}
+		put(s, "method", c.Method)
+		put(s, "password", c.Key)
+		clients := make([]interface{}, len(c.Destinations))
+		for i, dest := range c.Destinations {
+			client := object{"address": address(dest.Address), "port": dest.Port}
+			put(client, "password", dest.Key)
+			put(client, "email", dest.Email)
+			put(client, "level", dest.Level)
+			clients[i] = client
+		}
+		put(s, "clients", clients)
+		put(s, "network", networks(c.Network))
+		return "shadowsocks", s, nil
+	case *vlessinbound.Config:
+		s := object{}
+		clients, err := d.users(c.Clients)
+		if err != nil {
+			return "", nil, err
+		}
+		s["clients"] = clients
+		put(s, "decryption", c.Decryption)
+		fallbacks := make([]interface{}, len(c.Fallbacks))
+		for i, fb := range c.Fallbacks {
+			fallbacks[i] = fallback(fb.Name, fb.Alpn, fb.Path, fb.Type, fb.Dest, fb.Xver, fb.ProxyProtocolTlvs)
+		}
+		put(s, "fallbacks", fallbacks)
+		return "vless", s, nil
+	case *vmessinbound.Config:
+		s := object{}
+		clients, err := d.users(c.User)
+		if err != nil {
+			return "", nil, err
+		}
+		s["clients"] = clients
+		if c.Default != nil {
+			s["default"] = object{"level": c.Default.Level}
+		}
+		if c.Detour != nil {
+			s["detour"] = object{"to": c.Detour.To}
+		}
+		return "vmess", s, nil
+	case *trojan.ServerConfig:
+		s := object{}
+		clients, err := d.users(c.Users)
+		if err != nil {
+			return "", nil, err
+		}
+		s["clients"] = clients
+		fallbacks := make([]interface{}, len(c.Fallbacks))
+		for i, fb := range c.Fallbacks {
+			fallbacks[i] = fallback(fb.Name, fb.Alpn, fb.Path, fb.Type, fb.Dest, fb.Xver, fb.ProxyProtocolTlvs)
+		}
+		put(s, "fallbacks", fallbacks)
+		return "trojan", s, nil
+	case *wireguard.DeviceConfig:
+		s, err := d.wireguard(c)
+		return "wireguard", s, err
+	case nil:
+		return "", nil, errors.New("no proxy settings")
+	default:
+		return "", nil, errors.New("unsupported inbound proxy ", serial.GetMessageType(p))
+	}
+}
+
+// accounts returns the accounts of HTTP and SOCKS inbounds. The plain accounts are sorted by
+// their usernames, and followed by the full users.
+func (d *Decompiler) accounts(plain map[string]string, users []*protocol.User) ([]interface{}, error) {
+	names := sortedKeys(plain)
+	accounts := make([]interface{}, 0, len(names)+len(users))
+	for _, name := range names {
+		accounts = append(accounts, object{"user": name, "pass": plain[name]})
+	}
+	for _, u := range users {
+		account, err := d.user(u)
+		if err != nil {
+			return nil, err
+		}
+		// A level makes it a full user even if the email is empty.
+		account["level"] = u.Level
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (d *Decompiler) shadowsocksServer(c *shadowsocks.ServerConfig) (object, error) {
+	s := object{}
+	put(s, "network", networks(c.Network))
+	if len(c.Users) == 1 {
+		user, err := d.user(c.Users[0])
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range user {
+			s[k] = v
+		}
+	} else {
+		clients, err := d.users(c.Users)
+		if err != nil {
+			return nil, err
+		}
+		s["clients"] = clients
+	}
+	if len(c.Users) > 0 {
+		if account, err := instance(c.Users[0].Account); err == nil {
+			if account, ok := account.(*shadowsocks.Account); ok {
+				put(s, "ivCheck", account.IvCheck)
+			}
+		}
+	}
+	return s, nil
+}
+
+// fallback returns the fallback of VLESS and Trojan inbounds, where the type is omitted if it
+// can be inferred from the destination.
+func fallback(name, alpn, path, typ, dest string, xver uint64, tlvs []string) object {
+	fb := object{}
+	put(fb, "name", name)
+	put(fb, "alpn", alpn)
+	put(fb, "path", path)
+	var value interface{} = unpadUnix(dest)
+	if port, found := strings.CutPrefix(dest, "127.0.0.1:"); found && typ == "tcp" {
+		if n, err := strconv.Atoi(port); err == nil {
+			value = n
+		}
+	}
+	put(fb, "dest", value)
+	if typ != fallbackType(unpadUnix(dest)) {
+		put(fb, "type", typ)
+	}
+	put(fb, "xver", xver)
+	put(fb, "proxyProtocolTlvs", tlvs)
+	return fb
+}
+
+// fallbackType returns the type of the fallback inferred from the destination.
+func fallbackType(dest string) string {
+	switch {
+	case dest == "":
+		return ""
+	case dest == "serve-ws-none":
+		return "serve"
+	case filepath.IsAbs(dest) || dest[0] == '@':
+		return "unix"
+	}
+	if _, err := strconv.Atoi(dest); err == nil {
+		return "tcp"
+	}
+	if _, _, err := net.SplitHostPort(dest); err == nil {
+		return "tcp"
+	}
+	return ""
+}
+
+// unpadUnix reverts the padding of abstract unix sockets with "@@".
+func unpadUnix(dest string) string {
+	if strings.Contains(dest, "\x00") {
+		return "@" + strings.TrimRight(dest, "\x00")
+	}
+	return dest
+}
+
+func (d *Decompiler) users(users []*protocol.User) ([]interface{}, error) {
+	list := make([]interface{}, len(users))
+	for i, u := range users {
+		user, err := d.user(u)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = user
+	}
+	return list, nil
+}
+
+var vmessSecurities = map[protocol.SecurityType]string{
+	protocol.SecurityType_AES128_GCM:        "aes-128-gcm",
+	protocol.SecurityType_CHACHA20_POLY1305: "chacha20-poly1305",
+	protocol.SecurityType_NONE:              "none",
+	protocol.SecurityType_ZERO:              "zero",
+}
+
+var shadowsocksCiphers = map[shadowsocks.CipherType]string{
+	shadowsocks.CipherType_AES_128_GCM:        "aes-128-gcm",
+	shadowsocks.CipherType_AES_256_GCM:        "aes-256-gcm",
+	shadowsocks.CipherType_CHACHA20_POLY1305:  "chacha20-poly1305",
+	shadowsocks.CipherType_XCHACHA20_POLY1305: "xchacha20-poly1305",
+	shadowsocks.CipherType_NONE:               "none",
+}
+
+// user returns the user with the fields of its account.
+func (d *Decompiler) user(u *protocol.User) (object, error) {
+	user := object{}
+	account, err := instance(u.Account)
+	if err != nil {
+		return nil, err
+	}
+	switch a := account.(type) {
+	case *vless.Account:
+		put(user, "id", a.Id)
+		put(user, "flow", a.Flow)
+		put(user, "encryption", a.Encryption)
+	case *vmess.Account:
+		put(user, "id", a.Id)
+		put(user, "security", vmessSecurities[a.SecuritySettings.GetType()])
+		put(user, "experiments", a.TestsEnabled)
+	case *trojan.Account:
+		put(user, "password", a.Password)
+	case *shadowsocks.Account:
+		put(user, "method", shadowsocksCiphers[a.CipherType])
+		put(user, "password", a.Password)
+	case *shadowsocks_2022.Account:
+		put(user, "password", a.Key)
+	case *http.Account:
+		put(user, "user", a.Username)
+		put(user, "pass", a.Password)
+	case *socks.Account:
+		put(user, "user", a.Username)
+		put(user, "pass", a.Password)
+	default:
+		return nil, errors.New("unsupported account ", serial.GetMessageType(account))
+	}
+	put(user, "email", u.Email)
+	put(user, "level", u.Level)
+	return user, nil
+}
@@ -0,0 +1,426 @@
+package decompile
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	gonet "net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/app/proxyman"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/proxy/blackhole"
+	"github.com/HZ-PRE/XrarCore/proxy/dns"
+	"github.com/HZ-PRE/XrarCore/proxy/freedom"
+	"github.com/HZ-PRE/XrarCore/proxy/http"
+	"github.com/HZ-PRE/XrarCore/proxy/loopback"
+	"github.com/HZ-PRE/XrarCore/proxy/shadowsocks"
+	"github.com/HZ-PRE/XrarCore/proxy/shadowsocks_2022"
+	"github.com/HZ-PRE/XrarCore/proxy/socks"
+	"github.com/HZ-PRE/XrarCore/proxy/trojan"
+	vlessoutbound "github.com/HZ-PRE/XrarCore/proxy/vless/outbound"
+	vmessoutbound "github.com/HZ-PRE/XrarCore/proxy/vmess/outbound"
+	"github.com/HZ-PRE/XrarCore/proxy/wireguard"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	mdns "github.com/miekg/dns"
+	"google.golang.org/protobuf/proto"
+)
+
+// Outbound converts the outbound handler config.
+func (d *Decompiler) Outbound(config *core.OutboundHandlerConfig) (map[string]interface{}, error) {
+	out := object{}
+	put(out, "tag", config.Tag)
+
+	p, err := instance(config.ProxySettings)
+	if err != nil {
+		return nil, err
+	}
+	protocol, settings, err := d.outboundProxy(p)
+	if err != nil {
+		return nil, err
+	}
+	out["protocol"] = protocol
+	put(out, "settings", settings)
+
+	s, err := instance(config.SenderSettings)
+	if err != nil {
+		return nil, err
+	}
+	sender, ok := s.(*proxyman.SenderConfig)
+	if !ok {
+		if s != nil {
+			return nil, errors.New("unknown sender settings ", config.SenderSettings.Type)
+		}
+		return out, nil
+	}
+	if via := address(sender.Via); via != "" {
+		if sender.ViaCidr != "" {
+			via += "/" + sender.ViaCidr
+		}
+		out["sendThrough"] = via
+	}
+	if sender.StreamSettings != nil {
+		stream, err := d.stream(sender.StreamSettings)
+		if err != nil {
+			return nil, err
+		}
+		out["streamSettings"] = stream
+	}
+	if sender.ProxySettings != nil {
+		out["proxySettings"] = object{"tag": sender.ProxySettings.Tag}
+	}
+	if m := sender.MultiplexSettings; m != nil {
+		mux := object{"enabled": m.Enabled}
+		put(mux, "concurrency", m.Concurrency)
+		put(mux, "xudpConcurrency", m.XudpConcurrency)
+		if m.XudpProxyUDP443 != "reject" {
+			put(mux, "xudpProxyUDP443", m.XudpProxyUDP443)
+		}
+		out["mux"] = mux
+	}
+	if c := sender.ConnectionPool; c != nil {
+		pool := object{"size": c.Size}
+		put(pool, "idleTimeout", c.IdleTimeout)
+		out["connectionPool"] = pool
+	}
+	return out, nil
+}
+
+func (d *Decompiler) outboundProxy(p proto.Message) (string, object, error) {
+	switch c := p.(type) {
+	case *freedom.Config:
+		s, err := d.freedom(c)
+		return "freedom", s, err
+	case *blackhole.Config:
+		s := object{}
+		if c.Response != nil {
+			response, err := blackholeResponse(c.Response)
+			if err != nil {
+				return "", nil, err
+			}
+			s["response"] = response
+		}
+		return "blackhole", s, nil
+	case *loopback.Config:
+		s := object{}
+		put(s, "inboundTag", c.InboundTag)
+		return "loopback", s, nil
+	case *dns.Config:
+		s, err := d.dnsProxy(c)
+		return "dns", s, err
+	case *http.ClientConfig:
+		servers, err := d.servers(c.Server)
+		if err != nil {
+			return "", nil, err
+		}
+		s := object{"servers": servers}
+		if len(c.Header) > 0 {
+			headers := object{}
+			for _, h := range c.Header {
+				headers[h.Key] = h.Value
+			}
+			s["headers"] = headers
+		}
+		return "http", s, nil
+	case *socks.ClientConfig:
+		servers, err := d.servers(c.Server)
+		if err != nil {
+			return "", nil, err
+		}
+		return "socks", object{"servers": servers}, nil
+	case *shadowsocks.ClientConfig:
+		servers := make([]interface{}, len(c.Server))
+		for i, spec := range c.Server {
+			server := object{"address": address(spec.Address), "port": spec.Port}
+			for _, u := range spec.User {
+				user, err := d.user(u)
+				if err != nil {
+					return "", nil, err
+				}
+				for k, v := range user {
+					server[k] = v
+				}
+				if account, err := instance(u.Account); err == nil {
+					if account, ok := account.(*shadowsocks.Account); ok {
+						put(server, "ivCheck", account.IvCheck)
+					}
+				}
+			}
+			servers[i] = server
+		}
+		return "shadowsocks", object{"servers": servers}, nil
+	case *shadowsocks_2022.ClientConfig:
+		server := object{"address": address(c.Address), "port": c.Port}
+		put(server, "method", c.Method)
+		put(server, "password", c.Key)
+		put(server, "uot", c.UdpOverTcp)
+		put(server, "uotVersion", c.UdpOverTcpVersion)
+		return "shadowsocks", object{"servers": []interface{}{server}}, nil
+	case *vlessoutbound.Config:
+		vnext, err := d.servers(c.Vnext)
+		if err != nil {
+			return "", nil, err
+		}
+		return "vless", object{"vnext": vnext}, nil
+	case *vmessoutbound.Config:
+		vnext, err := d.servers(c.Receiver)
+		if err != nil {
+			return "", nil, err
+		}
+		return "vmess", object{"vnext": vnext}, nil
+	case *trojan.ClientConfig:
+		servers := make([]interface{}, len(c.Server))
+		for i, spec := range c.Server {
+			server := object{"address": address(spec.Address), "port": spec.Port}
+			for _, u := range spec.User {
+				user, err := d.user(u)
+				if err != nil {
+					return "", nil, err
+				}
+				for k, v := range user {
+					server[k] = v
+				}
+			}
+			servers[i] = server
+		}
+		return "trojan", object{"servers": servers}, nil
+	case *wireguard.DeviceConfig:
+		s, err := d.wireguard(c)
+		return "wireguard", s, err
+	case nil:
+		return "", nil, errors.New("no proxy settings")
+	default:
+		return "", nil, errors.New("unsupported outbound proxy ", serial.GetMessageType(p))
+	}
+}
+
+// servers returns the server endpoints of the outbounds having users.
+func (d *Decompiler) servers(specs []*protocol.ServerEndpoint) ([]interface{}, error) {
+	servers := make([]interface{}, len(specs))
+	for i, spec := range specs {
+		users, err := d.users(spec.User)
+		if err != nil {
+			return nil, err
+		}
+		server := object{"address": address(spec.Address), "port": spec.Port}
+		put(server, "users", users)
+		servers[i] = server
+	}
+	return servers, nil
+}
+
+func (d *Decompiler) freedom(c *freedom.Config) (object, error) {
+	s := object{}
+	put(s, "domainStrategy", domainStrategy(int32(c.DomainStrategy)))
+	if o := c.DestinationOverride; o != nil && o.Server != nil {
+		s["redirect"] = gonet.JoinHostPort(address(o.Server.Address), strconv.Itoa(int(o.Server.Port)))
+	}
+	put(s, "userLevel", c.UserLevel)
+	if f := c.Fragment; f != nil {
+		fragment := object{
+			"length":   rangeString(int64(f.LengthMin), int64(f.LengthMax)),
+			"interval": rangeString(int64(f.IntervalMin), int64(f.IntervalMax)),
+		}
+		switch {
+		case f.PacketsFrom == 0 && f.PacketsTo == 1:
+			fragment["packets"] = "tlshello"
+		case f.PacketsFrom == 0 && f.PacketsTo == 0:
+		default:
+			fragment["packets"] = rangeString(int64(f.PacketsFrom), int64(f.PacketsTo))
+		}
+		s["fragment"] = fragment
+	}
+	noises := make([]interface{}, len(c.Noises))
+	for i, n := range c.Noises {
+		noise := object{}
+		if len(n.Packet) == 0 {
+			noise["type"] = "rand"
+			noise["packet"] = rangeString(int64(n.LengthMin), int64(n.LengthMax))
+		} else {
+			noise["type"] = "base64"
+			noise["packet"] = base64.StdEncoding.EncodeToString(n.Packet)
+		}
+		if n.DelayMin != 0 || n.DelayMax != 0 {
+			noise["delay"] = rangeString(int64(n.DelayMin), int64(n.DelayMax))
+		}
+		noises[i] = noise
+	}
+	put(s, "noises", noises)
+	put(s, "proxyProtocol", c.ProxyProtocol)
+	put(s, "proxyProtocolTlvs", c.ProxyProtocolTlvs)
+	if c.HappyEyeballs != nil {
+		s["happyEyeballs"] = happyEyeballs(c.HappyEyeballs)
+	}
+	return s, nil
+}
+
+func happyEyeballs(c *internet.HappyEyeballsConfig) object {
+	h := object{}
+	put(h, "tryDelayMs", c.TryDelayMs)
+	put(h, "maxConcurrentTry", c.MaxConcurrentTry)
+	return h
+}
+
+func blackholeResponse(tm *serial.TypedMessage) (object, error) {
+	r, err := instance(tm)
+	if err != nil {
+		return nil, err
+	}
+	switch r := r.(type) {
+	case *blackhole.NoneResponse:
+		return object{"type": "none"}, nil
+	case *blackhole.HTTPResponse:
+		response := object{"type": "http"}
+		put(response, "statusCode", r.StatusCode)
+		put(response, "headers", r.Header)
+		put(response, "body", string(r.Body))
+		return response, nil
+	case *blackhole.RSTResponse:
+		return object{"type": "rst"}, nil
+	case *blackhole.TarpitResponse:
+		response := object{"type": "tarpit"}
+		put(response, "duration", r.Duration)
+		return response, nil
+	case *blackhole.NXDomainResponse:
+		return object{"type": "nxdomain"}, nil
+	default:
+		return nil, errors.New("unsupported blackhole response ", tm.Type)
+	}
+}
+
+var answerRewriteActions = map[dns.AnswerRewrite_Action]string{
+	dns.AnswerRewrite_Replace: "replace",
+	dns.AnswerRewrite_Filter:  "filter",
+}
+
+func (d *Decompiler) dnsProxy(c *dns.Config) (object, error) {
+	s := object{}
+	if server := c.Server; server != nil {
+		switch server.Network {
+		case net.Network_TCP, net.Network_UDP:
+			s["network"] = server.Network.SystemString()
+		}
+		put(s, "address", address(server.Address))
+		put(s, "port", server.Port)
+	}
+	put(s, "userLevel", c.UserLevel)
+	if c.Non_IPQuery != "drop" {
+		put(s, "nonIPQuery", c.Non_IPQuery)
+	}
+	put(s, "blockTypes", c.BlockTypes)
+	records := make([]interface{}, len(c.Records))
+	for i, r := range c.Records {
+		record, err := dnsRecord(r)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	put(s, "records", records)
+	rewrites := make([]interface{}, len(c.AnswerRewrites))
+	for i, r := range c.AnswerRewrites {
+		rewrite := object{}
+		put(rewrite, "domain", r.Domain)
+		put(rewrite, "ip", r.Ip)
+		put(rewrite, "action", answerRewriteActions[r.Action])
+		put(rewrite, "replaceIP", r.ReplaceIp)
+		put(rewrite, "ttl", r.Ttl)
+		rewrites[i] = rewrite
+	}
+	put(s, "answerRewrites", rewrites)
+	return s, nil
+}
+
+// dnsRecord splits the record in RFC 1035 presentation format. The records built from the
+// JSON configs are separated by spaces, while those loaded from zone files are by tabs.
+func dnsRecord(record string) (object, error) {
+	if fields := strings.SplitN(record, " ", 5); len(fields) == 5 && fields[2] == "IN" {
+		if ttl, err := strconv.ParseUint(fields[1], 10, 32); err == nil {
+			return object{"name": fields[0], "ttl": ttl, "type": fields[3], "value": fields[4]}, nil
+		}
+	}
+	rr, err := mdns.NewRR(record)
+	if err != nil || rr == nil {
+		return nil, errors.New("invalid DNS record: ", record).Base(err)
+	}
+	h := rr.Header()
+	return object{
+		"name":  h.Name,
+		"ttl":   h.Ttl,
+		"type":  mdns.TypeToString[h.Rrtype],
+		"value": strings.TrimSpace(strings.TrimPrefix(rr.String(), h.String())),
+	}, nil
+}
+
+var wireguardDomainStrategies = map[wireguard.DeviceConfig_DomainStrategy]string{
+	wireguard.DeviceConfig_FORCE_IP4:  "ForceIPv4",
+	wireguard.DeviceConfig_FORCE_IP6:  "ForceIPv6",
+	wireguard.DeviceConfig_FORCE_IP46: "ForceIPv4v6",
+	wireguard.DeviceConfig_FORCE_IP64: "ForceIPv6v4",
+}
+
+func (d *Decompiler) wireguard(c *wireguard.DeviceConfig) (object, error) {
+	s := object{}
+	key, err := wireguardKey(c.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	put(s, "secretKey", key)
+	s["address"] = c.Endpoint
+	peers := make([]interface{}, len(c.Peers))
+	for i, p := range c.Peers {
+		peer := object{"allowedIPs": p.AllowedIps}
+		if peer["publicKey"], err = wireguardKey(p.PublicKey); err != nil {
+			return nil, err
+		}
+		if p.PreSharedKey != "" {
+			if peer["preSharedKey"], err = wireguardKey(p.PreSharedKey); err != nil {
+				return nil, err
+			}
+		}
+		put(peer, "endpoint", p.Endpoint)
+		put(peer, "keepAlive", p.KeepAlive)
+		put(peer, "email", p.Email)
+		put(peer, "level", p.Level)
+		peers[i] = peer
+	}
+	put(s, "peers", peers)
+	if c.Mtu != 1420 {
+		put(s, "mtu", c.Mtu)
+	}
+	put(s, "workers", c.NumWorkers)
+	if len(c.Reserved) > 0 {
+		reserved := make([]int, len(c.Reserved))
+		for i, b := range c.Reserved {
+			reserved[i] = int(b)
+		}
+		s["reserved"] = reserved
+	}
+	put(s, "domainStrategy", wireguardDomainStrategies[c.DomainStrategy])
+	put(s, "noKernelTun", c.NoKernelTun)
+	return s, nil
+}
+
+// wireguardKey converts the key in hex into base64, which is the usual form in the configs.
+func wireguardKey(key string) (string, error) {
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		return "", errors.New("invalid WireGuard key").Base(err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,535 @@
+package decompile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/grpc"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/dns"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/http"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/noop"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/srtp"
+	headertls "github.com/HZ-PRE/XrarCore/transport/internet/headers/tls"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/utp"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/wechat"
+	"github.com/HZ-PRE/XrarCore/transport/internet/headers/wireguard"
+	"github.com/HZ-PRE/XrarCore/transport/internet/httpupgrade"
+	"github.com/HZ-PRE/XrarCore/transport/internet/kcp"
+	"github.com/HZ-PRE/XrarCore/transport/internet/reality"
+	"github.com/HZ-PRE/XrarCore/transport/internet/splithttp"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tcp"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
+	"github.com/HZ-PRE/XrarCore/transport/internet/websocket"
+)
+
+// transportNames are the names of the transports in JSON configs, with their settings keys.
+var transportNames = map[string]string{
+	"tcp":         "raw",
+	"splithttp":   "xhttp",
+	"mkcp":        "kcp",
+	"grpc":        "grpc",
+	"websocket":   "ws",
+	"httpupgrade": "httpupgrade",
+}
+
+func (d *Decompiler) stream(c *internet.StreamConfig) (object, error) {
+	s := object{}
+	put(s, "address", address(c.Address))
+	put(s, "port", c.Port)
+	if c.ProtocolName != "" && c.ProtocolName != "tcp" {
+		name, ok := transportNames[c.ProtocolName]
+		if !ok {
+			return nil, errors.New("unsupported transport ", c.ProtocolName)
+		}
+		s["network"] = name
+	}
+	for _, t := range c.TransportSettings {
+		name, ok := transportNames[t.ProtocolName]
+		if !ok {
+			d.warn("skipped settings of unsupported transport ", t.ProtocolName)
+			continue
+		}
+		settings, err := d.transport(t.Settings)
+		if err != nil {
+			return nil, errors.New("failed to convert ", name, " settings").Base(err)
+		}
+		s[name+"Settings"] = settings
+	}
+	for _, tm := range c.SecuritySettings {
+		if tm.Type != c.SecurityType {
+			continue
+		}
+		security, err := instance(tm)
+		if err != nil {
+			return nil, err
+		}
+		switch security := security.(type) {
+		case *tls.Config:
+			settings, err := d.tls(security)
+			if err != nil {
+				return nil, err
+			}
+			s["security"] = "tls"
+			s["tlsSettings"] = settings
+		case *reality.Config:
+			s["security"] = "reality"
+			s["realitySettings"] = realitySettings(security)
+		default:
+			return nil, errors.New("unsupported security ", tm.Type)
+		}
+	}
+	if c.SocketSettings != nil {
+		s["sockopt"] = d.sockopt(c.SocketSettings)
+	}
+	return s, nil
+}
+
+func (d *Decompiler) transport(tm *serial.TypedMessage) (object, error) {
+	t, err := instance(tm)
+	if err != nil {
+		return nil, err
+	}
+	s := object{}
+	switch c := t.(type) {
+	case *tcp.Config:
+		if c.HeaderSettings != nil {
+			header, err := tcpHeader(c.HeaderSettings)
+			if err != nil {
+				return nil, err
+			}
+			s["header"] = header
+		}
+		put(s, "acceptProxyProtocol", c.AcceptProxyProtocol)
+	case *splithttp.Config:
+		put(s, "host", c.Host)
+		put(s, "path", c.Path)
+		if c.Mode != "auto" {
+			put(s, "mode", c.Mode)
+		}
+		put(s, "headers", c.Headers)
+		putRange(s, "xPaddingBytes", c.XPaddingBytes)
+		put(s, "noGRPCHeader", c.NoGRPCHeader)
+		put(s, "noSSEHeader", c.NoSSEHeader)
+		putRange(s, "scMaxEachPostBytes", c.ScMaxEachPostBytes)
+		putRange(s, "scMinPostsIntervalMs", c.ScMinPostsIntervalMs)
+		put(s, "scMaxBufferedPosts", c.ScMaxBufferedPosts)
+		putRange(s, "scStreamUpServerSecs", c.ScStreamUpServerSecs)
+		if x := c.Xmux; x != nil && !defaultXmux(x) {
+			xmux := object{}
+			putRange(xmux, "maxConcurrency", x.MaxConcurrency)
+			putRange(xmux, "maxConnections", x.MaxConnections)
+			putRange(xmux, "cMaxReuseTimes", x.CMaxReuseTimes)
+			putRange(xmux, "hMaxRequestTimes", x.HMaxRequestTimes)
+			putRange(xmux, "hMaxReusableSecs", x.HMaxReusableSecs)
+			put(xmux, "hKeepAlivePeriod", x.HKeepAlivePeriod)
+			s["xmux"] = xmux
+		}
+		if c.DownloadSettings != nil {
+			download, err := d.stream(c.DownloadSettings)
+			if err != nil {
+				return nil, errors.New("failed to convert download settings").Base(err)
+			}
+			s["downloadSettings"] = download
+		}
+	case *kcp.Config:
+		if c.Mtu != nil {
+			s["mtu"] = c.Mtu.Value
+		}
+		if c.Tti != nil {
+			s["tti"] = c.Tti.Value
+		}
+		if c.UplinkCapacity != nil {
+			s["uplinkCapacity"] = c.UplinkCapacity.Value
+		}
+		if c.DownlinkCapacity != nil {
+			s["downlinkCapacity"] = c.DownlinkCapacity.Value
+		}
+		put(s, "congestion", c.Congestion)
+		if c.ReadBuffer != nil {
+			s["readBufferSize"] = kcpBufferSize(c.ReadBuffer.Size)
+		}
+		if c.WriteBuffer != nil {
+			s["writeBufferSize"] = kcpBufferSize(c.WriteBuffer.Size)
+		}
+		if c.HeaderConfig != nil {
+			header, err := kcpHeader(c.HeaderConfig)
+			if err != nil {
+				return nil, err
+			}
+			s["header"] = header
+		}
+		if c.Seed != nil {
+			s["seed"] = c.Seed.Seed
+		}
+		if c.Fec != nil {
+			s["fec"] = object{"dataShards": c.Fec.DataShards, "parityShards": c.Fec.ParityShards}
+		}
+	case *grpc.Config:
+		put(s, "authority", c.Authority)
+		put(s, "serviceName", c.ServiceName)
+		put(s, "multiMode", c.MultiMode)
+		put(s, "idle_timeout", c.IdleTimeout)
+		put(s, "health_check_timeout", c.HealthCheckTimeout)
+		put(s, "permit_without_stream", c.PermitWithoutStream)
+		put(s, "initial_windows_size", c.InitialWindowsSize)
+		put(s, "user_agent", c.UserAgent)
+	case *websocket.Config:
+		put(s, "host", c.Host)
+		put(s, "path", earlyDataPath(c.Path, c.Ed))
+		put(s, "headers", c.Header)
+		put(s, "acceptProxyProtocol", c.AcceptProxyProtocol)
+		put(s, "heartbeatPeriod", c.HeartbeatPeriod)
+	case *httpupgrade.Config:
+		put(s, "host", c.Host)
+		put(s, "path", earlyDataPath(c.Path, c.Ed))
+		put(s, "headers", c.Header)
+		put(s, "acceptProxyProtocol", c.AcceptProxyProtocol)
+	default:
+		return nil, errors.New("unsupported transport settings ", tm.Type)
+	}
+	return s, nil
+}
+
+// putRange sets the range unless it is zero.
+func putRange(o object, key string, r *splithttp.RangeConfig) {
+	if r == nil || (r.From == 0 && r.To == 0) {
+		return
+	}
+	o[key] = rangeString(int64(r.From), int64(r.To))
+}
+
+// defaultXmux reports whether the XMUX config is the one filled in by default.
+func defaultXmux(x *splithttp.XmuxConfig) bool {
+	equal := func(r *splithttp.RangeConfig, from, to int32) bool {
+		return r.GetFrom() == from && r.GetTo() == to
+	}
+	return equal(x.MaxConcurrency, 16, 32) && equal(x.MaxConnections, 0, 0) &&
+		equal(x.CMaxReuseTimes, 0, 0) && equal(x.HMaxRequestTimes, 600, 900) &&
+		equal(x.HMaxReusableSecs, 1800, 3000) && x.HKeepAlivePeriod == 0
+}
+
+// earlyDataPath returns the path with the size of early data in the query.
+func earlyDataPath(path string, ed uint32) string {
+	if ed == 0 {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return fmt.Sprint(path, "&ed=", ed)
+	}
+	return fmt.Sprint(path, "?ed=", ed)
+}
+
+// kcpBufferSize returns the buffer size in MB, where 0 stands for the default 512 KB.
+func kcpBufferSize(size uint32) uint32 {
+	if size == 512*1024 {
+		return 0
+	}
+	return size / 1024 / 1024
+}
+
+func kcpHeader(tm *serial.TypedMessage) (object, error) {
+	h, err := instance(tm)
+	if err != nil {
+		return nil, err
+	}
+	switch h := h.(type) {
+	case *noop.Config:
+		return object{"type": "none"}, nil
+	case *srtp.Config:
+		return object{"type": "srtp"}, nil
+	case *utp.Config:
+		return object{"type": "utp"}, nil
+	case *wechat.VideoConfig:
+		return object{"type": "wechat-video"}, nil
+	case *headertls.PacketConfig:
+		return object{"type": "dtls"}, nil
+	case *wireguard.WireguardConfig:
+		return object{"type": "wireguard"}, nil
+	case *dns.Config:
+		return object{"type": "dns", "domain": h.Domain}, nil
+	default:
+		return nil, errors.New("unsupported mKCP header ", tm.Type)
+	}
+}
+
+func tcpHeader(tm *serial.TypedMessage) (object, error) {
+	h, err := instance(tm)
+	if err != nil {
+		return nil, err
+	}
+	switch h := h.(type) {
+	case *noop.ConnectionConfig:
+		return object{"type": "none"}, nil
+	case *http.Config:
+		header := object{"type": "http"}
+		if r := h.Request; r != nil {
+			request := object{}
+			put(request, "version", r.Version.GetValue())
+			put(request, "method", r.Method.GetValue())
+			put(request, "path", r.Uri)
+			put(request, "headers", httpHeaders(r.Header))
+			header["request"] = request
+		}
+		if r := h.Response; r != nil {
+			response := object{}
+			put(response, "version", r.Version.GetValue())
+			put(response, "status", r.Status.GetCode())
+			put(response, "reason", r.Status.GetReason())
+			put(response, "headers", httpHeaders(r.Header))
+			header["response"] = response
+		}
+		return header, nil
+	default:
+		return nil, errors.New("unsupported RAW header ", tm.Type)
+	}
+}
+
+func httpHeaders(headers []*http.Header) object {
+	o := object{}
+	for _, h := range headers {
+		o[h.Name] = h.Value
+	}
+	return o
+}
+
+var (
+	certificateUsages = map[tls.Certificate_Usage]string{
+		tls.Certificate_AUTHORITY_VERIFY: "verify",
+		tls.Certificate_AUTHORITY_ISSUE:  "issue",
+	}
+	acmeChallenges = map[tls.AcmeConfig_Challenge]string{
+		tls.AcmeConfig_TLS_ALPN01: "tls-alpn-01",
+		tls.AcmeConfig_DNS01:      "dns-01",
+	}
+	clientAuths = map[tls.Config_ClientAuth]string{
+		tls.Config_VERIFY_IF_GIVEN:    "optional",
+		tls.Config_REQUIRE_AND_VERIFY: "required",
+	}
+)
+
+// lines splits the PEM data into lines, as the certificates are usually inlined in JSON configs.
+func lines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+func base64List(list [][]byte) []string {
+	s := make([]string, len(list))
+	for i, b := range list {
+		s[i] = base64.StdEncoding.EncodeToString(b)
+	}
+	return s
+}
+
+func (d *Decompiler) tls(c *tls.Config) (object, error) {
+	s := object{}
+	put(s, "allowInsecure", c.AllowInsecure)
+	certificates := make([]interface{}, len(c.Certificate))
+	for i, cert := range c.Certificate {
+		certificate := object{}
+		if cert.CertificatePath != "" {
+			certificate["certificateFile"] = cert.CertificatePath
+		} else {
+			put(certificate, "certificate", lines(cert.Certificate))
+		}
+		if cert.KeyPath != "" {
+			certificate["keyFile"] = cert.KeyPath
+		} else {
+			put(certificate, "key", lines(cert.Key))
+		}
+		put(certificate, "usage", certificateUsages[cert.Usage])
+		put(certificate, "ocspStapling", cert.OcspStapling)
+		if cert.CertificatePath != "" || cert.KeyPath != "" {
+			put(certificate, "oneTimeLoading", cert.OneTimeLoading)
+		}
+		put(certificate, "buildChain", cert.BuildChain)
+		certificates[i] = certificate
+	}
+	put(s, "certificates", certificates)
+	put(s, "serverName", c.ServerName)
+	put(s, "alpn", c.NextProtocol)
+	put(s, "enableSessionResumption", c.EnableSessionResumption)
+	put(s, "disableSystemRoot", c.DisableSystemRoot)
+	put(s, "minVersion", c.MinVersion)
+	put(s, "maxVersion", c.MaxVersion)
+	put(s, "cipherSuites", c.CipherSuites)
+	put(s, "fingerprint", c.Fingerprint)
+	put(s, "rejectUnknownSni", c.RejectUnknownSni)
+	put(s, "pinnedPeerCertificateChainSha256", base64List(c.PinnedPeerCertificateChainSha256))
+	put(s, "pinnedPeerCertificatePublicKeySha256", base64List(c.PinnedPeerCertificatePublicKeySha256))
+	put(s, "curvePreferences", c.CurvePreferences)
+	put(s, "masterKeyLog", c.MasterKeyLog)
+	put(s, "verifyPeerCertInNames", c.VerifyPeerCertInNames)
+	if len(c.EchServerKeys) > 0 {
+		s["echServerKeys"] = base64.StdEncoding.EncodeToString(c.EchServerKeys)
+	}
+	if len(c.EchConfigList) > 0 {
+		s["echConfigList"] = base64.StdEncoding.EncodeToString(c.EchConfigList)
+	}
+	put(s, "echConfigDomain", c.EchConfigDomain)
+	if a := c.Acme; a != nil {
+		acme := object{}
+		put(acme, "directoryUrl", a.DirectoryUrl)
+		put(acme, "email", a.Email)
+		put(acme, "domains", a.Domains)
+		put(acme, "storagePath", a.StoragePath)
+		put(acme, "challenge", acmeChallenges[a.Challenge])
+		put(acme, "http01Listen", a.Http01Listen)
+		put(acme, "dns01Hook", a.Dns01Hook)
+		put(acme, "renewBeforeDays", a.RenewBefore/86400)
+		s["acme"] = acme
+	}
+	if c.ClientAuth != tls.Config_NO_CLIENT_CERT {
+		s["clientAuth"] = clientAuths[c.ClientAuth]
+		put(s, "clientCa", lines(c.ClientCa))
+		if len(c.ClientCrl) > 0 {
+			s["clientCrl"] = lines(c.ClientCrl[0])
+			if len(c.ClientCrl) > 1 {
+				d.warn("skipped client CRLs except the first one")
+			}
+		}
+		users := make([]interface{}, len(c.ClientUsers))
+		for i, u := range c.ClientUsers {
+			user := object{"email": u.Email}
+			put(user, "commonName", u.CommonName)
+			put(user, "san", u.San)
+			put(user, "fingerprint", hex.EncodeToString(u.Fingerprint))
+			users[i] = user
+		}
+		put(s, "clientUsers", users)
+	}
+	return s, nil
+}
+
+// shortID returns the short ID in hex, without the zero padding.
+func shortID(id []byte) string {
+	return hex.EncodeToString(bytes.TrimRight(id, "\x00"))
+}
+
+func clientVersion(v []byte) string {
+	if len(v) != 3 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// realitySettings converts the REALITY config, which is a server one if the target is set.
+func realitySettings(c *reality.Config) object {
+	s := object{}
+	put(s, "masterKeyLog", c.MasterKeyLog)
+	put(s, "show", c.Show)
+	if c.Dest != "" {
+		target := unpadUnix(c.Dest)
+		s["target"] = target
+		if c.Type != fallbackType(target) {
+			put(s, "type", c.Type)
+		}
+		put(s, "xver", c.Xver)
+		put(s, "serverNames", c.ServerNames)
+		s["privateKey"] = base64.RawURLEncoding.EncodeToString(c.PrivateKey)
+		put(s, "minClientVer", clientVersion(c.MinClientVer))
+		put(s, "maxClientVer", clientVersion(c.MaxClientVer))
+		put(s, "maxTimeDiff", c.MaxTimeDiff)
+		shortIDs := make([]string, len(c.ShortIds))
+		for i, id := range c.ShortIds {
+			shortIDs[i] = shortID(id)
+		}
+		s["shortIds"] = shortIDs
+		return s
+	}
+	put(s, "fingerprint", c.Fingerprint)
+	put(s, "serverName", c.ServerName)
+	s["password"] = base64.RawURLEncoding.EncodeToString(c.PublicKey)
+	put(s, "shortId", shortID(c.ShortId))
+	if spiderX := spiderX(c.SpiderX, c.SpiderY); spiderX != "/" {
+		put(s, "spiderX", spiderX)
+	}
+	return s
+}
+
+// spiderX returns the path of the spider with the parameters in its query.
+func spiderX(path string, y []int64) string {
+	var params []string
+	for i, name := range []string{"p", "c", "t", "i", "r"} {
+		if len(y) < 2*i+2 || (y[2*i] == 0 && y[2*i+1] == 0) {
+			continue
+		}
+		params = append(params, name+"="+rangeString(y[2*i], y[2*i+1]))
+	}
+	if len(params) == 0 {
+		return path
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.RawQuery == "" {
+		return path + "?" + strings.Join(params, "&")
+	}
+	return path + "&" + strings.Join(params, "&")
+}
+
+var (
+	tproxyModes = map[internet.SocketConfig_TProxyMode]string{
+		internet.SocketConfig_TProxy:   "tproxy",
+		internet.SocketConfig_Redirect: "redirect",
+	}
+	addressPortStrategies = []string{
+		"None", "SrvPortOnly", "SrvAddressOnly", "SrvPortAndAddress",
+		"TxtPortOnly", "TxtAddressOnly", "TxtPortAndAddress",
+	}
+)
+
+func (d *Decompiler) sockopt(c *internet.SocketConfig) object {
+	s := object{}
+	put(s, "mark", c.Mark)
+	switch c.Tfo {
+	case 0:
+	case 256:
+		s["tcpFastOpen"] = true
+	case -1:
+		s["tcpFastOpen"] = false
+	default:
+		s["tcpFastOpen"] = c.Tfo
+	}
+	put(s, "tproxy", tproxyModes[c.Tproxy])
+	put(s, "acceptProxyProtocol", c.AcceptProxyProtocol)
+	put(s, "domainStrategy", domainStrategy(int32(c.DomainStrategy)))
+	put(s, "dialerProxy", c.DialerProxy)
+	put(s, "tcpKeepAliveInterval", c.TcpKeepAliveInterval)
+	put(s, "tcpKeepAliveIdle", c.TcpKeepAliveIdle)
+	put(s, "tcpCongestion", c.TcpCongestion)
+	put(s, "tcpWindowClamp", c.TcpWindowClamp)
+	put(s, "tcpMaxSeg", c.TcpMaxSeg)
+	put(s, "penetrate", c.Penetrate)
+	put(s, "tcpUserTimeout", c.TcpUserTimeout)
+	put(s, "v6only", c.V6Only)
+	put(s, "interface", c.Interface)
+	put(s, "tcpMptcp", c.TcpMptcp)
+	sockopts := make([]interface{}, len(c.CustomSockopt))
+	for i, o := range c.CustomSockopt {
+		sockopt := object{}
+		put(sockopt, "level", o.Level)
+		put(sockopt, "opt", o.Opt)
+		put(sockopt, "value", o.Value)
+		put(sockopt, "type", o.Type)
+		sockopts[i] = sockopt
+	}
+	put(s, "customSockopt", sockopts)
+	if i := int(c.AddressPortStrategy); i > 0 && i < len(addressPortStrategies) {
+		s["addressPortStrategy"] = addressPortStrategies[i]
+	}
+	if c.HappyEyeballs != nil {
+		s["happyEyeballs"] = happyEyeballs(c.HappyEyeballs)
+	}
+	if len(c.BindAddress) > 0 || c.BindPort != 0 || c.ReceiveOriginalDestAddress {
+		d.warn("skipped socket settings that have no JSON equivalent")
+	}
+	return s
+}
@@ -1,19 +1,87 @@
 package conf
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/platform/filesystem"
 	"github.com/HZ-PRE/XrarCore/proxy/dns"
+	mdns "github.com/miekg/dns"
 	"google.golang.org/protobuf/proto"
 )
 
+type DNSRecordConfig struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   uint32 `json:"ttl"`
+}
+
+// Build returns the record in RFC 1035 presentation format.
+func (c *DNSRecordConfig) Build() (string, error) {
+	if c.Name == "" || c.Type == "" || c.Value == "" {
+		return "", errors.New("DNS record requires name, type and value")
+	}
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = 300
+	}
+	value := c.Value
+	if strings.EqualFold(c.Type, "TXT") && !strings.HasPrefix(value, `"`) {
+		value = strconv.Quote(value)
+	}
+	record := fmt.Sprintf("%s %d IN %s %s", mdns.Fqdn(c.Name), ttl, strings.ToUpper(c.Type), value)
+	if _, err := mdns.NewRR(record); err != nil {
+		return "", errors.New("invalid DNS record: ", record).Base(err)
+	}
+	return record, nil
+}
+
+type DNSAnswerRewriteConfig struct {
+	Domain    StringList `json:"domain"`
+	IP        StringList `json:"ip"`
+	Action    string     `json:"action"`
+	ReplaceIP StringList `json:"replaceIP"`
+	TTL       uint32     `json:"ttl"`
+}
+
+func (c *DNSAnswerRewriteConfig) Build() (*dns.AnswerRewrite, error) {
+	config := &dns.AnswerRewrite{
+		Ttl: c.TTL,
+	}
+	switch strings.ToLower(c.Action) {
+	case "", "keep":
+		config.Action = dns.AnswerRewrite_Keep
+	case "replace":
+		config.Action = dns.AnswerRewrite_Replace
+	case "filter":
+		config.Action = dns.AnswerRewrite_Filter
+	default:
+		return nil, errors.New(`unknown answer rewrite "action": `, c.Action)
+	}
+	config.Domain = c.Domain
+	config.Ip = c.IP
+	config.ReplaceIp = c.ReplaceIP
+	if config.Action == dns.AnswerRewrite_Replace && len(config.ReplaceIp) == 0 {
+		return nil, errors.New(`"replaceIP" is required by answer rewrite action "replace"`)
+	}
+	return config, nil
+}
+
 type DNSOutboundConfig struct {
-	Network    Network  `json:"network"`
-	Address    *Address `json:"address"`
-	Port       uint16   `json:"port"`
-	UserLevel  uint32   `json:"userLevel"`
-	NonIPQuery string   `json:"nonIPQuery"`
-	BlockTypes []int32  `json:"blockTypes"`
+	Network        Network                   `json:"network"`
+	Address        *Address                  `json:"address"`
+	Port           uint16                    `json:"port"`
+	UserLevel      uint32                    `json:"userLevel"`
+	NonIPQuery     string                    `json:"nonIPQuery"`
+	BlockTypes     []int32                   `json:"blockTypes"`
+	Records        []*DNSRecordConfig        `json:"records"`
+	ZoneFiles      []string                  `json:"zoneFiles"`
+	AnswerRewrites []*DNSAnswerRewriteConfig `json:"answerRewrites"`
 }
 
 func (c *DNSOutboundConfig) Build() (proto.Message, error) {
@@ -36,5 +104,44 @@ func (c *DNSOutboundConfig) Build() (proto.Message, error) {
 	}
 	config.Non_IPQuery = c.NonIPQuery
 	config.BlockTypes = c.BlockTypes
+	for _, r := range c.Records {
+		record, err := r.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Records = append(config.Records, record)
+	}
+	for _, file := range c.ZoneFiles {
+		records, err := loadZoneFile(file)
+		if err != nil {
+			return nil, err
+		}
+		config.Records = append(config.Records, records...)
+	}
+	for _, r := range c.AnswerRewrites {
+		rule, err := r.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.AnswerRewrites = append(config.AnswerRewrites, rule)
+	}
 	return config, nil
 }
+
+// loadZoneFile reads the records of an RFC 1035 zone file. Relative names are
+// resolved against the $ORIGIN of the file.
+func loadZoneFile(file string) ([]string, error) {
+	content, err := filesystem.ReadFile(file)
+	if err != nil {
+		return nil, errors.New("failed to read zone file ", file).Base(err)
+	}
+	var records []string
+	zp := mdns.NewZoneParser(bytes.NewReader(content), "", file)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		records = append(records, rr.String())
+	}
+	if err := zp.Err(); err != nil {
+		return nil, errors.New("failed to parse zone file ", file).Base(err)
+	}
+	return records, nil
+}
@@ -30,5 +30,33 @@ func TestDnsProxyConfig(t *testing.T) {
 				Non_IPQuery: "drop",
 			},
 		},
+		{
+			Input: `{
+				"records": [
+					{"name": "web.internal", "type": "A", "value": "10.0.0.1"},
+					{"name": "web.internal", "type": "TXT", "value": "hello", "ttl": 60}
+				],
+				"answerRewrites": [
+					{"domain": ["example.com"], "ip": ["10.0.0.0/8"], "action": "filter", "ttl": 30}
+				]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &dns.Config{
+				Server:      &net.Endpoint{},
+				Non_IPQuery: "drop",
+				Records: []string{
+					"web.internal. 300 IN A 10.0.0.1",
+					`web.internal. 60 IN TXT "hello"`,
+				},
+				AnswerRewrites: []*dns.AnswerRewrite{
+					{
+						Domain: []string{"example.com"},
+						Ip:     []string{"10.0.0.0/8"},
+						Action: dns.AnswerRewrite_Filter,
+						Ttl:    30,
+					},
+				},
+			},
+		},
 	})
 }
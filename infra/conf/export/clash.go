@@ -0,0 +1,318 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+)
+
+// Clash exports the config in the format of Clash (Mihomo), which can be encoded in YAML.
+func (e *Exporter) Clash(config *conf.Config) map[string]interface{} {
+	p := e.parse(config)
+
+	names := make(map[*outbound]string)
+	var proxies, groups, rules []interface{}
+	for _, o := range p.outbounds {
+		switch o.kind {
+		case kindDirect:
+			names[o] = "DIRECT"
+			continue
+		case kindBlock:
+			names[o] = "REJECT"
+			continue
+		}
+		proxy, err := clashProxy(o)
+		if err != nil {
+			e.warn("skipped outbound ", o.name, ": ", err)
+			continue
+		}
+		names[o] = o.name
+		proxies = append(proxies, proxy)
+	}
+
+	for _, g := range p.groups {
+		var members []string
+		for _, o := range g.members {
+			if name, found := names[o]; found {
+				members = append(members, name)
+			}
+		}
+		if len(members) == 0 {
+			e.warn("balancer ", g.name, " has no exported outbounds")
+			continue
+		}
+		group := object{
+			"name":    g.name,
+			"proxies": members,
+		}
+		switch g.strategy {
+		case "leastping", "leastload":
+			group["type"] = "url-test"
+			group["url"] = "https://www.gstatic.com/generate_204"
+			group["interval"] = 300
+		case "roundrobin":
+			group["type"] = "load-balance"
+			group["strategy"] = "round-robin"
+		default:
+			group["type"] = "load-balance"
+		}
+		names[g] = g.name
+		groups = append(groups, group)
+	}
+
+	for i, r := range p.rules {
+		target, found := names[r.target]
+		if !found {
+			e.warn("skipped rule ", i, ": outbound ", r.target.name, " is not exported")
+			continue
+		}
+		lines, err := clashRule(r, target)
+		if err != nil {
+			e.warn("skipped rule ", i, ": ", err)
+			continue
+		}
+		for _, line := range lines {
+			rules = append(rules, line)
+		}
+	}
+	if final, found := names[p.final]; found {
+		rules = append(rules, "MATCH,"+final)
+	}
+
+	c := object{"mode": "rule"}
+	if len(proxies) > 0 {
+		c["proxies"] = proxies
+	}
+	if len(groups) > 0 {
+		c["proxy-groups"] = groups
+	}
+	if len(rules) > 0 {
+		c["rules"] = rules
+	}
+	return c
+}
+
+func clashProxy(o *outbound) (object, error) {
+	if o.wireguard != nil {
+		return clashWireGuard(o)
+	}
+	l := o.link
+	proxy := object{
+		"name":   o.name,
+		"server": l.Address,
+		"port":   l.Port,
+		"udp":    true,
+	}
+	switch l.Protocol {
+	case sharelink.ProtocolVLESS:
+		proxy["type"] = "vless"
+		proxy["uuid"] = l.ID
+		putString(proxy, "flow", l.Params.Get("flow"))
+	case sharelink.ProtocolVMess:
+		proxy["type"] = "vmess"
+		proxy["uuid"] = l.ID
+		proxy["alterId"] = 0
+		proxy["cipher"] = l.Method
+	case sharelink.ProtocolTrojan:
+		proxy["type"] = "trojan"
+		proxy["password"] = l.ID
+	case sharelink.ProtocolShadowsocks:
+		proxy["type"] = "ss"
+		proxy["cipher"] = l.Method
+		proxy["password"] = l.ID
+	}
+
+	p := l.Params
+	switch network := p.Get("type"); network {
+	case "", "tcp":
+		if t := p.Get("headerType"); t != "" {
+			return nil, errors.New("header ", t, " of raw transport is not supported")
+		}
+	case "ws", "httpupgrade":
+		proxy["network"] = "ws"
+		opts := object{}
+		putString(opts, "path", p.Get("path"))
+		if host := p.Get("host"); host != "" {
+			opts["headers"] = object{"Host": host}
+		}
+		if network == "httpupgrade" {
+			opts["v2ray-http-upgrade"] = true
+		}
+		proxy["ws-opts"] = opts
+	case "grpc":
+		proxy["network"] = "grpc"
+		proxy["grpc-opts"] = object{"grpc-service-name": p.Get("serviceName")}
+	default:
+		return nil, errors.New("transport ", network, " is not supported")
+	}
+
+	switch security := p.Get("security"); security {
+	case "", "none":
+	case "tls", "reality":
+		if l.Protocol == sharelink.ProtocolTrojan {
+			putString(proxy, "sni", p.Get("sni"))
+		} else {
+			proxy["tls"] = true
+			putString(proxy, "servername", p.Get("sni"))
+		}
+		putString(proxy, "client-fingerprint", p.Get("fp"))
+		if alpn := p.Get("alpn"); alpn != "" {
+			proxy["alpn"] = strings.Split(alpn, ",")
+		}
+		if p.Get("allowInsecure") == "1" {
+			proxy["skip-cert-verify"] = true
+		}
+		if security == "reality" {
+			opts := object{"public-key": p.Get("pbk")}
+			putString(opts, "short-id", p.Get("sid"))
+			proxy["reality-opts"] = opts
+		}
+	default:
+		return nil, errors.New("security ", security, " is not supported")
+	}
+	putString(proxy, "dialer-proxy", o.detour)
+	return proxy, nil
+}
+
+func clashWireGuard(o *outbound) (object, error) {
+	wg := o.wireguard
+	peer := wg.Peers[0]
+	server, port, err := splitEndpoint(peer.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	proxy := object{
+		"name":   o.name,
+		"type":   "wireguard",
+		"server": server,
+		"port":   port,
+		"udp":    true,
+	}
+	for _, key := range []struct{ name, value string }{
+		{"private-key", wg.SecretKey},
+		{"public-key", peer.PublicKey},
+		{"pre-shared-key", peer.PreSharedKey},
+	} {
+		k, err := wireguardKey(key.value)
+		if err != nil {
+			return nil, err
+		}
+		putString(proxy, key.name, k)
+	}
+	for _, addr := range wg.Address {
+		_, cidr, v6, err := splitIP(addr)
+		if err != nil {
+			return nil, err
+		}
+		ip, _, _ := strings.Cut(cidr, "/")
+		if v6 {
+			proxy["ipv6"] = ip
+		} else {
+			proxy["ip"] = ip
+		}
+	}
+	if len(peer.AllowedIPs) > 0 {
+		proxy["allowed-ips"] = peer.AllowedIPs
+	}
+	if len(wg.Reserved) > 0 {
+		reserved := make([]int, len(wg.Reserved))
+		for i, b := range wg.Reserved {
+			reserved[i] = int(b)
+		}
+		proxy["reserved"] = reserved
+	}
+	if wg.MTU > 0 {
+		proxy["mtu"] = wg.MTU
+	}
+	putString(proxy, "dialer-proxy", o.detour)
+	return proxy, nil
+}
+
+// clashRule returns the lines of the rule. Conditions of different kinds are joined with AND.
+func clashRule(r *rule, target string) ([]string, error) {
+	var conditions [][]string
+	if len(r.protocol) > 0 {
+		return nil, errors.New("conditions of protocols are not supported")
+	}
+	if len(r.domains) > 0 {
+		var list []string
+		for _, d := range r.domains {
+			kind, value, err := splitDomain(d)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, clashDomainTypes[kind]+","+value)
+		}
+		conditions = append(conditions, list)
+	}
+	if len(r.ips) > 0 {
+		var list []string
+		for _, s := range r.ips {
+			geoip, cidr, v6, err := splitIP(s)
+			switch {
+			case err != nil:
+				return nil, err
+			case geoip == "private":
+				list = append(list, "GEOIP,LAN")
+			case geoip != "":
+				list = append(list, "GEOIP,"+strings.ToUpper(geoip))
+			case v6:
+				list = append(list, "IP-CIDR6,"+cidr)
+			default:
+				list = append(list, "IP-CIDR,"+cidr)
+			}
+		}
+		conditions = append(conditions, list)
+	}
+	if len(r.ports) > 0 {
+		var list []string
+		for _, p := range r.ports {
+			list = append(list, "DST-PORT,"+portRange(p, "-"))
+		}
+		conditions = append(conditions, list)
+	}
+	if r.network != "" {
+		conditions = append(conditions, []string{"NETWORK," + strings.ToUpper(r.network)})
+	}
+
+	if len(conditions) == 1 {
+		lines := make([]string, len(conditions[0]))
+		for i, c := range conditions[0] {
+			lines[i] = c + "," + target
+		}
+		return lines, nil
+	}
+	parts := make([]string, len(conditions))
+	for i, list := range conditions {
+		if len(list) == 1 {
+			parts[i] = "(" + list[0] + ")"
+			continue
+		}
+		parts[i] = "(OR,((" + strings.Join(list, "),(") + ")))"
+	}
+	return []string{"AND,(" + strings.Join(parts, ",") + ")," + target}, nil
+}
+
+var clashDomainTypes = map[string]string{
+	domainSuffix:  "DOMAIN-SUFFIX",
+	domainFull:    "DOMAIN",
+	domainKeyword: "DOMAIN-KEYWORD",
+	domainRegexp:  "DOMAIN-REGEX",
+	domainGeosite: "GEOSITE",
+}
+
+func portRange(r conf.PortRange, sep string) string {
+	if r.From == r.To {
+		return fmt.Sprint(r.From)
+	}
+	return fmt.Sprint(r.From, sep, r.To)
+}
+
+func putString(o object, key, value string) {
+	if value != "" {
+		o[key] = value
+	}
+}
@@ -0,0 +1,347 @@
+// Package export converts the outbounds and routing rules of configs into the configs of other
+// clients, Clash (Mihomo) and sing-box, so that a config can be the only source of truth of a
+// mixed fleet of clients.
+package export
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+)
+
+type object = map[string]interface{}
+
+// Exporter exports configs. What can't be represented in the target format is skipped, with
+// warnings recorded.
+type Exporter struct {
+	Warnings []string
+}
+
+func (e *Exporter) warn(v ...interface{}) {
+	e.Warnings = append(e.Warnings, serial.Concat(v...))
+}
+
+// Kinds of outbounds.
+const (
+	kindProxy = iota
+	kindDirect
+	kindBlock
+	kindGroup
+)
+
+// outbound is an outbound or a balancer that can be exported.
+type outbound struct {
+	name string
+	kind int
+	// link is set for VLESS, VMess, Trojan and Shadowsocks.
+	link *sharelink.Link
+	// wireguard is set for WireGuard.
+	wireguard *conf.WireGuardConfig
+	// detour is the name of the outbound to dial through.
+	detour string
+	// strategy and members are set for balancers.
+	strategy string
+	members  []*outbound
+}
+
+// rule is a routing rule that can be exported. Conditions of different kinds are ANDed, and values
+// of the same kind are ORed.
+type rule struct {
+	domains  []string
+	ips      []string
+	ports    []conf.PortRange
+	network  string
+	protocol []string
+	target   *outbound
+}
+
+// fieldRule is the JSON of routing rules.
+type fieldRule struct {
+	OutboundTag string            `json:"outboundTag"`
+	BalancerTag string            `json:"balancerTag"`
+	Domain      *conf.StringList  `json:"domain"`
+	Domains     *conf.StringList  `json:"domains"`
+	IP          *conf.StringList  `json:"ip"`
+	Port        *conf.PortList    `json:"port"`
+	Network     *conf.NetworkList `json:"network"`
+	Protocols   *conf.StringList  `json:"protocol"`
+	SourceIP    *conf.StringList  `json:"source"`
+	SourcePort  *conf.PortList    `json:"sourcePort"`
+	User        *conf.StringList  `json:"user"`
+	InboundTag  *conf.StringList  `json:"inboundTag"`
+	Attributes  map[string]string `json:"attrs"`
+}
+
+// parsed is a config parsed for exporting.
+type parsed struct {
+	outbounds []*outbound
+	groups    []*outbound
+	rules     []*rule
+	// final is the default outbound, which is the first one.
+	final *outbound
+}
+
+func (e *Exporter) parse(config *conf.Config) *parsed {
+	p := &parsed{}
+	byTag := make(map[string]*outbound)
+	for i := range config.OutboundConfigs {
+		c := &config.OutboundConfigs[i]
+		o := e.outbound(c, i)
+		if i == 0 {
+			if o == nil {
+				e.warn("the default outbound ", c.Tag, " is not exported")
+			}
+			p.final = o
+		}
+		if o == nil {
+			continue
+		}
+		p.outbounds = append(p.outbounds, o)
+		if c.Tag != "" {
+			byTag[c.Tag] = o
+		}
+	}
+	for _, o := range p.outbounds {
+		if o.detour == "" {
+			continue
+		}
+		if d := byTag[o.detour]; d != nil && d.kind == kindProxy {
+			o.detour = d.name
+		} else {
+			e.warn("outbound ", o.name, " is exported without its proxy ", o.detour)
+			o.detour = ""
+		}
+	}
+	if config.RouterConfig == nil {
+		return p
+	}
+
+	balancers := make(map[string]*outbound)
+	for _, b := range config.RouterConfig.Balancers {
+		g := &outbound{name: b.Tag, kind: kindGroup, strategy: strings.ToLower(b.Strategy.Type)}
+		for _, o := range p.outbounds {
+			for _, s := range b.Selectors {
+				if o.kind != kindBlock && strings.HasPrefix(o.name, s) {
+					g.members = append(g.members, o)
+					break
+				}
+			}
+		}
+		if len(g.members) == 0 {
+			e.warn("balancer ", b.Tag, " has no exported outbounds")
+			continue
+		}
+		if b.FallbackTag != "" {
+			e.warn("fallback of balancer ", b.Tag, " is not exported")
+		}
+		p.groups = append(p.groups, g)
+		balancers[b.Tag] = g
+	}
+
+	for i, raw := range config.RouterConfig.RuleList {
+		r, err := e.rule(raw, byTag, balancers)
+		if err != nil {
+			e.warn("skipped rule ", i, ": ", err)
+			continue
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p
+}
+
+func (e *Exporter) outbound(c *conf.OutboundDetourConfig, index int) *outbound {
+	o := &outbound{name: c.Tag}
+	protocol := strings.ToLower(c.Protocol)
+	if o.name == "" {
+		o.name = fmt.Sprint(protocol, "-", index)
+	}
+	switch protocol {
+	case "freedom":
+		o.kind = kindDirect
+		return o
+	case "blackhole":
+		o.kind = kindBlock
+		return o
+	case "vless", "vmess", "trojan", "shadowsocks", "ss":
+		links, err := sharelink.FromOutbound(c)
+		if err != nil {
+			e.warn("skipped outbound ", o.name, ": ", err)
+			return nil
+		}
+		if len(links) > 1 {
+			e.warn("outbound ", o.name, " is exported with only the first of its ", len(links), " servers")
+		}
+		o.link = links[0]
+	case "wireguard":
+		o.wireguard = new(conf.WireGuardConfig)
+		if c.Settings != nil {
+			if err := json.Unmarshal(*c.Settings, o.wireguard); err != nil {
+				e.warn("skipped outbound ", o.name, ": ", err)
+				return nil
+			}
+		}
+		if len(o.wireguard.Peers) == 0 {
+			e.warn("skipped outbound ", o.name, ": no peer")
+			return nil
+		}
+		if len(o.wireguard.Peers) > 1 {
+			e.warn("outbound ", o.name, " is exported with only the first of its ", len(o.wireguard.Peers), " peers")
+		}
+	default:
+		e.warn("skipped outbound ", o.name, ": protocol ", c.Protocol, " is not supported")
+		return nil
+	}
+	if c.ProxySettings != nil {
+		o.detour = c.ProxySettings.Tag
+	}
+	if c.MuxSettings != nil && c.MuxSettings.Enabled {
+		e.warn("mux of outbound ", o.name, " is not exported")
+	}
+	return o
+}
+
+func (e *Exporter) rule(raw json.RawMessage, byTag, balancers map[string]*outbound) (*rule, error) {
+	var f fieldRule
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	switch {
+	case f.SourceIP != nil, f.SourcePort != nil:
+		return nil, errors.New("conditions of sources are not supported")
+	case f.InboundTag != nil:
+		return nil, errors.New("conditions of inbounds are not supported")
+	case f.User != nil:
+		return nil, errors.New("conditions of users are not supported")
+	case len(f.Attributes) > 0:
+		return nil, errors.New("conditions of attributes are not supported")
+	}
+
+	r := &rule{}
+	switch {
+	case f.OutboundTag != "":
+		if r.target = byTag[f.OutboundTag]; r.target == nil {
+			return nil, errors.New("outbound ", f.OutboundTag, " is not exported")
+		}
+	case f.BalancerTag != "":
+		if r.target = balancers[f.BalancerTag]; r.target == nil {
+			return nil, errors.New("balancer ", f.BalancerTag, " is not exported")
+		}
+	default:
+		return nil, errors.New("no target")
+	}
+
+	for _, list := range []*conf.StringList{f.Domain, f.Domains} {
+		if list != nil {
+			r.domains = append(r.domains, *list...)
+		}
+	}
+	if f.IP != nil {
+		r.ips = *f.IP
+	}
+	if f.Port != nil {
+		r.ports = f.Port.Range
+	}
+	if f.Network != nil {
+		// Both TCP and UDP are the same as no network.
+		if networks := *f.Network; len(networks) == 1 {
+			r.network = strings.ToLower(string(networks[0]))
+		}
+	}
+	if f.Protocols != nil {
+		r.protocol = *f.Protocols
+	}
+	if len(r.domains)+len(r.ips)+len(r.ports)+len(r.protocol) == 0 && r.network == "" {
+		return nil, errors.New("no condition")
+	}
+	return r, nil
+}
+
+// Kinds of domains, named after their prefixes in configs.
+const (
+	domainSuffix  = "domain"
+	domainFull    = "full"
+	domainKeyword = "keyword"
+	domainRegexp  = "regexp"
+	domainGeosite = "geosite"
+)
+
+// splitDomain returns the kind and value of a domain in configs.
+func splitDomain(d string) (string, string, error) {
+	kind, value, found := strings.Cut(d, ":")
+	if !found {
+		// Plain domains match substrings.
+		return domainKeyword, d, nil
+	}
+	switch kind {
+	case domainSuffix, domainFull, domainKeyword, domainRegexp:
+		return kind, value, nil
+	case domainGeosite:
+		if strings.Contains(value, "@") {
+			return "", "", errors.New("attributes of geosite are not supported: ", d)
+		}
+		return kind, strings.ToLower(value), nil
+	}
+	return "", "", errors.New("domain is not supported: ", d)
+}
+
+// splitIP returns the country code of a geoip, or the CIDR of an IP, which is IPv6 if v6 is true.
+func splitIP(s string) (geoip string, cidr string, v6 bool, err error) {
+	if code, found := strings.CutPrefix(s, "geoip:"); found {
+		if strings.HasPrefix(code, "!") {
+			return "", "", false, errors.New("reverse matching of geoip is not supported: ", s)
+		}
+		return strings.ToLower(code), "", false, nil
+	}
+	addr, _, _ := strings.Cut(s, "/")
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", "", false, errors.New("IP is not supported: ", s)
+	}
+	v6 = ip.To4() == nil
+	if !strings.Contains(s, "/") {
+		if v6 {
+			s += "/128"
+		} else {
+			s += "/32"
+		}
+	}
+	return "", s, v6, nil
+}
+
+// wireguardKey returns a key of WireGuard in base64.
+func wireguardKey(key string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	h, err := conf.ParseWireGuardKey(key)
+	if err != nil {
+		return "", err
+	}
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// splitEndpoint splits the endpoint of a WireGuard peer.
+func splitEndpoint(endpoint string) (string, int, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", 0, err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, errors.New("invalid port of endpoint: ", endpoint)
+	}
+	return host, p, nil
+}
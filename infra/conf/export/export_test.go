@@ -0,0 +1,208 @@
+package export_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/export"
+)
+
+const config = `{
+	"outbounds": [
+		{
+			"tag": "proxy-reality",
+			"protocol": "vless",
+			"settings": {
+				"vnext": [{
+					"address": "example.com",
+					"port": 443,
+					"users": [{"id": "b831381d-6324-4d53-ad4f-8cda48b30811", "encryption": "none", "flow": "xtls-rprx-vision"}]
+				}]
+			},
+			"streamSettings": {
+				"network": "raw",
+				"security": "reality",
+				"realitySettings": {
+					"serverName": "www.example.com",
+					"fingerprint": "chrome",
+					"password": "SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA",
+					"shortId": "6ba85179e30d4fc2"
+				}
+			}
+		},
+		{
+			"tag": "proxy-ws",
+			"protocol": "vmess",
+			"settings": {
+				"vnext": [{"address": "1.2.3.4", "port": 443, "users": [{"id": "b831381d-6324-4d53-ad4f-8cda48b30811"}]}]
+			},
+			"streamSettings": {
+				"network": "ws",
+				"wsSettings": {"path": "/ws", "host": "example.com"},
+				"security": "tls",
+				"tlsSettings": {"serverName": "example.com", "alpn": ["http/1.1"]}
+			}
+		},
+		{
+			"tag": "xhttp",
+			"protocol": "trojan",
+			"settings": {"servers": [{"address": "example.com", "port": 443, "password": "password"}]},
+			"streamSettings": {"network": "xhttp", "security": "tls"}
+		},
+		{
+			"tag": "ss",
+			"protocol": "shadowsocks",
+			"settings": {"servers": [{"address": "example.com", "port": 8388, "method": "2022-blake3-aes-128-gcm", "password": "AAAAAAAAAAAAAAAAAAAAAA=="}]}
+		},
+		{
+			"tag": "wg",
+			"protocol": "wireguard",
+			"settings": {
+				"secretKey": "QEFCQ0RFRkdISUpLTE1OT1BRUlNUVVZXWFlaW1xdXl8=",
+				"address": ["10.0.0.2/32", "fd00::2/128"],
+				"peers": [{"publicKey": "YGFiY2RlZmdoaWprbG1ub3BxcnN0dXZ3eHl6e3x9fn8=", "endpoint": "1.2.3.4:51820"}],
+				"reserved": [1, 2, 3]
+			}
+		},
+		{"tag": "direct", "protocol": "freedom"},
+		{"tag": "block", "protocol": "blackhole"},
+		{"tag": "dns", "protocol": "dns"}
+	],
+	"routing": {
+		"balancers": [{"tag": "auto", "selector": ["proxy-"], "strategy": {"type": "leastPing"}}],
+		"rules": [
+			{"domain": ["geosite:category-ads-all"], "outboundTag": "block"},
+			{"domain": ["domain:example.org", "full:www.example.net"], "ip": ["geoip:private", "10.0.0.0/8"], "outboundTag": "direct"},
+			{"port": "53,1000-2000", "network": "udp", "outboundTag": "wg"},
+			{"ip": ["2001:db8::1"], "balancerTag": "auto"},
+			{"protocol": ["bittorrent"], "outboundTag": "direct"},
+			{"inboundTag": ["socks"], "outboundTag": "direct"},
+			{"domain": ["example.com"], "outboundTag": "xhttp"}
+		]
+	}
+}`
+
+func load(t *testing.T) *conf.Config {
+	t.Helper()
+	c := new(conf.Config)
+	common.Must(json.Unmarshal([]byte(config), c))
+	return c
+}
+
+func find(list interface{}, key, value string) map[string]interface{} {
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok && m[key] == value {
+			return m
+		}
+	}
+	return nil
+}
+
+func hasWarning(warnings []string, s string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClash(t *testing.T) {
+	e := &Exporter{}
+	c := e.Clash(load(t))
+
+	reality := find(c["proxies"], "name", "proxy-reality")
+	if reality == nil || reality["type"] != "vless" || reality["flow"] != "xtls-rprx-vision" ||
+		!reflect.DeepEqual(reality["reality-opts"], map[string]interface{}{
+			"public-key": "SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA",
+			"short-id":   "6ba85179e30d4fc2",
+		}) {
+		t.Error("unexpected proxy: ", reality)
+	}
+	ws := find(c["proxies"], "name", "proxy-ws")
+	if ws == nil || ws["network"] != "ws" || ws["servername"] != "example.com" {
+		t.Error("unexpected proxy: ", ws)
+	}
+	wg := find(c["proxies"], "name", "wg")
+	if wg == nil || wg["ip"] != "10.0.0.2" || wg["ipv6"] != "fd00::2" ||
+		wg["private-key"] != "QEFCQ0RFRkdISUpLTE1OT1BRUlNUVVZXWFlaW1xdXl8=" {
+		t.Error("unexpected proxy: ", wg)
+	}
+	if find(c["proxies"], "name", "xhttp") != nil {
+		t.Error("xhttp is exported")
+	}
+	group := find(c["proxy-groups"], "name", "auto")
+	if group == nil || group["type"] != "url-test" || !reflect.DeepEqual(group["proxies"], []string{"proxy-reality", "proxy-ws"}) {
+		t.Error("unexpected group: ", group)
+	}
+
+	expectedRules := []interface{}{
+		"GEOSITE,category-ads-all,REJECT",
+		"AND,((OR,((DOMAIN-SUFFIX,example.org),(DOMAIN,www.example.net))),(OR,((GEOIP,LAN),(IP-CIDR,10.0.0.0/8)))),DIRECT",
+		"AND,((OR,((DST-PORT,53),(DST-PORT,1000-2000))),(NETWORK,UDP)),wg",
+		"IP-CIDR6,2001:db8::1/128,auto",
+		"MATCH,proxy-reality",
+	}
+	if !reflect.DeepEqual(c["rules"], expectedRules) {
+		t.Error("unexpected rules: ", c["rules"])
+	}
+
+	for _, w := range []string{"xhttp", "dns", "protocols", "inbounds"} {
+		if !hasWarning(e.Warnings, w) {
+			t.Error("expected warning of ", w, " in ", e.Warnings)
+		}
+	}
+}
+
+func TestSingBox(t *testing.T) {
+	e := &Exporter{}
+	c := e.SingBox(load(t))
+
+	reality := find(c["outbounds"], "tag", "proxy-reality")
+	tls, _ := reality["tls"].(map[string]interface{})
+	if reality["type"] != "vless" || tls == nil || !reflect.DeepEqual(tls["reality"], map[string]interface{}{
+		"enabled":    true,
+		"public_key": "SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA",
+		"short_id":   "6ba85179e30d4fc2",
+	}) {
+		t.Error("unexpected outbound: ", reality)
+	}
+	ws := find(c["outbounds"], "tag", "proxy-ws")
+	if transport, _ := ws["transport"].(map[string]interface{}); transport == nil || transport["type"] != "ws" || transport["path"] != "/ws" {
+		t.Error("unexpected outbound: ", ws)
+	}
+	if group := find(c["outbounds"], "tag", "auto"); group == nil || group["type"] != "urltest" {
+		t.Error("unexpected group: ", group)
+	}
+	if wg := find(c["endpoints"], "tag", "wg"); wg == nil || wg["private_key"] != "QEFCQ0RFRkdISUpLTE1OT1BRUlNUVVZXWFlaW1xdXl8=" {
+		t.Error("unexpected endpoint: ", wg)
+	}
+
+	route, _ := c["route"].(map[string]interface{})
+	rules, _ := route["rules"].([]interface{})
+	if len(rules) != 5 || route["final"] != "proxy-reality" {
+		t.Fatal("unexpected route: ", route)
+	}
+	if ads := rules[0].(map[string]interface{}); ads["action"] != "reject" ||
+		!reflect.DeepEqual(ads["rule_set"], []interface{}{"geosite-category-ads-all"}) {
+		t.Error("unexpected rule: ", ads)
+	}
+	if logical := rules[1].(map[string]interface{}); logical["type"] != "logical" || logical["outbound"] != "direct" {
+		t.Error("unexpected rule: ", logical)
+	}
+	if ports := rules[2].(map[string]interface{}); !reflect.DeepEqual(ports["port"], []interface{}{uint32(53)}) ||
+		!reflect.DeepEqual(ports["port_range"], []interface{}{"1000:2000"}) || ports["network"] != "udp" {
+		t.Error("unexpected rule: ", ports)
+	}
+	if bt := rules[4].(map[string]interface{}); !reflect.DeepEqual(bt["protocol"], []string{"bittorrent"}) {
+		t.Error("unexpected rule: ", bt)
+	}
+	if !hasWarning(e.Warnings, "inbounds") {
+		t.Error("expected warning of inbounds in ", e.Warnings)
+	}
+}
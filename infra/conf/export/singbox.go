@@ -0,0 +1,337 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+)
+
+// Rule sets of geosite and geoip, published by sing-box.
+const (
+	singBoxGeositeURL = "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-%s.srs"
+	singBoxGeoIPURL   = "https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-%s.srs"
+)
+
+// SingBox exports the config in the format of sing-box 1.11 and later.
+func (e *Exporter) SingBox(config *conf.Config) map[string]interface{} {
+	p := e.parse(config)
+
+	exported := make(map[*outbound]bool)
+	var outbounds, endpoints []interface{}
+	for _, o := range p.outbounds {
+		switch {
+		case o.kind == kindBlock:
+			// Blocking is an action of rules.
+			exported[o] = true
+			continue
+		case o.kind == kindDirect:
+			outbounds = append(outbounds, object{"type": "direct", "tag": o.name})
+		case o.wireguard != nil:
+			endpoint, err := singBoxWireGuard(o)
+			if err != nil {
+				e.warn("skipped outbound ", o.name, ": ", err)
+				continue
+			}
+			endpoints = append(endpoints, endpoint)
+		default:
+			outbound, err := singBoxOutbound(o)
+			if err != nil {
+				e.warn("skipped outbound ", o.name, ": ", err)
+				continue
+			}
+			outbounds = append(outbounds, outbound)
+		}
+		exported[o] = true
+	}
+
+	for _, g := range p.groups {
+		var members []string
+		for _, o := range g.members {
+			if exported[o] {
+				members = append(members, o.name)
+			}
+		}
+		if len(members) == 0 {
+			e.warn("balancer ", g.name, " has no exported outbounds")
+			continue
+		}
+		group := object{
+			"tag":       g.name,
+			"outbounds": members,
+		}
+		switch g.strategy {
+		case "leastping", "leastload":
+			group["type"] = "urltest"
+		default:
+			e.warn("balancer ", g.name, " is exported as a selector, for load balancing is not supported")
+			group["type"] = "selector"
+		}
+		outbounds = append(outbounds, group)
+		exported[g] = true
+	}
+
+	ruleSets := make(map[string]bool)
+	var rules, sets []interface{}
+	for i, r := range p.rules {
+		if !exported[r.target] {
+			e.warn("skipped rule ", i, ": outbound ", r.target.name, " is not exported")
+			continue
+		}
+		rule, err := singBoxRule(r, func(tag, url string) {
+			if !ruleSets[tag] {
+				ruleSets[tag] = true
+				sets = append(sets, object{"type": "remote", "tag": tag, "format": "binary", "url": url})
+			}
+		})
+		if err != nil {
+			e.warn("skipped rule ", i, ": ", err)
+			continue
+		}
+		if r.target.kind == kindBlock {
+			rule["action"] = "reject"
+		} else {
+			rule["outbound"] = r.target.name
+		}
+		rules = append(rules, rule)
+	}
+
+	route := object{}
+	if len(rules) > 0 {
+		route["rules"] = rules
+	}
+	if len(sets) > 0 {
+		route["rule_set"] = sets
+	}
+	if p.final != nil && exported[p.final] {
+		if p.final.kind == kindBlock {
+			e.warn("the default outbound ", p.final.name, " blocks everything, which is not exported")
+		} else {
+			route["final"] = p.final.name
+		}
+	}
+
+	c := object{}
+	if len(outbounds) > 0 {
+		c["outbounds"] = outbounds
+	}
+	if len(endpoints) > 0 {
+		c["endpoints"] = endpoints
+	}
+	if len(route) > 0 {
+		c["route"] = route
+	}
+	return c
+}
+
+func singBoxOutbound(o *outbound) (object, error) {
+	l := o.link
+	outbound := object{
+		"tag":         o.name,
+		"server":      l.Address,
+		"server_port": l.Port,
+	}
+	switch l.Protocol {
+	case sharelink.ProtocolVLESS:
+		outbound["type"] = "vless"
+		outbound["uuid"] = l.ID
+		putString(outbound, "flow", l.Params.Get("flow"))
+		outbound["packet_encoding"] = "xudp"
+	case sharelink.ProtocolVMess:
+		outbound["type"] = "vmess"
+		outbound["uuid"] = l.ID
+		outbound["security"] = l.Method
+	case sharelink.ProtocolTrojan:
+		outbound["type"] = "trojan"
+		outbound["password"] = l.ID
+	case sharelink.ProtocolShadowsocks:
+		outbound["type"] = "shadowsocks"
+		outbound["method"] = l.Method
+		outbound["password"] = l.ID
+	}
+
+	p := l.Params
+	switch network := p.Get("type"); network {
+	case "", "tcp":
+		if t := p.Get("headerType"); t != "" {
+			return nil, errors.New("header ", t, " of raw transport is not supported")
+		}
+	case "ws":
+		transport := object{"type": "ws"}
+		putString(transport, "path", p.Get("path"))
+		if host := p.Get("host"); host != "" {
+			transport["headers"] = object{"Host": host}
+		}
+		outbound["transport"] = transport
+	case "httpupgrade":
+		transport := object{"type": "httpupgrade"}
+		putString(transport, "path", p.Get("path"))
+		putString(transport, "host", p.Get("host"))
+		outbound["transport"] = transport
+	case "grpc":
+		transport := object{"type": "grpc"}
+		putString(transport, "service_name", p.Get("serviceName"))
+		outbound["transport"] = transport
+	default:
+		return nil, errors.New("transport ", network, " is not supported")
+	}
+
+	switch security := p.Get("security"); security {
+	case "", "none":
+	case "tls", "reality":
+		tls := object{"enabled": true}
+		putString(tls, "server_name", p.Get("sni"))
+		if p.Get("allowInsecure") == "1" {
+			tls["insecure"] = true
+		}
+		if alpn := p.Get("alpn"); alpn != "" {
+			tls["alpn"] = strings.Split(alpn, ",")
+		}
+		if fp := p.Get("fp"); fp != "" {
+			tls["utls"] = object{"enabled": true, "fingerprint": fp}
+		}
+		if security == "reality" {
+			reality := object{"enabled": true, "public_key": p.Get("pbk")}
+			putString(reality, "short_id", p.Get("sid"))
+			tls["reality"] = reality
+		}
+		outbound["tls"] = tls
+	default:
+		return nil, errors.New("security ", security, " is not supported")
+	}
+	putString(outbound, "detour", o.detour)
+	return outbound, nil
+}
+
+func singBoxWireGuard(o *outbound) (object, error) {
+	wg := o.wireguard
+	peer := wg.Peers[0]
+	server, port, err := splitEndpoint(peer.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := wireguardKey(wg.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	p := object{
+		"address": server,
+		"port":    port,
+	}
+	for _, key := range []struct{ name, value string }{
+		{"public_key", peer.PublicKey},
+		{"pre_shared_key", peer.PreSharedKey},
+	} {
+		k, err := wireguardKey(key.value)
+		if err != nil {
+			return nil, err
+		}
+		putString(p, key.name, k)
+	}
+	p["allowed_ips"] = []string{"0.0.0.0/0", "::/0"}
+	if len(peer.AllowedIPs) > 0 {
+		p["allowed_ips"] = peer.AllowedIPs
+	}
+	if peer.KeepAlive > 0 {
+		p["persistent_keepalive_interval"] = peer.KeepAlive
+	}
+	if len(wg.Reserved) > 0 {
+		reserved := make([]int, len(wg.Reserved))
+		for i, b := range wg.Reserved {
+			reserved[i] = int(b)
+		}
+		p["reserved"] = reserved
+	}
+
+	endpoint := object{
+		"type":        "wireguard",
+		"tag":         o.name,
+		"address":     wg.Address,
+		"private_key": privateKey,
+		"peers":       []interface{}{p},
+	}
+	if wg.MTU > 0 {
+		endpoint["mtu"] = wg.MTU
+	}
+	putString(endpoint, "detour", o.detour)
+	return endpoint, nil
+}
+
+// singBoxRule returns the rule without its action. Domains and IPs are ORed in a rule of sing-box,
+// so the rule is a logical one if it has both.
+func singBoxRule(r *rule, addRuleSet func(tag, url string)) (object, error) {
+	domains := object{}
+	for _, d := range r.domains {
+		kind, value, err := splitDomain(d)
+		if err != nil {
+			return nil, err
+		}
+		if kind == domainGeosite {
+			tag := "geosite-" + value
+			addRuleSet(tag, fmt.Sprintf(singBoxGeositeURL, value))
+			appendList(domains, "rule_set", tag)
+			continue
+		}
+		appendList(domains, singBoxDomainTypes[kind], value)
+	}
+
+	ips := object{}
+	for _, s := range r.ips {
+		geoip, cidr, _, err := splitIP(s)
+		switch {
+		case err != nil:
+			return nil, err
+		case geoip == "private":
+			ips["ip_is_private"] = true
+		case geoip != "":
+			tag := "geoip-" + geoip
+			addRuleSet(tag, fmt.Sprintf(singBoxGeoIPURL, geoip))
+			appendList(ips, "rule_set", tag)
+		default:
+			appendList(ips, "ip_cidr", cidr)
+		}
+	}
+
+	others := object{}
+	for _, p := range r.ports {
+		if p.From == p.To {
+			appendList(others, "port", p.From)
+		} else {
+			appendList(others, "port_range", portRange(p, ":"))
+		}
+	}
+	if r.network != "" {
+		others["network"] = r.network
+	}
+	if len(r.protocol) > 0 {
+		others["protocol"] = r.protocol
+	}
+
+	if len(domains) > 0 && len(ips) > 0 {
+		rules := []interface{}{domains, ips}
+		if len(others) > 0 {
+			rules = append(rules, others)
+		}
+		return object{"type": "logical", "mode": "and", "rules": rules}, nil
+	}
+	for _, m := range []object{domains, ips} {
+		for k, v := range m {
+			others[k] = v
+		}
+	}
+	return others, nil
+}
+
+var singBoxDomainTypes = map[string]string{
+	domainSuffix:  "domain_suffix",
+	domainFull:    "domain",
+	domainKeyword: "domain_keyword",
+	domainRegexp:  "domain_regex",
+}
+
+func appendList(o object, key string, value interface{}) {
+	list, _ := o[key].([]interface{})
+	o[key] = append(list, value)
+}
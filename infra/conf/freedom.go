@@ -9,18 +9,21 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	v2net "github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/proxy"
 	"github.com/HZ-PRE/XrarCore/proxy/freedom"
 	"google.golang.org/protobuf/proto"
 )
 
 type FreedomConfig struct {
-	DomainStrategy string    `json:"domainStrategy"`
-	Redirect       string    `json:"redirect"`
-	UserLevel      uint32    `json:"userLevel"`
-	Fragment       *Fragment `json:"fragment"`
-	Noise          *Noise    `json:"noise"`
-	Noises         []*Noise  `json:"noises"`
-	ProxyProtocol  uint32    `json:"proxyProtocol"`
+	DomainStrategy string               `json:"domainStrategy"`
+	Redirect       string               `json:"redirect"`
+	UserLevel      uint32               `json:"userLevel"`
+	Fragment       *Fragment            `json:"fragment"`
+	Noise          *Noise               `json:"noise"`
+	Noises         []*Noise             `json:"noises"`
+	ProxyProtocol  uint32               `json:"proxyProtocol"`
+	TLVs           []string             `json:"proxyProtocolTlvs"`
+	HappyEyeballs  *HappyEyeballsConfig `json:"happyEyeballs"`
 }
 
 type Fragment struct {
@@ -147,6 +150,16 @@ func (c *FreedomConfig) Build() (proto.Message, error) {
 	if c.ProxyProtocol > 0 && c.ProxyProtocol <= 2 {
 		config.ProxyProtocol = c.ProxyProtocol
 	}
+	if len(c.TLVs) > 0 {
+		if c.ProxyProtocol != 2 {
+			return nil, errors.New(`Freedom: "proxyProtocolTlvs" requires "proxyProtocol" 2`)
+		}
+		if err := proxy.ValidateProxyProtocolTLVs(c.TLVs); err != nil {
+			return nil, errors.New(`Freedom: invalid "proxyProtocolTlvs"`).Base(err)
+		}
+		config.ProxyProtocolTlvs = c.TLVs
+	}
+	config.HappyEyeballs = c.HappyEyeballs.Build()
 	return config, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/protocol"
 	. "github.com/HZ-PRE/XrarCore/infra/conf"
 	"github.com/HZ-PRE/XrarCore/proxy/freedom"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
 )
 
 func TestFreedomConfig(t *testing.T) {
@@ -37,5 +38,33 @@ func TestFreedomConfig(t *testing.T) {
 				UserLevel: 1,
 			},
 		},
+		{
+			Input: `{
+				"domainStrategy": "UseIP",
+				"happyEyeballs": {
+					"tryDelayMs": 100,
+					"maxConcurrentTry": 2
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &freedom.Config{
+				DomainStrategy: freedom.Config_USE_IP,
+				HappyEyeballs: &internet.HappyEyeballsConfig{
+					TryDelayMs:       100,
+					MaxConcurrentTry: 2,
+				},
+			},
+		},
+		{
+			Input: `{
+				"proxyProtocol": 2,
+				"proxyProtocolTlvs": ["email", "inboundTag", "sni", "alpn"]
+			}`,
+			Parser: loadJSON(creator),
+			Output: &freedom.Config{
+				ProxyProtocol:     2,
+				ProxyProtocolTlvs: []string{"email", "inboundTag", "sni", "alpn"},
+			},
+		},
 	})
 }
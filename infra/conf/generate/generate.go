@@ -0,0 +1,313 @@
+// Package generate generates matched server and client configs from a profile of protocol,
+// transport and security. The clients are derived from the server with share links, so that
+// both sides always agree.
+package generate
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/uuid"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+	"golang.org/x/crypto/curve25519"
+)
+
+type object = map[string]interface{}
+
+// Profile describes the deployment to generate.
+type Profile struct {
+	// Protocol is one of vless, vmess, trojan and shadowsocks. Default vless.
+	Protocol string
+	// Transport is one of raw, xhttp, ws, grpc and httpupgrade. Default raw.
+	Transport string
+	// Security is one of reality, tls and none. Default reality, or none for Shadowsocks.
+	Security string
+	// Address is the address of the server for clients.
+	Address string
+	// Port is the listening port of the server. Default 443.
+	Port uint16
+	// ServerName is the SNI of TLS and REALITY. TLS defaults to the address if it's a domain,
+	// and REALITY defaults to the host of Target.
+	ServerName string
+	// Target is the target of REALITY. Default www.microsoft.com:443.
+	Target string
+	// CertificateFile and KeyFile are the certificate of TLS.
+	CertificateFile string
+	KeyFile         string
+	// Users is the number of users. Default 1.
+	Users int
+}
+
+// Result is the generated configs, in the JSON format of configs.
+type Result struct {
+	Server map[string]interface{}
+	Client map[string]interface{}
+	// Links are the share links of the users.
+	Links []string
+}
+
+// Generate generates the configs of the profile, with random keys, IDs, short IDs and paths.
+func Generate(p *Profile) (*Result, error) {
+	p, err := p.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	inbound := object{
+		"tag":      "in",
+		"port":     p.Port,
+		"protocol": p.Protocol,
+	}
+	inbound["settings"] = p.settings()
+	inbound["streamSettings"] = p.streamSettings()
+	if p.Security != "none" {
+		inbound["sniffing"] = object{
+			"enabled":      true,
+			"destOverride": []string{"http", "tls", "quic"},
+			"routeOnly":    true,
+		}
+	}
+
+	b, err := json.Marshal(inbound)
+	if err != nil {
+		return nil, err
+	}
+	in := new(conf.InboundDetourConfig)
+	if err := json.Unmarshal(b, in); err != nil {
+		return nil, err
+	}
+	links, err := sharelink.FromInbound(in, &sharelink.InboundOptions{Address: p.Address})
+	if err != nil {
+		return nil, errors.New("failed to generate share links").Base(err)
+	}
+
+	result := &Result{
+		Server: object{
+			"log":      object{"loglevel": "warning"},
+			"inbounds": []interface{}{inbound},
+			"outbounds": []interface{}{
+				object{"tag": "direct", "protocol": "freedom"},
+				object{"tag": "block", "protocol": "blackhole"},
+			},
+		},
+	}
+	// The client config is of the first user, and the others have share links only.
+	for i, link := range links {
+		result.Links = append(result.Links, link.String())
+		if i > 0 {
+			continue
+		}
+		outbound, err := link.Outbound()
+		if err != nil {
+			return nil, err
+		}
+		outbound["tag"] = "proxy"
+		result.Client = object{
+			"log": object{"loglevel": "warning"},
+			"inbounds": []interface{}{
+				object{
+					"tag":      "socks",
+					"listen":   "127.0.0.1",
+					"port":     10808,
+					"protocol": "socks",
+					"settings": object{"udp": true},
+				},
+				object{
+					"tag":      "http",
+					"listen":   "127.0.0.1",
+					"port":     10809,
+					"protocol": "http",
+				},
+			},
+			"outbounds": []interface{}{
+				outbound,
+				object{"tag": "direct", "protocol": "freedom"},
+			},
+		}
+	}
+	return result, nil
+}
+
+func (p Profile) normalize() (*Profile, error) {
+	p.Protocol = strings.ToLower(p.Protocol)
+	p.Transport = strings.ToLower(p.Transport)
+	p.Security = strings.ToLower(p.Security)
+	switch p.Protocol {
+	case "":
+		p.Protocol = "vless"
+	case "ss":
+		p.Protocol = "shadowsocks"
+	case "vless", "vmess", "trojan", "shadowsocks":
+	default:
+		return nil, errors.New("unsupported protocol: ", p.Protocol)
+	}
+	switch p.Transport {
+	case "", "tcp":
+		p.Transport = "raw"
+	case "splithttp":
+		p.Transport = "xhttp"
+	case "websocket":
+		p.Transport = "ws"
+	case "raw", "xhttp", "ws", "grpc", "httpupgrade":
+	default:
+		return nil, errors.New("unsupported transport: ", p.Transport)
+	}
+	if p.Security == "" {
+		p.Security = "reality"
+		if p.Protocol == "shadowsocks" {
+			p.Security = "none"
+		}
+	}
+
+	switch p.Security {
+	case "reality":
+		switch p.Transport {
+		case "raw", "xhttp", "grpc":
+		default:
+			return nil, errors.New("REALITY doesn't support transport ", p.Transport)
+		}
+		if p.Target == "" {
+			p.Target = "www.microsoft.com:443"
+		}
+		if !strings.Contains(p.Target, ":") {
+			p.Target += ":443"
+		}
+		if p.ServerName == "" {
+			host, _, err := net.SplitHostPort(p.Target)
+			if err != nil {
+				return nil, errors.New("invalid target of REALITY: ", p.Target).Base(err)
+			}
+			p.ServerName = host
+		}
+	case "tls":
+		if p.CertificateFile == "" || p.KeyFile == "" {
+			return nil, errors.New("TLS needs a certificate file and a key file")
+		}
+		if p.ServerName == "" && net.ParseIP(p.Address) == nil {
+			p.ServerName = p.Address
+		}
+	case "none":
+		if p.Protocol == "trojan" {
+			return nil, errors.New("Trojan needs TLS or REALITY")
+		}
+	default:
+		return nil, errors.New("unsupported security: ", p.Security)
+	}
+	if p.Protocol == "shadowsocks" && (p.Transport != "raw" || p.Security != "none") {
+		return nil, errors.New("Shadowsocks only supports raw transport without security")
+	}
+
+	if p.Address == "" {
+		return nil, errors.New("address of the server is not specified")
+	}
+	if p.Port == 0 {
+		p.Port = 443
+	}
+	if p.Users <= 0 {
+		p.Users = 1
+	}
+	return &p, nil
+}
+
+func (p *Profile) settings() object {
+	clients := make([]interface{}, p.Users)
+	for i := range clients {
+		client := object{"email": fmt.Sprint("user", i+1)}
+		id := uuid.New()
+		switch p.Protocol {
+		case "vless":
+			client["id"] = id.String()
+			if p.Transport == "raw" && p.Security != "none" {
+				client["flow"] = "xtls-rprx-vision"
+			}
+		case "vmess":
+			client["id"] = id.String()
+		case "trojan":
+			client["password"] = randomHex(16)
+		case "shadowsocks":
+			client["password"] = randomBase64(16)
+		}
+		clients[i] = client
+	}
+	settings := object{"clients": clients}
+	switch p.Protocol {
+	case "vless":
+		settings["decryption"] = "none"
+	case "shadowsocks":
+		settings["method"] = "2022-blake3-aes-128-gcm"
+		settings["password"] = randomBase64(16)
+		settings["network"] = "tcp,udp"
+	}
+	return settings
+}
+
+func (p *Profile) streamSettings() object {
+	stream := object{"network": p.Transport}
+	path := "/" + randomHex(8)
+	switch p.Transport {
+	case "xhttp":
+		stream["xhttpSettings"] = object{"path": path}
+	case "ws":
+		stream["wsSettings"] = object{"path": path}
+	case "httpupgrade":
+		stream["httpupgradeSettings"] = object{"path": path}
+	case "grpc":
+		stream["grpcSettings"] = object{"serviceName": randomHex(8)}
+	}
+
+	switch p.Security {
+	case "reality":
+		stream["security"] = "reality"
+		stream["realitySettings"] = object{
+			"target":      p.Target,
+			"serverNames": []string{p.ServerName},
+			"privateKey":  base64.RawURLEncoding.EncodeToString(x25519()),
+			"shortIds":    []string{randomHex(8)},
+		}
+	case "tls":
+		tls := object{
+			"certificates": []interface{}{
+				object{"certificateFile": p.CertificateFile, "keyFile": p.KeyFile},
+			},
+		}
+		if p.ServerName != "" {
+			tls["serverName"] = p.ServerName
+		}
+		stream["security"] = "tls"
+		stream["tlsSettings"] = tls
+	}
+	return stream
+}
+
+// x25519 returns a random private key of X25519.
+func x25519() []byte {
+	key := randomBytes(curve25519.ScalarSize)
+	// https://cr.yp.to/ecdh.html
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+	return key
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	common.Must(err)
+	return b
+}
+
+func randomHex(n int) string {
+	return hex.EncodeToString(randomBytes(n))
+}
+
+func randomBase64(n int) string {
+	return base64.StdEncoding.EncodeToString(randomBytes(n))
+}
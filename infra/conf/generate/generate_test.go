@@ -0,0 +1,95 @@
+package generate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/protocol/tls/cert"
+	"github.com/HZ-PRE/XrarCore/core"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/generate"
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
+)
+
+func build(t *testing.T, config map[string]interface{}) *core.Config {
+	t.Helper()
+	b, err := json.Marshal(config)
+	common.Must(err)
+	c, err := serial.LoadJSONConfig(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("failed to build config: ", err, "\n", string(b))
+	}
+	return c
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	certPEM, keyPEM := cert.MustGenerate(nil, cert.DNSNames("example.com")).ToPEM()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	common.Must(os.WriteFile(certFile, certPEM, 0o600))
+	common.Must(os.WriteFile(keyFile, keyPEM, 0o600))
+
+	for _, tc := range []struct {
+		profile Profile
+		link    string
+	}{
+		{
+			profile: Profile{Address: "1.2.3.4"},
+			link:    "security=reality",
+		},
+		{
+			profile: Profile{Transport: "xhttp", Address: "1.2.3.4", Target: "example.com", Users: 3},
+			link:    "type=xhttp",
+		},
+		{
+			profile: Profile{Protocol: "vmess", Transport: "ws", Security: "tls", Address: "example.com", CertificateFile: certFile, KeyFile: keyFile},
+			link:    "vmess://",
+		},
+		{
+			profile: Profile{Protocol: "trojan", Transport: "grpc", Security: "tls", Address: "example.com", Port: 8443, CertificateFile: certFile, KeyFile: keyFile},
+			link:    "trojan://",
+		},
+		{
+			profile: Profile{Protocol: "ss", Address: "example.com", Port: 8388},
+			link:    "ss://",
+		},
+	} {
+		result, err := Generate(&tc.profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		build(t, result.Server)
+		build(t, result.Client)
+
+		users := tc.profile.Users
+		if users == 0 {
+			users = 1
+		}
+		if len(result.Links) != users {
+			t.Error("expected ", users, " links, but got ", result.Links)
+		}
+		if !strings.Contains(result.Links[0], tc.link) {
+			t.Error("expected ", tc.link, " in link ", result.Links[0])
+		}
+	}
+}
+
+func TestGenerateMismatch(t *testing.T) {
+	for _, p := range []Profile{
+		{},
+		{Address: "example.com", Protocol: "socks"},
+		{Address: "example.com", Transport: "ws", Security: "reality"},
+		{Address: "example.com", Security: "tls"},
+		{Address: "example.com", Protocol: "trojan", Security: "none"},
+		{Address: "example.com", Protocol: "shadowsocks", Security: "tls"},
+	} {
+		if _, err := Generate(&p); err == nil {
+			t.Error("expected error for profile ", p)
+		}
+	}
+}
@@ -11,8 +11,10 @@ import (
 )
 
 type HTTPAccount struct {
-	Username string `json:"user"`
-	Password string `json:"pass"`
+	Username string  `json:"user"`
+	Password string  `json:"pass"`
+	Email    string  `json:"email"`
+	Level    *uint32 `json:"level"`
 }
 
 func (v *HTTPAccount) Build() *http.Account {
@@ -34,11 +36,27 @@ func (c *HTTPServerConfig) Build() (proto.Message, error) {
 		UserLevel:        c.UserLevel,
 	}
 
-	if len(c.Accounts) > 0 {
-		config.Accounts = make(map[string]string)
-		for _, account := range c.Accounts {
+	for _, account := range c.Accounts {
+		// Accounts with an email or a level are full users, others stay plain accounts.
+		if account.Email == "" && account.Level == nil {
+			if config.Accounts == nil {
+				config.Accounts = make(map[string]string)
+			}
 			config.Accounts[account.Username] = account.Password
+			continue
+		}
+		user := &protocol.User{
+			Email:   account.Email,
+			Level:   c.UserLevel,
+			Account: serial.ToTypedMessage(account.Build()),
+		}
+		if user.Email == "" {
+			user.Email = account.Username
+		}
+		if account.Level != nil {
+			user.Level = *account.Level
 		}
+		config.Users = append(config.Users, user)
 	}
 
 	return config, nil
@@ -3,6 +3,8 @@ package conf_test
 import (
 	"testing"
 
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
 	. "github.com/HZ-PRE/XrarCore/infra/conf"
 	"github.com/HZ-PRE/XrarCore/proxy/http"
 )
@@ -33,5 +35,44 @@ func TestHTTPServerConfig(t *testing.T) {
 				UserLevel:        1,
 			},
 		},
+		{
+			Input: `{
+				"accounts": [
+					{
+						"user": "my-username",
+						"pass": "my-password",
+						"email": "love@example.com"
+					},
+					{
+						"user": "other-username",
+						"pass": "other-password",
+						"level": 2
+					}
+				],
+				"userLevel": 1
+			}`,
+			Parser: loadJSON(creator),
+			Output: &http.ServerConfig{
+				Users: []*protocol.User{
+					{
+						Email: "love@example.com",
+						Level: 1,
+						Account: serial.ToTypedMessage(&http.Account{
+							Username: "my-username",
+							Password: "my-password",
+						}),
+					},
+					{
+						Email: "other-username",
+						Level: 2,
+						Account: serial.ToTypedMessage(&http.Account{
+							Username: "other-username",
+							Password: "other-password",
+						}),
+					},
+				},
+				UserLevel: 1,
+			},
+		},
 	})
 }
@@ -2,4 +2,13 @@ package conf
 
 func init() {
 	RegisterConfigureFilePostProcessingStage("FakeDNS", &FakeDNSPostProcessingStage{})
+
+	RegisterLintCheck("duplicate-tag", LintCheckFunc(lintDuplicateTags))
+	RegisterLintCheck("duplicate-port", LintCheckFunc(lintDuplicatePorts))
+	RegisterLintCheck("unknown-tag", LintCheckFunc(lintUnknownTags))
+	RegisterLintCheck("shadowed-rule", LintCheckFunc(lintShadowedRules))
+	RegisterLintCheck("unmatched-selector", LintCheckFunc(lintUnmatchedSelectors))
+	RegisterLintCheck("unreachable-inbound", LintCheckFunc(lintUnreachableInbounds))
+	RegisterLintCheck("weak-setting", LintCheckFunc(lintWeakSettings))
+	RegisterLintCheck("deprecated-transport", LintCheckFunc(lintDeprecatedTransports))
 }
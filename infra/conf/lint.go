@@ -1,6 +1,10 @@
 package conf
 
-import "github.com/HZ-PRE/XrarCore/common/errors"
+import (
+	"sort"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+)
 
 type ConfigureFilePostProcessingStage interface {
 	Process(conf *Config) error
@@ -23,3 +27,68 @@ func PostProcessConfigureFile(conf *Config) error {
 	}
 	return nil
 }
+
+// LintSeverity is the severity of lint issues.
+type LintSeverity string
+
+const (
+	// LintError is for configs that don't work as intended, or don't work at all.
+	LintError LintSeverity = "error"
+	// LintWarning is for configs that work, but are likely mistakes or insecure.
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is an issue found by a lint check.
+type LintIssue struct {
+	// Check is the name of the check.
+	Check    string       `json:"check"`
+	Severity LintSeverity `json:"severity"`
+	// File and Line are the location of the issue, if they are known.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Path is the JSON path of the issue in the merged config, such as "routing.rules[2]".
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// LintCheck checks the config, and returns the issues found.
+type LintCheck interface {
+	Check(conf *Config) []*LintIssue
+}
+
+var lintChecks map[string]LintCheck
+
+// RegisterLintCheck registers a check, which is run by Lint with the name.
+func RegisterLintCheck(name string, check LintCheck) {
+	if lintChecks == nil {
+		lintChecks = make(map[string]LintCheck)
+	}
+	lintChecks[name] = check
+}
+
+// Lint runs all the checks on the config, and returns the issues found, ordered by the names of
+// the checks.
+func Lint(conf *Config) []*LintIssue {
+	names := make([]string, 0, len(lintChecks))
+	for name := range lintChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []*LintIssue
+	for _, name := range names {
+		for _, issue := range lintChecks[name].Check(conf) {
+			issue.Check = name
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// LintCheckFunc is a function as a LintCheck.
+type LintCheckFunc func(conf *Config) []*LintIssue
+
+// Check implements LintCheck.
+func (f LintCheckFunc) Check(conf *Config) []*LintIssue {
+	return f(conf)
+}
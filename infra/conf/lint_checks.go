@@ -0,0 +1,562 @@
+package conf
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func lintErrorf(path string, msg ...string) *LintIssue {
+	return &LintIssue{Severity: LintError, Path: path, Message: strings.Join(msg, "")}
+}
+
+func lintWarningf(path string, msg ...string) *LintIssue {
+	return &LintIssue{Severity: LintWarning, Path: path, Message: strings.Join(msg, "")}
+}
+
+func inboundPath(i int) string {
+	return "inbounds[" + strconv.Itoa(i) + "]"
+}
+
+func outboundPath(i int) string {
+	return "outbounds[" + strconv.Itoa(i) + "]"
+}
+
+func rulePath(i int) string {
+	return "routing.rules[" + strconv.Itoa(i) + "]"
+}
+
+func balancerPath(i int) string {
+	return "routing.balancers[" + strconv.Itoa(i) + "]"
+}
+
+// lintTags are the tags that can be referenced in the config.
+type lintTags struct {
+	inbounds  map[string]bool
+	outbounds map[string]bool
+	balancers map[string]bool
+	// prefixes are the tag prefixes of the outbounds of subscriptions, which are only known at runtime.
+	prefixes []string
+}
+
+func (c *Config) lintTags() *lintTags {
+	t := &lintTags{
+		inbounds:  make(map[string]bool),
+		outbounds: make(map[string]bool),
+		balancers: make(map[string]bool),
+	}
+	for _, in := range c.InboundConfigs {
+		t.inbounds[in.Tag] = true
+	}
+	for _, out := range c.OutboundConfigs {
+		t.outbounds[out.Tag] = true
+	}
+	if c.Reverse != nil {
+		// Traffic from bridges is routed with their tags, and portals are outbounds.
+		for _, bridge := range c.Reverse.Bridges {
+			t.inbounds[bridge.Tag] = true
+		}
+		for _, portal := range c.Reverse.Portals {
+			t.outbounds[portal.Tag] = true
+		}
+	}
+	if c.RouterConfig != nil {
+		for _, balancer := range c.RouterConfig.Balancers {
+			t.balancers[balancer.Tag] = true
+		}
+	}
+	for _, p := range c.Providers {
+		if p == nil {
+			continue
+		}
+		prefix := p.TagPrefix
+		if prefix == "" {
+			prefix = p.Tag + "-"
+		}
+		t.prefixes = append(t.prefixes, prefix)
+	}
+	return t
+}
+
+func (t *lintTags) hasOutbound(tag string) bool {
+	if t.outbounds[tag] {
+		return true
+	}
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintRules returns the field rules of the config, with nil for rules that are invalid or not field
+// rules, so that the indexes are kept.
+func (c *Config) lintRules() []*fieldRule {
+	if c.RouterConfig == nil {
+		return nil
+	}
+	rules := make([]*fieldRule, len(c.RouterConfig.RuleList))
+	for i, raw := range c.RouterConfig.RuleList {
+		rule := new(fieldRule)
+		if err := json.Unmarshal(raw, rule); err != nil {
+			continue
+		}
+		if rule.Type != "" && !strings.EqualFold(rule.Type, "field") {
+			continue
+		}
+		rules[i] = rule
+	}
+	return rules
+}
+
+func lintDuplicateTags(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	inbounds := make(map[string]int)
+	for i, in := range c.InboundConfigs {
+		if in.Tag == "" {
+			continue
+		}
+		if j, found := inbounds[in.Tag]; found {
+			issues = append(issues, lintErrorf(inboundPath(i), `tag "`, in.Tag, `" is also used by `, inboundPath(j)))
+			continue
+		}
+		inbounds[in.Tag] = i
+	}
+	outbounds := make(map[string]int)
+	for i, out := range c.OutboundConfigs {
+		if out.Tag == "" {
+			continue
+		}
+		if j, found := outbounds[out.Tag]; found {
+			issues = append(issues, lintErrorf(outboundPath(i), `tag "`, out.Tag, `" is also used by `, outboundPath(j)))
+			continue
+		}
+		outbounds[out.Tag] = i
+	}
+	if c.RouterConfig != nil {
+		balancers := make(map[string]int)
+		for i, balancer := range c.RouterConfig.Balancers {
+			if j, found := balancers[balancer.Tag]; found {
+				issues = append(issues, lintErrorf(balancerPath(i), `tag "`, balancer.Tag, `" is also used by `, balancerPath(j)))
+				continue
+			}
+			balancers[balancer.Tag] = i
+		}
+	}
+	return issues
+}
+
+// listenOnSocket returns whether the inbound listens on a Unix domain socket.
+func listenOnSocket(in *InboundDetourConfig) bool {
+	if in.ListenOn == nil || !in.ListenOn.Family().IsDomain() {
+		return false
+	}
+	domain := in.ListenOn.Domain()
+	return filepath.IsAbs(domain) || strings.HasPrefix(domain, "@")
+}
+
+// listenAddress returns the address the inbound listens on, or "" for all addresses.
+func listenAddress(in *InboundDetourConfig) string {
+	if in.ListenOn == nil || (in.ListenOn.Family().IsIP() && in.ListenOn.IP().IsUnspecified()) {
+		return ""
+	}
+	return in.ListenOn.String()
+}
+
+// inboundSettings returns the settings of the inbound, or nil if they're invalid.
+func inboundSettings(in *InboundDetourConfig) interface{} {
+	settings := []byte("{}")
+	if in.Settings != nil {
+		settings = *in.Settings
+	}
+	config, _ := inboundConfigLoader.LoadWithID(settings, in.Protocol)
+	return config
+}
+
+// inboundNetworks returns the networks the inbound listens on.
+func inboundNetworks(in *InboundDetourConfig) map[string]bool {
+	var list *NetworkList
+	switch s := inboundSettings(in).(type) {
+	case *DokodemoConfig:
+		list = s.NetworkList
+	case *ShadowsocksServerConfig:
+		list = s.NetworkList
+	}
+	networks := make(map[string]bool)
+	switch {
+	case list != nil:
+		for _, network := range list.Build() {
+			networks[strings.ToLower(network.String())] = true
+		}
+	case strings.EqualFold(in.Protocol, "wireguard"):
+		networks["udp"] = true
+	case in.StreamSetting != nil && in.StreamSetting.Network != nil &&
+		(strings.EqualFold(string(*in.StreamSetting.Network), "kcp") || strings.EqualFold(string(*in.StreamSetting.Network), "mkcp")):
+		networks["udp"] = true
+	default:
+		networks["tcp"] = true
+	}
+	return networks
+}
+
+func portsOverlap(a, b *PortList) (uint32, bool) {
+	for _, ra := range a.Range {
+		for _, rb := range b.Range {
+			if ra.From <= rb.To && rb.From <= ra.To {
+				return max(ra.From, rb.From), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func lintDuplicatePorts(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	for i := range c.InboundConfigs {
+		a := &c.InboundConfigs[i]
+		if a.PortList == nil || listenOnSocket(a) {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			b := &c.InboundConfigs[j]
+			if b.PortList == nil || listenOnSocket(b) {
+				continue
+			}
+			la, lb := listenAddress(a), listenAddress(b)
+			if la != "" && lb != "" && la != lb {
+				continue
+			}
+			port, overlap := portsOverlap(a.PortList, b.PortList)
+			if !overlap {
+				continue
+			}
+			nb := inboundNetworks(b)
+			for network := range inboundNetworks(a) {
+				if nb[network] {
+					issues = append(issues, lintErrorf(inboundPath(i), network, " port ", strconv.Itoa(int(port)), " is also used by ", inboundPath(j)))
+					break
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func lintUnknownTags(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	tags := c.lintTags()
+	for i, rule := range c.lintRules() {
+		if rule == nil {
+			continue
+		}
+		if rule.OutboundTag != "" && !tags.hasOutbound(rule.OutboundTag) {
+			issues = append(issues, lintErrorf(rulePath(i), `outbound "`, rule.OutboundTag, `" doesn't exist`))
+		}
+		if rule.BalancerTag != "" && !tags.balancers[rule.BalancerTag] {
+			issues = append(issues, lintErrorf(rulePath(i), `balancer "`, rule.BalancerTag, `" doesn't exist`))
+		}
+		if rule.InboundTag != nil {
+			for _, tag := range *rule.InboundTag {
+				if !tags.inbounds[tag] && (c.API == nil || c.API.Tag != tag) {
+					issues = append(issues, lintWarningf(rulePath(i), `inbound "`, tag, `" doesn't exist`))
+				}
+			}
+		}
+	}
+	if c.RouterConfig != nil {
+		for i, balancer := range c.RouterConfig.Balancers {
+			if balancer.FallbackTag != "" && !tags.hasOutbound(balancer.FallbackTag) {
+				issues = append(issues, lintErrorf(balancerPath(i), `fallback outbound "`, balancer.FallbackTag, `" doesn't exist`))
+			}
+		}
+	}
+	for i, out := range c.OutboundConfigs {
+		if out.ProxySettings != nil && out.ProxySettings.Tag != "" && !tags.hasOutbound(out.ProxySettings.Tag) {
+			issues = append(issues, lintErrorf(outboundPath(i), `proxy outbound "`, out.ProxySettings.Tag, `" doesn't exist`))
+		}
+		if s := out.StreamSetting; s != nil && s.SocketSettings != nil && s.SocketSettings.DialerProxy != "" && !tags.hasOutbound(s.SocketSettings.DialerProxy) {
+			issues = append(issues, lintErrorf(outboundPath(i), `dialer proxy outbound "`, s.SocketSettings.DialerProxy, `" doesn't exist`))
+		}
+	}
+	return issues
+}
+
+// ruleConditions returns the lists of the conditions of the rule. Traffic matches the rule if it
+// matches any value of each list.
+func ruleConditions(r *fieldRule) map[string][]string {
+	conditions := make(map[string][]string)
+	add := func(name string, list *StringList) {
+		if list != nil {
+			conditions[name] = append(conditions[name], *list...)
+		}
+	}
+	add("domain", r.Domain)
+	add("domain", r.Domains)
+	add("ip", r.IP)
+	add("source", r.SourceIP)
+	add("user", r.User)
+	add("inboundTag", r.InboundTag)
+	add("protocol", r.Protocols)
+	if r.Network != nil {
+		for _, network := range r.Network.Build() {
+			conditions["network"] = append(conditions["network"], network.String())
+		}
+	}
+	return conditions
+}
+
+// portsContain returns whether all the ports of b are in a.
+func portsContain(a, b *PortList) bool {
+	for _, rb := range b.Range {
+		contained := false
+		for _, ra := range a.Range {
+			if ra.From <= rb.From && rb.To <= ra.To {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleShadows returns whether all traffic matching b also matches a. Conditions are compared
+// literally, so that it's never reported wrongly, e.g. "geosite:google" doesn't contain "google.com".
+func ruleShadows(a, b *fieldRule) bool {
+	ca, cb := ruleConditions(a), ruleConditions(b)
+	if len(ca) == 0 && a.Port == nil && a.SourcePort == nil && len(a.Attributes) == 0 {
+		// Rules without conditions are invalid.
+		return false
+	}
+	for name, values := range ca {
+		valuesB, found := cb[name]
+		if !found {
+			return false
+		}
+		for _, v := range valuesB {
+			if !containsString(values, v) {
+				return false
+			}
+		}
+	}
+	for _, ports := range [][2]*PortList{{a.Port, b.Port}, {a.SourcePort, b.SourcePort}} {
+		if ports[0] != nil && (ports[1] == nil || !portsContain(ports[0], ports[1])) {
+			return false
+		}
+	}
+	// Traffic matches all the attributes.
+	for k, v := range a.Attributes {
+		if vb, found := b.Attributes[k]; !found || vb != v {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func lintShadowedRules(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	rules := c.lintRules()
+	for i, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if rules[j] != nil && ruleShadows(rules[j], rule) {
+				issues = append(issues, lintWarningf(rulePath(i), "rule is never matched, as it's shadowed by ", rulePath(j)))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+func lintUnmatchedSelectors(c *Config) []*LintIssue {
+	if c.RouterConfig == nil {
+		return nil
+	}
+	var issues []*LintIssue
+	tags := c.lintTags()
+	for i, balancer := range c.RouterConfig.Balancers {
+	selectors:
+		for _, selector := range balancer.Selectors {
+			for tag := range tags.outbounds {
+				if strings.HasPrefix(tag, selector) {
+					continue selectors
+				}
+			}
+			for _, prefix := range tags.prefixes {
+				if strings.HasPrefix(prefix, selector) || strings.HasPrefix(selector, prefix) {
+					continue selectors
+				}
+			}
+			issues = append(issues, lintErrorf(balancerPath(i), `selector "`, selector, `" matches no outbounds`))
+		}
+	}
+	return issues
+}
+
+func lintUnreachableInbounds(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	for i := range c.InboundConfigs {
+		in := &c.InboundConfigs[i]
+		path := inboundPath(i)
+		socket := listenOnSocket(in)
+		switch {
+		case in.PortList == nil && !socket:
+			issues = append(issues, lintErrorf(path, "no port to listen on"))
+		case in.PortList != nil && socket:
+			issues = append(issues, lintWarningf(path, "port is ignored when listening on a Unix domain socket"))
+		}
+
+		var clients int
+		switch s := inboundSettings(in).(type) {
+		case *VLessInboundConfig:
+			clients = len(s.Clients)
+		case *VMessInboundConfig:
+			clients = len(s.Users)
+		case *TrojanServerConfig:
+			clients = len(s.Clients)
+		default:
+			continue
+		}
+		if clients == 0 {
+			issues = append(issues, lintWarningf(path, "no clients, traffic is rejected until users are added with the API"))
+		}
+	}
+	return issues
+}
+
+// publicListen returns whether the inbound listens on addresses other than loopback.
+func publicListen(in *InboundDetourConfig) bool {
+	if in.ListenOn == nil {
+		return true
+	}
+	if in.ListenOn.Family().IsIP() {
+		return !in.ListenOn.IP().IsLoopback()
+	}
+	return !listenOnSocket(in) && in.ListenOn.Domain() != "localhost"
+}
+
+func weakCipher(method string) bool {
+	switch strings.ToLower(method) {
+	case "none", "plain":
+		return true
+	}
+	return false
+}
+
+func lintStreamSettings(path string, s *StreamConfig) []*LintIssue {
+	if s == nil || s.TLSSettings == nil || !s.TLSSettings.Insecure {
+		return nil
+	}
+	return []*LintIssue{lintWarningf(path+".streamSettings.tlsSettings", `"allowInsecure" disables the verification of certificates`)}
+}
+
+func lintWeakSettings(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	for i := range c.InboundConfigs {
+		in := &c.InboundConfigs[i]
+		path := inboundPath(i)
+		issues = append(issues, lintStreamSettings(path, in.StreamSetting)...)
+		switch s := inboundSettings(in).(type) {
+		case *SocksServerConfig:
+			if s.AuthMethod != AuthMethodUserPass && publicListen(in) {
+				issues = append(issues, lintWarningf(path, "open proxy without authentication on public addresses"))
+			}
+		case *HTTPServerConfig:
+			if len(s.Accounts) == 0 && publicListen(in) {
+				issues = append(issues, lintWarningf(path, "open proxy without authentication on public addresses"))
+			}
+		case *ShadowsocksServerConfig:
+			if weakCipher(s.Cipher) {
+				issues = append(issues, lintWarningf(path, `method "`, s.Cipher, `" doesn't encrypt traffic`))
+			}
+			for _, user := range s.Users {
+				if weakCipher(user.Cipher) {
+					issues = append(issues, lintWarningf(path, `method "`, user.Cipher, `" of user "`, user.Email, `" doesn't encrypt traffic`))
+				}
+			}
+		}
+	}
+
+	for i := range c.OutboundConfigs {
+		out := &c.OutboundConfigs[i]
+		path := outboundPath(i)
+		issues = append(issues, lintStreamSettings(path, out.StreamSetting)...)
+		if out.Settings == nil {
+			continue
+		}
+		settings, _ := outboundConfigLoader.LoadWithID(*out.Settings, out.Protocol)
+		switch s := settings.(type) {
+		case *VMessOutboundConfig:
+			for _, target := range s.Receivers {
+				for _, raw := range target.Users {
+					account := new(VMessAccount)
+					if err := json.Unmarshal(raw, account); err != nil {
+						continue
+					}
+					switch strings.ToLower(account.Security) {
+					case "none", "zero":
+						issues = append(issues, lintWarningf(path, `VMess security "`, account.Security, `" doesn't use AEAD encryption`))
+					}
+				}
+			}
+		case *ShadowsocksClientConfig:
+			for _, server := range s.Servers {
+				if weakCipher(server.Cipher) {
+					issues = append(issues, lintWarningf(path, `method "`, server.Cipher, `" doesn't encrypt traffic`))
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func lintTransport(path string, s *StreamConfig) []*LintIssue {
+	if s == nil {
+		return nil
+	}
+	var issues []*LintIssue
+	if s.Network != nil {
+		switch network := strings.ToLower(string(*s.Network)); network {
+		case "grpc", "ws", "websocket", "httpupgrade":
+			issues = append(issues, lintWarningf(path+".streamSettings", `transport "`, network, `" is deprecated, use "xhttp" instead`))
+		case "h2", "h3", "http", "quic":
+			issues = append(issues, lintErrorf(path+".streamSettings", `transport "`, network, `" is removed, use "xhttp" instead`))
+		}
+	}
+	if s.TCPSettings != nil {
+		issues = append(issues, lintWarningf(path+".streamSettings", `"tcpSettings" is deprecated, use "rawSettings" instead`))
+	}
+	if s.SplitHTTPSettings != nil {
+		issues = append(issues, lintWarningf(path+".streamSettings", `"splithttpSettings" is deprecated, use "xhttpSettings" instead`))
+	}
+	return issues
+}
+
+func lintDeprecatedTransports(c *Config) []*LintIssue {
+	var issues []*LintIssue
+	if len(c.Transport) > 0 {
+		issues = append(issues, lintErrorf("transport", "global transport config is removed, use streamSettings in inbounds and outbounds instead"))
+	}
+	for i := range c.InboundConfigs {
+		issues = append(issues, lintTransport(inboundPath(i), c.InboundConfigs[i].StreamSetting)...)
+	}
+	for i := range c.OutboundConfigs {
+		issues = append(issues, lintTransport(outboundPath(i), c.OutboundConfigs[i].StreamSetting)...)
+	}
+	return issues
+}
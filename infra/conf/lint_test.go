@@ -0,0 +1,70 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	. "github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+func TestLint(t *testing.T) {
+	var config Config
+	common.Must(json.Unmarshal([]byte(`{
+		"inbounds": [
+			{"tag": "socks", "port": 1080, "listen": "127.0.0.1", "protocol": "socks"},
+			{"tag": "socks", "port": "1000-2000", "protocol": "http"},
+			{"tag": "dns", "port": 1080, "protocol": "dokodemo-door", "settings": {"network": "udp"}},
+			{"tag": "unix", "port": 1234, "listen": "/run/xray.sock", "protocol": "vless", "settings": {"clients": []}},
+			{"tag": "noport", "protocol": "trojan", "settings": {"clients": [{"password": "p"}]}}
+		],
+		"outbounds": [
+			{"tag": "direct", "protocol": "freedom"},
+			{"tag": "ss", "protocol": "shadowsocks", "settings": {"servers": [{"address": "1.2.3.4", "port": 8388, "method": "none"}]},
+			 "streamSettings": {"network": "quic", "splithttpSettings": {}}, "proxySettings": {"tag": "gone"}}
+		],
+		"routing": {
+			"rules": [
+				{"domain": ["example.com", "example.org"], "port": "1-1000", "outboundTag": "direct"},
+				{"domain": ["example.com"], "port": 443, "network": "tcp", "outboundTag": "ss"},
+				{"domain": ["geosite:example"], "outboundTag": "direct"},
+				{"domain": ["example.com"], "outboundTag": "sub-node"},
+				{"inboundTag": ["api"], "balancerTag": "b"}
+			],
+			"balancers": [{"tag": "b", "selector": ["s", "sub-", "none"], "fallbackTag": "direct"}]
+		},
+		"api": {"tag": "api"},
+		"providers": [{"tag": "sub", "file": "sub.txt"}]
+	}`), &config))
+
+	type issue struct {
+		Check    string
+		Severity LintSeverity
+		Path     string
+	}
+	var issues []issue
+	for _, i := range Lint(&config) {
+		issues = append(issues, issue{i.Check, i.Severity, i.Path})
+	}
+	expected := []issue{
+		{"deprecated-transport", LintError, "outbounds[1].streamSettings"},
+		{"deprecated-transport", LintWarning, "outbounds[1].streamSettings"},
+		{"duplicate-port", LintError, "inbounds[1]"},
+		{"duplicate-tag", LintError, "inbounds[1]"},
+		{"shadowed-rule", LintWarning, "routing.rules[1]"},
+		{"unknown-tag", LintError, "outbounds[1]"},
+		{"unmatched-selector", LintError, "routing.balancers[0]"},
+		{"unreachable-inbound", LintWarning, "inbounds[3]"},
+		{"unreachable-inbound", LintWarning, "inbounds[3]"},
+		{"unreachable-inbound", LintError, "inbounds[4]"},
+		{"weak-setting", LintWarning, "inbounds[1]"},
+		{"weak-setting", LintWarning, "outbounds[1]"},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("unexpected issues: %v", issues)
+		for _, i := range Lint(&config) {
+			t.Log(i.Check, " ", i.Path, ": ", i.Message)
+		}
+	}
+}
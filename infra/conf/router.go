@@ -528,22 +528,24 @@ func ToCidrList(ips StringList) ([]*router.GeoIP, error) {
 	return geoipList, nil
 }
 
+// fieldRule is the JSON of field rules.
+type fieldRule struct {
+	RouterRule
+	Domain     *StringList       `json:"domain"`
+	Domains    *StringList       `json:"domains"`
+	IP         *StringList       `json:"ip"`
+	Port       *PortList         `json:"port"`
+	Network    *NetworkList      `json:"network"`
+	SourceIP   *StringList       `json:"source"`
+	SourcePort *PortList         `json:"sourcePort"`
+	User       *StringList       `json:"user"`
+	InboundTag *StringList       `json:"inboundTag"`
+	Protocols  *StringList       `json:"protocol"`
+	Attributes map[string]string `json:"attrs"`
+}
+
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
-	type RawFieldRule struct {
-		RouterRule
-		Domain     *StringList       `json:"domain"`
-		Domains    *StringList       `json:"domains"`
-		IP         *StringList       `json:"ip"`
-		Port       *PortList         `json:"port"`
-		Network    *NetworkList      `json:"network"`
-		SourceIP   *StringList       `json:"source"`
-		SourcePort *PortList         `json:"sourcePort"`
-		User       *StringList       `json:"user"`
-		InboundTag *StringList       `json:"inboundTag"`
-		Protocols  *StringList       `json:"protocol"`
-		Attributes map[string]string `json:"attrs"`
-	}
-	rawFieldRule := new(RawFieldRule)
+	rawFieldRule := new(fieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
 	if err != nil {
 		return nil, err
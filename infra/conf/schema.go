@@ -0,0 +1,334 @@
+package conf
+
+import (
+	"encoding/json"
+	"math"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf/cfgcommon/duration"
+	"github.com/sagernet/sing-shadowsocks/shadowaead_2022"
+)
+
+// SchemaURI is the URI of JSON Schema draft 2020-12.
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+type schema = map[string]interface{}
+
+// schemaEnum is the string values of a field, which are matched case-insensitively unless exact is set.
+type schemaEnum struct {
+	values []string
+	exact  bool
+}
+
+// schemaEnums are the string values of fields, keyed by the types and names of the fields.
+var schemaEnums = map[reflect.Type]map[string]*schemaEnum{
+	reflect.TypeOf(LogConfig{}): {
+		"LogLevel": {values: []string{"debug", "info", "warning", "error", "none"}},
+	},
+	reflect.TypeOf(RouterConfig{}): {
+		"DomainStrategy": {values: []string{"AsIs", "IPIfNonMatch", "IPOnDemand", "AlwaysIP"}},
+	},
+	reflect.TypeOf(SniffingConfig{}): {
+		"DestOverride": {values: []string{"http", "tls", "quic", "fakedns", "fakedns+others"}},
+	},
+	reflect.TypeOf(StreamConfig{}): {
+		"Security": {values: []string{"none", "tls", "reality"}},
+	},
+	reflect.TypeOf(SplitHTTPConfig{}): {
+		"Mode": {values: []string{"auto", "packet-up", "stream-up", "stream-one"}, exact: true},
+	},
+	reflect.TypeOf(SocksServerConfig{}): {
+		"AuthMethod": {values: []string{AuthMethodNoAuth, AuthMethodUserPass}, exact: true},
+	},
+	reflect.TypeOf(VMessAccount{}): {
+		"Security": {values: []string{"auto", "aes-128-gcm", "chacha20-poly1305", "none", "zero"}},
+	},
+	reflect.TypeOf(FreedomConfig{}): {
+		"DomainStrategy": {values: []string{
+			"AsIs", "UseIP", "UseIPv4", "UseIPv6", "UseIPv4v6", "UseIPv6v4",
+			"ForceIP", "ForceIPv4", "ForceIPv6", "ForceIPv4v6", "ForceIPv6v4",
+		}},
+	},
+	reflect.TypeOf(ShadowsocksServerConfig{}): {"Cipher": shadowsocksMethods},
+	reflect.TypeOf(ShadowsocksUserConfig{}):   {"Cipher": shadowsocksMethods},
+	reflect.TypeOf(ShadowsocksServerTarget{}): {"Cipher": shadowsocksMethods},
+}
+
+// shadowsocksMethods is generated from the methods accepted by the Shadowsocks configs, including aliases.
+var shadowsocksMethods = func() *schemaEnum {
+	e := new(schemaEnum)
+	for _, cipher := range shadowsocksCiphers {
+		e.values = append(e.values, cipher.name)
+	}
+	e.values = append(e.values, shadowaead_2022.List...)
+	return e
+}()
+
+func oneOrMore(item schema) schema {
+	return schema{"anyOf": []interface{}{item, schema{"type": "array", "items": item}}}
+}
+
+func portSchema() schema {
+	return schema{"type": "integer", "minimum": 0, "maximum": math.MaxUint16}
+}
+
+// caseInsensitivePattern returns the regular expression matching s case-insensitively.
+func caseInsensitivePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		upper, lower := unicode.ToUpper(r), unicode.ToLower(r)
+		if upper != lower {
+			b.WriteString("[" + string(upper) + string(lower) + "]")
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	return b.String()
+}
+
+func (e *schemaEnum) schema() schema {
+	if e.exact {
+		return schema{"type": "string", "enum": e.values}
+	}
+	patterns := make([]string, len(e.values))
+	for i, v := range e.values {
+		patterns[i] = caseInsensitivePattern(v)
+	}
+	// The enum is for completion, and the pattern is for validation.
+	return schema{
+		"type": "string",
+		"anyOf": []interface{}{
+			schema{"enum": e.values},
+			schema{"pattern": "^(?:" + strings.Join(patterns, "|") + ")$"},
+		},
+	}
+}
+
+// Schema returns the JSON Schema (draft 2020-12) of the JSON config.
+func Schema() map[string]interface{} {
+	g := &schemaGenerator{
+		defs:  make(map[string]interface{}),
+		names: make(map[reflect.Type]string),
+	}
+	root := g.ref(reflect.TypeOf(Config{}))
+	return schema{
+		"$schema": SchemaURI,
+		"title":   "Xray config",
+		"$ref":    root["$ref"],
+		"$defs":   g.defs,
+	}
+}
+
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+// custom returns the schema of t if it has custom UnmarshalJSON.
+func (g *schemaGenerator) custom(t reflect.Type) (schema, bool) {
+	switch t {
+	case reflect.TypeOf(json.RawMessage{}):
+		return schema{}, true
+	case reflect.TypeOf(StringList{}):
+		return oneOrMore(schema{"type": "string"}), true
+	case reflect.TypeOf(Address{}):
+		return schema{"type": "string"}, true
+	case reflect.TypeOf(NetworkList{}):
+		return oneOrMore(schema{"type": "string"}), true
+	case reflect.TypeOf(PortRange{}):
+		return schema{"anyOf": []interface{}{portSchema(), schema{"type": "string"}}}, true
+	case reflect.TypeOf(PortList{}):
+		return schema{"anyOf": []interface{}{portSchema(), schema{"type": "string"}}}, true
+	case reflect.TypeOf(Int32Range{}):
+		return schema{"anyOf": []interface{}{schema{"type": "integer"}, schema{"type": "string"}}}, true
+	case reflect.TypeOf(TransportProtocol("")):
+		return (&schemaEnum{values: []string{"raw", "tcp", "xhttp", "splithttp", "kcp", "mkcp", "grpc", "ws", "websocket", "httpupgrade"}}).schema(), true
+	case reflect.TypeOf(NameServerConfig{}):
+		return schema{"anyOf": []interface{}{schema{"type": "string"}, g.ref(reflect.TypeOf(NameServerConfig{}))}}, true
+	case reflect.TypeOf(HostAddress{}):
+		return oneOrMore(schema{"type": "string"}), true
+	case reflect.TypeOf(HostsWrapper{}):
+		return schema{"type": "object", "additionalProperties": oneOrMore(schema{"type": "string"})}, true
+	case reflect.TypeOf(FakeDNSConfig{}):
+		return oneOrMore(g.ref(reflect.TypeOf(FakeDNSPoolElementConfig{}))), true
+	case reflect.TypeOf(duration.Duration(0)):
+		return schema{"type": "string", "description": `Duration, such as "300ms" or "1h30m".`}, true
+	}
+	return nil, false
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, found := g.custom(t); found {
+		return s
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.ref(t)
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return schema{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return schema{"type": "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		bits := t.Bits()
+		return schema{"type": "integer", "minimum": -(int64(1) << (bits - 1)), "maximum": int64(1)<<(bits-1) - 1}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return schema{"type": "integer", "minimum": 0}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return schema{"type": "integer", "minimum": 0, "maximum": uint64(1)<<t.Bits() - 1}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.String:
+		return schema{"type": "string"}
+	default:
+		return schema{}
+	}
+}
+
+// name returns the name of struct t in $defs, which is qualified with the package out of conf.
+func (g *schemaGenerator) name(t reflect.Type) string {
+	if name, found := g.names[t]; found {
+		return name
+	}
+	name := t.Name()
+	if t.PkgPath() != reflect.TypeOf(Config{}).PkgPath() {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	g.names[t] = name
+	return name
+}
+
+func (g *schemaGenerator) ref(t reflect.Type) schema {
+	name := g.name(t)
+	if _, found := g.defs[name]; !found {
+		// The placeholder stops the recursion of recursive types.
+		g.defs[name] = nil
+		g.defs[name] = g.object(t)
+	}
+	return schema{"$ref": "#/$defs/" + name}
+}
+
+// object returns the schema of the JSON object of all the struct types at the same time.
+func (g *schemaGenerator) object(types ...reflect.Type) schema {
+	properties := make(schema)
+	var allOf []interface{}
+	for _, t := range types {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		for _, field := range jsonFields(t) {
+			if _, found := properties[field.name]; found {
+				continue
+			}
+			raw := rawFields[field.owner][field.Name]
+			if raw != nil && raw.sibling {
+				// The types depend on the sibling key.
+				properties[field.name] = schema{}
+				properties[raw.key] = raw.enum().schema()
+				allOf = append(allOf, g.siblingUnion(field.name, raw)...)
+				continue
+			}
+			properties[field.name] = g.field(field, raw)
+		}
+	}
+
+	s := schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(allOf) > 0 {
+		s["allOf"] = allOf
+	}
+	return s
+}
+
+func (g *schemaGenerator) field(field jsonField, raw *rawField) schema {
+	var s schema
+	switch {
+	case raw != nil:
+		s = g.raw(raw)
+		if field.Type.Kind() == reflect.Slice {
+			s = schema{"type": "array", "items": s}
+		}
+	case schemaEnums[field.owner][field.Name] != nil:
+		s = schemaEnums[field.owner][field.Name].schema()
+		if t := field.Type; t == reflect.TypeOf(StringList{}) || t == reflect.TypeOf(&StringList{}) {
+			s = oneOrMore(s)
+		}
+	default:
+		s = g.schemaOf(field.Type)
+	}
+	return s
+}
+
+// ids returns the IDs of configs of the loader.
+func (f *rawField) ids() []string {
+	ids := make([]string, 0, len(f.loader.cache))
+	for id := range f.loader.cache {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (f *rawField) enum() *schemaEnum {
+	return &schemaEnum{values: f.ids()}
+}
+
+// raw returns the schema of the raw field, which doesn't depend on the sibling keys.
+func (g *schemaGenerator) raw(f *rawField) schema {
+	if f.loader == nil {
+		if len(f.types) == 1 {
+			return g.schemaOf(f.types[0])
+		}
+		return g.object(f.types...)
+	}
+
+	var allOf []interface{}
+	for _, id := range f.ids() {
+		allOf = append(allOf, schema{
+			"if":   schema{"properties": schema{f.key: schema{"pattern": "^" + caseInsensitivePattern(id) + "$"}}},
+			"then": g.object(f.loaderTypes(id)...),
+		})
+	}
+	return schema{
+		"type":       "object",
+		"properties": schema{f.key: f.enum().schema()},
+		"required":   []string{f.key},
+		"allOf":      allOf,
+	}
+}
+
+// siblingUnion returns the conditions of the field, of which the types depend on the sibling key.
+func (g *schemaGenerator) siblingUnion(name string, f *rawField) []interface{} {
+	var allOf []interface{}
+	for _, id := range f.ids() {
+		allOf = append(allOf, schema{
+			"if": schema{
+				"properties": schema{f.key: schema{"pattern": "^" + caseInsensitivePattern(id) + "$"}},
+				"required":   []string{f.key},
+			},
+			"then": schema{"properties": schema{name: g.schemaOf(f.loaderTypes(id)[0])}},
+		})
+	}
+	return allOf
+}
@@ -0,0 +1,104 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	. "github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+func TestSchema(t *testing.T) {
+	b, err := json.Marshal(Schema())
+	common.Must(err)
+	var s map[string]interface{}
+	common.Must(json.Unmarshal(b, &s))
+
+	if s["$schema"] != SchemaURI {
+		t.Error("unexpected $schema: ", s["$schema"])
+	}
+	defs := s["$defs"].(map[string]interface{})
+
+	// All the refs must be defined.
+	for _, ref := range regexp.MustCompile(`"\$ref":"#/\$defs/([^"]+)"`).FindAllStringSubmatch(string(b), -1) {
+		if defs[ref[1]] == nil {
+			t.Error("undefined ref: ", ref[1])
+		}
+	}
+
+	inbound := defs["InboundDetourConfig"].(map[string]interface{})
+	if inbound["additionalProperties"] != false {
+		t.Error("additional properties of inbounds are allowed")
+	}
+	var vless bool
+	for _, c := range inbound["allOf"].([]interface{}) {
+		c, _ := json.Marshal(c)
+		if strings.Contains(string(c), `"^[Vv][Ll][Ee][Ss][Ss]$"`) && strings.Contains(string(c), `"#/$defs/VLessInboundConfig"`) {
+			vless = true
+		}
+	}
+	if !vless {
+		t.Error("no vless settings in inbounds")
+	}
+
+	protocol, _ := json.Marshal(inbound["properties"].(map[string]interface{})["protocol"])
+	if !strings.Contains(string(protocol), `"vless"`) || !strings.Contains(string(protocol), `"dokodemo-door"`) {
+		t.Error("unexpected protocols of inbounds: ", string(protocol))
+	}
+
+	network, _ := json.Marshal(defs["StreamConfig"].(map[string]interface{})["properties"].(map[string]interface{})["network"])
+	if !strings.Contains(string(network), `"xhttp"`) {
+		t.Error("unexpected networks: ", string(network))
+	}
+
+	header, _ := json.Marshal(defs["TCPConfig"].(map[string]interface{})["properties"].(map[string]interface{})["header"])
+	if !strings.Contains(string(header), `"#/$defs/AuthenticatorRequest"`) {
+		t.Error("no http header in tcp settings: ", string(header))
+	}
+}
+
+func TestSchemaShadowsocksMethods(t *testing.T) {
+	b, err := json.Marshal(Schema())
+	common.Must(err)
+	var s map[string]interface{}
+	common.Must(json.Unmarshal(b, &s))
+	defs := s["$defs"].(map[string]interface{})
+
+	for _, def := range []string{"ShadowsocksServerConfig", "ShadowsocksUserConfig", "ShadowsocksServerTarget"} {
+		method := defs[def].(map[string]interface{})["properties"].(map[string]interface{})["method"].(map[string]interface{})
+		anyOf := method["anyOf"].([]interface{})
+		enum := anyOf[0].(map[string]interface{})["enum"].([]interface{})
+		pattern := regexp.MustCompile(anyOf[1].(map[string]interface{})["pattern"].(string))
+		for _, name := range []string{
+			"aes-128-gcm", "aead_aes_128_gcm", "AEAD_AES_256_GCM",
+			"chacha20-ietf-poly1305", "xchacha20-ietf-poly1305", "aead_xchacha20_poly1305",
+			"plain", "none", "2022-blake3-aes-128-gcm",
+		} {
+			if !pattern.MatchString(name) {
+				t.Error(def, ": method ", name, " is rejected")
+			}
+			// Every method in the schema is accepted by the config.
+			config := &ShadowsocksServerConfig{Cipher: name, Password: "password"}
+			if strings.HasPrefix(name, "2022-") {
+				config.Password = "AAAAAAAAAAAAAAAAAAAAAA=="
+			}
+			if _, err := config.Build(); err != nil {
+				t.Error("method ", name, " isn't accepted: ", err)
+			}
+		}
+		for _, name := range enum {
+			config := &ShadowsocksServerConfig{Cipher: name.(string), Password: "password"}
+			if strings.HasPrefix(config.Cipher, "2022-") {
+				continue
+			}
+			if _, err := config.Build(); err != nil {
+				t.Error("method ", name, " in the schema isn't accepted: ", err)
+			}
+		}
+		if pattern.MatchString("rc4-md5") {
+			t.Error(def, ": unsupported method is accepted")
+		}
+	}
+}
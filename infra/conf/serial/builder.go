@@ -1,9 +1,11 @@
 package serial
 
 import (
+	"bytes"
 	"context"
 	"io"
 
+	"github.com/HZ-PRE/XrarCore/app/subscription"
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	creflect "github.com/HZ-PRE/XrarCore/common/reflect"
 	"github.com/HZ-PRE/XrarCore/core"
@@ -12,28 +14,59 @@ import (
 )
 
 func MergeConfigFromFiles(files []*core.ConfigSource) (string, error) {
-	c, err := mergeConfigs(files)
+	interpolator := &Interpolator{Strict: Strict}
+	c, err := mergeConfigs(files, interpolator)
 	if err != nil {
 		return "", err
 	}
 
 	if j, ok := creflect.MarshalToJson(c, true); ok {
-		return j, nil
+		return interpolator.Mask(j), nil
 	}
 	return "", errors.New("marshal to json failed.").AtError()
 }
 
-func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
+func mergeConfigs(files []*core.ConfigSource, interpolator *Interpolator) (*conf.Config, error) {
+	cf, unknownFields, err := decodeConfigs(files, interpolator, Strict)
+	if err != nil {
+		return nil, err
+	}
+	if len(unknownFields) > 0 {
+		return nil, unknownFieldsError(unknownFields)
+	}
+	return cf, nil
+}
+
+// decodeConfigs interpolates, decodes and merges the config files, and finds the unknown fields in
+// them if findUnknownFields is set.
+func decodeConfigs(files []*core.ConfigSource, interpolator *Interpolator, findUnknownFields bool) (*conf.Config, []*UnknownField, error) {
 	cf := &conf.Config{}
+	var unknownFields []*UnknownField
 	for i, file := range files {
 		errors.LogInfo(context.Background(), "Reading config: ", file)
 		r, err := confloader.LoadConfig(file.Name)
 		if err != nil {
-			return nil, errors.New("failed to read config: ", file).Base(err)
+			return nil, nil, errors.New("failed to read config: ", file).Base(err)
 		}
-		c, err := ReaderDecoderByFormat[file.Format](r)
+		content, err := io.ReadAll(r)
 		if err != nil {
-			return nil, errors.New("failed to decode config: ", file).Base(err)
+			return nil, nil, errors.New("failed to read config: ", file).Base(err)
+		}
+		if !isRemoteConfig(file.Name) {
+			if content, err = interpolator.Interpolate(file.Name, file.Format, content); err != nil {
+				return nil, nil, errors.New("failed to interpolate config: ", file).Base(err)
+			}
+		}
+		if findUnknownFields {
+			fields, err := FindUnknownFields(file.Name, file.Format, content)
+			if err != nil {
+				return nil, nil, errors.New("failed to decode config: ", file).Base(err)
+			}
+			unknownFields = append(unknownFields, fields...)
+		}
+		c, err := ReaderDecoderByFormat[file.Format](bytes.NewReader(content))
+		if err != nil {
+			return nil, nil, errors.New("failed to decode config: ", file).Base(err)
 		}
 		if i == 0 {
 			*cf = *c
@@ -41,11 +74,11 @@ func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
 		}
 		cf.Override(c, file.Name)
 	}
-	return cf, nil
+	return cf, unknownFields, nil
 }
 
 func BuildConfig(files []*core.ConfigSource) (*core.Config, error) {
-	config, err := mergeConfigs(files)
+	config, err := mergeConfigs(files, &Interpolator{Strict: Strict})
 	if err != nil {
 		return nil, err
 	}
@@ -63,4 +96,6 @@ func init() {
 
 	core.ConfigBuilderForFiles = BuildConfig
 	core.ConfigMergedFormFiles = MergeConfigFromFiles
+
+	subscription.ParseOutbounds = DecodeSubscription
 }
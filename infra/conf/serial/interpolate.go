@@ -0,0 +1,322 @@
+package serial
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/ghodss/yaml"
+	"github.com/pelletier/go-toml"
+)
+
+// Mask replaces the interpolated values in dumped configs.
+const Mask = "******"
+
+var (
+	// interpolationPattern matches "${env:NAME}" and "${file:/path}", with optional defaults
+	// after ":-". "$${" is an escaped "${".
+	interpolationPattern = regexp.MustCompile(`\$?\$\{(env|file):([^}]*)\}`)
+	envNamePattern       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	jsonStringPattern    = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(\s*:)?`)
+	// yamlPlainPattern matches the values that can be unquoted in YAML as they are.
+	yamlPlainPattern = regexp.MustCompile(`^[A-Za-z0-9_./+=~-]*$`)
+	// yamlValueStartPattern and yamlValueEndPattern match the texts before and after a whole
+	// unquoted value in a line of YAML.
+	yamlValueStartPattern = regexp.MustCompile(`(^|[ \t]-|:)[ \t]*$`)
+	yamlValueEndPattern   = regexp.MustCompile(`^[ \t]*(#.*)?\r?$`)
+)
+
+// Interpolator replaces the references to environment variables and files in config files, so
+// that secrets can be kept out of them:
+//
+//	${env:NAME}            the environment variable NAME
+//	${env:NAME:-default}   the environment variable NAME, or default if it's unset or empty
+//	${file:/path}          the content of the file, without trailing newlines
+//	${file:/path:-default} the content of the file, or default if it can't be read
+//	$${env:NAME}           the literal "${env:NAME}"
+//
+// The values are escaped as JSON strings in JSON and TOML config files. In YAML config files, they
+// are escaped in quoted strings, and quoted if they are whole unquoted values other than plain
+// words. References in YAML comments are left as they are.
+type Interpolator struct {
+	// Strict fails on the references that can't be resolved and have no defaults, which are
+	// replaced with empty strings otherwise.
+	Strict bool
+
+	// interpolated are the decoded strings that contain interpolated values.
+	interpolated map[string]bool
+	unresolved   []*UnresolvedReference
+}
+
+// UnresolvedReference is a reference that can't be resolved and has no default.
+type UnresolvedReference struct {
+	File      string
+	Reference string
+}
+
+// Contexts of references in the lines of config files.
+const (
+	contextPlain = iota
+	contextDoubleQuoted
+	contextSingleQuoted
+	contextComment
+)
+
+// Interpolate replaces the references in the content of the config file.
+func (i *Interpolator) Interpolate(file, format string, content []byte) ([]byte, error) {
+	var result []byte
+	var spans [][2]int
+	last := 0
+	for _, loc := range interpolationPattern.FindAllSubmatchIndex(content, -1) {
+		start, end := loc[0], loc[1]
+		result = append(result, content[last:start]...)
+		last = end
+		if content[start+1] == '$' {
+			result = append(result, content[start+1:end]...)
+			continue
+		}
+		lineStart, lineEnd := lineBounds(content, start)
+		context, _ := lineContext(format, content[lineStart:start])
+		if context == contextComment {
+			result = append(result, content[start:end]...)
+			continue
+		}
+		value, err := i.resolve(file, string(content[loc[2]:loc[3]]), string(content[loc[4]:loc[5]]))
+		if err != nil {
+			return nil, err
+		}
+		value, err = quote(format, context, value, content[lineStart:start], content[end:lineEnd])
+		if err != nil {
+			return nil, errors.New("failed to interpolate ", string(content[start:end]), " in ", file).Base(err)
+		}
+		spans = append(spans, [2]int{len(result), len(result) + len(value)})
+		result = append(result, value...)
+	}
+	result = append(result, content[last:]...)
+
+	for _, span := range spans {
+		if s, ok := enclosingString(format, result, span); ok && s != "" {
+			if i.interpolated == nil {
+				i.interpolated = make(map[string]bool)
+			}
+			i.interpolated[s] = true
+		}
+	}
+	return result, nil
+}
+
+func (i *Interpolator) resolve(file, kind, ref string) (string, error) {
+	ref, def, hasDefault := strings.Cut(ref, ":-")
+	var value string
+	var found bool
+	switch kind {
+	case "env":
+		if !envNamePattern.MatchString(ref) {
+			return "", errors.New("invalid environment variable name in ", file, ": ", ref)
+		}
+		value = os.Getenv(ref)
+		found = value != ""
+	case "file":
+		content, err := os.ReadFile(ref)
+		if err != nil && !hasDefault && i.Strict {
+			return "", errors.New("failed to read ${file:", ref, "} in ", file).Base(err)
+		}
+		value = strings.TrimRight(string(content), "\r\n")
+		found = err == nil
+	}
+	switch {
+	case found:
+		return value, nil
+	case hasDefault:
+		return def, nil
+	case i.Strict:
+		return "", errors.New("${", kind, ":", ref, "} in ", file, " is not set")
+	}
+	errors.LogWarning(context.Background(), "${", kind, ":", ref, "} in ", file, " is not set, which is replaced with an empty string")
+	i.unresolved = append(i.unresolved, &UnresolvedReference{File: file, Reference: "${" + kind + ":" + ref + "}"})
+	return "", nil
+}
+
+// Unresolved returns the references that can't be resolved and have no defaults.
+func (i *Interpolator) Unresolved() []*UnresolvedReference {
+	return i.unresolved
+}
+
+// Mask replaces the strings containing interpolated values in the JSON config. Only whole strings
+// are masked, so that other strings that happen to contain the values are left as they are.
+func (i *Interpolator) Mask(config string) string {
+	if len(i.interpolated) == 0 {
+		return config
+	}
+	return jsonStringPattern.ReplaceAllStringFunc(config, func(s string) string {
+		if strings.HasSuffix(s, ":") {
+			// Keys are not masked.
+			return s
+		}
+		var value string
+		if err := json.Unmarshal([]byte(s), &value); err != nil || !i.interpolated[value] {
+			return s
+		}
+		return `"` + Mask + `"`
+	})
+}
+
+// quote escapes the value for its context, given the texts before and after it in its line.
+func quote(format string, context int, value string, before, after []byte) (string, error) {
+	switch {
+	case format == "toml" && context == contextSingleQuoted:
+		// Literal strings of TOML have no escapes.
+		if strings.ContainsAny(value, "'\r\n") {
+			return "", errors.New("the value can't be in a literal string of TOML")
+		}
+		return value, nil
+	case format != "yaml", context == contextDoubleQuoted:
+		// Double-quoted strings of YAML accept the escapes of JSON.
+		return escapeString(value), nil
+	case context == contextSingleQuoted:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", errors.New("the value of multiple lines can't be in a single-quoted string of YAML")
+		}
+		return strings.ReplaceAll(value, "'", "''"), nil
+	case yamlPlainPattern.MatchString(value):
+		return value, nil
+	case yamlValueStartPattern.Match(before) && yamlValueEndPattern.Match(after):
+		return `"` + escapeString(value) + `"`, nil
+	}
+	return "", errors.New("the value can't be a part of an unquoted string of YAML, which should be quoted")
+}
+
+// lineBounds returns the start and the end of the line at pos.
+func lineBounds(content []byte, pos int) (int, int) {
+	start := bytes.LastIndexByte(content[:pos], '\n') + 1
+	end := bytes.IndexByte(content[pos:], '\n')
+	if end < 0 {
+		return start, len(content)
+	}
+	return start, pos + end
+}
+
+// lineContext returns the context at the end of prefix, which starts at a line, and the index of
+// the opening quote if it's in a string. Strings across lines are not recognized.
+func lineContext(format string, prefix []byte) (int, int) {
+	context, quoteStart := contextPlain, -1
+	for j := 0; j < len(prefix); j++ {
+		c := prefix[j]
+		switch context {
+		case contextDoubleQuoted:
+			if c == '\\' {
+				j++
+			} else if c == '"' {
+				context = contextPlain
+			}
+		case contextSingleQuoted:
+			// '' in YAML is an escaped ', which is taken as the end and the start of strings here.
+			if c == '\'' {
+				context = contextPlain
+			}
+		default:
+			// Quotes always start strings in JSON, but only at the starts of values in YAML and TOML.
+			valueStart := format == "json" || j == 0 || strings.IndexByte(" \t[{,", prefix[j-1]) >= 0
+			switch {
+			case c == '"' && valueStart:
+				context, quoteStart = contextDoubleQuoted, j
+			case c == '\'' && valueStart && format != "json":
+				context, quoteStart = contextSingleQuoted, j
+			case c == '#' && format != "json" && (j == 0 || prefix[j-1] == ' ' || prefix[j-1] == '\t'):
+				return contextComment, -1
+			}
+		}
+	}
+	return context, quoteStart
+}
+
+// enclosingString returns the decoded string containing the span of content, if it's in a string.
+func enclosingString(format string, content []byte, span [2]int) (string, bool) {
+	lineStart, lineEnd := lineBounds(content, span[0])
+	line := content[lineStart:lineEnd]
+	context, quoteStart := lineContext(format, content[lineStart:span[0]])
+	var literal []byte
+	switch {
+	case context == contextDoubleQuoted, context == contextSingleQuoted:
+		quoteEnd := closingQuote(format, line, span[1]-lineStart, line[quoteStart])
+		if quoteEnd < 0 {
+			return "", false
+		}
+		literal = line[quoteStart : quoteEnd+1]
+	case context == contextPlain && format == "yaml":
+		// The unquoted value, which starts after the key or the dash, and ends before the comment.
+		start, end := 0, len(line)
+		prefix := line[:span[0]-lineStart]
+		for _, indicator := range []string{": ", ":\t", "- ", "-\t"} {
+			if i := bytes.LastIndex(prefix, []byte(indicator)); i >= 0 && i+2 > start {
+				start = i + 2
+			}
+		}
+		for _, comment := range []string{" #", "\t#"} {
+			if i := bytes.Index(line[span[1]-lineStart:], []byte(comment)); i >= 0 && span[1]-lineStart+i < end {
+				end = span[1] - lineStart + i
+			}
+		}
+		literal = bytes.TrimSpace(line[start:end])
+	default:
+		// Unquoted values of JSON and TOML are not strings.
+		return "", false
+	}
+	return decodeString(format, literal)
+}
+
+// closingQuote returns the index of the quote closing a string in line, searching from pos.
+func closingQuote(format string, line []byte, pos int, quote byte) int {
+	for j := pos; j < len(line); j++ {
+		switch {
+		case quote == '"' && line[j] == '\\':
+			j++
+		case line[j] != quote:
+		case quote == '\'' && format == "yaml" && j+1 < len(line) && line[j+1] == '\'':
+			j++
+		default:
+			return j
+		}
+	}
+	return -1
+}
+
+// decodeString decodes a string literal of the format.
+func decodeString(format string, literal []byte) (string, bool) {
+	var value interface{}
+	switch format {
+	case "json":
+		if json.Unmarshal(literal, &value) != nil {
+			return "", false
+		}
+	case "yaml", "toml":
+		var m map[string]interface{}
+		var err error
+		if format == "yaml" {
+			err = yaml.Unmarshal(append([]byte("v: "), literal...), &m)
+		} else {
+			err = toml.Unmarshal(append([]byte("v = "), literal...), &m)
+		}
+		if err != nil {
+			return "", false
+		}
+		value = m["v"]
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+func escapeString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+
+// isRemoteConfig returns whether the config file is fetched from remote, in which the references
+// are not interpolated, so that local secrets are not leaked to it.
+func isRemoteConfig(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
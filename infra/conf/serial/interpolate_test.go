@@ -0,0 +1,117 @@
+package serial_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/core"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/serial"
+	"github.com/ghodss/yaml"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	common.Must(os.WriteFile(secret, []byte("p\"ass\n"), 0o600))
+	t.Setenv("XRAY_TEST_ID", "27848739-7e62-4138-9fd3-098a63964b6b")
+
+	interpolator := &Interpolator{}
+	content := `{"id": "${env:XRAY_TEST_ID}", "password": "${file:` + secret + `}",
+"level": ${env:XRAY_TEST_UNSET:-1}, "email": "${env:XRAY_TEST_UNSET}", "literal": "$${env:XRAY_TEST_ID}"}`
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "json",
+			expected: `{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "password": "p\"ass",
+"level": 1, "email": "", "literal": "${env:XRAY_TEST_ID}"}`,
+		},
+		{
+			format: "yaml",
+			expected: `{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "password": "p\"ass",
+"level": 1, "email": "", "literal": "${env:XRAY_TEST_ID}"}`,
+		},
+	} {
+		b, err := interpolator.Interpolate("config."+tc.format, tc.format, []byte(content))
+		common.Must(err)
+		if string(b) != tc.expected {
+			t.Errorf("unexpected interpolation of %s: %s", tc.format, b)
+		}
+	}
+	if refs := interpolator.Unresolved(); len(refs) != 2 || refs[0].Reference != "${env:XRAY_TEST_UNSET}" {
+		t.Error("unexpected unresolved references: ", refs)
+	}
+
+	strict := &Interpolator{Strict: true}
+	if _, err := strict.Interpolate("config.json", "json", []byte(content)); err == nil {
+		t.Error("expected error of unresolved reference")
+	}
+	if _, err := strict.Interpolate("config.json", "json", []byte(`"${file:`+filepath.Join(dir, "none")+`}"`)); err == nil {
+		t.Error("expected error of unreadable file")
+	}
+	if _, err := strict.Interpolate("config.json", "json", []byte(`"${env:1NAME}"`)); err == nil {
+		t.Error("expected error of invalid name")
+	}
+}
+
+func TestInterpolateYAML(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	common.Must(os.WriteFile(secret, []byte("line1\nline2: x 'y'\n"), 0o600))
+
+	interpolator := &Interpolator{}
+	b, err := interpolator.Interpolate("config.yaml", "yaml", []byte(`tag: ${file:`+secret+`} # ${file:`+secret+`}
+list:
+  - ${file:`+secret+`}
+quoted: "${file:`+secret+`}"
+`))
+	common.Must(err)
+	var m map[string]interface{}
+	common.Must(yaml.Unmarshal(b, &m))
+	expected := "line1\nline2: x 'y'"
+	if m["tag"] != expected || m["quoted"] != expected || !reflect.DeepEqual(m["list"], []interface{}{expected}) {
+		t.Error("unexpected interpolation: ", string(b))
+	}
+
+	for _, content := range []string{
+		`path: /ws/${file:` + secret + `}`,
+		`tag: '${file:` + secret + `}'`,
+	} {
+		if _, err := interpolator.Interpolate("config.yaml", "yaml", []byte(content)); err == nil {
+			t.Error("expected error of multi-line value in ", content)
+		}
+	}
+}
+
+func TestMergeConfigMask(t *testing.T) {
+	t.Setenv("XRAY_TEST_ID", "27848739-7e62-4138-9fd3-098a63964b6b")
+	t.Setenv("XRAY_TEST_TAG", "direct")
+	t.Setenv("XRAY_TEST_PATH", "secret")
+	t.Setenv("XRAY_TEST_LEVEL", "0")
+	t.Setenv("XRAY_TEST_EMAIL", "n")
+	file := filepath.Join(t.TempDir(), "config.json")
+	common.Must(os.WriteFile(file, []byte(`{
+  "log": {"loglevel": "warning"},
+  "outbounds": [{"tag": "${env:XRAY_TEST_TAG}", "protocol": "freedom"},
+    {"tag": "ws", "protocol": "freedom", "streamSettings": {"network": "ws", "wsSettings": {"path": "/${env:XRAY_TEST_PATH}"}}}],
+  "inbounds": [{"tag": "in", "port": 1080, "protocol": "vless",
+    "settings": {"clients": [{"id": "${env:XRAY_TEST_ID}", "level": ${env:XRAY_TEST_LEVEL}, "email": "${env:XRAY_TEST_EMAIL}"}], "decryption": "none"}}]
+}`), 0o600))
+
+	config, err := MergeConfigFromFiles([]*core.ConfigSource{{Name: file, Format: "json"}})
+	common.Must(err)
+	for _, s := range []string{"27848739", `"direct"`, "secret"} {
+		if strings.Contains(config, s) {
+			t.Error("unmasked value ", s, " in ", config)
+		}
+	}
+	for _, s := range []string{`"tag": "` + Mask + `"`, `"path": "` + Mask + `"`, `"loglevel": "warning"`} {
+		if !strings.Contains(config, s) {
+			t.Error("no ", s, " in ", config)
+		}
+	}
+}
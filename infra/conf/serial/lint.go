@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+// LintConfig lints the config files, and returns the issues found, including unknown fields,
+// unresolved references and errors of building the merged config.
+func LintConfig(files []*core.ConfigSource) ([]*conf.LintIssue, error) {
+	interpolator := &Interpolator{}
+	c, unknownFields, err := decodeConfigs(files, interpolator, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*conf.LintIssue
+	for _, ref := range interpolator.Unresolved() {
+		issues = append(issues, &conf.LintIssue{
+			Check:    "unresolved-reference",
+			Severity: conf.LintWarning,
+			File:     ref.File,
+			Message:  ref.Reference + " is not set, which is replaced with an empty string",
+		})
+	}
+	for _, f := range unknownFields {
+		issues = append(issues, &conf.LintIssue{
+			Check:    "unknown-field",
+			Severity: conf.LintError,
+			File:     f.File,
+			Line:     f.Line,
+			Path:     f.Path,
+			Message:  "unknown field, which is ignored",
+		})
+	}
+	issues = append(issues, conf.Lint(c)...)
+	if _, err := c.Build(); err != nil {
+		issues = append(issues, &conf.LintIssue{
+			Check:    "build",
+			Severity: conf.LintError,
+			Message:  err.Error(),
+		})
+	}
+	return issues, nil
+}
@@ -0,0 +1,99 @@
+package serial
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	json_reader "github.com/HZ-PRE/XrarCore/infra/conf/json"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+)
+
+// DecodeSubscription decodes the content of a subscription into outbounds. The content is either
+// share links, one per line and optionally encoded in base64, or outbounds in JSON, as an array or as
+// the "outbounds" of a config. Share links that are not supported are skipped.
+func DecodeSubscription(content []byte) ([]*core.OutboundHandlerConfig, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) > 0 && (content[0] == '[' || content[0] == '{') {
+		return decodeJSONSubscription(content)
+	}
+
+	if !bytes.Contains(content, []byte("://")) {
+		b, err := base64.StdEncoding.DecodeString(string(content))
+		if err != nil {
+			b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(string(content), "="))
+		}
+		if err != nil {
+			return nil, errors.New("invalid subscription").Base(err)
+		}
+		content = b
+	}
+
+	var outbounds []*core.OutboundHandlerConfig
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		outbound, err := buildLink(line)
+		if err != nil {
+			errors.LogWarningInner(context.Background(), err, "skipped share link in subscription")
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("failed to read subscription").Base(err)
+	}
+	return outbounds, nil
+}
+
+func buildLink(s string) (*core.OutboundHandlerConfig, error) {
+	link, err := sharelink.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	b, err := link.OutboundJSON()
+	if err != nil {
+		return nil, err
+	}
+	outbound := new(conf.OutboundDetourConfig)
+	if err := json.Unmarshal(b, outbound); err != nil {
+		return nil, err
+	}
+	return outbound.Build()
+}
+
+func decodeJSONSubscription(content []byte) ([]*core.OutboundHandlerConfig, error) {
+	var configs []conf.OutboundDetourConfig
+	decoder := json.NewDecoder(&json_reader.Reader{Reader: bytes.NewReader(content)})
+	if content[0] == '[' {
+		if err := decoder.Decode(&configs); err != nil {
+			return nil, errors.New("invalid outbounds of subscription").Base(err)
+		}
+	} else {
+		var config conf.Config
+		if err := decoder.Decode(&config); err != nil {
+			return nil, errors.New("invalid config of subscription").Base(err)
+		}
+		configs = config.OutboundConfigs
+	}
+
+	outbounds := make([]*core.OutboundHandlerConfig, 0, len(configs))
+	for i := range configs {
+		outbound, err := configs[i].Build()
+		if err != nil {
+			return nil, errors.New("failed to build outbound ", configs[i].Tag, " of subscription").Base(err)
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	return outbounds, nil
+}
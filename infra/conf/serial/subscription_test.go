@@ -0,0 +1,51 @@
+package serial_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
+)
+
+func TestDecodeSubscription(t *testing.T) {
+	links := "vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?security=tls&sni=example.com&type=ws&path=%2Fws#node-1\n" +
+		"# comment\n" +
+		"hysteria2://password@example.com:443#unsupported\n" +
+		"trojan://password@example.com:443?security=tls#node-2\n"
+
+	for _, tc := range []struct {
+		content string
+		tags    []string
+	}{
+		{links, []string{"node-1", "node-2"}},
+		{base64.StdEncoding.EncodeToString([]byte(links)), []string{"node-1", "node-2"}},
+		{base64.RawStdEncoding.EncodeToString([]byte(links)), []string{"node-1", "node-2"}},
+		{`[{"tag": "a", "protocol": "freedom"}, {"protocol": "blackhole"}]`, []string{"a", ""}},
+		{`{
+			// comment
+			"outbounds": [{"tag": "b", "protocol": "freedom"}]
+		}`, []string{"b"}},
+	} {
+		outbounds, err := serial.DecodeSubscription([]byte(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outbounds) != len(tc.tags) {
+			t.Fatal("unexpected number of outbounds: ", len(outbounds), ", expected ", len(tc.tags))
+		}
+		for i, outbound := range outbounds {
+			if outbound.Tag != tc.tags[i] {
+				t.Error("unexpected tag: ", outbound.Tag, ", expected ", tc.tags[i])
+			}
+		}
+	}
+
+	for _, content := range []string{
+		"not base64!",
+		`[{"protocol": "unknown"}]`,
+	} {
+		if _, err := serial.DecodeSubscription([]byte(content)); err == nil {
+			t.Error("expected error of ", content)
+		}
+	}
+}
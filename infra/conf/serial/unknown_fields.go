@@ -0,0 +1,232 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	json_reader "github.com/HZ-PRE/XrarCore/infra/conf/json"
+	"github.com/ghodss/yaml"
+	"github.com/pelletier/go-toml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Strict makes the config loader fail on unknown fields in config files, which are ignored
+// otherwise.
+var Strict bool
+
+// UnknownField is a key in a config file, which is unknown and ignored by the decoder.
+type UnknownField struct {
+	File string
+	// Line is 0 if it's unknown.
+	Line int
+	// Path is the JSON path of the key, such as "inbounds[0].streamSetings".
+	Path string
+}
+
+func (f *UnknownField) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		b.WriteString(":")
+	}
+	if f.Line > 0 {
+		b.WriteString(strconv.Itoa(f.Line))
+		b.WriteString(":")
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString("unknown field ")
+	b.WriteString(f.Path)
+	return b.String()
+}
+
+// FindUnknownFields returns the unknown fields of the config file, in json, yaml or toml.
+func FindUnknownFields(file string, format string, content []byte) ([]*UnknownField, error) {
+	var jsonContent []byte
+	var lines map[string]int
+	switch format {
+	case "json":
+		b, err := io.ReadAll(&json_reader.Reader{Reader: bytes.NewReader(content)})
+		if err != nil {
+			return nil, errors.New("failed to read config file").Base(err)
+		}
+		jsonContent = b
+		lines = jsonKeyLines(b)
+	case "yaml":
+		b, err := yaml.YAMLToJSON(content)
+		if err != nil {
+			return nil, errors.New("failed to convert yaml to json").Base(err)
+		}
+		jsonContent = b
+		lines = yamlKeyLines(content)
+	case "toml":
+		tree, err := toml.LoadBytes(content)
+		if err != nil {
+			return nil, errors.New("failed to convert toml to map").Base(err)
+		}
+		b, err := json.Marshal(tree.ToMap())
+		if err != nil {
+			return nil, errors.New("failed to convert map to json").Base(err)
+		}
+		jsonContent = b
+		lines = make(map[string]int)
+		tomlKeyLines(lines, "", tree)
+	default:
+		return nil, errors.New("unknown format of config file: ", format)
+	}
+
+	paths, err := conf.FindUnknownFields(jsonContent)
+	if err != nil {
+		return nil, errors.New("failed to read config file").Base(err)
+	}
+	fields := make([]*UnknownField, len(paths))
+	for i, path := range paths {
+		fields[i] = &UnknownField{File: file, Line: lines[path], Path: path}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Line < fields[j].Line
+	})
+	return fields, nil
+}
+
+// unknownFieldsError is the error of all the unknown fields in the config files.
+func unknownFieldsError(fields []*UnknownField) error {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(len(fields)))
+	b.WriteString(" unknown field(s) in config:")
+	for _, f := range fields {
+		b.WriteString("\n  ")
+		b.WriteString(f.String())
+	}
+	return errors.New(b.String())
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// jsonKeyLines returns the lines of the keys in JSON, keyed by the paths.
+func jsonKeyLines(content []byte) map[string]int {
+	lines := make(map[string]int)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	line, offset := 1, 0
+	lineAt := func(o int) int {
+		line += bytes.Count(content[offset:o], []byte{'\n'})
+		offset = o
+		return line
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				p := joinPath(path, key.(string))
+				lines[p] = lineAt(int(decoder.InputOffset()))
+				if err := walk(p); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(indexPath(path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+	// Errors are reported by the decoder, and the lines found are still useful.
+	_ = walk("")
+	return lines
+}
+
+// yamlKeyLines returns the lines of the keys in YAML, keyed by the paths.
+func yamlKeyLines(content []byte) map[string]int {
+	lines := make(map[string]int)
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &root); err != nil {
+		return lines
+	}
+
+	var walk func(path string, node *yamlv3.Node)
+	walk = func(path string, node *yamlv3.Node) {
+		switch node.Kind {
+		case yamlv3.DocumentNode:
+			for _, n := range node.Content {
+				walk(path, n)
+			}
+		case yamlv3.AliasNode:
+			walk(path, node.Alias)
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Tag == "!!merge" {
+					walk(path, value)
+					continue
+				}
+				p := joinPath(path, key.Value)
+				if _, found := lines[p]; !found {
+					lines[p] = key.Line
+				}
+				walk(p, value)
+			}
+		case yamlv3.SequenceNode:
+			for i, n := range node.Content {
+				walk(indexPath(path, i), n)
+			}
+		}
+	}
+	walk("", &root)
+	return lines
+}
+
+// tomlKeyLines puts the lines of the keys in the TOML tree into lines, keyed by the paths.
+func tomlKeyLines(lines map[string]int, path string, tree *toml.Tree) {
+	for _, key := range tree.Keys() {
+		p := joinPath(path, key)
+		if line := tree.GetPositionPath([]string{key}).Line; line > 0 {
+			lines[p] = line
+		}
+		tomlValueLines(lines, p, tree.GetPath([]string{key}))
+	}
+}
+
+func tomlValueLines(lines map[string]int, path string, value interface{}) {
+	switch v := value.(type) {
+	case *toml.Tree:
+		tomlKeyLines(lines, path, v)
+	case []*toml.Tree:
+		for i, t := range v {
+			tomlKeyLines(lines, indexPath(path, i), t)
+		}
+	case []interface{}:
+		for i, e := range v {
+			tomlValueLines(lines, indexPath(path, i), e)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package serial_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/core"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/serial"
+	_ "github.com/HZ-PRE/XrarCore/main/confloader/external"
+)
+
+func TestFindUnknownFields(t *testing.T) {
+	for _, tc := range []struct {
+		format  string
+		content string
+	}{
+		{
+			format: "json",
+			content: `{ // comments are stripped
+  "log": {"loglevel": "info"},
+  "inbounds": [{
+    "protocol": "socks",
+    /* multi-line
+       comments */
+    "streamSetings": {}
+  }],
+  "outbounds": [{"protocol": "freedom",
+    "settings": {
+      "domainStrategy": "AsIs",
+      "redirct": ""}}]
+}`,
+		},
+		{
+			format: "yaml",
+			content: `# comments
+log:
+  loglevel: info
+inbounds:
+  - protocol: socks
+
+    streamSetings: {}
+outbounds:
+  - protocol: freedom
+    settings:
+      domainStrategy: AsIs
+      redirct: ""
+`,
+		},
+		{
+			format: "toml",
+			content: `# comments
+[log]
+loglevel = "info"
+[[inbounds]]
+protocol = "socks"
+
+  [inbounds.streamSetings]
+[[outbounds]]
+protocol = "freedom"
+  [outbounds.settings]
+  domainStrategy = "AsIs"
+  redirct = ""
+`,
+		},
+	} {
+		fields, err := FindUnknownFields("config."+tc.format, tc.format, []byte(tc.content))
+		common.Must(err)
+		expected := []*UnknownField{
+			{File: "config." + tc.format, Line: 7, Path: "inbounds[0].streamSetings"},
+			{File: "config." + tc.format, Line: 12, Path: "outbounds[0].settings.redirct"},
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("unexpected unknown fields of %s: %v", tc.format, fields)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	dir := t.TempDir()
+	files := []*core.ConfigSource{
+		{Name: filepath.Join(dir, "00_base.json"), Format: "json"},
+		{Name: filepath.Join(dir, "01_outbounds.yaml"), Format: "yaml"},
+	}
+	common.Must(os.WriteFile(files[0].Name, []byte(`{
+  "log": {"logLevel": "none", "acessLog": "none"}
+}`), 0o600))
+	common.Must(os.WriteFile(files[1].Name, []byte(`outbounds:
+  - protocol: freedom
+    tga: direct
+`), 0o600))
+
+	if _, err := BuildConfig(files); err != nil {
+		t.Fatal("unknown fields are not ignored: ", err)
+	}
+
+	Strict = true
+	defer func() {
+		Strict = false
+	}()
+	_, err := BuildConfig(files)
+	if err == nil {
+		t.Fatal("expected error of unknown fields")
+	}
+	for _, s := range []string{
+		files[0].Name + ":2: unknown field log.acessLog",
+		files[1].Name + ":3: unknown field outbounds[0].tga",
+	} {
+		if !strings.Contains(err.Error(), s) {
+			t.Error("expected ", s, " in ", err)
+		}
+	}
+}
@@ -13,21 +13,34 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// shadowsocksCiphers are the accepted methods other than Shadowsocks 2022, in lower case. Names of the
+// same cipher are aliases, and the first one is preferred.
+var shadowsocksCiphers = []struct {
+	name   string
+	cipher shadowsocks.CipherType
+}{
+	{"aes-128-gcm", shadowsocks.CipherType_AES_128_GCM},
+	{"aead_aes_128_gcm", shadowsocks.CipherType_AES_128_GCM},
+	{"aes-256-gcm", shadowsocks.CipherType_AES_256_GCM},
+	{"aead_aes_256_gcm", shadowsocks.CipherType_AES_256_GCM},
+	{"chacha20-poly1305", shadowsocks.CipherType_CHACHA20_POLY1305},
+	{"aead_chacha20_poly1305", shadowsocks.CipherType_CHACHA20_POLY1305},
+	{"chacha20-ietf-poly1305", shadowsocks.CipherType_CHACHA20_POLY1305},
+	{"xchacha20-poly1305", shadowsocks.CipherType_XCHACHA20_POLY1305},
+	{"aead_xchacha20_poly1305", shadowsocks.CipherType_XCHACHA20_POLY1305},
+	{"xchacha20-ietf-poly1305", shadowsocks.CipherType_XCHACHA20_POLY1305},
+	{"none", shadowsocks.CipherType_NONE},
+	{"plain", shadowsocks.CipherType_NONE},
+}
+
 func cipherFromString(c string) shadowsocks.CipherType {
-	switch strings.ToLower(c) {
-	case "aes-128-gcm", "aead_aes_128_gcm":
-		return shadowsocks.CipherType_AES_128_GCM
-	case "aes-256-gcm", "aead_aes_256_gcm":
-		return shadowsocks.CipherType_AES_256_GCM
-	case "chacha20-poly1305", "aead_chacha20_poly1305", "chacha20-ietf-poly1305":
-		return shadowsocks.CipherType_CHACHA20_POLY1305
-	case "xchacha20-poly1305", "aead_xchacha20_poly1305", "xchacha20-ietf-poly1305":
-		return shadowsocks.CipherType_XCHACHA20_POLY1305
-	case "none", "plain":
-		return shadowsocks.CipherType_NONE
-	default:
-		return shadowsocks.CipherType_UNKNOWN
+	c = strings.ToLower(c)
+	for _, cipher := range shadowsocksCiphers {
+		if cipher.name == c {
+			return cipher.cipher
+		}
 	}
+	return shadowsocks.CipherType_UNKNOWN
 }
 
 type ShadowsocksUserConfig struct {
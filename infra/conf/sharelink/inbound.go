@@ -0,0 +1,253 @@
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"golang.org/x/crypto/curve25519"
+)
+
+// InboundOptions are what links need but inbounds don't have.
+type InboundOptions struct {
+	// Address is the address of the server for clients. The listening address of the inbound is used
+	// if it's empty.
+	Address string
+	// ServerName overrides the SNI of TLS and REALITY.
+	ServerName string
+	// Fingerprint is the uTLS fingerprint of TLS and REALITY. REALITY defaults to chrome.
+	Fingerprint string
+}
+
+// inboundUser has the fields of users of all protocols.
+type inboundUser struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+	Method   string `json:"method"`
+	Flow     string `json:"flow"`
+	Email    string `json:"email"`
+	Security string `json:"security"`
+}
+
+// inboundSettings has the fields of settings of all protocols.
+type inboundSettings struct {
+	Clients    []*inboundUser `json:"clients"`
+	Decryption string         `json:"decryption"`
+	Method     string         `json:"method"`
+	Password   string         `json:"password"`
+	Email      string         `json:"email"`
+}
+
+// FromInbound returns the links of all users of in.
+func FromInbound(in *conf.InboundDetourConfig, options *InboundOptions) ([]*Link, error) {
+	protocol := strings.ToLower(in.Protocol)
+	if protocol == "ss" {
+		protocol = ProtocolShadowsocks
+	}
+	switch protocol {
+	case ProtocolVLESS, ProtocolVMess, ProtocolTrojan, ProtocolShadowsocks:
+	default:
+		return nil, errors.New("share links of ", in.Protocol, " are not supported")
+	}
+
+	address := options.Address
+	if address == "" && in.ListenOn != nil && (in.ListenOn.Family().IsDomain() || !in.ListenOn.IP().IsUnspecified()) {
+		address = in.ListenOn.String()
+	}
+	if address == "" {
+		return nil, errors.New("address of the server is not specified")
+	}
+	if in.PortList == nil || len(in.PortList.Range) == 0 {
+		return nil, errors.New("port of the inbound is not specified")
+	}
+	port := in.PortList.Range[0].From
+
+	params, err := streamParams(in.StreamSetting, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings inboundSettings
+	if in.Settings != nil {
+		if err := json.Unmarshal(*in.Settings, &settings); err != nil {
+			return nil, errors.New("invalid settings of inbound ", in.Tag).Base(err)
+		}
+	}
+	users := settings.Clients
+	if protocol == ProtocolShadowsocks && len(users) == 0 {
+		users = []*inboundUser{{Method: settings.Method, Password: settings.Password, Email: settings.Email}}
+	}
+
+	links := make([]*Link, 0, len(users))
+	for _, user := range users {
+		link := &Link{
+			Protocol: protocol,
+			Address:  address,
+			Port:     uint16(port),
+			Name:     user.Email,
+			Params:   make(url.Values),
+		}
+		if link.Name == "" {
+			link.Name = in.Tag
+		}
+		for k, v := range params {
+			link.Params[k] = v
+		}
+		switch protocol {
+		case ProtocolVLESS:
+			link.ID = user.ID
+			link.Params.Set("encryption", "none")
+			setParam(link.Params, "flow", user.Flow)
+		case ProtocolVMess:
+			link.ID = user.ID
+			link.Method = valueOr(user.Security, "auto")
+		case ProtocolTrojan:
+			link.ID = user.Password
+		case ProtocolShadowsocks:
+			link.Method = valueOr(user.Method, settings.Method)
+			link.ID = user.Password
+			if strings.HasPrefix(link.Method, "2022-") && len(settings.Clients) > 0 {
+				// Users of multi-user Shadowsocks 2022 need both the server key and their own.
+				link.ID = settings.Password + ":" + user.Password
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// streamParams returns the link parameters of the stream settings of an inbound or an outbound.
+func streamParams(stream *conf.StreamConfig, options *InboundOptions) (url.Values, error) {
+	p := make(url.Values)
+	if stream == nil {
+		p.Set("type", "tcp")
+		return p, nil
+	}
+
+	network := "raw"
+	if stream.Network != nil {
+		network = strings.ToLower(string(*stream.Network))
+	}
+	switch network {
+	case "", "raw", "tcp":
+		p.Set("type", "tcp")
+		raw := stream.RAWSettings
+		if raw == nil {
+			raw = stream.TCPSettings
+		}
+		if raw != nil && raw.HeaderConfig != nil {
+			var header struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(raw.HeaderConfig, &header); err != nil {
+				return nil, errors.New("invalid header of raw settings").Base(err)
+			}
+			if header.Type != "" && header.Type != "none" {
+				p.Set("headerType", header.Type)
+			}
+		}
+	case "ws", "websocket":
+		p.Set("type", "ws")
+		if ws := stream.WSSettings; ws != nil {
+			setParam(p, "path", ws.Path)
+			setParam(p, "host", ws.Host)
+		}
+	case "httpupgrade":
+		p.Set("type", "httpupgrade")
+		if hu := stream.HTTPUPGRADESettings; hu != nil {
+			setParam(p, "path", hu.Path)
+			setParam(p, "host", hu.Host)
+		}
+	case "grpc":
+		p.Set("type", "grpc")
+		if grpc := stream.GRPCSettings; grpc != nil {
+			setParam(p, "serviceName", grpc.ServiceName)
+			setParam(p, "authority", grpc.Authority)
+			if grpc.MultiMode {
+				p.Set("mode", "multi")
+			}
+		}
+	case "xhttp", "splithttp":
+		p.Set("type", "xhttp")
+		xhttp := stream.XHTTPSettings
+		if xhttp == nil {
+			xhttp = stream.SplitHTTPSettings
+		}
+		if xhttp != nil {
+			setParam(p, "path", xhttp.Path)
+			setParam(p, "host", xhttp.Host)
+			setParam(p, "mode", xhttp.Mode)
+			if len(xhttp.Extra) > 0 {
+				p.Set("extra", string(xhttp.Extra))
+			}
+		}
+	case "kcp", "mkcp":
+		p.Set("type", "kcp")
+		if kcp := stream.KCPSettings; kcp != nil && kcp.Seed != nil {
+			p.Set("seed", *kcp.Seed)
+		}
+	default:
+		return nil, errors.New("share links of transport ", network, " are not supported")
+	}
+
+	switch security := strings.ToLower(stream.Security); security {
+	case "", "none":
+		p.Set("security", "none")
+	case "tls":
+		p.Set("security", "tls")
+		sni, fp := options.ServerName, options.Fingerprint
+		if tls := stream.TLSSettings; tls != nil {
+			sni = valueOr(sni, tls.ServerName)
+			fp = valueOr(fp, tls.Fingerprint)
+			if tls.ALPN != nil && len(*tls.ALPN) > 0 {
+				p.Set("alpn", strings.Join(*tls.ALPN, ","))
+			}
+			if tls.Insecure {
+				p.Set("allowInsecure", "1")
+			}
+		}
+		setParam(p, "sni", sni)
+		setParam(p, "fp", fp)
+	case "reality":
+		reality := stream.REALITYSettings
+		if reality == nil {
+			return nil, errors.New("empty REALITY settings")
+		}
+		p.Set("security", "reality")
+		sni := valueOr(options.ServerName, reality.ServerName)
+		if sni == "" && len(reality.ServerNames) > 0 {
+			sni = reality.ServerNames[0]
+		}
+		setParam(p, "sni", sni)
+		p.Set("fp", valueOr(options.Fingerprint, valueOr(reality.Fingerprint, "chrome")))
+		if reality.PrivateKey == "" {
+			// Settings of outbounds have the public key already.
+			pbk := valueOr(reality.Password, reality.PublicKey)
+			if pbk == "" {
+				return nil, errors.New(`"password" of REALITY is not specified`)
+			}
+			p.Set("pbk", pbk)
+			setParam(p, "sid", reality.ShortId)
+			setParam(p, "spx", reality.SpiderX)
+			break
+		}
+		privateKey, err := base64.RawURLEncoding.DecodeString(reality.PrivateKey)
+		if err != nil || len(privateKey) != 32 {
+			return nil, errors.New(`invalid "privateKey" of REALITY: `, reality.PrivateKey)
+		}
+		publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+		if err != nil {
+			return nil, errors.New("failed to derive the public key of REALITY").Base(err)
+		}
+		p.Set("pbk", base64.RawURLEncoding.EncodeToString(publicKey))
+		if len(reality.ShortIds) > 0 {
+			setParam(p, "sid", reality.ShortIds[0])
+		}
+	default:
+		return nil, errors.New("share links of security ", security, " are not supported")
+	}
+	return p, nil
+}
@@ -0,0 +1,100 @@
+package sharelink
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+// outboundServer has the fields of servers of all protocols.
+type outboundServer struct {
+	Address  *conf.Address  `json:"address"`
+	Port     uint16         `json:"port"`
+	Users    []*inboundUser `json:"users"`
+	Method   string         `json:"method"`
+	Password string         `json:"password"`
+	Email    string         `json:"email"`
+}
+
+// outboundSettings has the fields of settings of all protocols.
+type outboundSettings struct {
+	Vnext   []*outboundServer `json:"vnext"`
+	Servers []*outboundServer `json:"servers"`
+}
+
+// FromOutbound returns the links of all servers and users of out, named after the tag of out.
+func FromOutbound(out *conf.OutboundDetourConfig) ([]*Link, error) {
+	protocol := strings.ToLower(out.Protocol)
+	if protocol == "ss" {
+		protocol = ProtocolShadowsocks
+	}
+	switch protocol {
+	case ProtocolVLESS, ProtocolVMess, ProtocolTrojan, ProtocolShadowsocks:
+	default:
+		return nil, errors.New("share links of ", out.Protocol, " are not supported")
+	}
+
+	params, err := streamParams(out.StreamSetting, &InboundOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var settings outboundSettings
+	if out.Settings != nil {
+		if err := json.Unmarshal(*out.Settings, &settings); err != nil {
+			return nil, errors.New("invalid settings of outbound ", out.Tag).Base(err)
+		}
+	}
+	servers := settings.Servers
+	if protocol == ProtocolVLESS || protocol == ProtocolVMess {
+		servers = settings.Vnext
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no server in outbound ", out.Tag)
+	}
+
+	var links []*Link
+	for _, server := range servers {
+		if server.Address == nil {
+			return nil, errors.New("address of the server is not specified")
+		}
+		users := server.Users
+		if protocol == ProtocolTrojan || protocol == ProtocolShadowsocks {
+			users = []*inboundUser{{Method: server.Method, Password: server.Password, Email: server.Email}}
+		}
+		for _, user := range users {
+			link := &Link{
+				Protocol: protocol,
+				Address:  server.Address.String(),
+				Port:     server.Port,
+				Name:     out.Tag,
+				Params:   make(url.Values),
+			}
+			if server.Address.Family().IsIP() {
+				link.Address = server.Address.IP().String()
+			}
+			for k, v := range params {
+				link.Params[k] = v
+			}
+			switch protocol {
+			case ProtocolVLESS:
+				link.ID = user.ID
+				link.Params.Set("encryption", "none")
+				setParam(link.Params, "flow", user.Flow)
+			case ProtocolVMess:
+				link.ID = user.ID
+				link.Method = valueOr(user.Security, "auto")
+			case ProtocolTrojan:
+				link.ID = user.Password
+			case ProtocolShadowsocks:
+				link.Method = user.Method
+				link.ID = user.Password
+			}
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,486 @@
+// Package sharelink converts between share links (vless://, vmess://, trojan:// and ss://) and configs.
+package sharelink
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+)
+
+// Protocols of links, named as in configs.
+const (
+	ProtocolVLESS       = "vless"
+	ProtocolVMess       = "vmess"
+	ProtocolTrojan      = "trojan"
+	ProtocolShadowsocks = "shadowsocks"
+)
+
+// Link is a share link of a server. Transport and security parameters are kept in Params with the
+// keys of VLESS links, e.g. type, security, sni, pbk, path and serviceName, whatever the protocol is.
+type Link struct {
+	Protocol string
+	Address  string
+	Port     uint16
+	// Name is the remark of the link, which is used as the tag of outbounds.
+	Name string
+	// ID is the UUID of VLESS and VMess, or the password of Trojan and Shadowsocks.
+	ID string
+	// Method is the cipher of Shadowsocks or the security of VMess.
+	Method string
+	Params url.Values
+}
+
+// Parse parses a share link.
+func Parse(s string) (*Link, error) {
+	s = strings.TrimSpace(s)
+	scheme, _, found := strings.Cut(s, "://")
+	if !found {
+		return nil, errors.New("invalid share link: ", s)
+	}
+	switch strings.ToLower(scheme) {
+	case "vless":
+		return parseURL(ProtocolVLESS, s)
+	case "trojan":
+		return parseURL(ProtocolTrojan, s)
+	case "vmess":
+		return parseVMess(s)
+	case "ss":
+		return parseShadowsocks(s)
+	default:
+		return nil, errors.New("unsupported share link scheme: ", scheme)
+	}
+}
+
+func parseURL(protocol, s string) (*Link, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.New("invalid ", protocol, " link").Base(err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("no user in ", protocol, " link")
+	}
+	link := &Link{
+		Protocol: protocol,
+		Name:     u.Fragment,
+		ID:       u.User.Username(),
+		Params:   u.Query(),
+	}
+	if err := link.setHostPort(u.Host); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (l *Link) setHostPort(hostport string) error {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return errors.New("invalid server address: ", hostport).Base(err)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return errors.New("invalid server port: ", port)
+	}
+	l.Address = host
+	l.Port = uint16(p)
+	return nil
+}
+
+// vmessLink is the JSON of VMess links, in the format of v2rayN.
+type vmessLink struct {
+	Version  json.RawMessage `json:"v,omitempty"`
+	Name     string          `json:"ps"`
+	Address  string          `json:"add"`
+	Port     json.RawMessage `json:"port"`
+	ID       string          `json:"id"`
+	AlterID  json.RawMessage `json:"aid,omitempty"`
+	Security string          `json:"scy,omitempty"`
+	Network  string          `json:"net"`
+	Type     string          `json:"type"`
+	Host     string          `json:"host"`
+	Path     string          `json:"path"`
+	TLS      string          `json:"tls"`
+	SNI      string          `json:"sni,omitempty"`
+	ALPN     string          `json:"alpn,omitempty"`
+	FP       string          `json:"fp,omitempty"`
+}
+
+func parseVMess(s string) (*Link, error) {
+	b, err := decodeBase64(s[len("vmess://"):])
+	if err != nil {
+		return nil, errors.New("invalid vmess link").Base(err)
+	}
+	var v vmessLink
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, errors.New("invalid vmess link").Base(err)
+	}
+	if v.ID == "" {
+		return nil, errors.New("no user in vmess link")
+	}
+	link := &Link{
+		Protocol: ProtocolVMess,
+		Name:     v.Name,
+		ID:       v.ID,
+		Method:   v.Security,
+		Params:   make(url.Values),
+	}
+	if err := link.setHostPort(net.JoinHostPort(v.Address, strings.Trim(string(v.Port), `"`))); err != nil {
+		return nil, err
+	}
+
+	p := link.Params
+	setParam(p, "type", v.Network)
+	switch v.Network {
+	case "grpc":
+		setParam(p, "serviceName", v.Path)
+		setParam(p, "authority", v.Host)
+		setParam(p, "mode", v.Type)
+	case "xhttp", "splithttp":
+		setParam(p, "mode", v.Type)
+		setParam(p, "host", v.Host)
+		setParam(p, "path", v.Path)
+	default:
+		if v.Type != "none" {
+			setParam(p, "headerType", v.Type)
+		}
+		setParam(p, "host", v.Host)
+		setParam(p, "path", v.Path)
+	}
+	if v.TLS == "tls" {
+		p.Set("security", "tls")
+		setParam(p, "sni", v.SNI)
+		setParam(p, "alpn", v.ALPN)
+		setParam(p, "fp", v.FP)
+	}
+	return link, nil
+}
+
+func parseShadowsocks(s string) (*Link, error) {
+	rest, name, _ := strings.Cut(s[len("ss://"):], "#")
+	if !strings.Contains(rest, "@") {
+		// The legacy format: ss://base64(method:password@host:port)#name
+		b, err := decodeBase64(rest)
+		if err != nil {
+			return nil, errors.New("invalid ss link").Base(err)
+		}
+		rest = string(b)
+	}
+	u, err := url.Parse("ss://" + rest)
+	if err != nil || u.User == nil {
+		return nil, errors.New("invalid ss link").Base(err)
+	}
+	if u.Fragment, err = url.PathUnescape(name); err != nil {
+		return nil, errors.New("invalid name of ss link").Base(err)
+	}
+
+	link := &Link{
+		Protocol: ProtocolShadowsocks,
+		Name:     u.Fragment,
+		Params:   u.Query(),
+	}
+	if password, ok := u.User.Password(); ok {
+		link.Method, link.ID = u.User.Username(), password
+	} else {
+		b, err := decodeBase64(u.User.Username())
+		if err != nil {
+			return nil, errors.New("invalid user of ss link").Base(err)
+		}
+		method, password, found := strings.Cut(string(b), ":")
+		if !found {
+			return nil, errors.New("invalid user of ss link")
+		}
+		link.Method, link.ID = method, password
+	}
+	if link.Params.Has("plugin") {
+		return nil, errors.New("ss plugins are not supported: ", link.Params.Get("plugin"))
+	}
+	if err := link.setHostPort(u.Host); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var b []byte
+		if b, err = encoding.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+func setParam(p url.Values, key, value string) {
+	if value != "" {
+		p.Set(key, value)
+	}
+}
+
+// String returns the share link.
+func (l *Link) String() string {
+	hostport := net.JoinHostPort(l.Address, strconv.Itoa(int(l.Port)))
+	switch l.Protocol {
+	case ProtocolVMess:
+		return l.vmessString()
+	case ProtocolShadowsocks:
+		u := &url.URL{
+			Scheme:   "ss",
+			Host:     hostport,
+			RawQuery: l.Params.Encode(),
+			Fragment: l.Name,
+		}
+		if strings.HasPrefix(l.Method, "2022-") {
+			u.User = url.UserPassword(l.Method, l.ID)
+		} else {
+			u.User = url.User(base64.RawURLEncoding.EncodeToString([]byte(l.Method + ":" + l.ID)))
+		}
+		return u.String()
+	default:
+		u := &url.URL{
+			Scheme:   l.Protocol,
+			User:     url.User(l.ID),
+			Host:     hostport,
+			RawQuery: l.Params.Encode(),
+			Fragment: l.Name,
+		}
+		return u.String()
+	}
+}
+
+func (l *Link) vmessString() string {
+	p := l.Params
+	v := vmessLink{
+		Version:  json.RawMessage(`"2"`),
+		Name:     l.Name,
+		Address:  l.Address,
+		Port:     json.RawMessage(strconv.Quote(strconv.Itoa(int(l.Port)))),
+		ID:       l.ID,
+		AlterID:  json.RawMessage(`"0"`),
+		Security: l.Method,
+		Network:  p.Get("type"),
+		Host:     p.Get("host"),
+		Path:     p.Get("path"),
+		Type:     p.Get("headerType"),
+	}
+	if v.Network == "" {
+		v.Network = "tcp"
+	}
+	switch v.Network {
+	case "grpc":
+		v.Path = p.Get("serviceName")
+		v.Host = p.Get("authority")
+		v.Type = p.Get("mode")
+	case "xhttp", "splithttp":
+		v.Type = p.Get("mode")
+	}
+	if v.Type == "" {
+		v.Type = "none"
+	}
+	if p.Get("security") == "tls" {
+		v.TLS = "tls"
+		v.SNI = p.Get("sni")
+		v.ALPN = p.Get("alpn")
+		v.FP = p.Get("fp")
+	}
+	b, _ := json.Marshal(v)
+	return "vmess://" + base64.StdEncoding.EncodeToString(b)
+}
+
+// Outbound returns the outbound of the link in the JSON format of configs, which can be decoded into
+// conf.OutboundDetourConfig.
+func (l *Link) Outbound() (map[string]interface{}, error) {
+	var settings map[string]interface{}
+	switch l.Protocol {
+	case ProtocolVLESS:
+		user := map[string]interface{}{
+			"id":         l.ID,
+			"encryption": "none",
+		}
+		if e := l.Params.Get("encryption"); e != "" {
+			user["encryption"] = e
+		}
+		if flow := l.Params.Get("flow"); flow != "" {
+			user["flow"] = flow
+		}
+		settings = l.vnext(user)
+	case ProtocolVMess:
+		user := map[string]interface{}{
+			"id": l.ID,
+		}
+		if l.Method != "" {
+			user["security"] = l.Method
+		}
+		settings = l.vnext(user)
+	case ProtocolTrojan:
+		settings = l.servers(map[string]interface{}{
+			"password": l.ID,
+		})
+	case ProtocolShadowsocks:
+		settings = l.servers(map[string]interface{}{
+			"method":   l.Method,
+			"password": l.ID,
+		})
+	default:
+		return nil, errors.New("unknown protocol: ", l.Protocol)
+	}
+
+	stream, err := l.streamSettings()
+	if err != nil {
+		return nil, err
+	}
+	outbound := map[string]interface{}{
+		"protocol":       l.Protocol,
+		"settings":       settings,
+		"streamSettings": stream,
+	}
+	if l.Name != "" {
+		outbound["tag"] = l.Name
+	}
+	return outbound, nil
+}
+
+func (l *Link) vnext(user map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"vnext": []interface{}{
+			map[string]interface{}{
+				"address": l.Address,
+				"port":    l.Port,
+				"users":   []interface{}{user},
+			},
+		},
+	}
+}
+
+func (l *Link) servers(server map[string]interface{}) map[string]interface{} {
+	server["address"] = l.Address
+	server["port"] = l.Port
+	return map[string]interface{}{
+		"servers": []interface{}{server},
+	}
+}
+
+func (l *Link) streamSettings() (map[string]interface{}, error) {
+	p := l.Params
+	stream := make(map[string]interface{})
+
+	network := p.Get("type")
+	switch network {
+	case "", "tcp", "raw":
+		stream["network"] = "raw"
+		if p.Get("headerType") == "http" {
+			request := map[string]interface{}{
+				"path": strings.Split(valueOr(p.Get("path"), "/"), ","),
+			}
+			if host := p.Get("host"); host != "" {
+				request["headers"] = map[string]interface{}{
+					"Host": strings.Split(host, ","),
+				}
+			}
+			stream["rawSettings"] = map[string]interface{}{
+				"header": map[string]interface{}{
+					"type":    "http",
+					"request": request,
+				},
+			}
+		}
+	case "ws", "httpupgrade":
+		stream["network"] = network
+		settings := make(map[string]interface{})
+		putParam(settings, "path", p.Get("path"))
+		putParam(settings, "host", p.Get("host"))
+		stream[network+"Settings"] = settings
+	case "grpc":
+		stream["network"] = network
+		settings := make(map[string]interface{})
+		putParam(settings, "serviceName", p.Get("serviceName"))
+		putParam(settings, "authority", p.Get("authority"))
+		if p.Get("mode") == "multi" {
+			settings["multiMode"] = true
+		}
+		stream["grpcSettings"] = settings
+	case "xhttp", "splithttp":
+		stream["network"] = "xhttp"
+		settings := make(map[string]interface{})
+		putParam(settings, "path", p.Get("path"))
+		putParam(settings, "host", p.Get("host"))
+		putParam(settings, "mode", p.Get("mode"))
+		if extra := p.Get("extra"); extra != "" {
+			if !json.Valid([]byte(extra)) {
+				return nil, errors.New("invalid xhttp extra: ", extra)
+			}
+			settings["extra"] = json.RawMessage(extra)
+		}
+		stream["xhttpSettings"] = settings
+	case "kcp", "mkcp":
+		stream["network"] = "kcp"
+		settings := make(map[string]interface{})
+		putParam(settings, "seed", p.Get("seed"))
+		if t := p.Get("headerType"); t != "" && t != "none" {
+			settings["header"] = map[string]interface{}{"type": t}
+		}
+		stream["kcpSettings"] = settings
+	default:
+		return nil, errors.New("unsupported transport: ", network)
+	}
+
+	switch security := p.Get("security"); security {
+	case "", "none":
+	case "tls":
+		stream["security"] = security
+		settings := make(map[string]interface{})
+		putParam(settings, "serverName", p.Get("sni"))
+		putParam(settings, "fingerprint", p.Get("fp"))
+		if alpn := p.Get("alpn"); alpn != "" {
+			settings["alpn"] = strings.Split(alpn, ",")
+		}
+		switch p.Get("allowInsecure") {
+		case "1", "true":
+			settings["allowInsecure"] = true
+		}
+		stream["tlsSettings"] = settings
+	case "reality":
+		stream["security"] = security
+		settings := make(map[string]interface{})
+		putParam(settings, "serverName", p.Get("sni"))
+		putParam(settings, "fingerprint", valueOr(p.Get("fp"), "chrome"))
+		putParam(settings, "password", p.Get("pbk"))
+		putParam(settings, "shortId", p.Get("sid"))
+		putParam(settings, "spiderX", p.Get("spx"))
+		stream["realitySettings"] = settings
+	default:
+		return nil, errors.New("unsupported security: ", security)
+	}
+	return stream, nil
+}
+
+func putParam(settings map[string]interface{}, key, value string) {
+	if value != "" {
+		settings[key] = value
+	}
+}
+
+func valueOr(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// OutboundJSON returns the outbound of the link as indented JSON.
+func (l *Link) OutboundJSON() ([]byte, error) {
+	outbound, err := l.Outbound()
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(outbound, "", "  ")
+	if err != nil {
+		return nil, errors.New("failed to marshal outbound of ", l.Name).Base(err)
+	}
+	return b, nil
+}
@@ -0,0 +1,250 @@
+package sharelink_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	. "github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+)
+
+func buildOutbound(t *testing.T, link *Link) *conf.OutboundDetourConfig {
+	t.Helper()
+	b, err := link.OutboundJSON()
+	common.Must(err)
+	outbound := new(conf.OutboundDetourConfig)
+	common.Must(json.Unmarshal(b, outbound))
+	if _, err := outbound.Build(); err != nil {
+		t.Fatal("failed to build outbound of ", link, ": ", err, "\n", string(b))
+	}
+	return outbound
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		link     string
+		protocol string
+		address  string
+		port     uint16
+		name     string
+		id       string
+		method   string
+		check    func(*conf.StreamConfig) bool
+	}{
+		{
+			link:     "vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?encryption=none&flow=xtls-rprx-vision&security=reality&sni=www.example.com&fp=chrome&pbk=SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA&sid=6ba85179e30d4fc2&type=tcp#reality%20node",
+			protocol: ProtocolVLESS,
+			address:  "example.com",
+			port:     443,
+			name:     "reality node",
+			id:       "b831381d-6324-4d53-ad4f-8cda48b30811",
+			check: func(s *conf.StreamConfig) bool {
+				r := s.REALITYSettings
+				return s.Security == "reality" && r.ServerName == "www.example.com" && r.ShortId == "6ba85179e30d4fc2"
+			},
+		},
+		{
+			link:     "vless://b831381d-6324-4d53-ad4f-8cda48b30811@[2001:db8::1]:8443?security=tls&sni=example.com&alpn=h2%2Chttp%2F1.1&type=xhttp&path=%2Fx&mode=stream-one&extra=%7B%22noGRPCHeader%22%3Atrue%7D",
+			protocol: ProtocolVLESS,
+			address:  "2001:db8::1",
+			port:     8443,
+			id:       "b831381d-6324-4d53-ad4f-8cda48b30811",
+			check: func(s *conf.StreamConfig) bool {
+				x := s.XHTTPSettings
+				var extra struct{ NoGRPCHeader bool }
+				common.Must(json.Unmarshal(x.Extra, &extra))
+				return x.Path == "/x" && x.Mode == "stream-one" && extra.NoGRPCHeader &&
+					s.TLSSettings.ServerName == "example.com" && len(*s.TLSSettings.ALPN) == 2
+			},
+		},
+		{
+			link:     "trojan://p%40ss@example.com:443?security=tls&type=grpc&serviceName=svc&mode=multi#trojan",
+			protocol: ProtocolTrojan,
+			address:  "example.com",
+			port:     443,
+			name:     "trojan",
+			id:       "p@ss",
+			check: func(s *conf.StreamConfig) bool {
+				return s.GRPCSettings.ServiceName == "svc" && s.GRPCSettings.MultiMode
+			},
+		},
+		{
+			// {"v":"2","ps":"vmess","add":"example.com","port":443,"id":"b831381d-6324-4d53-ad4f-8cda48b30811","aid":"0","scy":"auto","net":"ws","type":"none","host":"cdn.example.com","path":"/ws","tls":"tls","sni":"example.com"}
+			link:     "vmess://eyJ2IjoiMiIsInBzIjoidm1lc3MiLCJhZGQiOiJleGFtcGxlLmNvbSIsInBvcnQiOjQ0MywiaWQiOiJiODMxMzgxZC02MzI0LTRkNTMtYWQ0Zi04Y2RhNDhiMzA4MTEiLCJhaWQiOiIwIiwic2N5IjoiYXV0byIsIm5ldCI6IndzIiwidHlwZSI6Im5vbmUiLCJob3N0IjoiY2RuLmV4YW1wbGUuY29tIiwicGF0aCI6Ii93cyIsInRscyI6InRscyIsInNuaSI6ImV4YW1wbGUuY29tIn0=",
+			protocol: ProtocolVMess,
+			address:  "example.com",
+			port:     443,
+			name:     "vmess",
+			id:       "b831381d-6324-4d53-ad4f-8cda48b30811",
+			method:   "auto",
+			check: func(s *conf.StreamConfig) bool {
+				return s.WSSettings.Path == "/ws" && s.WSSettings.Host == "cdn.example.com" && s.TLSSettings.ServerName == "example.com"
+			},
+		},
+		{
+			link:     "ss://YWVzLTI1Ni1nY206cGFzc3dvcmQ@1.2.3.4:8388#ss",
+			protocol: ProtocolShadowsocks,
+			address:  "1.2.3.4",
+			port:     8388,
+			name:     "ss",
+			id:       "password",
+			method:   "aes-256-gcm",
+		},
+		{
+			// The legacy format.
+			link:     "ss://YWVzLTI1Ni1nY206cGFzc3dvcmRAMS4yLjMuNDo4Mzg4#legacy",
+			protocol: ProtocolShadowsocks,
+			address:  "1.2.3.4",
+			port:     8388,
+			name:     "legacy",
+			id:       "password",
+			method:   "aes-256-gcm",
+		},
+		{
+			link:     "ss://2022-blake3-aes-128-gcm:AAAAAAAAAAAAAAAAAAAAAA%3D%3D%3ABBBBBBBBBBBBBBBBBBBBBA%3D%3D@1.2.3.4:8388",
+			protocol: ProtocolShadowsocks,
+			address:  "1.2.3.4",
+			port:     8388,
+			id:       "AAAAAAAAAAAAAAAAAAAAAA==:BBBBBBBBBBBBBBBBBBBBBA==",
+			method:   "2022-blake3-aes-128-gcm",
+		},
+	} {
+		link, err := Parse(tc.link)
+		if err != nil {
+			t.Fatal(tc.link, ": ", err)
+		}
+		if link.Protocol != tc.protocol || link.Address != tc.address || link.Port != tc.port || link.Name != tc.name || link.ID != tc.id || link.Method != tc.method {
+			t.Errorf("unexpected link of %s: %+v", tc.link, link)
+		}
+
+		outbound := buildOutbound(t, link)
+		if tc.check != nil && !tc.check(outbound.StreamSetting) {
+			t.Error("unexpected stream settings of ", tc.link)
+		}
+
+		// Links converted back should be the same.
+		again, err := Parse(link.String())
+		if err != nil {
+			t.Fatal(link.String(), ": ", err)
+		}
+		a, _ := link.OutboundJSON()
+		b, _ := again.OutboundJSON()
+		if string(a) != string(b) {
+			t.Error("unexpected outbound of ", link.String(), ":\n", string(b), "\nexpected:\n", string(a))
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, link := range []string{
+		"example.com:443",
+		"http://example.com",
+		"vless://example.com:443",
+		"vless://id@example.com",
+		"vless://id@example.com:443?type=quic",
+		"vmess://not-base64",
+		"ss://YWVzLTI1Ni1nY206cGFzc3dvcmQ@1.2.3.4:8388?plugin=obfs-local",
+	} {
+		l, err := Parse(link)
+		if err == nil {
+			_, err = l.Outbound()
+		}
+		if err == nil {
+			t.Error("expected error of ", link)
+		}
+	}
+}
+
+func TestFromInbound(t *testing.T) {
+	var config conf.Config
+	common.Must(json.Unmarshal([]byte(`{
+		"inbounds": [{
+			"tag": "reality",
+			"protocol": "vless",
+			"port": 443,
+			"settings": {
+				"clients": [
+					{"id": "b831381d-6324-4d53-ad4f-8cda48b30811", "flow": "xtls-rprx-vision", "email": "alice"},
+					{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "bob"}
+				],
+				"decryption": "none"
+			},
+			"streamSettings": {
+				"network": "raw",
+				"security": "reality",
+				"realitySettings": {
+					"target": "www.example.com:443",
+					"serverNames": ["www.example.com"],
+					"privateKey": "2KZ4uouMKgI8nR-LDJNP1_MHisCJOmKGj9jUjZLncVU",
+					"shortIds": ["6ba85179e30d4fc2"]
+				}
+			}
+		}, {
+			"tag": "ss2022",
+			"protocol": "shadowsocks",
+			"port": 8388,
+			"settings": {
+				"method": "2022-blake3-aes-128-gcm",
+				"password": "AAAAAAAAAAAAAAAAAAAAAA==",
+				"clients": [{"password": "BBBBBBBBBBBBBBBBBBBBBA==", "email": "carol"}]
+			}
+		}]
+	}`), &config))
+
+	links, err := FromInbound(&config.InboundConfigs[0], &InboundOptions{Address: "1.2.3.4"})
+	common.Must(err)
+	if len(links) != 2 {
+		t.Fatal("unexpected number of links: ", len(links))
+	}
+	link := links[0]
+	if link.Name != "alice" || link.Params.Get("flow") != "xtls-rprx-vision" || link.Params.Get("sni") != "www.example.com" ||
+		link.Params.Get("pbk") != "Z84J2IelR9ch3k8VtlVhhs5ycBUlXA7wHBWcBrjqnAw" {
+		t.Error("unexpected link: ", link)
+	}
+	buildOutbound(t, links[1])
+
+	links, err = FromInbound(&config.InboundConfigs[1], &InboundOptions{Address: "example.com"})
+	common.Must(err)
+	if len(links) != 1 || links[0].ID != "AAAAAAAAAAAAAAAAAAAAAA==:BBBBBBBBBBBBBBBBBBBBBA==" {
+		t.Fatal("unexpected links: ", links)
+	}
+	buildOutbound(t, links[0])
+
+	if _, err := FromInbound(&config.InboundConfigs[0], &InboundOptions{}); err == nil {
+		t.Error("expected error of no address")
+	}
+}
+
+func TestFromOutbound(t *testing.T) {
+	for _, s := range []string{
+		"vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?encryption=none&flow=xtls-rprx-vision&security=reality&sni=www.example.com&fp=chrome&pbk=SYPUMQ1eK6jx7V1B3ghazlBNQe6Prd9bH5vd0QCtwHA&sid=6ba85179e30d4fc2&type=tcp#reality",
+		"vless://b831381d-6324-4d53-ad4f-8cda48b30811@[2001:db8::1]:8443?encryption=none&security=tls&sni=example.com&alpn=h2&allowInsecure=1&type=xhttp&path=%2Fx&mode=stream-one#xhttp",
+		"trojan://password@example.com:443?security=tls&sni=example.com&fp=firefox&type=grpc&serviceName=svc#trojan",
+		"ss://MjAyMi1ibGFrZTMtYWVzLTEyOC1nY206QUFBQUFBQUFBQUFBQUFBQUFBQUFBQT09@1.2.3.4:8388#ss",
+	} {
+		link, err := Parse(s)
+		common.Must(err)
+		links, err := FromOutbound(buildOutbound(t, link))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 1 {
+			t.Fatal("unexpected links: ", links)
+		}
+		got := links[0]
+		if got.Protocol != link.Protocol || got.Address != link.Address || got.Port != link.Port ||
+			got.Name != link.Name || got.ID != link.ID || got.Method != link.Method {
+			t.Error("expected ", link, ", but got ", got)
+		}
+		for k := range link.Params {
+			if got.Params.Get(k) != link.Params.Get(k) {
+				t.Error("expected ", k, "=", link.Params.Get(k), ", but got ", got)
+			}
+		}
+	}
+
+	if _, err := FromOutbound(&conf.OutboundDetourConfig{Protocol: "freedom"}); err == nil {
+		t.Error("expected error of freedom")
+	}
+}
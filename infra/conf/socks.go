@@ -11,8 +11,10 @@ import (
 )
 
 type SocksAccount struct {
-	Username string `json:"user"`
-	Password string `json:"pass"`
+	Username string  `json:"user"`
+	Password string  `json:"pass"`
+	Email    string  `json:"email"`
+	Level    *uint32 `json:"level"`
 }
 
 func (v *SocksAccount) Build() *socks.Account {
@@ -33,6 +35,7 @@ type SocksServerConfig struct {
 	UDP        bool            `json:"udp"`
 	Host       *Address        `json:"ip"`
 	UserLevel  uint32          `json:"userLevel"`
+	Bind       bool            `json:"bind"`
 }
 
 func (v *SocksServerConfig) Build() (proto.Message, error) {
@@ -47,11 +50,27 @@ func (v *SocksServerConfig) Build() (proto.Message, error) {
 		config.AuthType = socks.AuthType_NO_AUTH
 	}
 
-	if len(v.Accounts) > 0 {
-		config.Accounts = make(map[string]string, len(v.Accounts))
-		for _, account := range v.Accounts {
+	for _, account := range v.Accounts {
+		// Accounts with an email or a level are full users, others stay plain accounts.
+		if account.Email == "" && account.Level == nil {
+			if config.Accounts == nil {
+				config.Accounts = make(map[string]string, len(v.Accounts))
+			}
 			config.Accounts[account.Username] = account.Password
+			continue
+		}
+		user := &protocol.User{
+			Email:   account.Email,
+			Level:   v.UserLevel,
+			Account: serial.ToTypedMessage(account.Build()),
+		}
+		if user.Email == "" {
+			user.Email = account.Username
+		}
+		if account.Level != nil {
+			user.Level = *account.Level
 		}
+		config.Users = append(config.Users, user)
 	}
 
 	config.UdpEnabled = v.UDP
@@ -60,6 +79,7 @@ func (v *SocksServerConfig) Build() (proto.Message, error) {
 	}
 
 	config.UserLevel = v.UserLevel
+	config.BindEnabled = v.Bind
 	return config, nil
 }
 
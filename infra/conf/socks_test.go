@@ -44,6 +44,37 @@ func TestSocksInboundConfig(t *testing.T) {
 				UserLevel: 1,
 			},
 		},
+		{
+			Input: `{
+				"auth": "password",
+				"accounts": [
+					{
+						"user": "my-username",
+						"pass": "my-password",
+						"email": "love@example.com",
+						"level": 2
+					}
+				],
+				"bind": true,
+				"userLevel": 1
+			}`,
+			Parser: loadJSON(creator),
+			Output: &socks.ServerConfig{
+				AuthType: socks.AuthType_PASSWORD,
+				Users: []*protocol.User{
+					{
+						Email: "love@example.com",
+						Level: 2,
+						Account: serial.ToTypedMessage(&socks.Account{
+							Username: "my-username",
+							Password: "my-password",
+						}),
+					},
+				},
+				BindEnabled: true,
+				UserLevel:   1,
+			},
+		},
 	})
 }
 
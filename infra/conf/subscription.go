@@ -0,0 +1,72 @@
+package conf
+
+import (
+	"net/url"
+
+	"github.com/HZ-PRE/XrarCore/app/subscription"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/infra/conf/cfgcommon/duration"
+	"google.golang.org/protobuf/proto"
+)
+
+type SubscriptionProviderConfig struct {
+	Tag         string            `json:"tag"`
+	URL         string            `json:"url"`
+	File        string            `json:"file"`
+	TagPrefix   string            `json:"tagPrefix"`
+	Interval    duration.Duration `json:"interval"`
+	OutboundTag string            `json:"outboundTag"`
+}
+
+// Build implements Buildable.
+func (c *SubscriptionProviderConfig) Build() (*subscription.Provider, error) {
+	if c.Tag == "" {
+		return nil, errors.New("empty tag of provider")
+	}
+	switch {
+	case c.URL != "" && c.File != "":
+		return nil, errors.New("both url and file of provider ", c.Tag)
+	case c.URL != "":
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("invalid url of provider ", c.Tag, ": ", c.URL)
+		}
+	case c.File == "":
+		return nil, errors.New("no url or file of provider ", c.Tag)
+	}
+	if c.Interval < 0 {
+		return nil, errors.New("invalid interval of provider ", c.Tag)
+	}
+	prefix := c.TagPrefix
+	if prefix == "" {
+		prefix = c.Tag + "-"
+	}
+	return &subscription.Provider{
+		Tag:         c.Tag,
+		Url:         c.URL,
+		File:        c.File,
+		TagPrefix:   prefix,
+		Interval:    int64(c.Interval),
+		OutboundTag: c.OutboundTag,
+	}, nil
+}
+
+type SubscriptionConfig []*SubscriptionProviderConfig
+
+// Build implements Buildable.
+func (c SubscriptionConfig) Build() (proto.Message, error) {
+	config := new(subscription.Config)
+	prefixes := make(map[string]string)
+	for _, p := range c {
+		provider, err := p.Build()
+		if err != nil {
+			return nil, err
+		}
+		if tag, found := prefixes[provider.TagPrefix]; found {
+			return nil, errors.New("providers ", tag, " and ", provider.Tag, " have the same tag prefix")
+		}
+		prefixes[provider.TagPrefix] = provider.Tag
+		config.Providers = append(config.Providers, provider)
+	}
+	return config, nil
+}
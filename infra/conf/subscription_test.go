@@ -0,0 +1,59 @@
+package conf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/app/subscription"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+func TestSubscriptionConfig(t *testing.T) {
+	creator := func() conf.Buildable {
+		return new(conf.SubscriptionConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `[{
+				"tag": "airport",
+				"url": "https://example.com/sub?token=abc",
+				"interval": "30m",
+				"outboundTag": "direct"
+			}, {
+				"tag": "local",
+				"file": "/etc/xray/sub.txt",
+				"tagPrefix": "local/"
+			}]`,
+			Parser: loadJSON(creator),
+			Output: &subscription.Config{
+				Providers: []*subscription.Provider{
+					{
+						Tag:         "airport",
+						Url:         "https://example.com/sub?token=abc",
+						TagPrefix:   "airport-",
+						Interval:    int64(30 * time.Minute),
+						OutboundTag: "direct",
+					},
+					{
+						Tag:       "local",
+						File:      "/etc/xray/sub.txt",
+						TagPrefix: "local/",
+					},
+				},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`[{"url": "https://example.com/sub"}]`,
+		`[{"tag": "a"}]`,
+		`[{"tag": "a", "url": "ftp://example.com/sub"}]`,
+		`[{"tag": "a", "url": "https://example.com/sub", "file": "sub.txt"}]`,
+		`[{"tag": "a", "file": "a.txt", "tagPrefix": "p-"}, {"tag": "b", "file": "b.txt", "tagPrefix": "p-"}]`,
+	} {
+		if _, err := loadJSON(creator)(input); err == nil {
+			t.Error("expected error of ", input)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package conf
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"math"
 	"net/url"
 	"runtime"
@@ -13,6 +16,7 @@ import (
 
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/platform"
 	"github.com/HZ-PRE/XrarCore/common/platform/filesystem"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/transport/internet"
@@ -53,6 +57,12 @@ type KCPConfig struct {
 	WriteBufferSize *uint32         `json:"writeBufferSize"`
 	HeaderConfig    json.RawMessage `json:"header"`
 	Seed            *string         `json:"seed"`
+	FEC             *KCPFECConfig   `json:"fec"`
+}
+
+type KCPFECConfig struct {
+	DataShards   uint32 `json:"dataShards"`
+	ParityShards uint32 `json:"parityShards"`
 }
 
 // Build implements Buildable.
@@ -114,6 +124,16 @@ func (c *KCPConfig) Build() (proto.Message, error) {
 		config.Seed = &kcp.EncryptionSeed{Seed: *c.Seed}
 	}
 
+	if c.FEC != nil {
+		if !kcp.ValidFECShards(int(c.FEC.DataShards), int(c.FEC.ParityShards)) {
+			return nil, errors.New("invalid mKCP FEC shards: ", c.FEC.DataShards, "+", c.FEC.ParityShards).AtError()
+		}
+		config.Fec = &kcp.FEC{
+			DataShards:   c.FEC.DataShards,
+			ParityShards: c.FEC.ParityShards,
+		}
+	}
+
 	return config, nil
 }
 
@@ -395,23 +415,252 @@ func (c *TLSCertConfig) Build() (*tls.Certificate, error) {
 }
 
 type TLSConfig struct {
-	Insecure                             bool             `json:"allowInsecure"`
-	Certs                                []*TLSCertConfig `json:"certificates"`
-	ServerName                           string           `json:"serverName"`
-	ALPN                                 *StringList      `json:"alpn"`
-	EnableSessionResumption              bool             `json:"enableSessionResumption"`
-	DisableSystemRoot                    bool             `json:"disableSystemRoot"`
-	MinVersion                           string           `json:"minVersion"`
-	MaxVersion                           string           `json:"maxVersion"`
-	CipherSuites                         string           `json:"cipherSuites"`
-	Fingerprint                          string           `json:"fingerprint"`
-	RejectUnknownSNI                     bool             `json:"rejectUnknownSni"`
-	PinnedPeerCertificateChainSha256     *[]string        `json:"pinnedPeerCertificateChainSha256"`
-	PinnedPeerCertificatePublicKeySha256 *[]string        `json:"pinnedPeerCertificatePublicKeySha256"`
-	CurvePreferences                     *StringList      `json:"curvePreferences"`
-	MasterKeyLog                         string           `json:"masterKeyLog"`
-	ServerNameToVerify                   string           `json:"serverNameToVerify"`
-	VerifyPeerCertInNames                []string         `json:"verifyPeerCertInNames"`
+	Insecure                             bool                           `json:"allowInsecure"`
+	Certs                                []*TLSCertConfig               `json:"certificates"`
+	ServerName                           string                         `json:"serverName"`
+	ALPN                                 *StringList                    `json:"alpn"`
+	EnableSessionResumption              bool                           `json:"enableSessionResumption"`
+	DisableSystemRoot                    bool                           `json:"disableSystemRoot"`
+	MinVersion                           string                         `json:"minVersion"`
+	MaxVersion                           string                         `json:"maxVersion"`
+	CipherSuites                         string                         `json:"cipherSuites"`
+	Fingerprint                          string                         `json:"fingerprint"`
+	RejectUnknownSNI                     bool                           `json:"rejectUnknownSni"`
+	PinnedPeerCertificateChainSha256     *[]string                      `json:"pinnedPeerCertificateChainSha256"`
+	PinnedPeerCertificatePublicKeySha256 *[]string                      `json:"pinnedPeerCertificatePublicKeySha256"`
+	CurvePreferences                     *StringList                    `json:"curvePreferences"`
+	MasterKeyLog                         string                         `json:"masterKeyLog"`
+	ServerNameToVerify                   string                         `json:"serverNameToVerify"`
+	VerifyPeerCertInNames                []string                       `json:"verifyPeerCertInNames"`
+	ECHServerKeys                        *StringList                    `json:"echServerKeys"`
+	ECHServerKeysFile                    string                         `json:"echServerKeysFile"`
+	ECHConfigList                        *StringList                    `json:"echConfigList"`
+	ECHConfigListFile                    string                         `json:"echConfigListFile"`
+	ECHConfigDomain                      string                         `json:"echConfigDomain"`
+	ACME                                 *ACMEConfig                    `json:"acme"`
+	ClientAuth                           string                         `json:"clientAuth"`
+	ClientCA                             *StringList                    `json:"clientCa"`
+	ClientCAFile                         string                         `json:"clientCaFile"`
+	ClientCRL                            *StringList                    `json:"clientCrl"`
+	ClientCRLFile                        string                         `json:"clientCrlFile"`
+	ClientUsers                          []*ClientCertificateUserConfig `json:"clientUsers"`
+}
+
+type ClientCertificateUserConfig struct {
+	Email       string `json:"email"`
+	CommonName  string `json:"commonName"`
+	SAN         string `json:"san"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Build implements Buildable.
+func (c *ClientCertificateUserConfig) Build() (*tls.ClientCertificateUser, error) {
+	if c.Email == "" {
+		return nil, errors.New(`"email" is required in "clientUsers"`)
+	}
+	user := &tls.ClientCertificateUser{
+		Email:      c.Email,
+		CommonName: c.CommonName,
+		San:        c.SAN,
+	}
+	if c.Fingerprint != "" {
+		fingerprint, err := hex.DecodeString(strings.ReplaceAll(c.Fingerprint, ":", ""))
+		if err != nil || len(fingerprint) != 32 {
+			return nil, errors.New("invalid SHA-256 fingerprint of ", c.Email, ": ", c.Fingerprint)
+		}
+		user.Fingerprint = fingerprint
+	}
+	if user.CommonName == "" && user.San == "" && len(user.Fingerprint) == 0 {
+		return nil, errors.New("no condition to match client certificates of ", c.Email)
+	}
+	return user, nil
+}
+
+// buildClientAuth fills the client certificate fields of config.
+func (c *TLSConfig) buildClientAuth(config *tls.Config) error {
+	switch strings.ToLower(c.ClientAuth) {
+	case "":
+		if c.ClientCA != nil || c.ClientCAFile != "" {
+			config.ClientAuth = tls.Config_REQUIRE_AND_VERIFY
+		}
+	case "none":
+	case "optional":
+		config.ClientAuth = tls.Config_VERIFY_IF_GIVEN
+	case "required":
+		config.ClientAuth = tls.Config_REQUIRE_AND_VERIFY
+	default:
+		return errors.New(`unknown "clientAuth": `, c.ClientAuth)
+	}
+	if config.ClientAuth == tls.Config_NO_CLIENT_CERT {
+		if c.ClientCRL != nil || c.ClientCRLFile != "" || len(c.ClientUsers) > 0 {
+			return errors.New(`"clientCrl" and "clientUsers" require "clientAuth"`)
+		}
+		return nil
+	}
+
+	var caStr []string
+	if c.ClientCA != nil {
+		caStr = *c.ClientCA
+	}
+	ca, err := readFileOrString(c.ClientCAFile, caStr)
+	if err != nil {
+		return errors.New(`"clientAuth" requires "clientCa"`).Base(err)
+	}
+	cas, err := tls.ParseClientCAs(ca)
+	if err != nil {
+		return errors.New("invalid client CA").Base(err)
+	}
+	config.ClientCa = ca
+
+	if c.ClientCRL != nil || c.ClientCRLFile != "" {
+		var crlStr []string
+		if c.ClientCRL != nil {
+			crlStr = *c.ClientCRL
+		}
+		crl, err := readFileOrString(c.ClientCRLFile, crlStr)
+		if err != nil {
+			return errors.New("failed to read client CRL").Base(err)
+		}
+		if _, err := tls.ParseClientCRL(crl, cas); err != nil {
+			return err
+		}
+		config.ClientCrl = [][]byte{crl}
+	}
+
+	for _, u := range c.ClientUsers {
+		user, err := u.Build()
+		if err != nil {
+			return err
+		}
+		config.ClientUsers = append(config.ClientUsers, user)
+	}
+	return nil
+}
+
+type ACMEConfig struct {
+	DirectoryURL    string      `json:"directoryUrl"`
+	Email           string      `json:"email"`
+	Domains         *StringList `json:"domains"`
+	StoragePath     string      `json:"storagePath"`
+	Challenge       string      `json:"challenge"`
+	HTTP01Listen    string      `json:"http01Listen"`
+	DNS01Hook       string      `json:"dns01Hook"`
+	RenewBeforeDays uint32      `json:"renewBeforeDays"`
+}
+
+// Build builds the ACME config, whose domains default to serverName.
+func (c *ACMEConfig) Build(serverName string) (*tls.AcmeConfig, error) {
+	config := &tls.AcmeConfig{
+		DirectoryUrl: c.DirectoryURL,
+		Email:        c.Email,
+		StoragePath:  c.StoragePath,
+		Http01Listen: c.HTTP01Listen,
+		Dns01Hook:    c.DNS01Hook,
+		RenewBefore:  uint64(c.RenewBeforeDays) * 24 * 3600,
+	}
+	if c.Domains != nil {
+		config.Domains = []string(*c.Domains)
+	} else if serverName != "" {
+		config.Domains = []string{serverName}
+	}
+	if len(config.Domains) == 0 {
+		return nil, errors.New(`"acme" requires "domains" or "serverName"`)
+	}
+	if config.StoragePath == "" {
+		config.StoragePath = platform.GetAssetLocation("acme")
+	}
+
+	switch strings.ToLower(c.Challenge) {
+	case "", "http-01", "http01":
+		config.Challenge = tls.AcmeConfig_HTTP01
+	case "tls-alpn-01", "tlsalpn01":
+		config.Challenge = tls.AcmeConfig_TLS_ALPN01
+	case "dns-01", "dns01":
+		config.Challenge = tls.AcmeConfig_DNS01
+		if c.DNS01Hook == "" {
+			return nil, errors.New(`"dns01Hook" is required by the dns-01 challenge`)
+		}
+	default:
+		return nil, errors.New("unknown ACME challenge: ", c.Challenge)
+	}
+	if config.Challenge != tls.AcmeConfig_DNS01 {
+		for _, domain := range config.Domains {
+			if strings.HasPrefix(domain, "*.") {
+				return nil, errors.New("wildcard domain ", domain, " requires the dns-01 challenge")
+			}
+		}
+	}
+	return config, nil
+}
+
+// decodeECH decodes ECH keys or configs, in PEM as printed by "xray tls ech", or in base64.
+func decodeECH(data []byte, pemType string) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != pemType {
+			return nil, errors.New("expect a PEM block of ", pemType)
+		}
+		return block.Bytes, nil
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+}
+
+// buildECH fills the ECH fields of config.
+func (c *TLSConfig) buildECH(config *tls.Config) error {
+	if c.ECHServerKeys != nil || c.ECHServerKeysFile != "" {
+		var keyStr []string
+		if c.ECHServerKeys != nil {
+			keyStr = *c.ECHServerKeys
+		}
+		data, err := readFileOrString(c.ECHServerKeysFile, keyStr)
+		if err != nil {
+			return errors.New("failed to read ECH server keys").Base(err)
+		}
+		if data, err = decodeECH(data, "ECH KEYS"); err != nil {
+			return errors.New("invalid ECH server keys").Base(err)
+		}
+		if _, err := tls.ParseECHKeys(data); err != nil {
+			return err
+		}
+		config.EchServerKeys = data
+	}
+
+	if c.ECHConfigList != nil || c.ECHConfigListFile != "" {
+		if c.ECHConfigDomain != "" {
+			return errors.New(`"echConfigList" and "echConfigDomain" can't be used together`)
+		}
+		var configStr []string
+		if c.ECHConfigList != nil {
+			configStr = *c.ECHConfigList
+		}
+		data, err := readFileOrString(c.ECHConfigListFile, configStr)
+		if err != nil {
+			return errors.New("failed to read ECHConfigList").Base(err)
+		}
+		if data, err = decodeECH(data, "ECH CONFIGS"); err != nil {
+			return errors.New("invalid ECHConfigList").Base(err)
+		}
+		if config.EchConfigList, err = tls.ParseECHConfigList(data); err != nil {
+			return err
+		}
+	}
+	config.EchConfigDomain = c.ECHConfigDomain
+
+	if len(config.EchServerKeys) == 0 && len(config.EchConfigList) == 0 && config.EchConfigDomain == "" {
+		return nil
+	}
+	if (c.MinVersion != "" && c.MinVersion != "1.3") || (c.MaxVersion != "" && c.MaxVersion != "1.3") {
+		return errors.New("ECH requires TLS 1.3")
+	}
+	if (len(config.EchConfigList) > 0 || config.EchConfigDomain != "") && config.Fingerprint != "" && config.Fingerprint != "unsafe" {
+		// ClientHello of uTLS can't be encrypted yet.
+		return errors.New(`"fingerprint" can't be used with ECH`)
+	}
+	if (len(config.EchConfigList) > 0 || config.EchConfigDomain != "") && config.Fingerprint == "" {
+		errors.LogWarning(context.Background(), `ECH is used with the TLS fingerprint of Go instead of the default "chrome", as ClientHello of uTLS can't be encrypted yet`)
+	}
+	return nil
 }
 
 // Build implements Buildable.
@@ -483,6 +732,22 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 	}
 	config.VerifyPeerCertInNames = c.VerifyPeerCertInNames
 
+	if err := c.buildECH(config); err != nil {
+		return nil, err
+	}
+
+	if err := c.buildClientAuth(config); err != nil {
+		return nil, err
+	}
+
+	if c.ACME != nil {
+		acme, err := c.ACME.Build(serverName)
+		if err != nil {
+			return nil, err
+		}
+		config.Acme = acme
+	}
+
 	return config, nil
 }
 
@@ -716,6 +981,23 @@ type SocketConfig struct {
 	TcpMptcp             bool                   `json:"tcpMptcp"`
 	CustomSockopt        []*CustomSockoptConfig `json:"customSockopt"`
 	AddressPortStrategy  string                 `json:"addressPortStrategy"`
+	HappyEyeballs        *HappyEyeballsConfig   `json:"happyEyeballs"`
+}
+
+type HappyEyeballsConfig struct {
+	TryDelayMs       uint64 `json:"tryDelayMs"`
+	MaxConcurrentTry uint32 `json:"maxConcurrentTry"`
+}
+
+// Build implements Buildable.
+func (c *HappyEyeballsConfig) Build() *internet.HappyEyeballsConfig {
+	if c == nil {
+		return nil
+	}
+	return &internet.HappyEyeballsConfig{
+		TryDelayMs:       c.TryDelayMs,
+		MaxConcurrentTry: c.MaxConcurrentTry,
+	}
 }
 
 // Build implements Buildable.
@@ -824,6 +1106,7 @@ func (c *SocketConfig) Build() (*internet.SocketConfig, error) {
 		TcpMptcp:             c.TcpMptcp,
 		CustomSockopt:        customSockopts,
 		AddressPortStrategy:  addressPortStrategy,
+		HappyEyeballs:        c.HappyEyeballs.Build(),
 	}, nil
 }
 
@@ -872,6 +1155,10 @@ func (c *StreamConfig) Build() (*internet.StreamConfig, error) {
 		if err != nil {
 			return nil, errors.New("Failed to build TLS config.").Base(err)
 		}
+		if len(ts.(*tls.Config).ClientUsers) > 0 && config.ProtocolName != "tcp" && config.ProtocolName != "mkcp" && config.ProtocolName != "httpupgrade" {
+			// Connections of the other transports don't carry the TLS connection to proxies.
+			return nil, errors.New(`"clientUsers" only supports RAW, mKCP and HTTPUpgrade for now.`)
+		}
 		tm := serial.ToTypedMessage(ts)
 		config.SecuritySettings = append(config.SecuritySettings, tm)
 		config.SecurityType = tm.Type
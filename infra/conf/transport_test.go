@@ -1,11 +1,17 @@
 package conf_test
 
 import (
+	"bytes"
+	"crypto/x509"
 	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/protocol/tls/cert"
 	. "github.com/HZ-PRE/XrarCore/infra/conf"
 	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -149,3 +155,167 @@ func TestSocketConfig(t *testing.T) {
 		t.Fatalf("unexpected parsed TFO value, which should be -1")
 	}
 }
+
+func TestTLSACMEConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(TLSConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"serverName": "example.com",
+				"acme": {
+					"email": "admin@example.com",
+					"storagePath": "/var/lib/xray/acme",
+					"http01Listen": ":80",
+					"renewBeforeDays": 20
+				}
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				ServerName:  "example.com",
+				Certificate: []*tls.Certificate{},
+				Acme: &tls.AcmeConfig{
+					Email:        "admin@example.com",
+					Domains:      []string{"example.com"},
+					StoragePath:  "/var/lib/xray/acme",
+					Http01Listen: ":80",
+					RenewBefore:  20 * 24 * 3600,
+				},
+			},
+		},
+		{
+			Input: `{
+				"acme": {
+					"directoryUrl": "https://acme.example.com/directory",
+					"domains": ["*.example.com"],
+					"storagePath": "/var/lib/xray/acme",
+					"challenge": "dns-01",
+					"dns01Hook": "/usr/local/bin/dns-hook"
+				}
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				Certificate: []*tls.Certificate{},
+				Acme: &tls.AcmeConfig{
+					DirectoryUrl: "https://acme.example.com/directory",
+					Domains:      []string{"*.example.com"},
+					StoragePath:  "/var/lib/xray/acme",
+					Challenge:    tls.AcmeConfig_DNS01,
+					Dns01Hook:    "/usr/local/bin/dns-hook",
+				},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"acme": {}}`,
+		`{"acme": {"domains": ["*.example.com"]}}`,
+		`{"acme": {"domains": "example.com", "challenge": "dns-01"}}`,
+		`{"acme": {"domains": "example.com", "challenge": "tls-sni-01"}}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for ", input)
+		}
+	}
+}
+
+func TestTLSClientAuthConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(TLSConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	caPEM, _ := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign)).ToPEM()
+	caLines := strings.Split(strings.TrimSpace(string(caPEM)), "\n")
+	caJSON, _ := json.Marshal(caLines)
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"clientCa": ` + string(caJSON) + `,
+				"clientUsers": [
+					{"email": "device1@example.com", "commonName": "device1"},
+					{"email": "device2@example.com", "san": "device2.example.com", "fingerprint": "` + strings.Repeat("AB:", 31) + `AB"}
+				]
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				Certificate: []*tls.Certificate{},
+				ClientAuth:  tls.Config_REQUIRE_AND_VERIFY,
+				ClientCa:    []byte(strings.Join(caLines, "\n")),
+				ClientUsers: []*tls.ClientCertificateUser{
+					{Email: "device1@example.com", CommonName: "device1"},
+					{Email: "device2@example.com", San: "device2.example.com", Fingerprint: bytes.Repeat([]byte{0xab}, 32)},
+				},
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"clientAuth": "required"}`,
+		`{"clientAuth": "sometimes", "clientCa": ` + string(caJSON) + `}`,
+		`{"clientUsers": [{"email": "device1@example.com", "commonName": "device1"}]}`,
+		`{"clientCa": ` + string(caJSON) + `, "clientUsers": [{"email": "device1@example.com"}]}`,
+		`{"clientCa": ` + string(caJSON) + `, "clientUsers": [{"email": "device1@example.com", "fingerprint": "abcd"}]}`,
+	} {
+		if _, err := createParser()(input); err == nil {
+			t.Error("expect error for ", input)
+		}
+	}
+
+	// Only transports that pass TLS connections to proxies can map client certificates to users.
+	tlsSettings := `{"clientCa": ` + string(caJSON) + `, "clientUsers": [{"email": "device1@example.com", "commonName": "device1"}]}`
+	for network, supported := range map[string]bool{
+		"raw": true, "mkcp": true, "httpupgrade": true,
+		"ws": false, "grpc": false, "xhttp": false,
+	} {
+		config := new(StreamConfig)
+		common.Must(json.Unmarshal([]byte(`{"network": "`+network+`", "security": "tls", "tlsSettings": `+tlsSettings+`}`), config))
+		if _, err := config.Build(); (err == nil) != supported {
+			t.Error("unexpected result of clientUsers with ", network, ": ", err)
+		}
+	}
+}
+
+func TestTLSECHConfig(t *testing.T) {
+	build := func(s string) (proto.Message, error) {
+		config := new(TLSConfig)
+		if err := json.Unmarshal([]byte(s), config); err != nil {
+			return nil, err
+		}
+		return config.Build()
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input:  `{"echConfigDomain": "example.com"}`,
+			Parser: build,
+			Output: &tls.Config{
+				Certificate:     []*tls.Certificate{},
+				EchConfigDomain: "example.com",
+			},
+		},
+	})
+
+	for _, input := range []string{
+		`{"echConfigDomain": "example.com", "fingerprint": "chrome"}`,
+		`{"echConfigDomain": "example.com", "maxVersion": "1.2"}`,
+	} {
+		if _, err := build(input); err == nil {
+			t.Error("expect error for ", input)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/protocol"
 	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/proxy"
 	"github.com/HZ-PRE/XrarCore/proxy/trojan"
 	"google.golang.org/protobuf/proto"
 )
@@ -81,6 +82,7 @@ type TrojanInboundFallback struct {
 	Type string          `json:"type"`
 	Dest json.RawMessage `json:"dest"`
 	Xver uint64          `json:"xver"`
+	TLVs []string        `json:"proxyProtocolTlvs"`
 }
 
 // TrojanUserConfig is user configuration
@@ -132,6 +134,8 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 			Type: fb.Type,
 			Dest: s,
 			Xver: fb.Xver,
+
+			ProxyProtocolTlvs: fb.TLVs,
 		})
 	}
 	for _, fb := range config.Fallbacks {
@@ -168,6 +172,14 @@ func (c *TrojanServerConfig) Build() (proto.Message, error) {
 		if fb.Xver > 2 {
 			return nil, errors.New(`Trojan fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
 		}
+		if len(fb.ProxyProtocolTlvs) > 0 {
+			if fb.Xver != 2 {
+				return nil, errors.New(`Trojan fallbacks: "proxyProtocolTlvs" requires "xver" 2`)
+			}
+			if err := proxy.ValidateProxyProtocolTLVs(fb.ProxyProtocolTlvs); err != nil {
+				return nil, errors.New(`Trojan fallbacks: invalid "proxyProtocolTlvs"`).Base(err)
+			}
+		}
 	}
 
 	return config, nil
@@ -0,0 +1,280 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/proxy/vless"
+)
+
+// rawField is a raw JSON field, which is decoded into other types in Build().
+type rawField struct {
+	// types are the types that the field is decoded into, all at the same time, so keys are known if
+	// any of the types has them.
+	types []reflect.Type
+	// loader decodes the field into the type with the ID in key, of the object that has the field if
+	// sibling is set, or of the field itself otherwise.
+	loader  *JSONConfigLoader
+	key     string
+	sibling bool
+}
+
+// typeKey is the "type" key of configs loaded by JSONConfigLoader without configKey.
+type typeKey struct {
+	Type string `json:"type"`
+}
+
+func rawTypes(values ...interface{}) *rawField {
+	types := make([]reflect.Type, len(values))
+	for i, v := range values {
+		types[i] = reflect.TypeOf(v)
+	}
+	return &rawField{types: types}
+}
+
+// loaderTypes returns the types of the config with the ID, or nil if the ID is unknown.
+func (f *rawField) loaderTypes(id string) []reflect.Type {
+	creator, found := f.loader.cache[strings.ToLower(id)]
+	if !found {
+		return nil
+	}
+	types := []reflect.Type{reflect.TypeOf(creator())}
+	if !f.sibling {
+		types = append(types, reflect.TypeOf(typeKey{}))
+	}
+	return types
+}
+
+// typesOf returns the types that value of the field is decoded into, with the object that has the
+// field. nil means that it's not checked.
+func (f *rawField) typesOf(parent map[string]interface{}, value interface{}) []reflect.Type {
+	if f.loader == nil {
+		return f.types
+	}
+	obj := parent
+	if !f.sibling {
+		obj, _ = value.(map[string]interface{})
+	}
+	id, _ := lookupKey(obj, f.key).(string)
+	return f.loaderTypes(id)
+}
+
+// rawFields are the raw JSON fields, keyed by the types and names of the fields.
+var rawFields = map[reflect.Type]map[string]*rawField{
+	reflect.TypeOf(InboundDetourConfig{}):  {"Settings": {loader: inboundConfigLoader, key: "protocol", sibling: true}},
+	reflect.TypeOf(OutboundDetourConfig{}): {"Settings": {loader: outboundConfigLoader, key: "protocol", sibling: true}},
+	reflect.TypeOf(StrategyConfig{}):       {"Settings": {loader: strategyConfigLoader, key: "type", sibling: true}},
+	reflect.TypeOf(TCPConfig{}):            {"HeaderConfig": {loader: tcpHeaderLoader, key: "type"}},
+	reflect.TypeOf(KCPConfig{}):            {"HeaderConfig": {loader: kcpHeaderLoader, key: "type"}},
+	reflect.TypeOf(BlackholeConfig{}):      {"Response": {loader: configLoader, key: "type"}},
+	reflect.TypeOf(SplitHTTPConfig{}):      {"Extra": rawTypes(SplitHTTPConfig{})},
+	reflect.TypeOf(RouterConfig{}):         {"RuleList": rawTypes(fieldRule{})},
+	reflect.TypeOf(VLessInboundConfig{}):   {"Clients": rawTypes(protocol.User{}, vless.Account{})},
+	reflect.TypeOf(VLessOutboundVnext{}):   {"Users": rawTypes(protocol.User{}, vless.Account{})},
+	reflect.TypeOf(VMessInboundConfig{}):   {"Users": rawTypes(protocol.User{}, VMessAccount{})},
+	reflect.TypeOf(VMessOutboundTarget{}):  {"Users": rawTypes(protocol.User{}, VMessAccount{})},
+	reflect.TypeOf(SocksRemoteConfig{}):    {"Users": rawTypes(protocol.User{}, SocksAccount{})},
+	reflect.TypeOf(HTTPRemoteConfig{}):     {"Users": rawTypes(protocol.User{}, HTTPAccount{})},
+}
+
+// customTypes are the types with custom UnmarshalJSON, which are decoded into other types.
+var customTypes = map[reflect.Type]*rawField{
+	reflect.TypeOf(FakeDNSConfig{}): rawTypes(FakeDNSPoolElementConfig{}),
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// FindUnknownFields returns the JSON paths of the keys in the JSON config, which are unknown and
+// ignored by the decoder, such as "inbounds[0].streamSetings". Keys are matched case-insensitively,
+// the same as the decoder.
+func FindUnknownFields(data []byte) ([]string, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	f := new(unknownFieldsFinder)
+	f.value("", v, reflect.TypeOf(Config{}))
+	sort.Strings(f.paths)
+	return f.paths, nil
+}
+
+type unknownFieldsFinder struct {
+	paths []string
+}
+
+func (f *unknownFieldsFinder) value(path string, v interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if raw, found := customTypes[t]; found {
+		f.union(path, v, raw.typesOf(nil, v))
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		// Objects are decoded into the fields of structs like NameServerConfig, which are strings or
+		// objects. Others are not checked.
+		if obj, ok := v.(map[string]interface{}); ok && t.Kind() == reflect.Struct && hasJSONFields(t) {
+			f.object(path, obj, []reflect.Type{t})
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if obj, ok := v.(map[string]interface{}); ok {
+			f.object(path, obj, []reflect.Type{t})
+		}
+	case reflect.Map:
+		if obj, ok := v.(map[string]interface{}); ok {
+			for k, e := range obj {
+				f.value(joinPath(path, k), e, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := v.([]interface{}); ok {
+			for i, e := range list {
+				f.value(path+"["+strconv.Itoa(i)+"]", e, t.Elem())
+			}
+		}
+	}
+}
+
+// union checks v, or each element of v if it's an array, against all the types.
+func (f *unknownFieldsFinder) union(path string, v interface{}, types []reflect.Type) {
+	if len(types) == 0 {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		f.object(path, v, types)
+	case []interface{}:
+		for i, e := range v {
+			f.union(path+"["+strconv.Itoa(i)+"]", e, types)
+		}
+	}
+}
+
+func (f *unknownFieldsFinder) object(path string, obj map[string]interface{}, types []reflect.Type) {
+	for k, v := range obj {
+		p := joinPath(path, k)
+		found := false
+		for _, t := range types {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			field, ok := lookupField(t, k)
+			if !ok {
+				continue
+			}
+			found = true
+			if raw, ok := rawFields[field.owner][field.Name]; ok {
+				f.union(p, v, raw.typesOf(obj, v))
+			} else {
+				f.value(p, v, field.Type)
+			}
+			break
+		}
+		if !found {
+			f.paths = append(f.paths, p)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// lookupKey returns the value of key in obj, matched the same as the decoder.
+func lookupKey(obj map[string]interface{}, key string) interface{} {
+	if v, found := obj[key]; found {
+		return v
+	}
+	for k, v := range obj {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+type jsonField struct {
+	reflect.StructField
+	name string
+	// owner is the struct that has the field, which differs from the type looked up for
+	// fields of embedded structs.
+	owner reflect.Type
+}
+
+var jsonFieldsCache sync.Map
+
+// jsonFields returns the fields of struct t in JSON, including those of embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	if fields, found := jsonFieldsCache.Load(t); found {
+		return fields.([]jsonField)
+	}
+	var fields, embedded []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Fields of embedded structs are shadowed by those of the outer struct.
+				embedded = append(embedded, jsonFields(ft)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, jsonField{StructField: sf, name: name, owner: t})
+	}
+	fields = append(fields, embedded...)
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+func hasJSONFields(t reflect.Type) bool {
+	for _, field := range jsonFields(t) {
+		if _, found := field.Tag.Lookup("json"); found {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupField(t reflect.Type, key string) (jsonField, bool) {
+	if t.Kind() != reflect.Struct {
+		return jsonField{}, false
+	}
+	fields := jsonFields(t)
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
@@ -0,0 +1,69 @@
+package conf_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/HZ-PRE/XrarCore/infra/conf"
+)
+
+func TestFindUnknownFields(t *testing.T) {
+	paths, err := FindUnknownFields([]byte(`{
+		"log": {"loglevel": "info", "logLevel": "debug", "acessLog": "/var/log/xray.log"},
+		"dns": {"servers": ["1.1.1.1", {"address": "8.8.8.8", "domain": ["example.com"]}]},
+		"fakedns": [{"ipPool": "198.18.0.0/15", "poolsize": 65535, "size": 1}],
+		"routing": {
+			"rules": [{"type": "field", "outboundTag": "direct", "domains": ["example.com"], "ips": ["geoip:private"]}],
+			"balancers": [{"tag": "b", "selector": ["p-"], "strategy": {"type": "leastLoad", "settings": {"expected": 2, "expect": 2}}}]
+		},
+		"inbounds": [{
+			"protocol": "vless",
+			"port": 443,
+			"settings": {
+				"clients": [{"id": "b831381d-6324-4d53-ad4f-8cda48b30811", "email": "alice", "flow": "xtls-rprx-vision", "flwo": ""}],
+				"decryption": "none",
+				"fallbacks": [{"dest": 80, "xver": 1}]
+			},
+			"streamSetings": {"network": "raw"}
+		}, {
+			"protocol": "unknown",
+			"settings": {"foo": "bar"}
+		}],
+		"outbounds": [{
+			"protocol": "blackhole",
+			"settings": {"response": {"type": "http", "body": "", "bodi": ""}}
+		}, {
+			"protocol": "freedom",
+			"streamSettings": {
+				"network": "raw",
+				"rawSettings": {"header": {"type": "http", "request": {"path": ["/"]}, "respone": {}}},
+				"xhttpSettings": {"path": "/", "extra": {"noGRPCHeader": true, "noGrpcHeaders": true}},
+				"realitySettings": {"target": {"anything": 1}}
+			}
+		}],
+		"foo": {"bar": 1}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"dns.servers[1].domain",
+		"fakedns[0].size",
+		"foo",
+		"inbounds[0].settings.clients[0].flwo",
+		"inbounds[0].streamSetings",
+		"log.acessLog",
+		"outbounds[0].settings.response.bodi",
+		"outbounds[1].streamSettings.rawSettings.header.respone",
+		"outbounds[1].streamSettings.xhttpSettings.extra.noGrpcHeaders",
+		"routing.balancers[0].strategy.settings.expect",
+		"routing.rules[0].ips",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected unknown fields: %q, expected: %q", paths, expected)
+	}
+
+	if _, err := FindUnknownFields([]byte(`{`)); err == nil {
+		t.Error("expected error of invalid JSON")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/common/protocol"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/common/uuid"
+	"github.com/HZ-PRE/XrarCore/proxy"
 	"github.com/HZ-PRE/XrarCore/proxy/vless"
 	"github.com/HZ-PRE/XrarCore/proxy/vless/inbound"
 	"github.com/HZ-PRE/XrarCore/proxy/vless/outbound"
@@ -26,6 +27,7 @@ type VLessInboundFallback struct {
 	Type string          `json:"type"`
 	Dest json.RawMessage `json:"dest"`
 	Xver uint64          `json:"xver"`
+	TLVs []string        `json:"proxyProtocolTlvs"`
 }
 
 type VLessInboundConfig struct {
@@ -88,6 +90,8 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 			Type: fb.Type,
 			Dest: s,
 			Xver: fb.Xver,
+
+			ProxyProtocolTlvs: fb.TLVs,
 		})
 	}
 	for _, fb := range config.Fallbacks {
@@ -124,6 +128,14 @@ func (c *VLessInboundConfig) Build() (proto.Message, error) {
 		if fb.Xver > 2 {
 			return nil, errors.New(`VLESS fallbacks: invalid PROXY protocol version, "xver" only accepts 0, 1, 2`)
 		}
+		if len(fb.ProxyProtocolTlvs) > 0 {
+			if fb.Xver != 2 {
+				return nil, errors.New(`VLESS fallbacks: "proxyProtocolTlvs" requires "xver" 2`)
+			}
+			if err := proxy.ValidateProxyProtocolTLVs(fb.ProxyProtocolTlvs); err != nil {
+				return nil, errors.New(`VLESS fallbacks: invalid "proxyProtocolTlvs"`).Base(err)
+			}
+		}
 	}
 
 	return config, nil
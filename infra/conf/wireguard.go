@@ -16,6 +16,8 @@ type WireGuardPeerConfig struct {
 	Endpoint     string   `json:"endpoint"`
 	KeepAlive    uint32   `json:"keepAlive"`
 	AllowedIPs   []string `json:"allowedIPs,omitempty"`
+	Email        string   `json:"email"`
+	Level        uint32   `json:"level"`
 }
 
 func (c *WireGuardPeerConfig) Build() (proto.Message, error) {
@@ -44,6 +46,8 @@ func (c *WireGuardPeerConfig) Build() (proto.Message, error) {
 	} else {
 		config.AllowedIps = c.AllowedIPs
 	}
+	config.Email = c.Email
+	config.Level = c.Level
 
 	return config, nil
 }
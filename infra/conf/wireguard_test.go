@@ -21,6 +21,12 @@ func TestWireGuardConfig(t *testing.T) {
 					{
 						"publicKey": "6e65ce0be17517110c17d77288ad87e7fd5252dcc7d09b95a39d61db03df832a",
 						"endpoint": "127.0.0.1:1234"
+					},
+					{
+						"publicKey": "6e65ce0be17517110c17d77288ad87e7fd5252dcc7d09b95a39d61db03df832b",
+						"allowedIPs": ["10.1.1.2/32"],
+						"email": "love@example.com",
+						"level": 1
 					}
 				],
 				"mtu": 1300,
@@ -41,6 +47,12 @@ func TestWireGuardConfig(t *testing.T) {
 						KeepAlive:  0,
 						AllowedIps: []string{"0.0.0.0/0", "::0/0"},
 					},
+					{
+						PublicKey:  "6e65ce0be17517110c17d77288ad87e7fd5252dcc7d09b95a39d61db03df832b",
+						AllowedIps: []string{"10.1.1.2/32"},
+						Email:      "love@example.com",
+						Level:      1,
+					},
 				},
 				Mtu:            1300,
 				NumWorkers:     2,
@@ -119,6 +119,19 @@ func (m *MuxConfig) Build() (*proxyman.MultiplexingConfig, error) {
 	}, nil
 }
 
+type ConnectionPoolConfig struct {
+	Size        uint32 `json:"size"`
+	IdleTimeout uint32 `json:"idleTimeout"`
+}
+
+// Build implements Buildable.
+func (c *ConnectionPoolConfig) Build() (*proxyman.ConnectionPoolConfig, error) {
+	return &proxyman.ConnectionPoolConfig{
+		Size:        c.Size,
+		IdleTimeout: c.IdleTimeout,
+	}, nil
+}
+
 type InboundDetourAllocationConfig struct {
 	Strategy    string  `json:"strategy"`
 	Concurrency *uint32 `json:"concurrency"`
@@ -259,13 +272,14 @@ func (c *InboundDetourConfig) Build() (*core.InboundHandlerConfig, error) {
 }
 
 type OutboundDetourConfig struct {
-	Protocol      string           `json:"protocol"`
-	SendThrough   *string          `json:"sendThrough"`
-	Tag           string           `json:"tag"`
-	Settings      *json.RawMessage `json:"settings"`
-	StreamSetting *StreamConfig    `json:"streamSettings"`
-	ProxySettings *ProxyConfig     `json:"proxySettings"`
-	MuxSettings   *MuxConfig       `json:"mux"`
+	Protocol       string                `json:"protocol"`
+	SendThrough    *string               `json:"sendThrough"`
+	Tag            string                `json:"tag"`
+	Settings       *json.RawMessage      `json:"settings"`
+	StreamSetting  *StreamConfig         `json:"streamSettings"`
+	ProxySettings  *ProxyConfig          `json:"proxySettings"`
+	MuxSettings    *MuxConfig            `json:"mux"`
+	ConnectionPool *ConnectionPoolConfig `json:"connectionPool"`
 }
 
 func (c *OutboundDetourConfig) checkChainProxyConfig() error {
@@ -336,6 +350,20 @@ func (c *OutboundDetourConfig) Build() (*core.OutboundHandlerConfig, error) {
 		senderSettings.MultiplexSettings = ms
 	}
 
+	if c.ConnectionPool != nil && c.ConnectionPool.Size > 0 {
+		if senderSettings.ProxySettings.HasTag() {
+			return nil, errors.New("connectionPool doesn't work with proxySettings.tag")
+		}
+		if c.SendThrough != nil && *c.SendThrough == "origin" {
+			return nil, errors.New("connectionPool doesn't work with sendThrough origin")
+		}
+		cp, err := c.ConnectionPool.Build()
+		if err != nil {
+			return nil, errors.New("failed to build connection pool config.").Base(err)
+		}
+		senderSettings.ConnectionPool = cp
+	}
+
 	settings := []byte("{}")
 	if c.Settings != nil {
 		settings = ([]byte)(*c.Settings)
@@ -381,6 +409,7 @@ type Config struct {
 	FakeDNS          *FakeDNSConfig          `json:"fakeDns"`
 	Observatory      *ObservatoryConfig      `json:"observatory"`
 	BurstObservatory *BurstObservatoryConfig `json:"burstObservatory"`
+	Providers        SubscriptionConfig      `json:"providers"`
 }
 
 func (c *Config) findInboundTag(tag string) int {
@@ -449,6 +478,10 @@ func (c *Config) Override(o *Config, fn string) {
 		c.BurstObservatory = o.BurstObservatory
 	}
 
+	if o.Providers != nil {
+		c.Providers = o.Providers
+	}
+
 	// update the Inbound in slice if the only one in override config has same tag
 	if len(o.InboundConfigs) > 0 {
 		for i := range o.InboundConfigs {
@@ -589,6 +622,14 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
+	if len(c.Providers) > 0 {
+		r, err := c.Providers.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 
 	if len(c.InboundConfigs) > 0 {
@@ -21,8 +21,11 @@ var CmdAPI = &base.Command{
 		cmdAddOutbounds,
 		cmdRemoveInbounds,
 		cmdRemoveOutbounds,
+		cmdListInbounds,
+		cmdListOutbounds,
 		cmdInboundUser,
 		cmdInboundUserCount,
+		cmdUsers,
 		cmdAddRules,
 		cmdRemoveRules,
 		cmdSourceIpBlock,
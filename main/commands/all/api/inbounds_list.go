@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	handlerService "github.com/HZ-PRE/XrarCore/app/proxyman/command"
+	"github.com/HZ-PRE/XrarCore/infra/conf/decompile"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdListInbounds = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api lsi [--server=127.0.0.1:8080]",
+	Short:       "List inbounds",
+	Long: `
+List the inbounds of Xray as a json config, including the ones added via
+the API and the users altered after the inbounds are created.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 > inbounds.json
+`,
+	Run: executeListInbounds,
+}
+
+func executeListInbounds(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := handlerService.NewHandlerServiceClient(conn)
+	resp, err := client.ListInbounds(ctx, &handlerService.ListInboundsRequest{})
+	if err != nil {
+		base.Fatalf("failed to list inbounds: %s", err)
+	}
+
+	d := &decompile.Decompiler{}
+	inbounds := make([]interface{}, 0, len(resp.Inbounds))
+	for _, in := range resp.Inbounds {
+		v, err := d.Inbound(in)
+		if err != nil {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("skipped inbound %s: %s", in.Tag, err))
+			continue
+		}
+		inbounds = append(inbounds, v)
+	}
+	showDecompiled(d, map[string]interface{}{"inbounds": inbounds})
+}
+
+// showDecompiled prints the decompiled config, with the warnings printed to stderr.
+func showDecompiled(d *decompile.Decompiler, v interface{}) {
+	for _, w := range d.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal json: %s", err)
+	}
+	fmt.Println(string(b))
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+
+	handlerService "github.com/HZ-PRE/XrarCore/app/proxyman/command"
+	"github.com/HZ-PRE/XrarCore/infra/conf/decompile"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdListOutbounds = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api lso [--server=127.0.0.1:8080]",
+	Short:       "List outbounds",
+	Long: `
+List the outbounds of Xray as a json config, including the ones added via
+the API.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 > outbounds.json
+`,
+	Run: executeListOutbounds,
+}
+
+func executeListOutbounds(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	cmd.Flag.Parse(args)
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+
+	client := handlerService.NewHandlerServiceClient(conn)
+	resp, err := client.ListOutbounds(ctx, &handlerService.ListOutboundsRequest{})
+	if err != nil {
+		base.Fatalf("failed to list outbounds: %s", err)
+	}
+
+	d := &decompile.Decompiler{}
+	outbounds := make([]interface{}, 0, len(resp.Outbounds))
+	for _, out := range resp.Outbounds {
+		v, err := d.Outbound(out)
+		if err != nil {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("skipped outbound %s: %s", out.Tag, err))
+			continue
+		}
+		outbounds = append(outbounds, v)
+	}
+	showDecompiled(d, map[string]interface{}{"outbounds": outbounds})
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdUsers = &base.Command{
+	UsageLine: "{{.Exec}} api users",
+	Short:     "Manage users of inbounds",
+	Long: `{{.Exec}} {{.LongName}} provides tools to manage users of inbounds.
+`,
+	Commands: []*base.Command{
+		cmdUsersSync,
+	},
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	handlerService "github.com/HZ-PRE/XrarCore/app/proxyman/command"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/decompile"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+	"google.golang.org/protobuf/proto"
+)
+
+var cmdUsersSync = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api users sync [--server=127.0.0.1:8080] -inbound=tag [-dry-run] [-json] <users file>",
+	Short:       "Sync users of an inbound to a list",
+	Long: `
+Sync the users of an inbound to the users in a file, with the fewest
+operations: users missing from the inbound are added, users missing from
+the file are removed, and users with changed settings are replaced. Users
+are matched by email, which is required for every user.
+
+Users are replaced by removing and then adding them, which is not atomic:
+they are missing from the inbound in between, and are left removed if they
+can't be added back.
+
+The file contains the users in the same format as the "clients" of the
+inbound settings, either as an array or as an object with "clients". If any
+operation fails, the applied operations are rolled back, and the users that
+can't be restored are reported.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for each API call. Default 3
+
+	-inbound <tag>
+		Inbound tag
+
+	-dry-run
+		Report the changes without applying them.
+
+	-json
+		Report the changes in json.
+
+Example:
+
+	{{.Exec}} {{.LongName}} -inbound=vless-in -dry-run users.json
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -timeout=30 -inbound=vless-in users.json
+`,
+	Run: executeUsersSync,
+}
+
+// userChanges are the operations to sync the users of an inbound.
+type userChanges struct {
+	Added     []*protocol.User
+	Removed   []*protocol.User
+	Updated   [][2]*protocol.User // old and new users
+	Unchanged int
+}
+
+func executeUsersSync(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	var tag string
+	var dryRun bool
+	cmd.Flag.StringVar(&tag, "inbound", "", "")
+	cmd.Flag.BoolVar(&dryRun, "dry-run", false, "")
+	cmd.Flag.Parse(args)
+
+	if tag == "" {
+		base.Fatalf("an inbound tag is required")
+	}
+	if cmd.Flag.NArg() != 1 {
+		base.Fatalf("a users file is required")
+	}
+	clients, err := loadClients(cmd.Flag.Arg(0))
+	if err != nil {
+		base.Fatalf("failed to load users: %s", err)
+	}
+
+	conn, _, close := dialAPIServer()
+	defer close()
+
+	// Each call has its own deadline, as syncing many users takes many calls.
+	timeout := time.Duration(apiTimeout) * time.Second
+	client := handlerService.NewHandlerServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	inbounds, err := client.ListInbounds(ctx, &handlerService.ListInboundsRequest{})
+	if err != nil {
+		base.Fatalf("failed to list inbounds: %s", err)
+	}
+	var inbound *core.InboundHandlerConfig
+	for _, in := range inbounds.Inbounds {
+		if in.Tag == tag {
+			inbound = in
+			break
+		}
+	}
+	if inbound == nil {
+		base.Fatalf("inbound not found: %s", tag)
+	}
+	desired, err := buildUsers(inbound, clients)
+	if err != nil {
+		base.Fatalf("failed to build users: %s", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := client.GetInboundUsers(ctx, &handlerService.GetInboundUserRequest{Tag: tag})
+	if err != nil {
+		base.Fatalf("failed to get inbound users: %s", err)
+	}
+	changes, err := diffUsers(resp.Users, desired)
+	if err != nil {
+		base.Fatalf("%s", err)
+	}
+
+	if !dryRun {
+		if err := applyUsers(client, tag, changes, timeout); err != nil {
+			base.Fatalf("failed to sync users: %s", err)
+		}
+	}
+	showUserChanges(changes, dryRun)
+}
+
+// loadClients loads the users in the format of the "clients" of inbound settings.
+func loadClients(arg string) ([]json.RawMessage, error) {
+	r, err := loadArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var clients []json.RawMessage
+	if err := json.Unmarshal(b, &clients); err == nil {
+		return clients, nil
+	}
+	var settings struct {
+		Clients []json.RawMessage `json:"clients"`
+	}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return nil, err
+	}
+	return settings.Clients, nil
+}
+
+// buildUsers builds the users with the settings of the inbound, so that the users are built
+// exactly as in a config file.
+func buildUsers(inbound *core.InboundHandlerConfig, clients []json.RawMessage) ([]*protocol.User, error) {
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	d := &decompile.Decompiler{}
+	in, err := d.Inbound(inbound)
+	if err != nil {
+		return nil, err
+	}
+	settings, _ := in["settings"].(map[string]interface{})
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	settings["clients"] = clients
+	b, err := json.Marshal(map[string]interface{}{
+		"protocol": in["protocol"],
+		"listen":   "127.0.0.1",
+		"port":     1,
+		"settings": settings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := new(conf.InboundDetourConfig)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	config, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+	p, err := config.ProxySettings.GetInstance()
+	if err != nil {
+		return nil, err
+	}
+
+	m := p.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !fd.IsList() || fd.Message() == nil || fd.Message().FullName() != "xray.common.protocol.User" {
+			continue
+		}
+		list := m.Get(fd).List()
+		users := make([]*protocol.User, list.Len())
+		for i := range users {
+			// Normalize the users as the ones returned by the inbound.
+			u, err := list.Get(i).Message().Interface().(*protocol.User).ToMemoryUser()
+			if err != nil {
+				return nil, err
+			}
+			users[i] = protocol.ToProtoUser(u)
+		}
+		return users, nil
+	}
+	return nil, fmt.Errorf("inbound %s does not support users", inbound.Tag)
+}
+
+// diffUsers returns the changes from the current users to the desired users.
+func diffUsers(current, desired []*protocol.User) (*userChanges, error) {
+	currentByEmail := make(map[string]*protocol.User, len(current))
+	for _, u := range current {
+		if u.Email == "" {
+			return nil, fmt.Errorf("the inbound has a user without email, which can't be synced")
+		}
+		currentByEmail[u.Email] = u
+	}
+	desiredByEmail := make(map[string]bool, len(desired))
+	changes := &userChanges{}
+	for _, u := range desired {
+		if u.Email == "" {
+			return nil, fmt.Errorf("user without email")
+		}
+		if desiredByEmail[u.Email] {
+			return nil, fmt.Errorf("duplicate user: %s", u.Email)
+		}
+		desiredByEmail[u.Email] = true
+		old, found := currentByEmail[u.Email]
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, u)
+		case !sameUser(old, u):
+			changes.Updated = append(changes.Updated, [2]*protocol.User{old, u})
+		default:
+			changes.Unchanged++
+		}
+	}
+	for _, u := range current {
+		if !desiredByEmail[u.Email] {
+			changes.Removed = append(changes.Removed, u)
+		}
+	}
+	return changes, nil
+}
+
+func sameUser(a, b *protocol.User) bool {
+	if a.Level != b.Level {
+		return false
+	}
+	accountA, err := a.Account.GetInstance()
+	if err != nil {
+		return false
+	}
+	accountB, err := b.Account.GetInstance()
+	if err != nil {
+		return false
+	}
+	return proto.Equal(accountA, accountB)
+}
+
+// undoOperation reverts an applied operation on the user with email.
+type undoOperation struct {
+	email  string
+	revert func() error
+}
+
+// applyUsers applies the changes in the order of removals, updates and additions, with timeout
+// for each call. If any operation fails, the applied ones are undone in the reverse order. All of
+// them are undone even if some fail, and the error reports the users left inconsistent.
+func applyUsers(client handlerService.HandlerServiceClient, tag string, changes *userChanges, timeout time.Duration) error {
+	alter := func(operation proto.Message) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, err := client.AlterInbound(ctx, &handlerService.AlterInboundRequest{
+			Tag:       tag,
+			Operation: serial.ToTypedMessage(operation),
+		})
+		return err
+	}
+	add := func(u *protocol.User) error {
+		if err := alter(&handlerService.AddUserOperation{User: u}); err != nil {
+			return fmt.Errorf("failed to add user %s: %w", u.Email, err)
+		}
+		return nil
+	}
+	remove := func(u *protocol.User) error {
+		if err := alter(&handlerService.RemoveUserOperation{Email: u.Email}); err != nil {
+			return fmt.Errorf("failed to remove user %s: %w", u.Email, err)
+		}
+		return nil
+	}
+
+	var undo []undoOperation
+	apply := func(do, revert func(*protocol.User) error, u *protocol.User) error {
+		if err := do(u); err != nil {
+			return err
+		}
+		undo = append(undo, undoOperation{
+			email:  u.Email,
+			revert: func() error { return revert(u) },
+		})
+		return nil
+	}
+	err := func() error {
+		for _, u := range changes.Removed {
+			if err := apply(remove, add, u); err != nil {
+				return err
+			}
+		}
+		for _, pair := range changes.Updated {
+			if err := apply(remove, add, pair[0]); err != nil {
+				return err
+			}
+			if err := apply(add, remove, pair[1]); err != nil {
+				return err
+			}
+		}
+		for _, u := range changes.Added {
+			if err := apply(add, remove, u); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err == nil {
+		return nil
+	}
+
+	var rollbackErrors, inconsistent []string
+	for i := len(undo) - 1; i >= 0; i-- {
+		if rerr := undo[i].revert(); rerr != nil {
+			rollbackErrors = append(rollbackErrors, rerr.Error())
+			if !slices.Contains(inconsistent, undo[i].email) {
+				inconsistent = append(inconsistent, undo[i].email)
+			}
+		}
+	}
+	if len(rollbackErrors) == 0 {
+		return fmt.Errorf("%w, rolled back", err)
+	}
+	return fmt.Errorf("%w, and failed to roll back: %s; users left inconsistent: %s",
+		err, strings.Join(rollbackErrors, "; "), strings.Join(inconsistent, ", "))
+}
+
+func showUserChanges(changes *userChanges, dryRun bool) {
+	if apiJSON {
+		emails := func(users []*protocol.User) []string {
+			result := make([]string, 0, len(users))
+			for _, u := range users {
+				result = append(result, u.Email)
+			}
+			return result
+		}
+		updated := make([]*protocol.User, 0, len(changes.Updated))
+		for _, pair := range changes.Updated {
+			updated = append(updated, pair[1])
+		}
+		b, _ := json.MarshalIndent(map[string]interface{}{
+			"added":     emails(changes.Added),
+			"removed":   emails(changes.Removed),
+			"updated":   emails(updated),
+			"unchanged": changes.Unchanged,
+			"dryRun":    dryRun,
+		}, "", "  ")
+		fmt.Println(string(b))
+		return
+	}
+
+	for _, u := range changes.Removed {
+		fmt.Println("-", u.Email)
+	}
+	for _, pair := range changes.Updated {
+		fmt.Println("~", pair[1].Email)
+	}
+	for _, u := range changes.Added {
+		fmt.Println("+", u.Email)
+	}
+	verb := "synced"
+	if dryRun {
+		verb = "would sync (dry run)"
+	}
+	fmt.Printf("%s: %d added, %d removed, %d updated, %d unchanged\n",
+		verb, len(changes.Added), len(changes.Removed), len(changes.Updated), changes.Unchanged)
+}
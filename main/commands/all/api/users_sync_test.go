@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	handlerService "github.com/HZ-PRE/XrarCore/app/proxyman/command"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/common/serial"
+	"github.com/HZ-PRE/XrarCore/proxy/vless"
+	"google.golang.org/grpc"
+)
+
+func vlessUser(email, id string, level uint32) *protocol.User {
+	return &protocol.User{
+		Email:   email,
+		Level:   level,
+		Account: serial.ToTypedMessage(&vless.Account{Id: id}),
+	}
+}
+
+func emails(users []*protocol.User) string {
+	result := make([]string, 0, len(users))
+	for _, u := range users {
+		result = append(result, u.Email)
+	}
+	return strings.Join(result, ",")
+}
+
+func TestDiffUsers(t *testing.T) {
+	const (
+		id1 = "27848739-7e62-4138-9fd3-098a63964b6b"
+		id2 = "d9d56fc1-d0c1-4a6e-ae2b-3d5c0b8e2f3a"
+	)
+	for _, c := range []struct {
+		name      string
+		current   []*protocol.User
+		desired   []*protocol.User
+		added     string
+		removed   string
+		updated   string
+		unchanged int
+		err       string
+	}{
+		{
+			name:    "add",
+			desired: []*protocol.User{vlessUser("a", id1, 0), vlessUser("b", id2, 0)},
+			added:   "a,b",
+		},
+		{
+			name:    "remove",
+			current: []*protocol.User{vlessUser("a", id1, 0), vlessUser("b", id2, 0)},
+			desired: []*protocol.User{vlessUser("b", id2, 0)},
+			removed: "a", unchanged: 1,
+		},
+		{
+			name:    "update account",
+			current: []*protocol.User{vlessUser("a", id1, 0)},
+			desired: []*protocol.User{vlessUser("a", id2, 0)},
+			updated: "a",
+		},
+		{
+			name:    "update level",
+			current: []*protocol.User{vlessUser("a", id1, 0)},
+			desired: []*protocol.User{vlessUser("a", id1, 1)},
+			updated: "a",
+		},
+		{
+			name:    "mixed",
+			current: []*protocol.User{vlessUser("a", id1, 0), vlessUser("b", id1, 0), vlessUser("c", id1, 0)},
+			desired: []*protocol.User{vlessUser("d", id1, 0), vlessUser("c", id2, 0), vlessUser("a", id1, 0)},
+			added:   "d", removed: "b", updated: "c", unchanged: 1,
+		},
+		{
+			name:    "duplicate",
+			desired: []*protocol.User{vlessUser("a", id1, 0), vlessUser("a", id2, 0)},
+			err:     "duplicate user: a",
+		},
+		{
+			name:    "desired without email",
+			desired: []*protocol.User{vlessUser("", id1, 0)},
+			err:     "user without email",
+		},
+		{
+			name:    "current without email",
+			current: []*protocol.User{vlessUser("", id1, 0)},
+			err:     "can't be synced",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			changes, err := diffUsers(c.current, c.desired)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatal("expect error ", c.err, ", but got ", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var updated []*protocol.User
+			for _, pair := range changes.Updated {
+				if pair[0].Email != pair[1].Email {
+					t.Error("unexpected update from ", pair[0].Email, " to ", pair[1].Email)
+				}
+				updated = append(updated, pair[1])
+			}
+			if s := emails(changes.Added); s != c.added {
+				t.Error("added: ", s)
+			}
+			if s := emails(changes.Removed); s != c.removed {
+				t.Error("removed: ", s)
+			}
+			if s := emails(updated); s != c.updated {
+				t.Error("updated: ", s)
+			}
+			if changes.Unchanged != c.unchanged {
+				t.Error("unchanged: ", changes.Unchanged)
+			}
+		})
+	}
+}
+
+// fakeHandlerServiceClient records the operations of AlterInbound, and fails the ones in fail.
+type fakeHandlerServiceClient struct {
+	handlerService.HandlerServiceClient
+	fail       map[string]bool
+	operations []string
+}
+
+func (c *fakeHandlerServiceClient) AlterInbound(ctx context.Context, in *handlerService.AlterInboundRequest, opts ...grpc.CallOption) (*handlerService.AlterInboundResponse, error) {
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Second {
+		return nil, errors.New("unexpected deadline")
+	}
+	operation, err := in.Operation.GetInstance()
+	if err != nil {
+		return nil, err
+	}
+	var op string
+	switch operation := operation.(type) {
+	case *handlerService.AddUserOperation:
+		op = "+" + operation.User.Email
+	case *handlerService.RemoveUserOperation:
+		op = "-" + operation.Email
+	}
+	c.operations = append(c.operations, op)
+	if c.fail[op] {
+		return nil, errors.New("failed")
+	}
+	return &handlerService.AlterInboundResponse{}, nil
+}
+
+func TestApplyUsers(t *testing.T) {
+	const id = "27848739-7e62-4138-9fd3-098a63964b6b"
+	changes := &userChanges{
+		Removed: []*protocol.User{vlessUser("a", id, 0)},
+		Updated: [][2]*protocol.User{{vlessUser("b", id, 0), vlessUser("b", id, 1)}},
+		Added:   []*protocol.User{vlessUser("c", id, 0), vlessUser("d", id, 0)},
+	}
+
+	client := &fakeHandlerServiceClient{}
+	if err := applyUsers(client, "in", changes, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(client.operations, " "); s != "-a -b +b +c +d" {
+		t.Error("unexpected operations: ", s)
+	}
+
+	// Every applied operation is undone in the reverse order.
+	client = &fakeHandlerServiceClient{fail: map[string]bool{"+d": true}}
+	err := applyUsers(client, "in", changes, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "failed to add user d") || !strings.Contains(err.Error(), "rolled back") {
+		t.Error("unexpected error: ", err)
+	}
+	if s := strings.Join(client.operations, " "); s != "-a -b +b +c +d -c -b +b +a" {
+		t.Error("unexpected operations: ", s)
+	}
+
+	// Failed undos don't stop the others, and the users are reported.
+	client = &fakeHandlerServiceClient{fail: map[string]bool{"+d": true, "-c": true, "+a": true}}
+	err = applyUsers(client, "in", changes, time.Second)
+	if s := strings.Join(client.operations, " "); s != "-a -b +b +c +d -c -b +b +a" {
+		t.Error("unexpected operations: ", s)
+	}
+	if err == nil || !strings.Contains(err.Error(), "users left inconsistent: c, a") {
+		t.Error("unexpected error: ", err)
+	}
+}
@@ -16,5 +16,8 @@ func init() {
 		cmdUUID,
 		cmdX25519,
 		cmdWG,
+		cmdLint,
+		cmdSchema,
+		cmdInit,
 	)
 }
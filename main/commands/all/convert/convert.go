@@ -13,5 +13,9 @@ var CmdConvert = &base.Command{
 	Commands: []*base.Command{
 		cmdProtobuf,
 		cmdJson,
+		cmdLink,
+		cmdUsers,
+		cmdDecompile,
+		cmdExport,
 	},
 }
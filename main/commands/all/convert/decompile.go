@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/HZ-PRE/XrarCore/common/cmdarg"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf/decompile"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdDecompile = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert decompile [-format auto] <config file> [config file] ...",
+	Short:       "Convert configs back to a json config",
+	Long: `
+Convert configs of any format, including protobuf, back to a json config.
+Settings without json equivalents are skipped, with warnings printed to
+stderr.
+
+Arguments:
+
+	-format <format>
+		Format of the configs: auto, json, toml, yaml or protobuf.
+		Default auto.
+
+Examples:
+
+    {{.Exec}} convert decompile mix.pb > config.json
+    {{.Exec}} convert decompile -format protobuf stdin: < mix.pb
+	`,
+	Run: executeDecompile,
+}
+
+func executeDecompile(cmd *base.Command, args []string) {
+	var format string
+	cmd.Flag.StringVar(&format, "format", "auto", "")
+	cmd.Flag.Parse(args)
+
+	files := cmdarg.Arg{}
+	for _, v := range cmd.Flag.Args() {
+		files.Set(v)
+	}
+	if len(files) < 1 {
+		base.Fatalf("empty config list")
+	}
+
+	config, err := core.LoadConfig(format, files)
+	if err != nil {
+		base.Fatalf("failed to load config: %s", err)
+	}
+
+	d := &decompile.Decompiler{}
+	v, err := d.Config(config)
+	if err != nil {
+		base.Fatalf("failed to decompile config: %s", err)
+	}
+	for _, w := range d.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal json: %s", err)
+	}
+	fmt.Println(string(b))
+}
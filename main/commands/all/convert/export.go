@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf/export"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+	"github.com/ghodss/yaml"
+)
+
+var cmdExport = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert export [-format clash] [-o file] <config file>",
+	Short:       "Export outbounds and rules to Clash or sing-box",
+	Long: `
+Export the outbounds and routing rules of a config to a Clash (Mihomo) config
+in YAML, or a sing-box config in JSON.
+
+VLESS, VMess, Trojan, Shadowsocks and WireGuard outbounds are exported, with
+raw, WebSocket, HTTPUpgrade and gRPC transports, and TLS and REALITY.
+Balancers are exported as groups. Outbounds, balancers and rules that can't
+be represented are skipped, with warnings printed to stderr.
+
+Arguments:
+
+	-format <format>
+		clash or singbox. Default clash.
+
+	-o <file>
+		The file to write the exported config to. Default stdout.
+
+Examples:
+
+    {{.Exec}} convert export -format clash -o clash.yaml config.json
+    {{.Exec}} convert export -format singbox config.json > sing-box.json
+	`,
+	Run: executeExport,
+}
+
+func executeExport(cmd *base.Command, args []string) {
+	var format, output string
+	cmd.Flag.StringVar(&format, "format", "clash", "")
+	cmd.Flag.StringVar(&output, "o", "", "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() != 1 {
+		base.Fatalf("a config file is required")
+	}
+	config := loadConfigFile(cmd.Flag.Arg(0))
+
+	e := &export.Exporter{}
+	var b []byte
+	var err error
+	switch format {
+	case "clash", "mihomo":
+		if b, err = json.Marshal(e.Clash(config)); err == nil {
+			b, err = yaml.JSONToYAML(b)
+		}
+	case "singbox", "sing-box":
+		b, err = json.MarshalIndent(e.SingBox(config), "", "  ")
+		b = append(b, '\n')
+	default:
+		base.Fatalf("unsupported format: %s", format)
+	}
+	if err != nil {
+		base.Fatalf("failed to encode config: %s", err)
+	}
+	for _, w := range e.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+
+	if output == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := os.WriteFile(output, b, 0o600); err != nil {
+		base.Fatalf("failed to write %s: %s", output, err)
+	}
+}
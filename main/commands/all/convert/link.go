@@ -0,0 +1,75 @@
+package convert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdLink = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert link [link] [link] ...",
+	Short:       "Convert share links to outbound json",
+	Long: `
+Convert share links to outbounds of a json config.
+
+Supported links are vless://, vmess:// (v2rayN format), trojan:// and ss://
+(SIP002 and the legacy format, including Shadowsocks 2022), with the parameters
+of RAW, XHTTP, WebSocket, HTTPUpgrade, gRPC and mKCP transports, and TLS and
+REALITY security.
+
+Links are read from stdin, one per line, if none is given. The remark of each
+link is used as the tag of its outbound.
+
+Examples:
+
+    {{.Exec}} convert link "vless://uuid@example.com:443?security=reality&..."
+    {{.Exec}} convert link < links.txt
+	`,
+	Run: executeConvertLink,
+}
+
+func executeConvertLink(cmd *base.Command, args []string) {
+	cmd.Flag.Parse(args)
+
+	links := cmd.Flag.Args()
+	if len(links) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				links = append(links, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			base.Fatalf("failed to read links: %s", err)
+		}
+	}
+	if len(links) == 0 {
+		base.Fatalf("no share link")
+	}
+
+	outbounds := make([]interface{}, 0, len(links))
+	for _, s := range links {
+		link, err := sharelink.Parse(s)
+		if err != nil {
+			base.Fatalf("failed to parse %s: %s", s, err)
+		}
+		outbound, err := link.Outbound()
+		if err != nil {
+			base.Fatalf("failed to convert %s: %s", s, err)
+		}
+		outbounds = append(outbounds, outbound)
+	}
+
+	b, err := json.MarshalIndent(map[string]interface{}{"outbounds": outbounds}, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal outbounds: %s", err)
+	}
+	fmt.Println(string(b))
+}
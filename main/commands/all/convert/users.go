@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/qrcode"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
+	"github.com/HZ-PRE/XrarCore/infra/conf/sharelink"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+	"github.com/HZ-PRE/XrarCore/main/confloader"
+)
+
+var cmdUsers = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert users [-inbound tag] [-address host] [-sni name] [-fp fingerprint] [-qr] <config file>",
+	Short:       "Export share links of users of an inbound",
+	Long: `
+Export share links of all users of a VLESS, VMess, Trojan or Shadowsocks
+inbound in a config, optionally with QR codes for terminals.
+
+Arguments:
+
+	-inbound <tag>
+		Tag of the inbound. Optional if the config has only one inbound.
+
+	-address <host>
+		Address of the server for clients. Defaults to the listening address
+		of the inbound, if it's not a wildcard.
+
+	-sni <name>
+		Server name of TLS and REALITY. Defaults to the server name of TLS
+		settings or the first server name of REALITY settings.
+
+	-fp <fingerprint>
+		uTLS fingerprint. REALITY defaults to chrome.
+
+	-qr
+		Print a QR code after each link.
+
+Examples:
+
+    {{.Exec}} convert users -inbound vless-in -address example.com -qr config.json
+	`,
+	Run: executeConvertUsers,
+}
+
+func executeConvertUsers(cmd *base.Command, args []string) {
+	var (
+		tag     string
+		options sharelink.InboundOptions
+		qr      bool
+	)
+	cmd.Flag.StringVar(&tag, "inbound", "", "")
+	cmd.Flag.StringVar(&options.Address, "address", "", "")
+	cmd.Flag.StringVar(&options.ServerName, "sni", "", "")
+	cmd.Flag.StringVar(&options.Fingerprint, "fp", "", "")
+	cmd.Flag.BoolVar(&qr, "qr", false, "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() != 1 {
+		base.Fatalf("a config file is required")
+	}
+	config := loadConfigFile(cmd.Flag.Arg(0))
+
+	var inbound *conf.InboundDetourConfig
+	for i := range config.InboundConfigs {
+		in := &config.InboundConfigs[i]
+		if tag == "" && len(config.InboundConfigs) == 1 || tag != "" && in.Tag == tag {
+			inbound = in
+			break
+		}
+	}
+	if inbound == nil {
+		if tag == "" {
+			base.Fatalf("the config has %d inbounds, specify one with -inbound", len(config.InboundConfigs))
+		}
+		base.Fatalf("inbound %s is not found", tag)
+	}
+
+	links, err := sharelink.FromInbound(inbound, &options)
+	if err != nil {
+		base.Fatalf("failed to export share links: %s", err)
+	}
+	for _, link := range links {
+		s := link.String()
+		fmt.Println(s)
+		if qr {
+			code, err := qrcode.Encode([]byte(s))
+			if err != nil {
+				base.Fatalf("failed to encode QR code of %s: %s", link.Name, err)
+			}
+			fmt.Print(code.Terminal())
+			fmt.Println()
+		}
+	}
+}
+
+// loadConfigFile decodes a json, yaml or toml config file, without building it.
+func loadConfigFile(name string) *conf.Config {
+	format := core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch format {
+	case "":
+		format = "json"
+	case "protobuf":
+		base.Fatalf("protobuf configs are not supported")
+	}
+	r, err := confloader.LoadConfig(name)
+	if err != nil {
+		base.Fatalf("failed to load %s: %s", name, err)
+	}
+	config, err := serial.ReaderDecoderByFormat[format](r)
+	if err != nil {
+		base.Fatalf("failed to decode %s: %s", name, err)
+	}
+	return config
+}
@@ -0,0 +1,191 @@
+package all
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf/generate"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+	"github.com/ghodss/yaml"
+	"github.com/pelletier/go-toml"
+)
+
+var cmdInit = &base.Command{
+	UsageLine: `{{.Exec}} init [-protocol vless] [-transport raw] [-security reality] [options] -address <host>`,
+	Short:     `Generate matched server and client configs`,
+	Long: `
+Generate a server config and a client config that agree with each other,
+with random keys, IDs, short IDs and paths, and print the share links of
+the users. The client config is of the first user.
+
+Arguments:
+
+	-address <host>
+		The address of the server for clients. Required.
+
+	-protocol <protocol>
+		vless, vmess, trojan or shadowsocks. Default vless.
+
+	-transport <transport>
+		raw, xhttp, ws, grpc or httpupgrade. Default raw.
+
+	-security <security>
+		reality, tls or none. Default reality, or none for shadowsocks.
+
+	-port <port>
+		The listening port of the server. Default 443.
+
+	-sni <name>
+		The server name of TLS and REALITY. TLS defaults to the address if
+		it's a domain, and REALITY defaults to the host of the target.
+
+	-target <host:port>
+		The target of REALITY. Default www.microsoft.com:443.
+
+	-cert <file>, -key <file>
+		The certificate and key files of TLS on the server. Required by TLS.
+
+	-users <n>
+		The number of users. Default 1.
+
+	-format <format>
+		json, toml or yaml. Default json.
+
+	-o <dir>
+		The directory to write server.<format> and client.<format> to.
+		Default the current directory.
+
+	-f
+		Overwrite existing files.
+
+Examples:
+
+    {{.Exec}} init -address 1.2.3.4
+    {{.Exec}} init -address 1.2.3.4 -transport xhttp -target www.example.com:443 -users 3
+    {{.Exec}} init -address example.com -protocol trojan -security tls -cert cert.pem -key key.pem -format yaml
+`,
+}
+
+var (
+	initProfile   generate.Profile
+	initPort      = cmdInit.Flag.Uint("port", 443, "")
+	initFormat    = cmdInit.Flag.String("format", "json", "")
+	initOutput    = cmdInit.Flag.String("o", ".", "")
+	initOverwrite = cmdInit.Flag.Bool("f", false, "")
+)
+
+func init() {
+	cmdInit.Run = executeInit // break init loop
+
+	cmdInit.Flag.StringVar(&initProfile.Address, "address", "", "")
+	cmdInit.Flag.StringVar(&initProfile.Protocol, "protocol", "", "")
+	cmdInit.Flag.StringVar(&initProfile.Transport, "transport", "", "")
+	cmdInit.Flag.StringVar(&initProfile.Security, "security", "", "")
+	cmdInit.Flag.StringVar(&initProfile.ServerName, "sni", "", "")
+	cmdInit.Flag.StringVar(&initProfile.Target, "target", "", "")
+	cmdInit.Flag.StringVar(&initProfile.CertificateFile, "cert", "", "")
+	cmdInit.Flag.StringVar(&initProfile.KeyFile, "key", "", "")
+	cmdInit.Flag.IntVar(&initProfile.Users, "users", 1, "")
+}
+
+func executeInit(cmd *base.Command, args []string) {
+	if *initPort == 0 || *initPort > 65535 {
+		base.Fatalf("invalid port: %d", *initPort)
+	}
+	initProfile.Port = uint16(*initPort)
+	format := strings.ToLower(*initFormat)
+	if format == "yml" {
+		format = "yaml"
+	}
+
+	result, err := generate.Generate(&initProfile)
+	if err != nil {
+		base.Fatalf("failed to generate configs: %s", err)
+	}
+
+	files := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{"server." + format, result.Server},
+		{"client." + format, result.Client},
+	}
+	for _, f := range files {
+		path := filepath.Join(*initOutput, f.name)
+		if _, err := os.Stat(path); err == nil && !*initOverwrite {
+			base.Fatalf("%s already exists, overwrite it with -f", path)
+		}
+	}
+	for _, f := range files {
+		b, err := encodeConfig(f.config, format)
+		if err != nil {
+			base.Fatalf("failed to encode %s: %s", f.name, err)
+		}
+		path := filepath.Join(*initOutput, f.name)
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			base.Fatalf("failed to write %s: %s", path, err)
+		}
+		fmt.Println("Wrote", path)
+	}
+
+	fmt.Println()
+	fmt.Println("Share links:")
+	for _, link := range result.Links {
+		fmt.Println(link)
+	}
+}
+
+// encodeConfig encodes the config in JSON format to the format.
+func encodeConfig(config map[string]interface{}, format string) ([]byte, error) {
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return append(b, '\n'), nil
+	case "yaml":
+		return yaml.JSONToYAML(b)
+	case "toml":
+		// Decode the numbers as they are, or integers would become floats in TOML.
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		var m map[string]interface{}
+		if err := d.Decode(&m); err != nil {
+			return nil, err
+		}
+		tree, err := toml.TreeFromMap(tomlNumbers(m).(map[string]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		s, err := tree.ToTomlString()
+		return []byte(s), err
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// tomlNumbers converts the JSON numbers in v to integers or floats.
+func tomlNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = tomlNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = tomlNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,162 @@
+package all
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/cmdarg"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdLint = &base.Command{
+	UsageLine: `{{.Exec}} lint [-json] [-c config.json] [-confdir dir]`,
+	Short:     `Check config files for mistakes`,
+	Long: `
+Check config files for mistakes that are not errors of loading, such as
+routing rules to nonexistent outbounds, rules shadowed by earlier rules,
+balancer selectors matching no outbounds, duplicate tags and ports,
+inbounds that can never receive traffic, weak settings and deprecated
+transports. Unknown fields, unresolved references and errors of building
+the config are also reported.
+
+Arguments:
+
+	-c, -config
+		Config files to check. Multiple assign is accepted.
+
+	-confdir
+		A dir with multiple config files to check.
+
+	-format
+		Format of config files, "json", "yaml", "toml" or "auto".
+		Default "auto".
+
+	-json
+		Print the issues in JSON, for CI.
+
+The exit code is 1 if any error is found, warnings don't fail.
+
+Examples:
+
+    {{.Exec}} lint -c config.json
+    {{.Exec}} lint -json -confdir /etc/xray/conf.d
+`,
+}
+
+func init() {
+	cmdLint.Run = executeLint // break init loop
+}
+
+var (
+	lintConfigFiles cmdarg.Arg
+	lintConfigDir   = cmdLint.Flag.String("confdir", "", "")
+	lintFormat      = cmdLint.Flag.String("format", "auto", "")
+	lintJSON        = cmdLint.Flag.Bool("json", false, "")
+
+	_ = func() bool {
+		cmdLint.Flag.Var(&lintConfigFiles, "config", "")
+		cmdLint.Flag.Var(&lintConfigFiles, "c", "")
+		return true
+	}()
+)
+
+// lintResult is the output of lint in JSON.
+type lintResult struct {
+	Files    []string          `json:"files"`
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Issues   []*conf.LintIssue `json:"issues"`
+}
+
+func executeLint(cmd *base.Command, args []string) {
+	names := lintConfigFiles
+	if *lintConfigDir != "" {
+		entries, err := os.ReadDir(*lintConfigDir)
+		if err != nil {
+			base.Fatalf("failed to read config dir: %s", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(entry.Name()), ".")) != "" {
+				names = append(names, filepath.Join(*lintConfigDir, entry.Name()))
+			}
+		}
+	}
+	names = append(names, cmd.Flag.Args()...)
+	if len(names) == 0 {
+		names = cmdarg.Arg{"config.json"}
+	}
+
+	files := make([]*core.ConfigSource, len(names))
+	for i, name := range names {
+		format := core.GetFormatByExtension(*lintFormat)
+		if format == "" {
+			format = core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(name), "."))
+		}
+		switch format {
+		case "json", "yaml", "toml":
+		case "":
+			format = "json"
+		default:
+			base.Fatalf("config of %s format can't be checked: %s", format, name)
+		}
+		files[i] = &core.ConfigSource{Name: name, Format: format}
+	}
+
+	issues, err := serial.LintConfig(files)
+	if err != nil {
+		base.Fatalf("failed to load config: %s", err)
+	}
+
+	result := &lintResult{
+		Files:  names,
+		Issues: issues,
+	}
+	if result.Issues == nil {
+		result.Issues = []*conf.LintIssue{}
+	}
+	for _, issue := range issues {
+		if issue.Severity == conf.LintError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+
+	if *lintJSON {
+		b, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(formatLintIssue(issue))
+		}
+		fmt.Printf("%d error(s), %d warning(s)\n", result.Errors, result.Warnings)
+	}
+	if result.Errors > 0 {
+		base.SetExitStatus(1)
+		base.Exit()
+	}
+}
+
+func formatLintIssue(issue *conf.LintIssue) string {
+	var b strings.Builder
+	if issue.File != "" {
+		b.WriteString(issue.File)
+		if issue.Line > 0 {
+			fmt.Fprintf(&b, ":%d", issue.Line)
+		}
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "%s [%s] ", issue.Severity, issue.Check)
+	if issue.Path != "" {
+		b.WriteString(issue.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(issue.Message)
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package all
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/HZ-PRE/XrarCore/infra/conf"
+	"github.com/HZ-PRE/XrarCore/main/commands/base"
+)
+
+var cmdSchema = &base.Command{
+	UsageLine: `{{.Exec}} schema [-o file]`,
+	Short:     `Generate JSON Schema of the config`,
+	Long: `
+Generate the JSON Schema (draft 2020-12) of the JSON config, for the
+autocompletion and validation of editors and CI.
+
+The settings of inbounds and outbounds are checked by their protocols,
+and the settings of transports by their types.
+
+Arguments:
+
+	-o
+		The file to write the schema to. Default stdout.
+
+Examples:
+
+    {{.Exec}} schema -o xray.schema.json
+`,
+}
+
+func init() {
+	cmdSchema.Run = executeSchema // break init loop
+}
+
+var schemaOutput = cmdSchema.Flag.String("o", "", "")
+
+func executeSchema(cmd *base.Command, args []string) {
+	b, err := json.MarshalIndent(conf.Schema(), "", "  ")
+	if err != nil {
+		base.Fatalf("failed to generate schema: %s", err)
+	}
+	if *schemaOutput == "" {
+		fmt.Println(string(b))
+		return
+	}
+	if err := os.WriteFile(*schemaOutput, append(b, '\n'), 0o644); err != nil {
+		base.Fatalf("failed to write schema: %s", err)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	_ "github.com/HZ-PRE/XrarCore/app/reverse"
 	_ "github.com/HZ-PRE/XrarCore/app/router"
 	_ "github.com/HZ-PRE/XrarCore/app/stats"
+	_ "github.com/HZ-PRE/XrarCore/app/subscription"
 
 	// Fix dependency cycle caused by core import in internet package
 	_ "github.com/HZ-PRE/XrarCore/transport/internet/tagged/taggedimpl"
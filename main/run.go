@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -19,6 +20,7 @@ import (
 	clog "github.com/HZ-PRE/XrarCore/common/log"
 	"github.com/HZ-PRE/XrarCore/common/platform"
 	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/infra/conf/serial"
 	"github.com/HZ-PRE/XrarCore/main/commands/base"
 )
 
@@ -39,7 +41,28 @@ Default "auto".
 The -test flag tells Xray to test config files only, 
 without launching the server.
 
-The -dump flag tells Xray to print the merged config.
+The -dump flag tells Xray to print the merged config, in which 
+the interpolated values are masked.
+
+The -strict flag tells Xray to fail on unknown fields in config 
+files, such as misspelled keys, which are ignored otherwise, and 
+on unresolved references. It's on by default with -test, use 
+-strict=false to turn it off.
+
+References in local config files are interpolated before decoding, 
+so that secrets can be kept out of them:
+
+	${env:NAME}             the environment variable NAME
+	${env:NAME:-default}    the variable, or default if it's unset
+	${file:/path}           the content of the file, such as secrets 
+	                        of Docker and Kubernetes
+	${file:/path:-default}  the content, or default if it can't be read
+	$${env:NAME}            the literal "${env:NAME}"
+
+Unresolved references without defaults are replaced with empty 
+strings, or fail with -strict. Values are escaped for the strings 
+they are in. In YAML, a whole unquoted value is quoted if needed, 
+and references in comments are left as they are.
 	`,
 }
 
@@ -54,6 +77,7 @@ var (
 	dump        = cmdRun.Flag.Bool("dump", false, "Dump merged config only, without launching Xray server.")
 	test        = cmdRun.Flag.Bool("test", false, "Test config file only, without launching Xray server.")
 	format      = cmdRun.Flag.String("format", "auto", "Format of input file.")
+	strict      = cmdRun.Flag.Bool("strict", false, "Fail on unknown fields and unresolved references in config files. On by default with -test.")
 
 	/* We have to do this here because Golang's Test will also need to parse flag, before
 	 * main func in this file is run.
@@ -68,6 +92,16 @@ var (
 )
 
 func executeRun(cmd *base.Command, args []string) {
+	serial.Strict = *strict
+	if *test {
+		serial.Strict = true
+		cmd.Flag.Visit(func(f *flag.Flag) {
+			if f.Name == "strict" {
+				serial.Strict = *strict
+			}
+		})
+	}
+
 	if *dump {
 		clog.ReplaceWithSeverityLogger(clog.Severity_Warning)
 		errCode := dumpConfig()
@@ -33,6 +33,15 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	ob := outbounds[len(outbounds)-1]
 	ob.Name = "blackhole"
 
+	if handler, ok := h.response.(responseHandler); ok {
+		if err := handler.handle(ctx, link); err != nil {
+			common.Interrupt(link.Writer)
+			return err
+		}
+		common.Close(link.Writer)
+		return nil
+	}
+
 	nBytes := h.response.WriteTo(link.Writer)
 	if nBytes > 0 {
 		// Sleep a little here to make sure the response is sent to client.
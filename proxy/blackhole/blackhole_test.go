@@ -2,15 +2,22 @@ package blackhole_test
 
 import (
 	"context"
+	"errors"
+	"io"
+	gonet "net"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/common/serial"
 	"github.com/HZ-PRE/XrarCore/common/session"
 	"github.com/HZ-PRE/XrarCore/proxy/blackhole"
 	"github.com/HZ-PRE/XrarCore/transport"
 	"github.com/HZ-PRE/XrarCore/transport/pipe"
+	"github.com/miekg/dns"
 )
 
 func TestBlackholeHTTPResponse(t *testing.T) {
@@ -40,3 +47,113 @@ func TestBlackholeHTTPResponse(t *testing.T) {
 		t.Error("expect http response, but nothing")
 	}
 }
+
+func TestBlackholeNXDomainResponse(t *testing.T) {
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.UDPDestination(net.LocalHostIP, 53),
+	}})
+	handler, err := blackhole.New(ctx, &blackhole.Config{
+		Response: serial.ToTypedMessage(&blackhole.NXDomainResponse{}),
+	})
+	common.Must(err)
+
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithoutSizeLimit())
+
+	query := new(dns.Msg)
+	query.SetQuestion("blocked.example.com.", dns.TypeA)
+	packed, err := query.Pack()
+	common.Must(err)
+	common.Must(uplinkWriter.WriteMultiBuffer(buf.MergeBytes(nil, packed)))
+	common.Must(uplinkWriter.Close())
+
+	var mb buf.MultiBuffer
+	var rerr error
+	done := make(chan struct{})
+	go func() {
+		mb, rerr = downlinkReader.ReadMultiBuffer()
+		close(done)
+	}()
+
+	link := transport.Link{
+		Reader: uplinkReader,
+		Writer: downlinkWriter,
+	}
+	common.Must(handler.Process(ctx, &link, nil))
+	<-done
+	common.Must(rerr)
+	reply := new(dns.Msg)
+	common.Must(reply.Unpack(mb[0].Bytes()))
+	if reply.Id != query.Id || reply.Rcode != dns.RcodeNameError {
+		t.Error("unexpected reply: ", reply)
+	}
+}
+
+func TestBlackholeRSTResponse(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+		common.Must(err)
+		client, err := gonet.Dial("tcp", listener.Addr().String())
+		common.Must(err)
+		server, err := listener.Accept()
+		common.Must(err)
+		listener.Close()
+
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.LocalHostIP, 80),
+		}})
+		ctx = session.ContextWithInbound(ctx, &session.Inbound{Conn: server, Shared: shared})
+		handler, err := blackhole.New(ctx, &blackhole.Config{
+			Response: serial.ToTypedMessage(&blackhole.RSTResponse{}),
+		})
+		common.Must(err)
+		reader, writer := pipe.New(pipe.WithoutSizeLimit())
+		common.Must(handler.Process(ctx, &transport.Link{Reader: reader, Writer: writer}, nil))
+		// The inbound closes the connection after the outbound returns.
+		server.Close()
+
+		_, err = client.Read(make([]byte, 1))
+		client.Close()
+		if shared {
+			if err != io.EOF {
+				t.Error("expect a shared connection to be closed gracefully, but got ", err)
+			}
+		} else if !errors.Is(err, syscall.ECONNRESET) {
+			t.Error("expect connection reset, but got ", err)
+		}
+	}
+}
+
+func TestBlackholeTarpitResponse(t *testing.T) {
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.LocalHostIP, 80),
+	}})
+	handler, err := blackhole.New(ctx, &blackhole.Config{
+		Response: serial.ToTypedMessage(&blackhole.TarpitResponse{Duration: 1}),
+	})
+	common.Must(err)
+
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	_, downlinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	common.Must(uplinkWriter.WriteMultiBuffer(buf.MergeBytes(nil, make([]byte, 2048))))
+	link := &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}
+
+	start := time.Now()
+	common.Must(handler.Process(ctx, link, nil))
+	if d := time.Since(start); d < time.Second || d > 2*time.Second {
+		t.Error("expect the connection to be held for 1 second, but got ", d)
+	}
+	// Nothing is read from the uplink.
+	if mb, _ := uplinkReader.ReadMultiBufferTimeout(time.Millisecond); mb.Len() != 2048 {
+		t.Error("expect the uplink to be left unread, but got ", mb.Len())
+	}
+
+	// The connection is released early if the request is canceled.
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	start = time.Now()
+	common.Must(handler.Process(ctx, link, nil))
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Error("expect canceled tarpit to return immediately, but got ", d)
+	}
+}
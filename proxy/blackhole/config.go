@@ -1,8 +1,19 @@
 package blackhole
 
 import (
-	"github.com/HZ-PRE/XrarCore/common"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/session"
+	"github.com/HZ-PRE/XrarCore/proxy"
+	"github.com/HZ-PRE/XrarCore/transport"
+	"github.com/miekg/dns"
 )
 
 const (
@@ -13,6 +24,9 @@ Content-Length: 0
 
 
 `
+
+	defaultTarpitDuration = time.Minute
+	udpIdleTimeout        = time.Second * 10
 )
 
 // ResponseConfig is the configuration for blackhole responses.
@@ -21,18 +35,147 @@ type ResponseConfig interface {
 	WriteTo(buf.Writer) int32
 }
 
+// responseHandler is implemented by responses that have to interact with the
+// link or the inbound connection, instead of writing a predefined response.
+type responseHandler interface {
+	handle(ctx context.Context, link *transport.Link) error
+}
+
 // WriteTo implements ResponseConfig.WriteTo().
 func (*NoneResponse) WriteTo(buf.Writer) int32 { return 0 }
 
 // WriteTo implements ResponseConfig.WriteTo().
-func (*HTTPResponse) WriteTo(writer buf.Writer) int32 {
-	b := buf.New()
-	common.Must2(b.WriteString(http403response))
-	n := b.Len()
-	writer.WriteMultiBuffer(buf.MultiBuffer{b})
+func (r *HTTPResponse) WriteTo(writer buf.Writer) int32 {
+	var mb buf.MultiBuffer
+	if r.GetStatusCode() == 0 && len(r.GetHeader()) == 0 && len(r.GetBody()) == 0 {
+		mb = buf.MergeBytes(mb, []byte(http403response))
+	} else {
+		mb = buf.MergeBytes(mb, r.build())
+	}
+	n := mb.Len()
+	writer.WriteMultiBuffer(mb)
 	return n
 }
 
+func (r *HTTPResponse) build() []byte {
+	code := int(r.GetStatusCode())
+	if code == 0 {
+		code = http.StatusForbidden
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "HTTP/1.1 %d %s\r\n", code, http.StatusText(code))
+
+	header := make(map[string]string, len(r.GetHeader()))
+	for k, v := range r.GetHeader() {
+		header[http.CanonicalHeaderKey(k)] = v
+	}
+	// The connection is always closed, and the body length is always known.
+	header["Connection"] = "close"
+	header["Content-Length"] = fmt.Sprint(len(r.GetBody()))
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s: %s\r\n", k, header[k])
+	}
+	sb.WriteString("\r\n")
+	sb.Write(r.GetBody())
+	return []byte(sb.String())
+}
+
+// WriteTo implements ResponseConfig.WriteTo().
+func (*RSTResponse) WriteTo(buf.Writer) int32 { return 0 }
+
+func (*RSTResponse) handle(ctx context.Context, link *transport.Link) error {
+	resetInboundConn(ctx)
+	return nil
+}
+
+// WriteTo implements ResponseConfig.WriteTo().
+func (*TarpitResponse) WriteTo(buf.Writer) int32 { return 0 }
+
+func (r *TarpitResponse) handle(ctx context.Context, link *transport.Link) error {
+	d := time.Duration(r.GetDuration()) * time.Second
+	if d == 0 {
+		d = defaultTarpitDuration
+	}
+	// Nothing is read from the link, so the client will soon stop being able to send anything.
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// WriteTo implements ResponseConfig.WriteTo().
+func (*NXDomainResponse) WriteTo(buf.Writer) int32 { return 0 }
+
+func (*NXDomainResponse) handle(ctx context.Context, link *transport.Link) error {
+	outbounds := session.OutboundsFromContext(ctx)
+	if outbounds[len(outbounds)-1].Target.Network != net.Network_UDP {
+		return nil
+	}
+	reader, ok := link.Reader.(buf.TimeoutReader)
+	if !ok {
+		return nil
+	}
+	for {
+		mb, err := reader.ReadMultiBufferTimeout(udpIdleTimeout)
+		if err != nil {
+			return nil
+		}
+		var resp buf.MultiBuffer
+		for _, b := range mb {
+			if r := nxdomainReply(b); r != nil {
+				resp = append(resp, r)
+			}
+		}
+		buf.ReleaseMulti(mb)
+		if !resp.IsEmpty() {
+			if err := link.Writer.WriteMultiBuffer(resp); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func nxdomainReply(b *buf.Buffer) *buf.Buffer {
+	req := new(dns.Msg)
+	if err := req.Unpack(b.Bytes()); err != nil || req.Response {
+		return nil
+	}
+	resp := new(dns.Msg)
+	resp.SetRcode(req, dns.RcodeNameError)
+	packed, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	r := buf.New()
+	if _, err := r.Write(packed); err != nil {
+		r.Release()
+		return nil
+	}
+	r.UDP = b.UDP
+	return r
+}
+
+// resetInboundConn makes the inbound TCP connection send RST instead of FIN when it is closed.
+// It does nothing if the inbound connection is shared by multiple requests.
+func resetInboundConn(ctx context.Context) {
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || inbound.Conn == nil || inbound.Shared {
+		return
+	}
+	conn, _, _ := proxy.UnwrapRawConn(inbound.Conn)
+	if tc, ok := conn.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+}
+
 // GetInternalResponse converts response settings from proto to internal data structure.
 func (c *Config) GetInternalResponse() (ResponseConfig, error) {
 	if c.GetResponse() == nil {
@@ -2,6 +2,7 @@ package blackhole_test
 
 import (
 	"bufio"
+	"io"
 	"net/http"
 	"testing"
 
@@ -24,3 +25,32 @@ func TestHTTPResponse(t *testing.T) {
 		t.Error("expected status code 403, but got ", response.StatusCode)
 	}
 }
+
+func TestCustomHTTPResponse(t *testing.T) {
+	buffer := buf.New()
+
+	httpResponse := &HTTPResponse{
+		StatusCode: 451,
+		Header: map[string]string{
+			"content-type": "text/html",
+		},
+		Body: []byte("blocked by policy"),
+	}
+	httpResponse.WriteTo(buf.NewWriter(buffer))
+
+	reader := bufio.NewReader(buffer)
+	response, err := http.ReadResponse(reader, nil)
+	common.Must(err)
+
+	if response.StatusCode != 451 {
+		t.Error("expected status code 451, but got ", response.StatusCode)
+	}
+	if v := response.Header.Get("Content-Type"); v != "text/html" {
+		t.Error("unexpected content type: ", v)
+	}
+	body, err := io.ReadAll(response.Body)
+	common.Must(err)
+	if string(body) != "blocked by policy" {
+		t.Error("unexpected body: ", string(body))
+	}
+}
@@ -3,6 +3,7 @@ package dns
 import (
 	"context"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,6 +51,8 @@ type Handler struct {
 	timeout         time.Duration
 	nonIPQuery      string
 	blockTypes      []int32
+	zone            *localZone
+	rewriters       []*answerRewriter
 }
 
 func (h *Handler) Init(config *Config, dnsClient dns.Client, policyManager policy.Manager) error {
@@ -65,6 +68,19 @@ func (h *Handler) Init(config *Config, dnsClient dns.Client, policyManager polic
 	}
 	h.nonIPQuery = config.Non_IPQuery
 	h.blockTypes = config.BlockTypes
+
+	zone, err := newLocalZone(config.Records)
+	if err != nil {
+		return err
+	}
+	h.zone = zone
+	for _, rule := range config.AnswerRewrites {
+		rewriter, err := newAnswerRewriter(rule)
+		if err != nil {
+			return err
+		}
+		h.rewriters = append(h.rewriters, rewriter)
+	}
 	return nil
 }
 
@@ -191,6 +207,13 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, d internet.
 						}
 					}
 				}
+				if reply := h.answerLocally(b.Bytes()); reply != nil {
+					b.Release()
+					if err := writer.WriteMessage(reply); err != nil {
+						return err
+					}
+					continue
+				}
 				if isIPQuery {
 					go h.handleIPQuery(id, qType, domain, writer)
 				}
@@ -263,6 +286,10 @@ func (h *Handler) handleIPQuery(id uint16, qType dnsmessage.Type, domain string,
 		ttl = 1
 	}
 
+	for _, rewriter := range h.rewriters {
+		ips, ttl = rewriter.rewrite(strings.ToLower(strings.TrimSuffix(domain, ".")), qType == dnsmessage.TypeA, ips, ttl)
+	}
+
 	switch qType {
 	case dnsmessage.TypeA:
 		for i, ip := range ips {
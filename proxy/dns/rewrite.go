@@ -0,0 +1,106 @@
+package dns
+
+import (
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+)
+
+type answerRewriter struct {
+	domains   []string
+	cidrs     []*net.IPNet
+	action    AnswerRewrite_Action
+	replaceIP []net.IP
+	ttl       uint32
+}
+
+func newAnswerRewriter(config *AnswerRewrite) (*answerRewriter, error) {
+	r := &answerRewriter{
+		action: config.Action,
+		ttl:    config.Ttl,
+	}
+	for _, domain := range config.Domain {
+		r.domains = append(r.domains, strings.ToLower(strings.Trim(domain, ".")))
+	}
+	for _, cidr := range config.Ip {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.New("invalid IP in answer rewrite: ", cidr).Base(err)
+		}
+		r.cidrs = append(r.cidrs, ipNet)
+	}
+	for _, s := range config.ReplaceIp {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.New("invalid replacement IP in answer rewrite: ", s)
+		}
+		r.replaceIP = append(r.replaceIP, ip)
+	}
+	if r.action == AnswerRewrite_Replace && len(r.replaceIP) == 0 {
+		return nil, errors.New("no replacement IP in answer rewrite")
+	}
+	return r, nil
+}
+
+func (r *answerRewriter) matchDomain(domain string) bool {
+	if len(r.domains) == 0 {
+		return true
+	}
+	for _, d := range r.domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *answerRewriter) matchIP(ip net.IP) bool {
+	if len(r.cidrs) == 0 {
+		return true
+	}
+	for _, ipNet := range r.cidrs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrite applies the rule to the answers of an A (ipv4 is true) or AAAA query for domain.
+func (r *answerRewriter) rewrite(domain string, ipv4 bool, ips []net.IP, ttl uint32) ([]net.IP, uint32) {
+	if !r.matchDomain(domain) {
+		return ips, ttl
+	}
+	if r.ttl != 0 {
+		ttl = r.ttl
+	}
+	if r.action == AnswerRewrite_Keep {
+		return ips, ttl
+	}
+
+	result := make([]net.IP, 0, len(ips))
+	matched := false
+	for _, ip := range ips {
+		if r.matchIP(ip) {
+			matched = true
+			continue
+		}
+		result = append(result, ip)
+	}
+	if matched && r.action == AnswerRewrite_Replace {
+		for _, ip := range r.replaceIP {
+			if (ip.To4() != nil) == ipv4 {
+				result = append(result, ip)
+			}
+		}
+	}
+	return result, ttl
+}
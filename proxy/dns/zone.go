@@ -0,0 +1,215 @@
+package dns
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	dns_feature "github.com/HZ-PRE/XrarCore/features/dns"
+	"github.com/miekg/dns"
+)
+
+// maxCNAMEChain is the maximum number of CNAME records followed inside local zones.
+const maxCNAMEChain = 8
+
+// localZone holds local resource records, indexed by their lower-cased owner names.
+type localZone struct {
+	records map[string][]dns.RR
+	// apexes are the owner names of SOA records. Names under an apex without any
+	// record are answered with NXDOMAIN.
+	apexes []string
+}
+
+func newLocalZone(records []string) (*localZone, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	z := &localZone{
+		records: make(map[string][]dns.RR),
+	}
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			return nil, errors.New("invalid local record: ", record).Base(err)
+		}
+		if rr == nil {
+			continue
+		}
+		name := strings.ToLower(rr.Header().Name)
+		z.records[name] = append(z.records[name], rr)
+		if rr.Header().Rrtype == dns.TypeSOA {
+			z.apexes = append(z.apexes, name)
+		}
+	}
+	return z, nil
+}
+
+func (z *localZone) soa(name string) dns.RR {
+	for _, apex := range z.apexes {
+		if dns.IsSubDomain(apex, name) {
+			for _, rr := range z.records[apex] {
+				if rr.Header().Rrtype == dns.TypeSOA {
+					return rr
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// answer builds a reply for req from local records. It returns nil if the question
+// is not covered by the local zone.
+func (z *localZone) answer(req *dns.Msg) *dns.Msg {
+	q := req.Question[0]
+	if q.Qclass != dns.ClassINET && q.Qclass != dns.ClassANY {
+		return nil
+	}
+	name := strings.ToLower(q.Name)
+
+	rrs, found := z.records[name]
+	if !found {
+		soa := z.soa(name)
+		if soa == nil {
+			return nil
+		}
+		resp := newAuthoritativeReply(req)
+		resp.Rcode = dns.RcodeNameError
+		resp.Ns = append(resp.Ns, dns.Copy(soa))
+		return resp
+	}
+
+	resp := newAuthoritativeReply(req)
+	for i := 0; i < maxCNAMEChain; i++ {
+		var cname *dns.CNAME
+		matched := false
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == q.Qtype || q.Qtype == dns.TypeANY {
+				resp.Answer = append(resp.Answer, dns.Copy(rr))
+				matched = true
+			} else if c, ok := rr.(*dns.CNAME); ok {
+				cname = c
+			}
+		}
+		if matched || cname == nil {
+			break
+		}
+		resp.Answer = append(resp.Answer, dns.Copy(cname))
+		// Targets outside of the local zone are left to the client to resolve.
+		if rrs = z.records[strings.ToLower(cname.Target)]; rrs == nil {
+			break
+		}
+	}
+	if len(resp.Answer) == 0 {
+		if soa := z.soa(name); soa != nil {
+			resp.Ns = append(resp.Ns, dns.Copy(soa))
+		}
+	}
+	return resp
+}
+
+// answerLocally answers the query in b from local records, or with a synthetic PTR
+// record for IPs in the FakeDNS pools. It returns nil if the query should be handled as usual.
+func (h *Handler) answerLocally(b []byte) *buf.Buffer {
+	if h.zone == nil && h.fdns == nil {
+		return nil
+	}
+	req := new(dns.Msg)
+	if err := req.Unpack(b); err != nil || req.Response || len(req.Question) != 1 {
+		return nil
+	}
+
+	var resp *dns.Msg
+	if h.zone != nil {
+		resp = h.zone.answer(req)
+	}
+	if resp == nil && h.fdns != nil && req.Question[0].Qtype == dns.TypePTR {
+		resp = h.fakePTR(req)
+	}
+	if resp == nil {
+		return nil
+	}
+
+	packed, err := resp.Pack()
+	if err != nil {
+		errors.LogInfoInner(context.Background(), err, "pack local answer")
+		return nil
+	}
+	reply := buf.NewWithSize(int32(len(packed)))
+	reply.Write(packed)
+	return reply
+}
+
+func (h *Handler) fakePTR(req *dns.Msg) *dns.Msg {
+	q := req.Question[0]
+	ip := ipFromReverseName(q.Name)
+	if ip == nil {
+		return nil
+	}
+	addr := net.IPAddress(ip)
+	if fkr0, ok := h.fdns.(dns_feature.FakeDNSEngineRev0); ok && !fkr0.IsIPInIPPool(addr) {
+		return nil
+	}
+	domain := h.fdns.GetDomainFromFakeDNS(addr)
+	if domain == "" {
+		return nil
+	}
+	resp := newAuthoritativeReply(req)
+	resp.Answer = append(resp.Answer, &dns.PTR{
+		Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 1},
+		Ptr: dns.Fqdn(domain),
+	})
+	return resp
+}
+
+func newAuthoritativeReply(req *dns.Msg) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+	resp.RecursionAvailable = true
+	return resp
+}
+
+// ipFromReverseName parses names like "4.3.2.1.in-addr.arpa." and
+// "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa." into IPs.
+func ipFromReverseName(name string) net.IP {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(labels) != net.IPv4len {
+			return nil
+		}
+		ip := make(net.IP, net.IPv4len)
+		for i, label := range labels {
+			v, err := strconv.ParseUint(label, 10, 8)
+			if err != nil {
+				return nil
+			}
+			ip[net.IPv4len-1-i] = byte(v)
+		}
+		return ip
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(labels) != net.IPv6len*2 {
+			return nil
+		}
+		ip := make(net.IP, net.IPv6len)
+		for i, label := range labels {
+			v, err := strconv.ParseUint(label, 16, 4)
+			if err != nil || len(label) != 1 {
+				return nil
+			}
+			pos := len(labels) - 1 - i
+			if pos%2 == 0 {
+				ip[pos/2] |= byte(v) << 4
+			} else {
+				ip[pos/2] |= byte(v)
+			}
+		}
+		return ip
+	}
+	return nil
+}
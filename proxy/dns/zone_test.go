@@ -0,0 +1,88 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/miekg/dns"
+)
+
+func TestLocalZone(t *testing.T) {
+	zone, err := newLocalZone([]string{
+		"internal. 3600 IN SOA ns.internal. admin.internal. 1 7200 3600 1209600 300",
+		"www.internal. 300 IN CNAME web.internal.",
+		"web.internal. 300 IN A 10.0.0.1",
+		"web.internal. 300 IN TXT \"hello\"",
+	})
+	common.Must(err)
+
+	req := new(dns.Msg)
+	req.SetQuestion("WWW.internal.", dns.TypeA)
+	resp := zone.answer(req)
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 2 {
+		t.Fatal("unexpected answer: ", resp)
+	}
+	if a, ok := resp.Answer[1].(*dns.A); !ok || !a.A.Equal(net.ParseIP("10.0.0.1")) {
+		t.Error("unexpected A record: ", resp.Answer[1])
+	}
+
+	req.SetQuestion("web.internal.", dns.TypeAAAA)
+	resp = zone.answer(req)
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 0 || len(resp.Ns) != 1 {
+		t.Error("expect NODATA, but got ", resp)
+	}
+
+	req.SetQuestion("missing.internal.", dns.TypeA)
+	resp = zone.answer(req)
+	if resp == nil || resp.Rcode != dns.RcodeNameError {
+		t.Error("expect NXDOMAIN, but got ", resp)
+	}
+
+	req.SetQuestion("example.com.", dns.TypeA)
+	if resp := zone.answer(req); resp != nil {
+		t.Error("expect no local answer, but got ", resp)
+	}
+}
+
+func TestIPFromReverseName(t *testing.T) {
+	cases := map[string]string{
+		"1.0.18.198.in-addr.arpa.": "198.18.0.1",
+		dns.Fqdn("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.c.f.ip6.arpa"): "fc00::1",
+		"256.0.18.198.in-addr.arpa.": "",
+		"example.com.":               "",
+	}
+	for name, expected := range cases {
+		ip := ipFromReverseName(name)
+		if expected == "" {
+			if ip != nil {
+				t.Error("expect nil IP for ", name, ", but got ", ip)
+			}
+			continue
+		}
+		if !ip.Equal(net.ParseIP(expected)) {
+			t.Error("expect ", expected, " for ", name, ", but got ", ip)
+		}
+	}
+}
+
+func TestAnswerRewrite(t *testing.T) {
+	rewriter, err := newAnswerRewriter(&AnswerRewrite{
+		Domain:    []string{"example.com"},
+		Ip:        []string{"10.0.0.0/8"},
+		Action:    AnswerRewrite_Replace,
+		ReplaceIp: []string{"192.168.1.1", "fd00::1"},
+		Ttl:       60,
+	})
+	common.Must(err)
+
+	ips, ttl := rewriter.rewrite("www.example.com", true, []net.IP{net.ParseIP("10.1.1.1"), net.ParseIP("1.1.1.1")}, 600)
+	if ttl != 60 || len(ips) != 2 || !ips[0].Equal(net.ParseIP("1.1.1.1")) || !ips[1].Equal(net.ParseIP("192.168.1.1")) {
+		t.Error("unexpected rewrite result: ", ips, " ", ttl)
+	}
+
+	ips, ttl = rewriter.rewrite("example.org", true, []net.IP{net.ParseIP("10.1.1.1")}, 600)
+	if ttl != 600 || len(ips) != 1 {
+		t.Error("unexpected rewrite result: ", ips, " ", ttl)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/core"
 	"github.com/HZ-PRE/XrarCore/features/policy"
 	"github.com/HZ-PRE/XrarCore/features/routing"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 )
@@ -247,34 +248,57 @@ func (d *DokodemoDoor) Process(ctx context.Context, network net.Network, conn st
 
 func NewPacketWriter(conn net.PacketConn, d *net.Destination, mark int, back *net.UDPAddr) buf.Writer {
 	writer := &PacketWriter{
-		conn:  conn,
-		conns: make(map[net.Destination]net.PacketConn),
-		mark:  mark,
-		back:  back,
+		conn:    conn,
+		conns:   make(map[net.Destination]net.PacketConn),
+		batches: make(map[net.PacketConn]*internet.UDPBatchConn),
+		mark:    mark,
+		back:    back,
 	}
 	writer.conns[*d] = conn
 	return writer
 }
 
 type PacketWriter struct {
-	conn  net.PacketConn
-	conns map[net.Destination]net.PacketConn
-	mark  int
-	back  *net.UDPAddr
+	conn    net.PacketConn
+	conns   map[net.Destination]net.PacketConn
+	batches map[net.PacketConn]*internet.UDPBatchConn
+	mark    int
+	back    *net.UDPAddr
+	msgs    []internet.UDPMessage
 }
 
 func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
-	for {
-		mb2, b := buf.SplitFirst(mb)
-		mb = mb2
-		if b == nil {
-			break
+	defer buf.ReleaseMulti(mb)
+
+	// Consecutive datagrams from the same source are written in a batch.
+	var conn net.PacketConn
+	var source *net.Destination
+	msgs := w.msgs[:0]
+	flush := func() error {
+		if len(msgs) == 0 {
+			return nil
+		}
+		err := w.writeBatch(conn, msgs)
+		msgs = msgs[:0]
+		if err == nil || source == nil {
+			return err
 		}
-		var err error
+		errors.LogInfo(context.Background(), err.Error())
+		w.conns[*source] = nil
+		delete(w.batches, conn)
+		conn.Close()
+		return nil
+	}
+
+	for _, b := range mb {
+		c := w.conn
+		var src *net.Destination
 		if b.UDP != nil && b.UDP.Address.Family().IsIP() {
-			conn := w.conns[*b.UDP]
-			if conn == nil {
-				conn, err = FakeUDP(
+			src = b.UDP
+			c = w.conns[*b.UDP]
+			if c == nil {
+				var err error
+				c, err = FakeUDP(
 					&net.UDPAddr{
 						IP:   b.UDP.Address.IP(),
 						Port: int(b.UDP.Port),
@@ -283,25 +307,39 @@ func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
 				)
 				if err != nil {
 					errors.LogInfo(context.Background(), err.Error())
-					b.Release()
 					continue
 				}
-				w.conns[*b.UDP] = conn
-			}
-			_, err = conn.WriteTo(b.Bytes(), w.back)
-			if err != nil {
-				errors.LogInfo(context.Background(), err.Error())
-				w.conns[*b.UDP] = nil
-				conn.Close()
+				w.conns[*b.UDP] = c
 			}
-			b.Release()
-		} else {
-			_, err = w.conn.WriteTo(b.Bytes(), w.back)
-			b.Release()
-			if err != nil {
-				buf.ReleaseMulti(mb)
+		}
+		if c != conn {
+			if err := flush(); err != nil {
 				return err
 			}
+			conn, source = c, src
+		}
+		msgs = append(msgs, internet.UDPMessage{Buffer: b.Bytes(), Addr: w.back})
+	}
+	err := flush()
+	w.msgs = msgs[:0]
+	return err
+}
+
+// writeBatch writes msgs to conn, in batches if it's a UDP socket.
+func (w *PacketWriter) writeBatch(conn net.PacketConn, msgs []internet.UDPMessage) error {
+	batch, found := w.batches[conn]
+	if !found {
+		if udpConn, ok := conn.(*net.UDPConn); ok {
+			batch = internet.NewUDPBatchConn(udpConn, false)
+		}
+		w.batches[conn] = batch
+	}
+	if batch != nil {
+		return batch.WriteBatch(msgs)
+	}
+	for _, msg := range msgs {
+		if _, err := conn.WriteTo(msg.Buffer, msg.Addr); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -71,6 +71,14 @@ func (h *Handler) policy() policy.Session {
 }
 
 func (h *Handler) resolveIP(ctx context.Context, domain string, localAddr net.Address) net.Address {
+	ips := h.resolveIPs(ctx, domain, localAddr)
+	if len(ips) == 0 {
+		return nil
+	}
+	return net.IPAddress(ips[dice.Roll(len(ips))])
+}
+
+func (h *Handler) resolveIPs(ctx context.Context, domain string, localAddr net.Address) []net.IP {
 	ips, err := h.dns.LookupIP(domain, dns.IPOption{
 		IPv4Enable: (localAddr == nil || localAddr.Family().IsIPv4()) && h.config.preferIP4(),
 		IPv6Enable: (localAddr == nil || localAddr.Family().IsIPv6()) && h.config.preferIP6(),
@@ -86,10 +94,7 @@ func (h *Handler) resolveIP(ctx context.Context, domain string, localAddr net.Ad
 	if err != nil {
 		errors.LogInfoInner(ctx, err, "failed to get IP address for domain ", domain)
 	}
-	if len(ips) == 0 {
-		return nil
-	}
-	return net.IPAddress(ips[dice.Roll(len(ips))])
+	return ips
 }
 
 func isValidAddress(addr *net.IPOrDomain) bool {
@@ -131,22 +136,33 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 
 	var conn stat.Connection
 	err := retry.ExponentialBackoff(5, 100).On(func() error {
-		dialDest := destination
-		if h.config.hasStrategy() && dialDest.Address.Family().IsDomain() {
-			ip := h.resolveIP(ctx, dialDest.Address.Domain(), dialer.Address())
-			if ip != nil {
-				dialDest = net.Destination{
-					Network: dialDest.Network,
-					Address: ip,
-					Port:    dialDest.Port,
-				}
-				errors.LogInfo(ctx, "dialing to ", dialDest)
-			} else if h.config.forceIP() {
+		var ips []net.IP
+		if h.config.hasStrategy() && destination.Address.Family().IsDomain() {
+			ips = h.resolveIPs(ctx, destination.Address.Domain(), dialer.Address())
+			if len(ips) == 0 && h.config.forceIP() {
 				return dns.ErrEmptyResponse
 			}
 		}
 
-		rawConn, err := dialer.Dial(ctx, dialDest)
+		var rawConn stat.Connection
+		var err error
+		if len(ips) > 1 && destination.Network == net.Network_TCP {
+			errors.LogInfo(ctx, "racing ", len(ips), " addresses of ", destination.Address)
+			var conn net.Conn
+			conn, err = internet.DialHappyEyeballs(ctx, internet.SortIPs(internet.ShuffleIPs(ips), h.config.preferIP6()), h.config.HappyEyeballs, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+				return dialer.Dial(ctx, net.TCPDestination(net.IPAddress(ip), destination.Port))
+			})
+			rawConn = conn
+		} else {
+			dialDest := destination
+			if len(ips) > 0 {
+				dialDest.Address = net.IPAddress(ips[dice.Roll(len(ips))])
+			}
+			if dialDest != destination {
+				errors.LogInfo(ctx, "dialing to ", dialDest)
+			}
+			rawConn, err = dialer.Dial(ctx, dialDest)
+		}
 		if err != nil {
 			return err
 		}
@@ -156,6 +172,20 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 			srcAddr := inbound.Source.RawNetAddr()
 			dstAddr := rawConn.RemoteAddr()
 			header := proxyproto.HeaderProxyFromAddrs(version, srcAddr, dstAddr)
+			if version == 2 && len(h.config.ProxyProtocolTlvs) > 0 {
+				info := proxy.ProxyProtocolInfo{InboundTag: inbound.Tag}
+				if inbound.User != nil {
+					info.Email = inbound.User.Email
+				}
+				if content := session.ContentFromContext(ctx); content != nil {
+					info.SNI = content.Attribute(session.AttributeSNI)
+					info.ALPN = content.Attribute(session.AttributeALPN)
+				}
+				if err = header.SetTLVs(info.TLVs(h.config.ProxyProtocolTlvs)); err != nil {
+					rawConn.Close()
+					return err
+				}
+			}
 			if _, err = header.WriteTo(rawConn); err != nil {
 				rawConn.Close()
 				return err
@@ -284,20 +314,32 @@ func NewPacketReader(conn net.Conn, UDPOverride net.Destination) buf.Reader {
 		counter = statConn.ReadCounter
 	}
 	if c, ok := iConn.(*internet.PacketConnWrapper); ok && UDPOverride.Address == nil && UDPOverride.Port == 0 {
-		return &PacketReader{
+		reader := &PacketReader{
 			PacketConnWrapper: c,
 			Counter:           counter,
 		}
+		if udpConn, ok := c.Conn.(*net.UDPConn); ok {
+			reader.batch = internet.NewUDPBatchReader(internet.NewUDPBatchConn(udpConn, false), udpReadBatchSize)
+		}
+		return reader
 	}
 	return &buf.PacketReader{Reader: conn}
 }
 
+// udpReadBatchSize is the number of datagrams read at once by PacketReader. It's smaller than
+// internet.UDPBatchSize, as each of them holds a buffer while waiting.
+const udpReadBatchSize = 8
+
 type PacketReader struct {
 	*internet.PacketConnWrapper
 	stats.Counter
+	batch *internet.UDPBatchReader
 }
 
 func (r *PacketReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	if r.batch != nil {
+		return r.readBatch()
+	}
 	b := buf.New()
 	b.Resize(0, buf.Size)
 	n, d, err := r.PacketConnWrapper.ReadFrom(b.Bytes())
@@ -317,6 +359,17 @@ func (r *PacketReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
 	return buf.MultiBuffer{b}, nil
 }
 
+func (r *PacketReader) readBatch() (buf.MultiBuffer, error) {
+	mb, err := r.batch.ReadMultiBuffer()
+	if err != nil {
+		return nil, err
+	}
+	if r.Counter != nil {
+		r.Counter.Add(int64(mb.Len()))
+	}
+	return mb, nil
+}
+
 func NewPacketWriter(conn net.Conn, h *Handler, ctx context.Context, UDPOverride net.Destination) buf.Writer {
 	iConn := conn
 	statConn, ok := iConn.(*stat.CounterConnection)
@@ -328,14 +381,20 @@ func NewPacketWriter(conn net.Conn, h *Handler, ctx context.Context, UDPOverride
 		counter = statConn.WriteCounter
 	}
 	if c, ok := iConn.(*internet.PacketConnWrapper); ok {
-		return &PacketWriter{
+		writer := &PacketWriter{
 			PacketConnWrapper: c,
 			Counter:           counter,
 			Handler:           h,
 			Context:           ctx,
 			UDPOverride:       UDPOverride,
 		}
-
+		udpConn, ok := c.Conn.(*net.UDPConn)
+		dest, isUDPAddr := c.Dest.(*net.UDPAddr)
+		if ok && isUDPAddr {
+			writer.batch = internet.NewUDPBatchConn(udpConn, false)
+			writer.dest = dest
+		}
+		return writer
 	}
 	return &buf.SequentialWriter{Writer: conn}
 }
@@ -346,9 +405,33 @@ type PacketWriter struct {
 	*Handler
 	context.Context
 	UDPOverride net.Destination
+	batch       *internet.UDPBatchConn
+	dest        *net.UDPAddr
+	msgs        []internet.UDPMessage
+}
+
+// udpAddr returns the address to send b to, or nil if it can't be resolved.
+func (w *PacketWriter) udpAddr(b *buf.Buffer) *net.UDPAddr {
+	if w.UDPOverride.Address != nil {
+		b.UDP.Address = w.UDPOverride.Address
+	}
+	if w.UDPOverride.Port != 0 {
+		b.UDP.Port = w.UDPOverride.Port
+	}
+	if w.Handler.config.hasStrategy() && b.UDP.Address.Family().IsDomain() {
+		ip := w.Handler.resolveIP(w.Context, b.UDP.Address.Domain(), nil)
+		if ip != nil {
+			b.UDP.Address = ip
+		}
+	}
+	destAddr, _ := net.ResolveUDPAddr("udp", b.UDP.NetAddr())
+	return destAddr
 }
 
 func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.batch != nil {
+		return w.writeBatch(mb)
+	}
 	for {
 		mb2, b := buf.SplitFirst(mb)
 		mb = mb2
@@ -358,19 +441,7 @@ func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
 		var n int
 		var err error
 		if b.UDP != nil {
-			if w.UDPOverride.Address != nil {
-				b.UDP.Address = w.UDPOverride.Address
-			}
-			if w.UDPOverride.Port != 0 {
-				b.UDP.Port = w.UDPOverride.Port
-			}
-			if w.Handler.config.hasStrategy() && b.UDP.Address.Family().IsDomain() {
-				ip := w.Handler.resolveIP(w.Context, b.UDP.Address.Domain(), nil)
-				if ip != nil {
-					b.UDP.Address = ip
-				}
-			}
-			destAddr, _ := net.ResolveUDPAddr("udp", b.UDP.NetAddr())
+			destAddr := w.udpAddr(b)
 			if destAddr == nil {
 				b.Release()
 				continue
@@ -391,6 +462,32 @@ func (w *PacketWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
 	return nil
 }
 
+// writeBatch writes mb with as few syscalls as possible.
+func (w *PacketWriter) writeBatch(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+
+	msgs := w.msgs[:0]
+	var n int64
+	for _, b := range mb {
+		addr := w.dest
+		if b.UDP != nil {
+			if addr = w.udpAddr(b); addr == nil {
+				continue
+			}
+		}
+		msgs = append(msgs, internet.UDPMessage{Buffer: b.Bytes(), Addr: addr})
+		n += int64(b.Len())
+	}
+	w.msgs = msgs[:0]
+	if err := w.batch.WriteBatch(msgs); err != nil {
+		return err
+	}
+	if w.Counter != nil {
+		w.Counter.Add(n)
+	}
+	return nil
+}
+
 type NoisePacketWriter struct {
 	buf.Writer
 	noises      []*Noise
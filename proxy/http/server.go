@@ -7,7 +7,9 @@ import (
 	"encoding/base64"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
@@ -24,12 +26,16 @@ import (
 	"github.com/HZ-PRE/XrarCore/features/policy"
 	"github.com/HZ-PRE/XrarCore/features/routing"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 )
 
 // Server is an HTTP proxy server.
 type Server struct {
 	config        *ServerConfig
+	validator     *protocol.PasswordValidator
 	policyManager policy.Manager
+	// authRequired is set once the server has any user, and stays set even if all users are removed.
+	authRequired atomic.Bool
 }
 
 // NewServer creates a new HTTP inbound handler.
@@ -37,16 +43,70 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	v := core.MustFromContext(ctx)
 	s := &Server{
 		config:        config,
+		validator:     new(protocol.PasswordValidator),
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
 
+	for username, password := range config.Accounts {
+		if err := s.AddUser(ctx, &protocol.MemoryUser{
+			Account: &Account{Username: username, Password: password},
+			Email:   username,
+			Level:   config.UserLevel,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, user := range config.Users {
+		u, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get HTTP user").Base(err).AtError()
+		}
+		if err := s.AddUser(ctx, u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
+	}
+
 	return s, nil
 }
 
-func (s *Server) policy() policy.Session {
-	config := s.config
-	p := s.policyManager.ForLevel(config.UserLevel)
-	return p
+func (s *Server) policy(inbound *session.Inbound) policy.Session {
+	level := s.config.UserLevel
+	if inbound != nil && inbound.User != nil {
+		level = inbound.User.Level
+	}
+	return s.policyManager.ForLevel(level)
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if _, ok := u.Account.(*Account); !ok {
+		return errors.New("account is not an HTTP account")
+	}
+	if err := s.validator.Add(u); err != nil {
+		return err
+	}
+	s.authRequired.Store(true)
+	return nil
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
 }
 
 // Network implements proxy.Inbound.
@@ -105,7 +165,7 @@ func (s *Server) ProcessWithFirstbyte(ctx context.Context, network net.Network,
 	}
 
 Start:
-	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
+	if err := conn.SetReadDeadline(time.Now().Add(s.policy(inbound).Timeouts.Handshake)); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to set read deadline")
 	}
 
@@ -118,14 +178,30 @@ Start:
 		return trace
 	}
 
-	if len(s.config.Accounts) > 0 {
-		user, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
-		if !ok || !s.config.HasAccount(user, pass) {
-			return common.Error2(conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n")))
+	if request.Method == "GET" && request.URL.Host == "" {
+		if keyAuth, ok := tls.HTTP01ChallengeResponse(request.URL.Path); ok {
+			errors.LogInfo(ctx, "answering ACME HTTP-01 challenge ", request.URL.Path)
+			return common.Error2(conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: " + strconv.Itoa(len(keyAuth)) + "\r\nConnection: close\r\n\r\n" + keyAuth)))
 		}
-		if inbound != nil {
-			inbound.User.Email = user
+	}
+
+	if email := tls.ClientUserEmail(conn); email != "" {
+		// A verified client certificate authenticates the user without password.
+		user := s.validator.GetByEmail(email)
+		if user == nil {
+			return errors.New("no user ", email, " for the client certificate").AtWarning()
+		}
+		inbound.User = user
+	} else if s.authRequired.Load() {
+		username, password, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
+		var user *protocol.MemoryUser
+		if ok {
+			user = s.validator.Get(username, password)
+		}
+		if user == nil {
+			return common.Error2(conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n")))
 		}
+		inbound.User = user
 	}
 
 	errors.LogInfo(ctx, "request to Method [", request.Method, "] Host [", request.Host, "] with URL [", request.URL, "]")
@@ -150,6 +226,7 @@ Start:
 		To:     request.URL,
 		Status: log.AccessAccepted,
 		Reason: "",
+		Email:  inbound.User.Email,
 	})
 
 	if strings.EqualFold(request.Method, "CONNECT") {
@@ -175,7 +252,7 @@ func (s *Server) handleConnect(ctx context.Context, _ *http.Request, reader *buf
 		return errors.New("failed to write back OK response").Base(err)
 	}
 
-	plcy := s.policy()
+	plcy := s.policy(inbound)
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
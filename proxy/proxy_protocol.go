@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"encoding/binary"
+
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/pires/go-proxyproto"
+)
+
+// Names of PROXY protocol v2 TLVs in configs.
+const (
+	ProxyProtocolTLVEmail      = "email"
+	ProxyProtocolTLVInboundTag = "inboundTag"
+	ProxyProtocolTLVSNI        = "sni"
+	ProxyProtocolTLVALPN       = "alpn"
+)
+
+// PROXY protocol v2 TLV types of the user email and the inbound tag, in the range for applications.
+// SNI and ALPN use the registered PP2_TYPE_AUTHORITY and PP2_TYPE_ALPN.
+const (
+	PP2TypeEmail      = proxyproto.PP2_TYPE_MIN_CUSTOM
+	PP2TypeInboundTag = proxyproto.PP2_TYPE_MIN_CUSTOM + 1
+)
+
+// ProxyProtocolInfo holds the values of PROXY protocol v2 TLVs of a connection.
+type ProxyProtocolInfo struct {
+	Email      string
+	InboundTag string
+	SNI        string
+	ALPN       string
+}
+
+// ValidateProxyProtocolTLVs returns an error if any of names is unknown.
+func ValidateProxyProtocolTLVs(names []string) error {
+	for _, name := range names {
+		switch name {
+		case ProxyProtocolTLVEmail, ProxyProtocolTLVInboundTag, ProxyProtocolTLVSNI, ProxyProtocolTLVALPN:
+		default:
+			return errors.New("unknown PROXY protocol TLV: ", name)
+		}
+	}
+	return nil
+}
+
+// TLVs returns the TLVs of names. TLVs with empty values are omitted.
+func (i *ProxyProtocolInfo) TLVs(names []string) []proxyproto.TLV {
+	var tlvs []proxyproto.TLV
+	add := func(typ proxyproto.PP2Type, value string) {
+		if value != "" {
+			tlvs = append(tlvs, proxyproto.TLV{Type: typ, Value: []byte(value)})
+		}
+	}
+	for _, name := range names {
+		switch name {
+		case ProxyProtocolTLVEmail:
+			add(PP2TypeEmail, i.Email)
+		case ProxyProtocolTLVInboundTag:
+			add(PP2TypeInboundTag, i.InboundTag)
+		case ProxyProtocolTLVSNI:
+			add(proxyproto.PP2_TYPE_AUTHORITY, i.SNI)
+		case ProxyProtocolTLVALPN:
+			add(proxyproto.PP2_TYPE_ALPN, i.ALPN)
+		}
+	}
+	return tlvs
+}
+
+// WriteProxyProtocolTLVs appends tlvs to the PROXY protocol v2 header in b, and updates the length
+// of the header.
+func WriteProxyProtocolTLVs(b *buf.Buffer, tlvs []proxyproto.TLV) error {
+	if len(tlvs) == 0 {
+		return nil
+	}
+	raw, err := proxyproto.JoinTLVs(tlvs)
+	if err != nil {
+		return err
+	}
+	if b.Len() < 16 {
+		return errors.New("invalid PROXY protocol v2 header")
+	}
+	header := b.Bytes()
+	length := int(binary.BigEndian.Uint16(header[14:])) + len(raw)
+	if length > 0xffff {
+		return errors.New("PROXY protocol v2 header is too long")
+	}
+	binary.BigEndian.PutUint16(header[14:], uint16(length))
+	_, err = b.Write(raw)
+	return err
+}
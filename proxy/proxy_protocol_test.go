@@ -0,0 +1,82 @@
+package proxy_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	. "github.com/HZ-PRE/XrarCore/proxy"
+	"github.com/pires/go-proxyproto"
+)
+
+func TestWriteProxyProtocolTLVs(t *testing.T) {
+	names := []string{ProxyProtocolTLVEmail, ProxyProtocolTLVInboundTag, ProxyProtocolTLVSNI, ProxyProtocolTLVALPN}
+	info := &ProxyProtocolInfo{
+		Email:      "love@example.com",
+		InboundTag: "in",
+		SNI:        "example.com",
+		ALPN:       "h2",
+	}
+	for _, c := range []struct {
+		name   string
+		header []byte
+		source net.IP
+	}{
+		{"ipv4", []byte("\x21\x11\x00\x0C\x7F\x00\x00\x02\x7F\x00\x00\x01\x30\x39\x01\xBB"), net.IPv4(127, 0, 0, 2)},
+		{"ipv6", append(append([]byte("\x21\x21\x00\x24"), bytes.Repeat([]byte{0xFE}, 32)...), "\x30\x39\x01\xBB"...), bytes.Repeat([]byte{0xFE}, 16)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// The header is written the same way as the fallbacks of VLESS and Trojan.
+			b := buf.New()
+			defer b.Release()
+			common.Must2(b.Write([]byte("\x0D\x0A\x0D\x0A\x00\x0D\x0A\x51\x55\x49\x54\x0A")))
+			common.Must2(b.Write(c.header))
+			addressLength := int(binary.BigEndian.Uint16(b.BytesRange(14, 16)))
+			common.Must(WriteProxyProtocolTLVs(b, info.TLVs(names)))
+
+			if length := int(binary.BigEndian.Uint16(b.BytesRange(14, 16))); length != int(b.Len())-16 || length <= addressLength {
+				t.Fatal("unexpected length ", length, " of a header of ", b.Len(), " bytes")
+			}
+
+			reader := bufio.NewReader(io.MultiReader(bytes.NewReader(b.Bytes()), strings.NewReader("payload")))
+			header, err := proxyproto.Read(reader)
+			common.Must(err)
+			if source, _, ok := header.IPs(); !ok || !source.Equal(c.source) {
+				t.Error("unexpected source ", source)
+			}
+			if source, destination, ok := header.Ports(); !ok || source != 12345 || destination != 443 {
+				t.Error("unexpected ports ", source, " ", destination)
+			}
+			tlvs, err := header.TLVs()
+			common.Must(err)
+			values := make(map[proxyproto.PP2Type]string)
+			for _, tlv := range tlvs {
+				values[tlv.Type] = string(tlv.Value)
+			}
+			for typ, value := range map[proxyproto.PP2Type]string{
+				PP2TypeEmail:                  info.Email,
+				PP2TypeInboundTag:             info.InboundTag,
+				proxyproto.PP2_TYPE_AUTHORITY: info.SNI,
+				proxyproto.PP2_TYPE_ALPN:      info.ALPN,
+			} {
+				if values[typ] != value {
+					t.Error("unexpected value of TLV ", typ, ": ", values[typ])
+				}
+			}
+			if len(tlvs) != 4 {
+				t.Error("expect 4 TLVs, but got ", len(tlvs))
+			}
+
+			// The payload after the header is left intact.
+			if rest, _ := io.ReadAll(reader); string(rest) != "payload" {
+				t.Error("unexpected payload ", string(rest))
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ const (
 	authNoMatchingMethod = 0xFF
 
 	statusSuccess       = 0x00
+	statusServerFailure = 0x01
 	statusCmdNotSupport = 0x07
 )
 
@@ -41,13 +42,22 @@ var addrParser = protocol.NewAddressParser(
 
 type ServerSession struct {
 	config       *ServerConfig
+	validator    *protocol.PasswordValidator
 	address      net.Address
 	port         net.Port
 	localAddress net.Address
+	// certUser is the user mapped from the verified client certificate, which is
+	// authenticated without password.
+	certUser *protocol.MemoryUser
+
+	version byte
+	// bind is true if the client requested the BIND command. Responses to BIND are
+	// written by the caller with writeBindResponse.
+	bind bool
 }
 
 func (s *ServerSession) handshake4(cmd byte, reader io.Reader, writer io.Writer) (*protocol.RequestHeader, error) {
-	if s.config.AuthType == AuthType_PASSWORD {
+	if s.config.AuthType == AuthType_PASSWORD && s.certUser == nil {
 		writeSocks4Response(writer, socks4RequestRejected, net.AnyIP, net.Port(0))
 		return nil, errors.New("socks 4 is not allowed when auth is required.")
 	}
@@ -69,7 +79,8 @@ func (s *ServerSession) handshake4(cmd byte, reader io.Reader, writer io.Writer)
 	if _, err := ReadUntilNull(reader); /* user id */ err != nil {
 		return nil, err
 	}
-	if address.IP()[0] == 0x00 {
+	// Socks 4a: IP address 0.0.0.x (x != 0) means a domain name follows the user id.
+	if ip := address.IP(); ip[0] == 0x00 && ip[1] == 0x00 && ip[2] == 0x00 && ip[3] != 0x00 {
 		domain, err := ReadUntilNull(reader)
 		if err != nil {
 			return nil, errors.New("failed to read domain for socks 4a").Base(err)
@@ -84,65 +95,81 @@ func (s *ServerSession) handshake4(cmd byte, reader io.Reader, writer io.Writer)
 			Address: address,
 			Port:    port,
 			Version: socks4Version,
+			User:    s.certUser,
 		}
 		if err := writeSocks4Response(writer, socks4RequestGranted, net.AnyIP, net.Port(0)); err != nil {
 			return nil, err
 		}
 		return request, nil
+	case cmdTCPBind:
+		if !s.config.BindEnabled {
+			writeSocks4Response(writer, socks4RequestRejected, net.AnyIP, net.Port(0))
+			return nil, errors.New("TCP bind is not enabled.")
+		}
+		s.bind = true
+		return &protocol.RequestHeader{
+			Command: protocol.RequestCommandTCP,
+			Address: address,
+			Port:    port,
+			Version: socks4Version,
+			User:    s.certUser,
+		}, nil
 	default:
 		writeSocks4Response(writer, socks4RequestRejected, net.AnyIP, net.Port(0))
 		return nil, errors.New("unsupported command: ", cmd)
 	}
 }
 
-func (s *ServerSession) auth5(nMethod byte, reader io.Reader, writer io.Writer) (username string, err error) {
+func (s *ServerSession) auth5(nMethod byte, reader io.Reader, writer io.Writer) (user *protocol.MemoryUser, err error) {
 	buffer := buf.StackNew()
 	defer buffer.Release()
 
 	if _, err = buffer.ReadFullFrom(reader, int32(nMethod)); err != nil {
-		return "", errors.New("failed to read auth methods").Base(err)
+		return nil, errors.New("failed to read auth methods").Base(err)
 	}
 
+	methods := buffer.BytesRange(0, int32(nMethod))
 	var expectedAuth byte = authNotRequired
-	if s.config.AuthType == AuthType_PASSWORD {
+	if s.config.AuthType == AuthType_PASSWORD && (s.certUser == nil || !hasAuthMethod(authNotRequired, methods)) {
 		expectedAuth = authPassword
 	}
 
-	if !hasAuthMethod(expectedAuth, buffer.BytesRange(0, int32(nMethod))) {
+	if !hasAuthMethod(expectedAuth, methods) {
 		writeSocks5AuthenticationResponse(writer, socks5Version, authNoMatchingMethod)
-		return "", errors.New("no matching auth method")
+		return nil, errors.New("no matching auth method")
 	}
 
 	if err := writeSocks5AuthenticationResponse(writer, socks5Version, expectedAuth); err != nil {
-		return "", errors.New("failed to write auth response").Base(err)
+		return nil, errors.New("failed to write auth response").Base(err)
 	}
 
 	if expectedAuth == authPassword {
 		username, password, err := ReadUsernamePassword(reader)
 		if err != nil {
-			return "", errors.New("failed to read username and password for authentication").Base(err)
+			return nil, errors.New("failed to read username and password for authentication").Base(err)
 		}
 
-		if !s.config.HasAccount(username, password) {
+		user := s.validator.Get(username, password)
+		if user == nil {
 			writeSocks5AuthenticationResponse(writer, 0x01, 0xFF)
-			return "", errors.New("invalid username or password")
+			return nil, errors.New("invalid username or password")
 		}
 
 		if err := writeSocks5AuthenticationResponse(writer, 0x01, 0x00); err != nil {
-			return "", errors.New("failed to write auth response").Base(err)
+			return nil, errors.New("failed to write auth response").Base(err)
 		}
-		return username, nil
+		if s.certUser != nil {
+			return s.certUser, nil
+		}
+		return user, nil
 	}
 
-	return "", nil
+	return s.certUser, nil
 }
 
 func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer io.Writer) (*protocol.RequestHeader, error) {
-	var (
-		username string
-		err      error
-	)
-	if username, err = s.auth5(nMethod, reader, writer); err != nil {
+	user, err := s.auth5(nMethod, reader, writer)
+	if err != nil {
 		return nil, err
 	}
 
@@ -158,9 +185,7 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer io.Wri
 	}
 
 	request := new(protocol.RequestHeader)
-	if username != "" {
-		request.User = &protocol.MemoryUser{Email: username}
-	}
+	request.User = user
 	switch cmd {
 	case cmdTCPConnect, cmdTorResolve, cmdTorResolvePTR:
 		// We don't have a solution for Tor case now. Simply treat it as connect command.
@@ -172,8 +197,12 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer io.Wri
 		}
 		request.Command = protocol.RequestCommandUDP
 	case cmdTCPBind:
-		writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
-		return nil, errors.New("TCP bind is not supported.")
+		if !s.config.BindEnabled {
+			writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
+			return nil, errors.New("TCP bind is not enabled.")
+		}
+		s.bind = true
+		request.Command = protocol.RequestCommandTCP
 	default:
 		writeSocks5Response(writer, statusCmdNotSupport, net.AnyIP, net.Port(0))
 		return nil, errors.New("unknown command ", cmd)
@@ -188,6 +217,10 @@ func (s *ServerSession) handshake5(nMethod byte, reader io.Reader, writer io.Wri
 	request.Address = addr
 	request.Port = port
 
+	if s.bind {
+		return request, nil
+	}
+
 	responseAddress := s.address
 	responsePort := s.port
 	//nolint:gocritic // Use if else chain for clarity
@@ -218,6 +251,7 @@ func (s *ServerSession) Handshake(reader io.Reader, writer io.Writer) (*protocol
 	version := buffer.Byte(0)
 	cmd := buffer.Byte(1)
 	buffer.Release()
+	s.version = version
 
 	switch version {
 	case socks4Version:
@@ -229,6 +263,27 @@ func (s *ServerSession) Handshake(reader io.Reader, writer io.Writer) (*protocol
 	}
 }
 
+// writeBindResponse writes one of the two replies to a BIND request. The first reply carries
+// the address the server listens on, and the second one the address of the incoming connection.
+func (s *ServerSession) writeBindResponse(writer io.Writer, success bool, address net.Address, port net.Port) error {
+	if s.version == socks4Version {
+		code := byte(socks4RequestGranted)
+		if !success {
+			code = socks4RequestRejected
+		}
+		// Socks 4 can only carry IPv4 addresses. 0.0.0.0 tells the client to use the address of the server.
+		if !address.Family().IsIPv4() {
+			address = net.AnyIP
+		}
+		return writeSocks4Response(writer, code, address, port)
+	}
+	code := byte(statusSuccess)
+	if !success {
+		code = statusServerFailure
+	}
+	return writeSocks5Response(writer, code, address, port)
+}
+
 // ReadUsernamePassword reads Socks 5 username/password message from the given reader.
 // +----+------+----------+------+----------+
 // |VER | ULEN |  UNAME   | PLEN |  PASSWD  |
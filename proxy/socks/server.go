@@ -19,15 +19,19 @@ import (
 	"github.com/HZ-PRE/XrarCore/core"
 	"github.com/HZ-PRE/XrarCore/features/policy"
 	"github.com/HZ-PRE/XrarCore/features/routing"
+	"github.com/HZ-PRE/XrarCore/features/stats"
 	"github.com/HZ-PRE/XrarCore/proxy/http"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 	"github.com/HZ-PRE/XrarCore/transport/internet/udp"
 )
 
 // Server is a SOCKS 5 proxy server
 type Server struct {
 	config        *ServerConfig
+	validator     *protocol.PasswordValidator
 	policyManager policy.Manager
+	stats         stats.Manager
 	cone          bool
 	udpFilter     *UDPFilter
 	httpServer    *http.Server
@@ -38,24 +42,103 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 	v := core.MustFromContext(ctx)
 	s := &Server{
 		config:        config,
+		validator:     new(protocol.PasswordValidator),
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		stats:         v.GetFeature(stats.ManagerType()).(stats.Manager),
 		cone:          ctx.Value("cone").(bool),
 	}
+	for username, password := range config.Accounts {
+		if err := s.validator.Add(&protocol.MemoryUser{
+			Account: &Account{Username: username, Password: password},
+			Email:   username,
+			Level:   config.UserLevel,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, user := range config.Users {
+		u, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get Socks user").Base(err).AtError()
+		}
+		if err := s.validator.Add(u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
+	}
 	httpConfig := &http.ServerConfig{
 		UserLevel: config.UserLevel,
 	}
+	s.httpServer, _ = http.NewServer(ctx, httpConfig)
 	if config.AuthType == AuthType_PASSWORD {
-		httpConfig.Accounts = config.Accounts
+		for _, u := range s.validator.GetAllUsers() {
+			if err := s.httpServer.AddUser(ctx, toHTTPUser(u)); err != nil {
+				return nil, err
+			}
+		}
 		s.udpFilter = new(UDPFilter) // We only use this when auth is enabled
 	}
-	s.httpServer, _ = http.NewServer(ctx, httpConfig)
 	return s, nil
 }
 
-func (s *Server) policy() policy.Session {
-	config := s.config
-	p := s.policyManager.ForLevel(config.UserLevel)
-	return p
+// toHTTPUser converts a Socks user into an HTTP user, for HTTP requests in mixed mode.
+func toHTTPUser(u *protocol.MemoryUser) *protocol.MemoryUser {
+	account := u.Account.(*Account)
+	return &protocol.MemoryUser{
+		Account: &http.Account{Username: account.Username, Password: account.Password},
+		Email:   u.Email,
+		Level:   u.Level,
+	}
+}
+
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if _, ok := u.Account.(*Account); !ok {
+		return errors.New("account is not a Socks account")
+	}
+	if err := s.validator.Add(u); err != nil {
+		return err
+	}
+	if s.config.AuthType == AuthType_PASSWORD {
+		if err := s.httpServer.AddUser(ctx, toHTTPUser(u)); err != nil {
+			s.validator.Del(u.Email)
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	if err := s.validator.Del(e); err != nil {
+		return err
+	}
+	if s.config.AuthType == AuthType_PASSWORD {
+		return s.httpServer.RemoveUser(ctx, e)
+	}
+	return nil
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
+}
+
+func (s *Server) policy(inbound *session.Inbound) policy.Session {
+	level := s.config.UserLevel
+	if inbound != nil && inbound.User != nil {
+		level = inbound.User.Level
+	}
+	return s.policyManager.ForLevel(level)
 }
 
 // Network implements proxy.Inbound.
@@ -99,22 +182,28 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 }
 
 func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher, firstbyte []byte) error {
-	plcy := s.policy()
-	if err := conn.SetReadDeadline(time.Now().Add(plcy.Timeouts.Handshake)); err != nil {
-		errors.LogInfoInner(ctx, err, "failed to set deadline")
-	}
-
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil || !inbound.Gateway.IsValid() {
 		return errors.New("inbound gateway not specified")
 	}
 
+	plcy := s.policy(inbound)
+	if err := conn.SetReadDeadline(time.Now().Add(plcy.Timeouts.Handshake)); err != nil {
+		errors.LogInfoInner(ctx, err, "failed to set deadline")
+	}
+
 	svrSession := &ServerSession{
 		config:       s.config,
+		validator:    s.validator,
 		address:      inbound.Gateway.Address,
 		port:         inbound.Gateway.Port,
 		localAddress: net.IPAddress(conn.LocalAddr().(*net.TCPAddr).IP),
 	}
+	if email := tls.ClientUserEmail(conn); email != "" {
+		if svrSession.certUser = s.validator.GetByEmail(email); svrSession.certUser == nil {
+			return errors.New("no user ", email, " for the client certificate").AtWarning()
+		}
+	}
 
 	// Firstbyte is for forwarded conn from SOCKS inbound
 	// Because it needs first byte to choose protocol
@@ -136,13 +225,17 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		return errors.New("failed to read request").Base(err)
 	}
 	if request.User != nil {
-		inbound.User.Email = request.User.Email
+		inbound.User = request.User
 	}
 
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to clear deadline")
 	}
 
+	if svrSession.bind {
+		return s.handleBind(ctx, svrSession, request, reader, conn, inbound)
+	}
+
 	if request.Command == protocol.RequestCommandTCP {
 		dest := request.Destination()
 		errors.LogInfo(ctx, "TCP Connect request to ", dest)
@@ -160,7 +253,7 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 
 	if request.Command == protocol.RequestCommandUDP {
 		if s.udpFilter != nil {
-			s.udpFilter.Add(conn.RemoteAddr())
+			s.udpFilter.Add(conn.RemoteAddr(), request.User)
 		}
 		return s.handleUDP(conn)
 	}
@@ -175,14 +268,14 @@ func (*Server) handleUDP(c io.Reader) error {
 }
 
 func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writer, dest net.Destination, dispatcher routing.Dispatcher, inbound *session.Inbound) error {
+	plcy := s.policy(inbound)
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, s.policy().Timeouts.ConnectionIdle)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
 	if inbound != nil {
 		inbound.Timer = timer
 	}
 
-	plcy := s.policy()
 	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
 	link, err := dispatcher.Dispatch(ctx, dest)
 	if err != nil {
@@ -221,9 +314,16 @@ func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writ
 }
 
 func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
-	if s.udpFilter != nil && !s.udpFilter.Check(conn.RemoteAddr()) {
-		errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
-		return nil
+	inbound := session.InboundFromContext(ctx)
+	if s.udpFilter != nil {
+		user, ok := s.udpFilter.Check(conn.RemoteAddr())
+		if !ok {
+			errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
+			return nil
+		}
+		if user != nil {
+			inbound.User = user
+		}
 	}
 	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
 		payload := packet.Payload
@@ -253,7 +353,6 @@ func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dis
 		conn.Write(udpMessage.Bytes())
 	})
 
-	inbound := session.InboundFromContext(ctx)
 	if inbound != nil && inbound.Source.IsValid() {
 		errors.LogInfo(ctx, "client UDP connection from ", inbound.Source)
 	}
@@ -306,6 +405,111 @@ func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dis
 	}
 }
 
+// handleBind serves a BIND request. It listens for one incoming connection on the IP of the
+// client connection, and relays it to the client. There is no outbound that can carry an
+// accepted connection, so it doesn't go through routing, but the policy and the traffic
+// counters of the user still apply.
+func (s *Server) handleBind(ctx context.Context, svrSession *ServerSession, request *protocol.RequestHeader, reader io.Reader, conn stat.Connection, inbound *session.Inbound) error {
+	plcy := s.policy(inbound)
+	localIP := conn.LocalAddr().(*net.TCPAddr).IP
+
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: localIP})
+	if err != nil {
+		svrSession.writeBindResponse(conn, false, net.AnyIP, net.Port(0))
+		return errors.New("failed to listen for BIND request").Base(err)
+	}
+	defer listener.Close()
+
+	bindAddress := net.IPAddress(localIP)
+	if s.config.Address != nil {
+		bindAddress = s.config.Address.AsAddress()
+	}
+	if err := svrSession.writeBindResponse(conn, true, bindAddress, net.Port(listener.Addr().(*net.TCPAddr).Port)); err != nil {
+		return err
+	}
+	errors.LogInfo(ctx, "TCP Bind request from ", request.Destination(), ", listening on ", listener.Addr())
+
+	if err := listener.SetDeadline(time.Now().Add(plcy.Timeouts.ConnectionIdle)); err != nil {
+		errors.LogInfoInner(ctx, err, "failed to set deadline")
+	}
+	var remote *net.TCPConn
+	for remote == nil {
+		c, err := listener.AcceptTCP()
+		if err != nil {
+			svrSession.writeBindResponse(conn, false, net.AnyIP, net.Port(0))
+			return errors.New("failed to accept connection for BIND request").Base(err)
+		}
+		// Only the expected host is allowed to connect, if the client told us its IP.
+		if request.Address.Family().IsIP() && !request.Address.IP().IsUnspecified() && !request.Address.IP().Equal(c.RemoteAddr().(*net.TCPAddr).IP) {
+			errors.LogInfo(ctx, "rejected unexpected connection from ", c.RemoteAddr(), " for BIND request")
+			c.Close()
+			continue
+		}
+		remote = c
+	}
+	defer remote.Close()
+	peer := s.countUserTraffic(inbound.User, remote)
+
+	remoteAddr := remote.RemoteAddr().(*net.TCPAddr)
+	if err := svrSession.writeBindResponse(conn, true, net.IPAddress(remoteAddr.IP), net.Port(remoteAddr.Port)); err != nil {
+		return err
+	}
+	if inbound.Source.IsValid() {
+		log.Record(&log.AccessMessage{
+			From:   net.DestinationFromAddr(remoteAddr),
+			To:     inbound.Source,
+			Status: log.AccessAccepted,
+			Reason: "socks bind",
+			Email:  inbound.User.Email,
+		})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+
+	requestDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
+		if err := buf.Copy(buf.NewReader(reader), buf.NewWriter(peer), buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to transport all TCP request").Base(err)
+		}
+		return nil
+	}
+
+	responseDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
+		if err := buf.Copy(buf.NewReader(peer), buf.NewWriter(conn), buf.UpdateActivity(timer)); err != nil {
+			return errors.New("failed to transport all TCP response").Base(err)
+		}
+		return nil
+	}
+
+	requestDonePost := task.OnSuccess(requestDone, remote.CloseWrite)
+	if err := task.Run(ctx, requestDonePost, responseDone); err != nil {
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
+
+// countUserTraffic counts the traffic of a BIND connection as the traffic of the user, the same
+// way as the dispatcher does for other requests. Data sent to the peer is uplink.
+func (s *Server) countUserTraffic(user *protocol.MemoryUser, conn stat.Connection) stat.Connection {
+	if user == nil || len(user.Email) == 0 {
+		return conn
+	}
+	p := s.policyManager.ForLevel(user.Level)
+	counter := &stat.CounterConnection{Connection: conn}
+	if p.Stats.UserUplink {
+		counter.WriteCounter, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+user.Email+">>>traffic>>>uplink")
+	}
+	if p.Stats.UserDownlink {
+		counter.ReadCounter, _ = stats.GetOrRegisterCounter(s.stats, "user>>>"+user.Email+">>>traffic>>>downlink")
+	}
+	if counter.ReadCounter == nil && counter.WriteCounter == nil {
+		return conn
+	}
+	return counter
+}
+
 func init() {
 	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		return NewServer(ctx, config.(*ServerConfig))
@@ -3,6 +3,8 @@ package socks
 import (
 	"net"
 	"sync"
+
+	"github.com/HZ-PRE/XrarCore/common/protocol"
 )
 
 /*
@@ -18,14 +20,20 @@ type UDPFilter struct {
 	ips sync.Map
 }
 
-func (f *UDPFilter) Add(addr net.Addr) bool {
+// Add allows UDP packets from the IP of addr, and attributes them to user. user may be nil.
+func (f *UDPFilter) Add(addr net.Addr, user *protocol.MemoryUser) bool {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	f.ips.Store(ip, true)
+	f.ips.Store(ip, user)
 	return true
 }
 
-func (f *UDPFilter) Check(addr net.Addr) bool {
+// Check returns whether UDP packets from the IP of addr are allowed, and the user they belong to.
+func (f *UDPFilter) Check(addr net.Addr) (*protocol.MemoryUser, bool) {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	_, ok := f.ips.Load(ip)
-	return ok
+	user, ok := f.ips.Load(ip)
+	if !ok {
+		return nil, false
+	}
+	u, _ := user.(*protocol.MemoryUser)
+	return u, true
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/core"
 	"github.com/HZ-PRE/XrarCore/features/policy"
 	"github.com/HZ-PRE/XrarCore/features/routing"
+	"github.com/HZ-PRE/XrarCore/proxy"
 	"github.com/HZ-PRE/XrarCore/transport/internet/reality"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
@@ -226,6 +227,13 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 		return errors.New("unable to set read deadline").Base(err).AtWarning()
 	}
 
+	if email := tls.ClientUserEmail(iConn); email != "" {
+		// The verified client certificate decides the user for routing and stats.
+		if user = s.validator.GetByEmail(email); user == nil {
+			return errors.New("no user ", email, " for the client certificate").AtWarning()
+		}
+	}
+
 	inbound := session.InboundFromContext(ctx)
 	inbound.Name = "trojan"
 	inbound.CanSpliceCopy = 3
@@ -370,6 +378,7 @@ func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.S
 		errors.LogInfo(ctx, "realName = "+name)
 		errors.LogInfo(ctx, "realAlpn = "+alpn)
 	}
+	proxyInfo := proxy.ProxyProtocolInfo{SNI: name, ALPN: alpn}
 	name = strings.ToLower(name)
 	alpn = strings.ToLower(alpn)
 
@@ -507,6 +516,15 @@ func (s *Server) fallback(ctx context.Context, err error, sessionPolicy policy.S
 				p2, _ := strconv.ParseUint(localPort, 10, 16)
 				common.Must2(pro.Write([]byte{byte(p1 >> 8), byte(p1), byte(p2 >> 8), byte(p2)}))
 			}
+			if fb.Xver == 2 && len(fb.ProxyProtocolTlvs) > 0 {
+				proxyInfo.Email = tls.ClientUserEmail(iConn)
+				if inbound := session.InboundFromContext(ctx); inbound != nil {
+					proxyInfo.InboundTag = inbound.Tag
+				}
+				if err := proxy.WriteProxyProtocolTLVs(pro, proxyInfo.TLVs(fb.ProxyProtocolTlvs)); err != nil {
+					return errors.New("failed to write PROXY protocol TLVs").Base(err).AtWarning()
+				}
+			}
 			if err := serverWriter.WriteMultiBuffer(buf.MultiBuffer{pro}); err != nil {
 				return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
 			}
@@ -249,6 +249,7 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 				errors.LogInfo(ctx, "realName = "+name)
 				errors.LogInfo(ctx, "realAlpn = "+alpn)
 			}
+			proxyInfo := proxy.ProxyProtocolInfo{SNI: name, ALPN: alpn}
 			name = strings.ToLower(name)
 			alpn = strings.ToLower(alpn)
 
@@ -401,6 +402,15 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 						p2, _ := strconv.ParseUint(localPort, 10, 16)
 						pro.Write([]byte{byte(p1 >> 8), byte(p1), byte(p2 >> 8), byte(p2)})
 					}
+					if fb.Xver == 2 && len(fb.ProxyProtocolTlvs) > 0 {
+						proxyInfo.Email = tls.ClientUserEmail(iConn)
+						if inbound := session.InboundFromContext(ctx); inbound != nil {
+							proxyInfo.InboundTag = inbound.Tag
+						}
+						if err := proxy.WriteProxyProtocolTLVs(pro, proxyInfo.TLVs(fb.ProxyProtocolTlvs)); err != nil {
+							return errors.New("failed to write PROXY protocol TLVs").Base(err).AtWarning()
+						}
+					}
 					if err := serverWriter.WriteMultiBuffer(buf.MultiBuffer{pro}); err != nil {
 						return errors.New("failed to set PROXY protocol v", fb.Xver).Base(err).AtWarning()
 					}
@@ -446,6 +456,13 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection s
 	}
 	errors.LogInfo(ctx, "received request for ", request.Destination())
 
+	if email := tls.ClientUserEmail(iConn); email != "" {
+		// The verified client certificate decides the user for routing and stats.
+		if request.User = h.validator.GetByEmail(email); request.User == nil {
+			return errors.New("no user ", email, " for the client certificate").AtWarning()
+		}
+	}
+
 	inbound := session.InboundFromContext(ctx)
 	if inbound == nil {
 		panic("no inbound metadata")
@@ -0,0 +1,73 @@
+package wireguard
+
+import (
+	"encoding/hex"
+	"net/netip"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// AsAccount implements protocol.AsAccount. It allows peers to be managed as users of WireGuard inbounds.
+func (c *PeerConfig) AsAccount() (protocol.Account, error) {
+	if key, err := hex.DecodeString(c.PublicKey); err != nil || len(key) != 32 {
+		return nil, errors.New("invalid public key of peer: ", c.PublicKey)
+	}
+	account := &MemoryAccount{
+		PublicKey:    c.PublicKey,
+		PreSharedKey: c.PreSharedKey,
+		KeepAlive:    c.KeepAlive,
+	}
+	for _, ip := range c.AllowedIps {
+		prefix, err := netip.ParsePrefix(ip)
+		if err != nil {
+			return nil, errors.New("invalid allowed IP of peer ", c.PublicKey).Base(err)
+		}
+		account.AllowedIPs = append(account.AllowedIPs, prefix.Masked())
+	}
+	return account, nil
+}
+
+// MemoryAccount is an in-memory form of a WireGuard peer.
+type MemoryAccount struct {
+	// PublicKey of the peer, in hex.
+	PublicKey string
+	// PreSharedKey of the peer, in hex. May be empty.
+	PreSharedKey string
+	KeepAlive    uint32
+	AllowedIPs   []netip.Prefix
+}
+
+// Equals implements protocol.Account.Equals().
+func (a *MemoryAccount) Equals(account protocol.Account) bool {
+	wgAccount, ok := account.(*MemoryAccount)
+	if !ok {
+		return false
+	}
+	return a.PublicKey == wgAccount.PublicKey
+}
+
+// ToProto implements protocol.Account.ToProto().
+func (a *MemoryAccount) ToProto() proto.Message {
+	peer := &PeerConfig{
+		PublicKey:    a.PublicKey,
+		PreSharedKey: a.PreSharedKey,
+		KeepAlive:    a.KeepAlive,
+	}
+	for _, prefix := range a.AllowedIPs {
+		peer.AllowedIps = append(peer.AllowedIps, prefix.String())
+	}
+	return peer
+}
+
+// matchIP returns the length of the longest allowed prefix containing ip, or -1 if there is none.
+func (a *MemoryAccount) matchIP(ip netip.Addr) int {
+	bits := -1
+	for _, prefix := range a.AllowedIPs {
+		if prefix.Contains(ip) && prefix.Bits() > bits {
+			bits = prefix.Bits()
+		}
+	}
+	return bits
+}
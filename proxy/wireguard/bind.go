@@ -13,16 +13,18 @@ import (
 
 	xnet "github.com/HZ-PRE/XrarCore/common/net"
 	"github.com/HZ-PRE/XrarCore/features/dns"
+	"github.com/HZ-PRE/XrarCore/features/stats"
 	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 )
 
 type netReadInfo struct {
 	// status
 	waiter sync.WaitGroup
 	// param
-	buff []byte
+	buffs [][]byte
 	// result
-	bytes    int
+	sizes    []int
 	endpoint conn.Endpoint
 	err      error
 }
@@ -76,7 +78,7 @@ func (n *netBind) ParseEndpoint(s string) (conn.Endpoint, error) {
 
 // BatchSize implements conn.Bind
 func (bind *netBind) BatchSize() int {
-	return 1
+	return internet.UDPBatchSize
 }
 
 // Open implements conn.Bind
@@ -92,13 +94,16 @@ func (bind *netBind) Open(uport uint16) ([]conn.ReceiveFunc, uint16, error) {
 		}()
 
 		r := &netReadInfo{
-			buff: bufs[0],
+			buffs: bufs,
 		}
 		r.waiter.Add(1)
 		bind.readQueue <- r
 		r.waiter.Wait() // wait read goroutine done, or we will miss the result
-		sizes[0], eps[0] = r.bytes, r.endpoint
-		return 1, r.err
+		n = copy(sizes, r.sizes)
+		for i := range n {
+			eps[i] = r.endpoint
+		}
+		return n, r.err
 	}
 	workers := bind.workers
 	if workers <= 0 {
@@ -134,24 +139,33 @@ func (bind *netBindClient) connectTo(endpoint *netEndpoint) error {
 		return err
 	}
 	endpoint.conn = c
+	endpoint.batch = newBatchConn(c)
 
 	go func(readQueue <-chan *netReadInfo, endpoint *netEndpoint) {
+		batch := endpoint.batch
 		for {
 			v, ok := <-readQueue
 			if !ok {
 				return
 			}
-			i, err := c.Read(v.buff)
+			if batch != nil {
+				v.sizes, v.err = batch.read(v.buffs)
+			} else {
+				var i int
+				i, v.err = c.Read(v.buffs[0])
+				v.sizes = []int{i}
+			}
 
-			if i > 3 {
-				v.buff[1] = 0
-				v.buff[2] = 0
-				v.buff[3] = 0
+			for i, size := range v.sizes {
+				if size > 3 {
+					v.buffs[i][1] = 0
+					v.buffs[i][2] = 0
+					v.buffs[i][3] = 0
+				}
 			}
 
-			v.bytes = i
 			v.endpoint = endpoint
-			v.err = err
+			err := v.err
 			v.waiter.Done()
 			if err != nil && errors.Is(err, io.EOF) {
 				endpoint.conn = nil
@@ -182,6 +196,11 @@ func (bind *netBindClient) Send(buff [][]byte, endpoint conn.Endpoint) error {
 		if len(buff) > 3 && len(bind.reserved) == 3 {
 			copy(buff[1:], bind.reserved)
 		}
+	}
+	if nend.batch != nil {
+		return nend.batch.write(buff)
+	}
+	for _, buff := range buff {
 		if _, err = nend.conn.Write(buff); err != nil {
 			return err
 		}
@@ -189,6 +208,85 @@ func (bind *netBindClient) Send(buff [][]byte, endpoint conn.Endpoint) error {
 	return nil
 }
 
+// batchConn does batch I/O on the UDP socket of a connection dialed by the outbound.
+type batchConn struct {
+	*internet.UDPBatchConn
+	dest         *net.UDPAddr
+	readCounter  stats.Counter
+	writeCounter stats.Counter
+
+	readMsgs  []internet.UDPMessage
+	writeLock sync.Mutex
+	writeMsgs []internet.UDPMessage
+}
+
+// newBatchConn returns a batchConn for c, or nil if c isn't on a UDP socket.
+func newBatchConn(c net.Conn) *batchConn {
+	var readCounter, writeCounter stats.Counter
+	if statConn, ok := c.(*stat.CounterConnection); ok {
+		c = statConn.Connection
+		readCounter, writeCounter = statConn.ReadCounter, statConn.WriteCounter
+	}
+	wrapper, ok := c.(*internet.PacketConnWrapper)
+	if !ok {
+		return nil
+	}
+	udpConn, ok := wrapper.Conn.(*net.UDPConn)
+	if !ok {
+		return nil
+	}
+	dest, ok := wrapper.Dest.(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	return &batchConn{
+		UDPBatchConn: internet.NewUDPBatchConn(udpConn, false),
+		dest:         dest,
+		readCounter:  readCounter,
+		writeCounter: writeCounter,
+	}
+}
+
+func (c *batchConn) read(buffs [][]byte) ([]int, error) {
+	msgs := c.readMsgs[:0]
+	for _, b := range buffs {
+		msgs = append(msgs, internet.UDPMessage{Buffer: b})
+	}
+	c.readMsgs = msgs
+	n, err := c.ReadBatch(msgs)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, n)
+	for i := range sizes {
+		sizes[i] = msgs[i].N
+		if c.readCounter != nil {
+			c.readCounter.Add(int64(msgs[i].N))
+		}
+	}
+	return sizes, nil
+}
+
+func (c *batchConn) write(buffs [][]byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	msgs := c.writeMsgs[:0]
+	var n int64
+	for _, b := range buffs {
+		msgs = append(msgs, internet.UDPMessage{Buffer: b, Addr: c.dest})
+		n += int64(len(b))
+	}
+	c.writeMsgs = msgs
+	if err := c.WriteBatch(msgs); err != nil {
+		return err
+	}
+	if c.writeCounter != nil {
+		c.writeCounter.Add(n)
+	}
+	return nil
+}
+
 type netBindServer struct {
 	netBind
 }
@@ -215,8 +313,9 @@ func (bind *netBindServer) Send(buff [][]byte, endpoint conn.Endpoint) error {
 }
 
 type netEndpoint struct {
-	dst  xnet.Destination
-	conn net.Conn
+	dst   xnet.Destination
+	conn  net.Conn
+	batch *batchConn
 }
 
 func (netEndpoint) ClearSrc() {}
@@ -3,13 +3,18 @@ package wireguard
 import (
 	"context"
 	goerrors "errors"
+	"fmt"
 	"io"
+	"net/netip"
+	"strings"
+	"sync"
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/buf"
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/log"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
 	"github.com/HZ-PRE/XrarCore/common/session"
 	"github.com/HZ-PRE/XrarCore/common/signal"
 	"github.com/HZ-PRE/XrarCore/common/task"
@@ -24,9 +29,14 @@ var nullDestination = net.TCPDestination(net.AnyIP, 0)
 
 type Server struct {
 	bindServer *netBindServer
+	tun        Tunnel
 
 	info          routingInfo
 	policyManager policy.Manager
+
+	access sync.RWMutex
+	// users are the peers of the device, including the ones without email.
+	users []*protocol.MemoryUser
 }
 
 type routingInfo struct {
@@ -58,6 +68,18 @@ func NewServer(ctx context.Context, conf *DeviceConfig) (*Server, error) {
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 	}
 
+	for _, peer := range conf.Peers {
+		account, err := peer.AsAccount()
+		if err != nil {
+			return nil, err
+		}
+		server.users = append(server.users, &protocol.MemoryUser{
+			Account: account,
+			Email:   peer.Email,
+			Level:   peer.Level,
+		})
+	}
+
 	tun, err := conf.createTun()(endpoints, int(conf.Mtu), server.forwardConnection)
 	if err != nil {
 		return nil, err
@@ -67,10 +89,114 @@ func NewServer(ctx context.Context, conf *DeviceConfig) (*Server, error) {
 		_ = tun.Close()
 		return nil, err
 	}
+	server.tun = tun
 
 	return server, nil
 }
 
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if u.Email == "" {
+		return errors.New("email must not be empty")
+	}
+	account, ok := u.Account.(*MemoryAccount)
+	if !ok {
+		return errors.New("account is not a WireGuard peer")
+	}
+
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, u.Email) {
+			return errors.New("User ", u.Email, " already exists.")
+		}
+		if user.Account.Equals(account) {
+			return errors.New("peer ", account.PublicKey, " already exists")
+		}
+	}
+
+	var request strings.Builder
+	writePeerIPCRequest(&request, account.ToProto().(*PeerConfig))
+	if err := s.tun.IpcSet(request.String()); err != nil {
+		return errors.New("failed to add peer ", account.PublicKey).Base(err)
+	}
+	s.users = append(s.users, u)
+	return nil
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, email string) error {
+	if email == "" {
+		return errors.New("email must not be empty")
+	}
+
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	for i, user := range s.users {
+		if !strings.EqualFold(user.Email, email) {
+			continue
+		}
+		account := user.Account.(*MemoryAccount)
+		if err := s.tun.IpcSet(fmt.Sprintf("public_key=%s\nremove=true\n", account.PublicKey)); err != nil {
+			return errors.New("failed to remove peer ", account.PublicKey).Base(err)
+		}
+		s.users = append(s.users[:i], s.users[i+1:]...)
+		return nil
+	}
+	return errors.New("User ", email, " not found.")
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	s.access.RLock()
+	defer s.access.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email != "" && strings.EqualFold(user.Email, email) {
+			return user
+		}
+	}
+	return nil
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	s.access.RLock()
+	defer s.access.RUnlock()
+
+	users := make([]*protocol.MemoryUser, 0, len(s.users))
+	for _, user := range s.users {
+		if user.Email != "" {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(ctx context.Context) int64 {
+	return int64(len(s.GetUsers(ctx)))
+}
+
+// userForIP returns the user of the peer that is allowed to send packets from ip.
+// The WireGuard device only accepts packets from the allowed IPs of their peers,
+// so the inner source address of a connection identifies its peer.
+func (s *Server) userForIP(ip netip.Addr) *protocol.MemoryUser {
+	s.access.RLock()
+	defer s.access.RUnlock()
+
+	var matched *protocol.MemoryUser
+	bits := -1
+	for _, user := range s.users {
+		if b := user.Account.(*MemoryAccount).matchIP(ip); b > bits {
+			matched, bits = user, b
+		}
+	}
+	return matched
+}
+
 // Network implements proxy.Inbound.
 func (*Server) Network() []net.Network {
 	return []net.Network{net.Network_UDP}
@@ -107,16 +233,26 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 			return err
 		}
 
-		for _, payload := range mpayload {
+		for len(mpayload) > 0 {
 			v, ok := <-s.bindServer.readQueue
 			if !ok {
 				return nil
 			}
-			i, err := payload.Read(v.buff)
+			// Hand over as many datagrams as the device accepts at once.
+			for len(mpayload) > 0 && len(v.sizes) < len(v.buffs) {
+				var payload *buf.Buffer
+				mpayload, payload = buf.SplitFirst(mpayload)
+				i, err := payload.Read(v.buffs[len(v.sizes)])
+				payload.Release()
+				if err != nil {
+					v.err = err
+					break
+				}
+				v.sizes = append(v.sizes, i)
+			}
 
-			v.bytes = i
 			v.endpoint = nep
-			v.err = err
+			err := v.err
 			v.waiter.Done()
 			if err != nil && goerrors.Is(err, io.EOF) {
 				nep.conn = nil
@@ -133,8 +269,18 @@ func (s *Server) forwardConnection(dest net.Destination, conn net.Conn) {
 	}
 	defer conn.Close()
 
+	var user *protocol.MemoryUser
+	if src, ok := netip.AddrFromSlice(net.DestinationFromAddr(conn.RemoteAddr()).Address.IP()); ok {
+		user = s.userForIP(src.Unmap())
+	}
+	var level uint32
+	var email string
+	if user != nil {
+		level, email = user.Level, user.Email
+	}
+
 	ctx, cancel := context.WithCancel(core.ToBackgroundDetachedContext(s.info.ctx))
-	plcy := s.policyManager.ForLevel(0)
+	plcy := s.policyManager.ForLevel(level)
 	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
 	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
@@ -142,10 +288,16 @@ func (s *Server) forwardConnection(dest net.Destination, conn net.Conn) {
 		To:     dest,
 		Status: log.AccessAccepted,
 		Reason: "",
+		Email:  email,
 	})
 
 	if s.info.inboundTag != nil {
-		ctx = session.ContextWithInbound(ctx, s.info.inboundTag)
+		// Connections of different peers must not share the same inbound session.
+		inbound := *s.info.inboundTag
+		if user != nil && user.Email != "" {
+			inbound.User = user
+		}
+		ctx = session.ContextWithInbound(ctx, &inbound)
 	}
 
 	// what's this?
@@ -0,0 +1,88 @@
+package wireguard
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/protocol"
+	"github.com/HZ-PRE/XrarCore/core"
+)
+
+func newTestServer(peers ...*PeerConfig) *Server {
+	v, err := core.New(&core.Config{})
+	common.Must(err)
+	server, err := core.CreateObject(v, &DeviceConfig{
+		SecretKey: strings.Repeat("01", 32),
+		Endpoint:  []string{"10.0.0.1"},
+		Mtu:       1420,
+		Peers:     peers,
+	})
+	common.Must(err)
+	return server.(*Server)
+}
+
+func newTestUser(email, publicKey string, allowedIPs ...string) *protocol.MemoryUser {
+	account, err := (&PeerConfig{PublicKey: publicKey, AllowedIps: allowedIPs}).AsAccount()
+	common.Must(err)
+	return &protocol.MemoryUser{Email: email, Account: account}
+}
+
+func TestServerUsers(t *testing.T) {
+	key1 := strings.Repeat("02", 32)
+	key2 := strings.Repeat("03", 32)
+	server := newTestServer(&PeerConfig{
+		PublicKey:  key1,
+		AllowedIps: []string{"10.0.0.0/24"},
+		Email:      "a",
+	})
+	ctx := context.Background()
+	inner := netip.MustParseAddr("10.0.0.2")
+	if u := server.userForIP(inner); u == nil || u.Email != "a" {
+		t.Fatal("unexpected user of ", inner, ": ", u)
+	}
+
+	// The more specific allowed IP of the added peer wins.
+	common.Must(server.AddUser(ctx, newTestUser("b", key2, "10.0.0.2/32")))
+	if u := server.GetUser(ctx, "B"); u == nil || u.Email != "b" {
+		t.Error("added user is not found")
+	}
+	if c := server.GetUsersCount(ctx); c != 2 {
+		t.Error("expect 2 users, but got ", c)
+	}
+	if u := server.userForIP(inner); u == nil || u.Email != "b" {
+		t.Error("unexpected user of ", inner, ": ", u)
+	}
+	if u := server.userForIP(netip.MustParseAddr("10.0.1.1")); u != nil {
+		t.Error("unexpected user of an IP not allowed: ", u.Email)
+	}
+
+	if err := server.AddUser(ctx, newTestUser("c", key2, "10.0.1.0/24")); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Error("expect the duplicate public key to be rejected, but got ", err)
+	}
+	if err := server.AddUser(ctx, newTestUser("A", strings.Repeat("04", 32))); err == nil {
+		t.Error("expect the duplicate email to be rejected")
+	}
+	if c := server.GetUsersCount(ctx); c != 2 {
+		t.Error("expect 2 users, but got ", c)
+	}
+
+	common.Must(server.RemoveUser(ctx, "b"))
+	if u := server.GetUser(ctx, "b"); u != nil {
+		t.Error("removed user is found")
+	}
+	if u := server.userForIP(inner); u == nil || u.Email != "a" {
+		t.Error("unexpected user of ", inner, " after removal: ", u)
+	}
+	if err := server.RemoveUser(ctx, "b"); err == nil {
+		t.Error("expect removing a missing user to fail")
+	}
+
+	// The public key of a removed peer can be added again.
+	common.Must(server.AddUser(ctx, newTestUser("c", key2, "10.0.1.0/24")))
+	if u := server.userForIP(netip.MustParseAddr("10.0.1.1")); u == nil || u.Email != "c" {
+		t.Error("unexpected user of 10.0.1.1: ", u)
+	}
+}
@@ -32,6 +32,7 @@ type promiscuousModeHandler func(dest xnet.Destination, conn net.Conn)
 
 type Tunnel interface {
 	BuildDevice(ipc string, bind conn.Bind) error
+	IpcSet(ipc string) error
 	DialContextTCPAddrPort(ctx context.Context, addr netip.AddrPort) (net.Conn, error)
 	DialUDPAddrPort(laddr, raddr netip.AddrPort) (net.Conn, error)
 	Close() error
@@ -76,6 +77,16 @@ func (t *tunnel) BuildDevice(ipc string, bind conn.Bind) (err error) {
 	return nil
 }
 
+func (t *tunnel) IpcSet(ipc string) error {
+	t.rw.Lock()
+	defer t.rw.Unlock()
+
+	if t.device == nil {
+		return errors.New("device is not initialized")
+	}
+	return t.device.IpcSet(ipc)
+}
+
 func (t *tunnel) Close() (err error) {
 	t.rw.Lock()
 	defer t.rw.Unlock()
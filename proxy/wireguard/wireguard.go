@@ -85,26 +85,31 @@ func createIPCRequest(conf *DeviceConfig) string {
 	}
 
 	for _, peer := range conf.Peers {
-		if peer.PublicKey != "" {
-			request.WriteString(fmt.Sprintf("public_key=%s\n", peer.PublicKey))
-		}
+		writePeerIPCRequest(&request, peer)
+	}
 
-		if peer.PreSharedKey != "" {
-			request.WriteString(fmt.Sprintf("preshared_key=%s\n", peer.PreSharedKey))
-		}
+	return request.String()[:request.Len()]
+}
 
-		if peer.Endpoint != "" {
-			request.WriteString(fmt.Sprintf("endpoint=%s\n", peer.Endpoint))
-		}
+// serialize a peer into the IPC request
+func writePeerIPCRequest(request *strings.Builder, peer *PeerConfig) {
+	if peer.PublicKey != "" {
+		request.WriteString(fmt.Sprintf("public_key=%s\n", peer.PublicKey))
+	}
 
-		for _, ip := range peer.AllowedIps {
-			request.WriteString(fmt.Sprintf("allowed_ip=%s\n", ip))
-		}
+	if peer.PreSharedKey != "" {
+		request.WriteString(fmt.Sprintf("preshared_key=%s\n", peer.PreSharedKey))
+	}
 
-		if peer.KeepAlive != 0 {
-			request.WriteString(fmt.Sprintf("persistent_keepalive_interval=%d\n", peer.KeepAlive))
-		}
+	if peer.Endpoint != "" {
+		request.WriteString(fmt.Sprintf("endpoint=%s\n", peer.Endpoint))
 	}
 
-	return request.String()[:request.Len()]
+	for _, ip := range peer.AllowedIps {
+		request.WriteString(fmt.Sprintf("allowed_ip=%s\n", ip))
+	}
+
+	if peer.KeepAlive != 0 {
+		request.WriteString(fmt.Sprintf("persistent_keepalive_interval=%d\n", peer.KeepAlive))
+	}
 }
@@ -1,9 +1,11 @@
 package internet
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	gonet "net"
+	"slices"
 	"strings"
 
 	"github.com/HZ-PRE/XrarCore/common"
@@ -17,6 +19,7 @@ import (
 	"github.com/HZ-PRE/XrarCore/transport"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 	"github.com/HZ-PRE/XrarCore/transport/pipe"
+	mdns "github.com/miekg/dns"
 )
 
 // Dialer is the interface for dialing outbound connections.
@@ -106,6 +109,15 @@ func lookupIP(domain string, strategy DomainStrategy, localAddr net.Address) ([]
 	return ips, err
 }
 
+// LookupRecords queries records of type qType for domain through the DNS feature of the core.
+func LookupRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, error) {
+	lookup, ok := dnsClient.(dns.RecordLookup)
+	if !ok {
+		return nil, errors.New("DNS client doesn't support querying ", mdns.Type(qType).String(), " records")
+	}
+	return lookup.LookupRecords(ctx, domain, qType)
+}
+
 func canLookupIP(ctx context.Context, dst net.Destination, sockopt *SocketConfig) bool {
 	if dst.Address.Family().IsIP() || dnsClient == nil {
 		return false
@@ -143,11 +155,93 @@ func redirect(ctx context.Context, dst net.Destination, obt string) net.Conn {
 	return nil
 }
 
-func checkAddressPortStrategy(ctx context.Context, dest net.Destination, sockopt *SocketConfig) (*net.Destination, error) {
+// lookupSRV returns the SRV records of domain, from the DNS feature of the core if possible.
+func lookupSRV(ctx context.Context, domain string) ([]*mdns.SRV, error) {
+	if _, ok := dnsClient.(dns.RecordLookup); !ok {
+		_, records, err := gonet.DefaultResolver.LookupSRV(ctx, "", "", domain)
+		if err != nil {
+			return nil, err
+		}
+		srvs := make([]*mdns.SRV, 0, len(records))
+		for _, r := range records {
+			srvs = append(srvs, &mdns.SRV{Priority: r.Priority, Weight: r.Weight, Port: r.Port, Target: r.Target})
+		}
+		return srvs, nil
+	}
+	records, err := LookupRecords(ctx, domain, mdns.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+	srvs := make([]*mdns.SRV, 0, len(records))
+	for _, rr := range records {
+		if srv, ok := rr.(*mdns.SRV); ok {
+			srvs = append(srvs, srv)
+		}
+	}
+	return srvs, nil
+}
+
+// lookupTXT returns the TXT records of domain, from the DNS feature of the core if possible.
+func lookupTXT(ctx context.Context, domain string) ([]string, error) {
+	if _, ok := dnsClient.(dns.RecordLookup); !ok {
+		return gonet.DefaultResolver.LookupTXT(ctx, domain)
+	}
+	records, err := LookupRecords(ctx, domain, mdns.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	txts := make([]string, 0, len(records))
+	for _, rr := range records {
+		if txt, ok := rr.(*mdns.TXT); ok {
+			txts = append(txts, strings.Join(txt.Txt, ""))
+		}
+	}
+	return txts, nil
+}
+
+// sortSRV orders SRV records as RFC 2782 specifies: by priority, and by a weighted random
+// selection among records of the same priority.
+func sortSRV(records []*mdns.SRV) []*mdns.SRV {
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b *mdns.SRV) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(a.Priority, b.Priority)
+		}
+		// Records of weight 0 are placed at the beginning of the list.
+		return cmp.Compare(min(a.Weight, 1), min(b.Weight, 1))
+	})
+	sorted := make([]*mdns.SRV, 0, len(records))
+	for len(records) > 0 {
+		end := 1
+		for end < len(records) && records[end].Priority == records[0].Priority {
+			end++
+		}
+		group := records[:end]
+		for len(group) > 0 {
+			sum := 0
+			for _, r := range group {
+				sum += int(r.Weight)
+			}
+			n := dice.Roll(sum + 1)
+			i, running := 0, int(group[0].Weight)
+			for running < n {
+				i++
+				running += int(group[i].Weight)
+			}
+			sorted = append(sorted, group[i])
+			group = slices.Delete(group, i, i+1)
+		}
+		records = records[end:]
+	}
+	return sorted
+}
+
+// checkAddressPortStrategy returns the destinations to try in order, or nil if dest should be used
+// as is.
+func checkAddressPortStrategy(ctx context.Context, dest net.Destination, sockopt *SocketConfig) ([]net.Destination, error) {
 	if sockopt.AddressPortStrategy == AddressPortStrategy_None {
 		return nil, nil
 	}
-	newDest := dest
 	var OverridePort, OverrideAddress bool
 	var OverrideBy string
 	switch sockopt.AddressPortStrategy {
@@ -183,51 +277,54 @@ func checkAddressPortStrategy(ctx context.Context, dest net.Destination, sockopt
 		return nil, nil
 	}
 
+	var dests []net.Destination
+	override := func(address net.Address, port net.Port) {
+		newDest := dest
+		if OverridePort {
+			newDest.Port = port
+		}
+		if OverrideAddress {
+			newDest.Address = address
+		}
+		dests = append(dests, newDest)
+	}
+
 	if OverrideBy == "srv" {
 		errors.LogDebug(ctx, "query SRV record for "+dest.Address.String())
-		parts := strings.SplitN(dest.Address.String(), ".", 3)
-		if len(parts) != 3 {
-			return nil, errors.New("invalid address format", dest.Address.String())
-		}
-		_, srvRecords, err := gonet.DefaultResolver.LookupSRV(context.Background(), parts[0][1:], parts[1][1:], parts[2])
+		records, err := lookupSRV(ctx, dest.Address.String())
 		if err != nil {
 			return nil, errors.New("failed to lookup SRV record").Base(err)
 		}
-		errors.LogDebug(ctx, "SRV record: "+fmt.Sprintf("addr=%s, port=%d, priority=%d, weight=%d", srvRecords[0].Target, srvRecords[0].Port, srvRecords[0].Priority, srvRecords[0].Weight))
-		if OverridePort {
-			newDest.Port = net.Port(srvRecords[0].Port)
-		}
-		if OverrideAddress {
-			newDest.Address = net.ParseAddress(srvRecords[0].Target)
+		for _, srv := range sortSRV(records) {
+			// A target of "." means that the service is decidedly not available (RFC 2782).
+			target := strings.TrimSuffix(srv.Target, ".")
+			if target == "" {
+				continue
+			}
+			errors.LogDebug(ctx, "SRV record: "+fmt.Sprintf("addr=%s, port=%d, priority=%d, weight=%d", target, srv.Port, srv.Priority, srv.Weight))
+			override(net.ParseAddress(target), net.Port(srv.Port))
 		}
-		return &newDest, nil
 	}
 	if OverrideBy == "txt" {
 		errors.LogDebug(ctx, "query TXT record for "+dest.Address.String())
-		txtRecords, err := gonet.DefaultResolver.LookupTXT(ctx, dest.Address.String())
+		txtRecords, err := lookupTXT(ctx, dest.Address.String())
 		if err != nil {
-			errors.LogError(ctx, "failed to lookup SRV record: "+err.Error())
-			return nil, errors.New("failed to lookup SRV record").Base(err)
+			return nil, errors.New("failed to lookup TXT record").Base(err)
 		}
 		for _, txtRecord := range txtRecords {
 			errors.LogDebug(ctx, "TXT record: "+txtRecord)
-			addr_s, port_s, _ := net.SplitHostPort(string(txtRecord))
-			addr := net.ParseAddress(addr_s)
+			addr_s, port_s, _ := net.SplitHostPort(txtRecord)
 			port, err := net.PortFromString(port_s)
 			if err != nil {
 				continue
 			}
-
-			if OverridePort {
-				newDest.Port = port
-			}
-			if OverrideAddress {
-				newDest.Address = addr
-			}
-			return &newDest, nil
+			override(net.ParseAddress(addr_s), port)
 		}
 	}
-	return nil, nil
+	if len(dests) == 0 {
+		return nil, errors.New("no usable ", OverrideBy, " record for ", dest.Address)
+	}
+	return dests, nil
 }
 
 // DialSystem calls system dialer to create a network connection.
@@ -242,13 +339,40 @@ func DialSystem(ctx context.Context, dest net.Destination, sockopt *SocketConfig
 		return effectiveSystemDialer.Dial(ctx, src, dest, sockopt)
 	}
 
-	if newDest, err := checkAddressPortStrategy(ctx, dest, sockopt); err == nil && newDest != nil {
+	dests, err := checkAddressPortStrategy(ctx, dest, sockopt)
+	if err != nil {
+		errors.LogWarningInner(ctx, err, "failed to apply address port strategy, dialing ", dest, " directly")
+	}
+	if len(dests) == 0 {
+		return dialSystem(ctx, src, dest, sockopt)
+	}
+	// Fail over to the next destination in order.
+	errs := make([]error, 0, len(dests))
+	for _, newDest := range dests {
 		errors.LogInfo(ctx, "replace destination with "+newDest.String())
-		dest = *newDest
+		conn, err := dialSystem(ctx, src, newDest, sockopt)
+		if err == nil {
+			return conn, nil
+		}
+		errors.LogInfoInner(ctx, err, "failed to dial ", newDest)
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
 	}
+	return nil, errors.New("failed to dial any destination of ", dest).Base(errors.Combine(errs...))
+}
 
+func dialSystem(ctx context.Context, src net.Address, dest net.Destination, sockopt *SocketConfig) (net.Conn, error) {
 	if canLookupIP(ctx, dest, sockopt) {
 		ips, err := lookupIP(dest.Address.String(), sockopt.DomainStrategy, src)
+		if err == nil && len(ips) > 1 && dest.Network == net.Network_TCP && (obm == nil || len(sockopt.DialerProxy) == 0) {
+			ips = SortIPs(ShuffleIPs(ips), sockopt.DomainStrategy.preferIP6())
+			errors.LogInfo(ctx, "racing ", len(ips), " addresses of ", dest.Address)
+			return DialHappyEyeballs(ctx, ips, sockopt.HappyEyeballs, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+				return effectiveSystemDialer.Dial(ctx, src, net.TCPDestination(net.IPAddress(ip), dest.Port), sockopt)
+			})
+		}
 		if err == nil && len(ips) > 0 {
 			dest.Address = net.IPAddress(ips[dice.Roll(len(ips))])
 			errors.LogInfo(ctx, "replace destination with "+dest.String())
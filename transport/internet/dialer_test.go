@@ -2,13 +2,16 @@ package internet_test
 
 import (
 	"context"
+	gonet "net"
 	"testing"
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/features/dns"
 	"github.com/HZ-PRE/XrarCore/testing/servers/tcp"
 	. "github.com/HZ-PRE/XrarCore/transport/internet"
 	"github.com/google/go-cmp/cmp"
+	mdns "github.com/miekg/dns"
 )
 
 func TestDialWithLocalAddr(t *testing.T) {
@@ -24,3 +27,89 @@ func TestDialWithLocalAddr(t *testing.T) {
 	}
 	conn.Close()
 }
+
+type fakeRecordLookup struct {
+	records map[uint16][]mdns.RR
+}
+
+func (*fakeRecordLookup) Type() interface{} { return dns.ClientType() }
+func (*fakeRecordLookup) Start() error      { return nil }
+func (*fakeRecordLookup) Close() error      { return nil }
+
+func (*fakeRecordLookup) LookupIP(domain string, option dns.IPOption) ([]net.IP, error) {
+	return nil, dns.ErrEmptyResponse
+}
+
+func (l *fakeRecordLookup) LookupRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, error) {
+	return l.records[qType], nil
+}
+
+func closedPort() net.Port {
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	listener.Close()
+	return net.Port(listener.Addr().(*gonet.TCPAddr).Port)
+}
+
+func TestDialAddressPortStrategyFailover(t *testing.T) {
+	server := &tcp.Server{}
+	dest, err := server.Start()
+	common.Must(err)
+	defer server.Close()
+
+	InitSystemDialer(&fakeRecordLookup{records: map[uint16][]mdns.RR{
+		mdns.TypeSRV: {
+			&mdns.SRV{Priority: 2, Weight: 1, Port: uint16(dest.Port), Target: "127.0.0.1."},
+			&mdns.SRV{Priority: 1, Weight: 1, Port: uint16(closedPort()), Target: "127.0.0.1."},
+			&mdns.SRV{Priority: 1, Weight: 0, Port: 1, Target: "."},
+		},
+		mdns.TypeTXT: {
+			&mdns.TXT{Txt: []string{"127.0.0.1:" + closedPort().String()}},
+			&mdns.TXT{Txt: []string{"127.0.0.1:", dest.Port.String()}},
+		},
+	}}, nil)
+	defer InitSystemDialer(nil, nil)
+
+	for _, strategy := range []AddressPortStrategy{AddressPortStrategy_SrvPortAndAddress, AddressPortStrategy_TxtPortAndAddress} {
+		conn, err := DialSystem(context.Background(), net.TCPDestination(net.DomainAddress("example.com"), 443), &SocketConfig{AddressPortStrategy: strategy})
+		if err != nil {
+			t.Fatal(strategy, " ", err)
+		}
+		if r := cmp.Diff(conn.RemoteAddr().String(), "127.0.0.1:"+dest.Port.String()); r != "" {
+			t.Error(strategy, " ", r)
+		}
+		conn.Close()
+	}
+}
+
+func TestDialSRVWeight(t *testing.T) {
+	light := &tcp.Server{}
+	lightDest, err := light.Start()
+	common.Must(err)
+	defer light.Close()
+	heavy := &tcp.Server{}
+	heavyDest, err := heavy.Start()
+	common.Must(err)
+	defer heavy.Close()
+
+	InitSystemDialer(&fakeRecordLookup{records: map[uint16][]mdns.RR{
+		mdns.TypeSRV: {
+			&mdns.SRV{Priority: 1, Weight: 1, Port: uint16(lightDest.Port), Target: "127.0.0.1"},
+			&mdns.SRV{Priority: 1, Weight: 9, Port: uint16(heavyDest.Port), Target: "127.0.0.1"},
+		},
+	}}, nil)
+	defer InitSystemDialer(nil, nil)
+
+	count := 0
+	for range 200 {
+		conn, err := DialSystem(context.Background(), net.TCPDestination(net.DomainAddress("example.com"), 443), &SocketConfig{AddressPortStrategy: AddressPortStrategy_SrvPortAndAddress})
+		common.Must(err)
+		if conn.RemoteAddr().(*gonet.TCPAddr).Port == int(heavyDest.Port) {
+			count++
+		}
+		conn.Close()
+	}
+	if count < 150 || count == 200 {
+		t.Error("expect the record of weight 9 to be picked about 180 times, but got ", count)
+	}
+}
@@ -126,11 +126,11 @@ func getGrpcClient(ctx context.Context, dest net.Destination, streamSettings *in
 			c, err := internet.DialSystem(gctx, net.TCPDestination(address, port), sockopt)
 			if err == nil {
 				if tlsConfig != nil {
-					config := tlsConfig.GetTLSConfig()
+					config := tlsConfig.GetTLSConfigContext(gctx)
 					if config.ServerName == "" && address.Family().IsDomain() {
 						config.ServerName = address.Domain()
 					}
-					if fingerprint := tls.GetFingerprint(tlsConfig.Fingerprint); fingerprint != nil {
+					if fingerprint := tlsConfig.ClientFingerprint(); fingerprint != nil {
 						return tls.UClient(c, config, fingerprint), nil
 					} else { // Fallback to normal gRPC TLS
 						return tls.Client(c, config), nil
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
@@ -23,6 +24,7 @@ type Listener struct {
 	handler internet.ConnHandler
 	local   net.Addr
 	config  *Config
+	acme    io.Closer
 
 	s *grpc.Server
 }
@@ -43,6 +45,7 @@ func (l Listener) TunMulti(server encoding.GRPCService_TunMultiServer) error {
 
 func (l Listener) Close() error {
 	l.s.Stop()
+	common.Close(l.acme)
 	return nil
 }
 
@@ -81,7 +84,9 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 	var s *grpc.Server
 	if config != nil {
 		// gRPC server may silently ignore TLS errors
-		options = append(options, grpc.Creds(credentials.NewTLS(config.GetTLSConfig(tls.WithNextProto("h2")))))
+		tlsConfig, acme := config.GetServerTLSConfig(tls.WithNextProto("h2"))
+		listener.acme = acme
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 {
 		options = append(options, grpc.KeepaliveParams(keepalive.ServerParameters{
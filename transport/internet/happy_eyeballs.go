@@ -0,0 +1,139 @@
+package internet
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/common/net"
+)
+
+// defaultTryDelay is the "Connection Attempt Delay" recommended by RFC 8305.
+const defaultTryDelay = 250 * time.Millisecond
+
+func (c *HappyEyeballsConfig) tryDelay() time.Duration {
+	if c == nil || c.TryDelayMs == 0 {
+		return defaultTryDelay
+	}
+	return time.Duration(c.TryDelayMs) * time.Millisecond
+}
+
+func (c *HappyEyeballsConfig) maxConcurrentTry() int {
+	if c == nil || c.MaxConcurrentTry == 0 {
+		return 0
+	}
+	return int(c.MaxConcurrentTry)
+}
+
+// ShuffleIPs returns a copy of ips in random order. Sorted by SortIPs, it spreads connections over
+// the addresses of each family, as picking one of ips at random does.
+func ShuffleIPs(ips []net.IP) []net.IP {
+	shuffled := make([]net.IP, len(ips))
+	copy(shuffled, ips)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// SortIPs orders ips for Happy Eyeballs, interleaving the address families and starting with the
+// preferred one (RFC 8305 4).
+func SortIPs(ips []net.IP, preferIPv6 bool) []net.IP {
+	var preferred, others []net.IP
+	for _, ip := range ips {
+		if (ip.To4() == nil) == preferIPv6 {
+			preferred = append(preferred, ip)
+		} else {
+			others = append(others, ip)
+		}
+	}
+	sorted := make([]net.IP, 0, len(ips))
+	for i := 0; i < len(preferred) || i < len(others); i++ {
+		if i < len(preferred) {
+			sorted = append(sorted, preferred[i])
+		}
+		if i < len(others) {
+			sorted = append(sorted, others[i])
+		}
+	}
+	return sorted
+}
+
+// DialHappyEyeballs races dial on ips in order (RFC 8305 5). An attempt is started when the
+// previous one fails or the try delay passes, and the first established connection wins. The
+// others are canceled or closed.
+func DialHappyEyeballs(ctx context.Context, ips []net.IP, config *HappyEyeballsConfig, dial func(ctx context.Context, ip net.IP) (net.Conn, error)) (net.Conn, error) {
+	if len(ips) == 1 {
+		return dial(ctx, ips[0])
+	}
+
+	type result struct {
+		index int
+		conn  net.Conn
+		err   error
+	}
+	results := make(chan result, len(ips))
+	cancels := make([]context.CancelFunc, 0, len(ips))
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	delay := config.tryDelay()
+	maxConcurrent := config.maxConcurrentTry()
+	pending := 0
+	start := func() {
+		index := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		pending++
+		go func() {
+			conn, err := dial(attemptCtx, ips[index])
+			results <- result{index: index, conn: conn, err: err}
+		}()
+	}
+	// closeLosers closes connections established after the race is over.
+	closeLosers := func() {
+		go func(n int) {
+			for range n {
+				if r := <-results; r.conn != nil {
+					r.conn.Close()
+				}
+			}
+		}(pending)
+	}
+
+	start()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var errs []error
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				// Keep the winner's context alive, as some dialers tie the connection to it.
+				cancels[r.index] = func() {}
+				closeLosers()
+				return r.conn, nil
+			}
+			errs = append(errs, r.err)
+			if len(cancels) < len(ips) {
+				start()
+				timer.Reset(delay)
+			} else if pending == 0 {
+				return nil, errors.New("failed to dial any of ", len(ips), " addresses").Base(errors.Combine(errs...))
+			}
+		case <-timer.C:
+			if len(cancels) < len(ips) && (maxConcurrent == 0 || pending < maxConcurrent) {
+				start()
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			closeLosers()
+			return nil, ctx.Err()
+		}
+	}
+}
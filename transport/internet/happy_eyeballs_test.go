@@ -0,0 +1,139 @@
+package internet_test
+
+import (
+	"context"
+	"errors"
+	gonet "net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common/net"
+	. "github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortIPs(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("1.1.1.1"),
+		net.ParseIP("1.0.0.1"),
+		net.ParseIP("2606:4700::1111"),
+		net.ParseIP("8.8.8.8"),
+	}
+	expected := []string{"2606:4700::1111", "1.1.1.1", "1.0.0.1", "8.8.8.8"}
+	var sorted []string
+	for _, ip := range SortIPs(ips, true) {
+		sorted = append(sorted, ip.String())
+	}
+	if r := cmp.Diff(sorted, expected); r != "" {
+		t.Error(r)
+	}
+
+	expected = []string{"1.1.1.1", "2606:4700::1111", "1.0.0.1", "8.8.8.8"}
+	sorted = nil
+	for _, ip := range SortIPs(ips, false) {
+		sorted = append(sorted, ip.String())
+	}
+	if r := cmp.Diff(sorted, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestShuffleIPs(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("1.1.1.1"),
+		net.ParseIP("1.0.0.1"),
+		net.ParseIP("8.8.8.8"),
+		net.ParseIP("2606:4700::1111"),
+		net.ParseIP("2606:4700::1001"),
+	}
+	firsts := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		shuffled := ShuffleIPs(ips)
+		if ips[0].String() != "1.1.1.1" {
+			t.Fatal("input is modified: ", ips)
+		}
+		seen := make(map[string]bool)
+		for _, ip := range shuffled {
+			seen[ip.String()] = true
+		}
+		if len(shuffled) != len(ips) || len(seen) != len(ips) {
+			t.Fatal("not a permutation: ", shuffled)
+		}
+
+		// The families are still interleaved after sorting.
+		sorted := SortIPs(shuffled, false)
+		for j, ip := range sorted[:4] {
+			if (ip.To4() != nil) != (j%2 == 0) {
+				t.Fatal("unexpected order: ", sorted)
+			}
+		}
+		firsts[sorted[0].String()] = true
+	}
+	if len(firsts) != 3 {
+		t.Error("expect every IPv4 address to be tried first, but got ", firsts)
+	}
+}
+
+func TestDialHappyEyeballs(t *testing.T) {
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}
+	config := &HappyEyeballsConfig{TryDelayMs: 50}
+
+	// The first address black-holes, the second one connects.
+	canceled := make(chan struct{})
+	start := time.Now()
+	conn, err := DialHappyEyeballs(context.Background(), ips, config, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		switch ip.String() {
+		case "2001:db8::1":
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		case "192.0.2.1":
+			c, _ := gonet.Pipe()
+			return c, nil
+		}
+		t.Error("unexpected attempt to ", ip)
+		return nil, errors.New("unexpected")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Error("took too long: ", d)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("losing attempt is not canceled")
+	}
+
+	// Failures start the next attempt right away.
+	var attempts atomic.Int32
+	start = time.Now()
+	_, err = DialHappyEyeballs(context.Background(), ips, &HappyEyeballsConfig{TryDelayMs: 10000}, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		attempts.Add(1)
+		return nil, errors.New("refused")
+	})
+	if err == nil {
+		t.Error("expect error")
+	}
+	if attempts.Load() != 3 || time.Since(start) > time.Second {
+		t.Error("unexpected attempts ", attempts.Load(), " in ", time.Since(start))
+	}
+
+	// No more than one attempt at a time.
+	var concurrent, maxConcurrent atomic.Int32
+	_, err = DialHappyEyeballs(context.Background(), ips, &HappyEyeballsConfig{TryDelayMs: 10, MaxConcurrentTry: 1}, func(ctx context.Context, ip net.IP) (net.Conn, error) {
+		n := concurrent.Add(1)
+		defer concurrent.Add(-1)
+		if n > maxConcurrent.Load() {
+			maxConcurrent.Store(n)
+		}
+		time.Sleep(50 * time.Millisecond)
+		return nil, errors.New("timeout")
+	})
+	if err == nil || maxConcurrent.Load() != 1 {
+		t.Error("unexpected concurrency ", maxConcurrent.Load(), " ", err)
+	}
+}
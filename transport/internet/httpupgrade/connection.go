@@ -1,6 +1,10 @@
 package httpupgrade
 
-import "net"
+import (
+	"net"
+
+	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
+)
 
 type connection struct {
 	net.Conn
@@ -17,3 +21,8 @@ func newConnection(conn net.Conn, remoteAddr net.Addr) *connection {
 func (c *connection) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
+
+// ClientUserEmail returns the email mapped from the client certificate of the TLS connection, if any.
+func (c *connection) ClientUserEmail() string {
+	return tls.ClientUserEmail(c.Conn)
+}
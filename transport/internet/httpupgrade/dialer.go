@@ -55,8 +55,8 @@ func dialhttpUpgrade(ctx context.Context, dest net.Destination, streamSettings *
 	var requestURL url.URL
 	tConfig := tls.ConfigFromStreamSettings(streamSettings)
 	if tConfig != nil {
-		tlsConfig := tConfig.GetTLSConfig(tls.WithDestination(dest), tls.WithNextProto("http/1.1"))
-		if fingerprint := tls.GetFingerprint(tConfig.Fingerprint); fingerprint != nil {
+		tlsConfig := tConfig.GetTLSConfigContext(ctx, tls.WithDestination(dest), tls.WithNextProto("http/1.1"))
+		if fingerprint := tConfig.ClientFingerprint(); fingerprint != nil {
 			conn = tls.UClient(pconn, tlsConfig, fingerprint)
 			if err := conn.(*tls.UConn).WebsocketHandshakeContext(ctx); err != nil {
 				return nil, err
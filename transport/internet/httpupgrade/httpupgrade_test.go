@@ -2,6 +2,8 @@ package httpupgrade_test
 
 import (
 	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
 	"runtime"
 	"testing"
 	"time"
@@ -210,3 +212,52 @@ func Test_listenHTTPUpgradeAndDial_TLS(t *testing.T) {
 		t.Error("end: ", end, " start: ", start)
 	}
 }
+
+func Test_listenHTTPUpgradeWithClientUsers(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), func(c *x509.Certificate) {
+		c.ExtKeyUsage = nil
+	})
+	caPEM, _ := ca.ToPEM()
+	clientCert := cert.MustGenerate(ca, cert.CommonName("device1"), func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	certPEM, keyPEM := clientCert.ToPEM()
+	keyPair, err := gotls.X509KeyPair(certPEM, keyPEM)
+	common.Must(err)
+
+	listenPort := tcp.PickPort()
+	emails := make(chan string, 1)
+	listen, err := ListenHTTPUpgrade(context.Background(), net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
+		ProtocolName:     "httpupgrade",
+		ProtocolSettings: &Config{Path: "httpupgrade"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+			ClientAuth:  tls.Config_REQUIRE_AND_VERIFY,
+			ClientCa:    caPEM,
+			ClientUsers: []*tls.ClientCertificateUser{{Email: "device1@example.com", CommonName: "device1"}},
+		},
+	}, func(conn stat.Connection) {
+		emails <- tls.ClientUserEmail(conn)
+		conn.Close()
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	conn, err := gotls.Dial("tcp", net.TCPDestination(net.LocalHostIP, listenPort).NetAddr(), &gotls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []gotls.Certificate{keyPair},
+	})
+	common.Must(err)
+	defer conn.Close()
+	common.Must2(conn.Write([]byte("GET /httpupgrade HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")))
+
+	select {
+	case email := <-emails:
+		if email != "device1@example.com" {
+			t.Error("expect device1@example.com, but got ", email)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
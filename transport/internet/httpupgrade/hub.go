@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
+	"io"
 	"net/http"
 	"strings"
 
@@ -20,9 +21,11 @@ type server struct {
 	config         *Config
 	addConn        internet.ConnHandler
 	innnerListener net.Listener
+	acme           io.Closer
 }
 
 func (s *server) Close() error {
+	common.Close(s.acme)
 	return s.innnerListener.Close()
 }
 
@@ -131,9 +134,11 @@ func ListenHTTPUpgrade(ctx context.Context, address net.Address, port net.Port,
 		errors.LogWarning(ctx, "accepting PROXY protocol")
 	}
 
+	var acme io.Closer
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-			listener = tls.NewListener(listener, tlsConfig)
+		var tlsConfig *tls.Config
+		if tlsConfig, acme = config.GetServerTLSConfig(); tlsConfig != nil {
+			listener = v2tls.NewListenerWithClientUsers(listener, tlsConfig, config.ClientUsers)
 		}
 	}
 
@@ -141,6 +146,7 @@ func ListenHTTPUpgrade(ctx context.Context, address net.Address, port net.Port,
 		config:         transportConfiguration,
 		addConn:        addConn,
 		innnerListener: listener,
+		acme:           acme,
 	}
 	go serverInstance.keepAccepting()
 	return serverInstance, nil
@@ -75,6 +75,14 @@ func (c *Config) GetPackerHeader() (internet.PacketHeader, error) {
 	return nil, nil
 }
 
+// GetFECShards returns the shard counts of FEC, or zeros if FEC is disabled.
+func (c *Config) GetFECShards() (int, int) {
+	if c == nil || c.Fec == nil || !ValidFECShards(int(c.Fec.DataShards), int(c.Fec.ParityShards)) {
+		return 0, 0
+	}
+	return int(c.Fec.DataShards), int(c.Fec.ParityShards)
+}
+
 func (c *Config) GetSendingInFlightSize() uint32 {
 	size := c.GetUplinkCapacityValue() * 1024 * 1024 / c.GetMTUValue() / (1000 / c.GetTTIValue())
 	if size < 8 {
@@ -20,17 +20,19 @@ var globalConv = uint32(dice.RollUint16())
 func fetchInput(_ context.Context, input io.Reader, reader PacketReader, conn *Connection) {
 	cache := make(chan *buf.Buffer, 1024)
 	go func() {
+		defer close(cache)
+		datagrams := newDatagramReader(input)
 		for {
-			payload := buf.New()
-			if _, err := payload.ReadFrom(input); err != nil {
-				payload.Release()
-				close(cache)
+			mb, err := datagrams.ReadMultiBuffer()
+			if err != nil {
 				return
 			}
-			select {
-			case cache <- payload:
-			default:
-				payload.Release()
+			for _, payload := range mb {
+				select {
+				case cache <- payload:
+				default:
+					payload.Release()
+				}
 			}
 		}
 	}()
@@ -44,6 +46,16 @@ func fetchInput(_ context.Context, input io.Reader, reader PacketReader, conn *C
 	}
 }
 
+// newDatagramReader reads datagrams from input, in batches if it's a UDP socket.
+func newDatagramReader(input io.Reader) buf.Reader {
+	if wrapper, ok := input.(*internet.PacketConnWrapper); ok {
+		if udpConn, ok := wrapper.Conn.(*net.UDPConn); ok {
+			return internet.NewUDPBatchReader(internet.NewUDPBatchConn(udpConn, false), internet.UDPBatchSize)
+		}
+	}
+	return &buf.PacketReader{Reader: input}
+}
+
 // DialKCP dials a new KCP connections to the specific destination.
 func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig) (stat.Connection, error) {
 	dest.Network = net.Network_UDP
@@ -73,6 +85,11 @@ func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet
 		Security: security,
 		Writer:   rawConn,
 	}
+	if dataShards, parityShards := kcpSettings.GetFECShards(); dataShards > 0 {
+		writer.FEC = newFECOffer(dataShards, parityShards)
+		reader.FEC = NewFECDecoder(fecRecoveredCounter(ctx))
+		reader.FEC.encoder = writer.FEC
+	}
 
 	conv := uint16(atomic.AddUint32(&globalConv, 1))
 	session := NewConnection(ConnMetadata{
@@ -86,7 +103,7 @@ func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet
 	var iConn stat.Connection = session
 
 	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		iConn = tls.Client(iConn, config.GetTLSConfig(tls.WithDestination(dest)))
+		iConn = tls.Client(iConn, config.GetTLSConfigContext(ctx, tls.WithDestination(dest)))
 	}
 
 	return iConn, nil
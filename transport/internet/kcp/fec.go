@@ -0,0 +1,442 @@
+package kcp
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/core"
+	"github.com/HZ-PRE/XrarCore/features/stats"
+)
+
+// An FEC frame replaces the plain payload of a packet, as follows:
+//
+//	conversation (2) | type (1) | data shards (1) | parity shards (1) | group (4) | index (1) | shard
+//
+// The conversation is at the same place as that of segments, and the type is never a valid command,
+// so that peers tell FEC frames from plain payloads. A data shard is the length of the payload (2)
+// followed by the payload. Parity shards are computed over the data shards of the group, padded to
+// the same length.
+//
+// Clients offer FEC by appending an offer to plain payloads, as follows:
+//
+//	^conversation (2) | type (1) | 0 (13) | data shards (1) | parity shards (1)
+//
+// Peers without FEC support parse the offer as a segment of another conversation, and ignore it.
+// Servers with FEC support reply with FEC frames, and clients send FEC frames once they see any.
+const (
+	fecHeaderSize = 10
+	fecOfferSize  = 18
+
+	fecTypeData   byte = 0xf1
+	fecTypeParity byte = 0xf2
+	fecTypeOffer  byte = 0xf3
+
+	// fecMaxGroups is the number of recent groups that FECDecoder keeps for recovery.
+	fecMaxGroups = 64
+)
+
+// isFECFrame returns true if b is an FEC frame rather than plain segments.
+func isFECFrame(b []byte) bool {
+	return len(b) > fecHeaderSize && (b[2] == fecTypeData || b[2] == fecTypeParity)
+}
+
+// fecShards returns the shard counts in the header of frame.
+func fecShards(frame []byte) (int, int) {
+	return int(frame[3]), int(frame[4])
+}
+
+// readFECOffer returns the shard counts if b is an FEC offer.
+func readFECOffer(b []byte) (int, int, bool) {
+	if len(b) != fecOfferSize || b[2] != fecTypeOffer {
+		return 0, 0, false
+	}
+	dataShards, parityShards := int(b[16]), int(b[17])
+	return dataShards, parityShards, ValidFECShards(dataShards, parityShards)
+}
+
+// ValidFECShards returns true if FEC works with the given shard counts.
+func ValidFECShards(dataShards, parityShards int) bool {
+	return dataShards > 0 && parityShards > 0 && dataShards+parityShards <= 255
+}
+
+// FECEncoder appends parity shards after each group of payloads. Encode is not safe for concurrent
+// use.
+type FECEncoder struct {
+	rs       *reedSolomon
+	offering atomic.Bool
+	conv     [2]byte
+	group    uint32
+	index    int
+	shards   [][]byte
+}
+
+// NewFECEncoder creates an FECEncoder. The shard counts must be valid.
+func NewFECEncoder(dataShards, parityShards int) *FECEncoder {
+	return &FECEncoder{
+		rs:     newReedSolomon(dataShards, parityShards),
+		shards: make([][]byte, dataShards),
+	}
+}
+
+// newFECOffer creates an FECEncoder that offers FEC until accept is called.
+func newFECOffer(dataShards, parityShards int) *FECEncoder {
+	e := NewFECEncoder(dataShards, parityShards)
+	e.offering.Store(true)
+	return e
+}
+
+// accept makes the encoder encode payloads, as the peer supports FEC.
+func (e *FECEncoder) accept() {
+	e.offering.Store(false)
+}
+
+// Overhead returns the max size added to each payload.
+func (e *FECEncoder) Overhead() int {
+	if e.offering.Load() {
+		return fecOfferSize
+	}
+	return fecHeaderSize + 2
+}
+
+// Encode returns the frames to send for payload. They are the data shard of payload, and the parity
+// shards if the group is complete. If the encoder is offering FEC, it's payload with the offer.
+func (e *FECEncoder) Encode(payload []byte) [][]byte {
+	if len(payload) < 2 {
+		return [][]byte{payload}
+	}
+	if e.offering.Load() {
+		frame := make([]byte, len(payload), len(payload)+fecOfferSize)
+		copy(frame, payload)
+		frame = append(frame, ^payload[0], ^payload[1], fecTypeOffer)
+		frame = append(frame, make([]byte, 13)...)
+		return [][]byte{append(frame, byte(e.rs.dataShards), byte(e.rs.parityShards))}
+	}
+
+	copy(e.conv[:], payload)
+	shard := binary.BigEndian.AppendUint16(e.shards[e.index][:0], uint16(len(payload)))
+	shard = append(shard, payload...)
+	e.shards[e.index] = shard
+	frames := [][]byte{e.frame(fecTypeData, e.index, shard)}
+
+	e.index++
+	if e.index == e.rs.dataShards {
+		size := 0
+		for _, shard := range e.shards {
+			size = max(size, len(shard))
+		}
+		for i, shard := range e.shards {
+			e.shards[i] = append(shard, make([]byte, size-len(shard))...)
+		}
+		for i, parity := range e.rs.encode(e.shards) {
+			frames = append(frames, e.frame(fecTypeParity, e.rs.dataShards+i, parity))
+		}
+		e.group++
+		e.index = 0
+	}
+	return frames
+}
+
+func (e *FECEncoder) frame(typ byte, index int, shard []byte) []byte {
+	frame := make([]byte, fecHeaderSize, fecHeaderSize+len(shard))
+	copy(frame, e.conv[:])
+	frame[2] = typ
+	frame[3] = byte(e.rs.dataShards)
+	frame[4] = byte(e.rs.parityShards)
+	binary.BigEndian.PutUint32(frame[5:], e.group)
+	frame[9] = byte(index)
+	return append(frame, shard...)
+}
+
+type fecGroup struct {
+	shards  [][]byte
+	present int
+	done    bool
+}
+
+// FECDecoder returns the payloads in FEC frames, and recovers lost ones from parity shards. It's
+// not safe for concurrent use.
+type FECDecoder struct {
+	rs        *reedSolomon
+	groups    map[uint32]*fecGroup
+	newest    uint32
+	recovered stats.Counter
+	// encoder, if not nil, is accepted on the first frame, as the peer supports FEC.
+	encoder *FECEncoder
+}
+
+// NewFECDecoder creates an FECDecoder. recovered counts the recovered payloads, if not nil.
+func NewFECDecoder(recovered stats.Counter) *FECDecoder {
+	return &FECDecoder{
+		groups:    make(map[uint32]*fecGroup),
+		recovered: recovered,
+	}
+}
+
+// Decode returns the payloads available after receiving frame.
+func (d *FECDecoder) Decode(frame []byte) [][]byte {
+	dataShards, parityShards := fecShards(frame)
+	index := int(frame[9])
+	if !ValidFECShards(dataShards, parityShards) || index >= dataShards+parityShards {
+		return nil
+	}
+	if d.encoder != nil {
+		d.encoder.accept()
+		d.encoder = nil
+	}
+	if d.rs == nil || d.rs.dataShards != dataShards || d.rs.parityShards != parityShards {
+		d.rs = newReedSolomon(dataShards, parityShards)
+		clear(d.groups)
+	}
+
+	id := binary.BigEndian.Uint32(frame[5:])
+	g := d.groups[id]
+	if g == nil {
+		if len(d.groups) > 0 && int32(id-d.newest) <= -fecMaxGroups {
+			return nil
+		}
+		g = &fecGroup{shards: make([][]byte, dataShards+parityShards)}
+		d.groups[id] = g
+		if len(d.groups) == 1 || int32(id-d.newest) > 0 {
+			d.newest = id
+			d.evict()
+		}
+	}
+	if g.done || g.shards[index] != nil {
+		return nil
+	}
+	shard := append([]byte(nil), frame[fecHeaderSize:]...)
+	g.shards[index] = shard
+	g.present++
+
+	var payloads [][]byte
+	if index < dataShards {
+		if payload := shardPayload(shard); payload != nil {
+			payloads = append(payloads, payload)
+		}
+	}
+	if g.present < dataShards {
+		return payloads
+	}
+
+	g.done = true
+	missing := 0
+	for _, shard := range g.shards[:dataShards] {
+		if shard == nil {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return payloads
+	}
+	recovered, err := d.rs.reconstruct(g.shards)
+	if err != nil {
+		errors.LogDebugInner(context.Background(), err, "failed to recover FEC group ", id)
+		return payloads
+	}
+	for _, shard := range recovered {
+		if payload := shardPayload(shard); payload != nil {
+			payloads = append(payloads, payload)
+		}
+	}
+	if d.recovered != nil {
+		d.recovered.Add(int64(len(recovered)))
+	}
+	return payloads
+}
+
+// evict removes the groups too old to be recovered.
+func (d *FECDecoder) evict() {
+	for id := range d.groups {
+		if int32(d.newest-id) >= fecMaxGroups {
+			delete(d.groups, id)
+		}
+	}
+}
+
+// shardPayload returns the payload in a data shard, or nil if the shard is invalid.
+func shardPayload(shard []byte) []byte {
+	if len(shard) < 2 {
+		return nil
+	}
+	size := int(binary.BigEndian.Uint16(shard))
+	if len(shard) < 2+size {
+		return nil
+	}
+	return shard[2 : 2+size]
+}
+
+// fecRecoveredCounter returns the stats counter of recovered payloads, or nil if stats are off.
+func fecRecoveredCounter(ctx context.Context) stats.Counter {
+	v := core.FromContext(ctx)
+	if v == nil {
+		return nil
+	}
+	statsManager, ok := v.GetFeature(stats.ManagerType()).(stats.Manager)
+	if !ok {
+		return nil
+	}
+	c, _ := stats.GetOrRegisterCounter(statsManager, "mkcp>>>fec>>>recovered")
+	return c
+}
+
+// gfExp and gfLog are the exponent and logarithm tables of GF(2^8) with the polynomial 0x11d.
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := range 255 {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(exp); i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// gfMulAdd adds c*in to out.
+func gfMulAdd(c byte, in, out []byte) {
+	if c == 0 {
+		return
+	}
+	logC := int(gfLog[c])
+	for i, b := range in {
+		if b != 0 {
+			out[i] ^= gfExp[logC+int(gfLog[b])]
+		}
+	}
+}
+
+// reedSolomon is a systematic Reed-Solomon code with a Cauchy matrix, so that any dataShards of
+// the shards recover the data.
+type reedSolomon struct {
+	dataShards   int
+	parityShards int
+	// parity[i][j] is the coefficient of data shard j in parity shard i.
+	parity [][]byte
+}
+
+func newReedSolomon(dataShards, parityShards int) *reedSolomon {
+	rs := &reedSolomon{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		parity:       make([][]byte, parityShards),
+	}
+	for i := range rs.parity {
+		rs.parity[i] = make([]byte, dataShards)
+		for j := range rs.parity[i] {
+			rs.parity[i][j] = gfInv(byte(dataShards+i) ^ byte(j))
+		}
+	}
+	return rs
+}
+
+// row returns the coefficients of shard index over the data shards.
+func (rs *reedSolomon) row(index int) []byte {
+	if index >= rs.dataShards {
+		return rs.parity[index-rs.dataShards]
+	}
+	row := make([]byte, rs.dataShards)
+	row[index] = 1
+	return row
+}
+
+// encode returns the parity shards of data, whose shards have the same length.
+func (rs *reedSolomon) encode(data [][]byte) [][]byte {
+	parity := make([][]byte, rs.parityShards)
+	for i := range parity {
+		parity[i] = make([]byte, len(data[0]))
+		for j, shard := range data {
+			gfMulAdd(rs.parity[i][j], shard, parity[i])
+		}
+	}
+	return parity
+}
+
+// reconstruct returns the missing data shards, in order, from shards, where missing ones are nil.
+// Data shards shorter than parity shards are padded with zeros.
+func (rs *reedSolomon) reconstruct(shards [][]byte) ([][]byte, error) {
+	size := 0
+	for _, shard := range shards[rs.dataShards:] {
+		size = max(size, len(shard))
+	}
+	indices := make([]int, 0, rs.dataShards)
+	for i, shard := range shards {
+		if shard != nil && len(indices) < rs.dataShards {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) < rs.dataShards {
+		return nil, errors.New("too few shards: ", len(indices))
+	}
+
+	// Invert the matrix of the present shards, with Gauss-Jordan elimination.
+	n := rs.dataShards
+	m := make([][]byte, n)
+	inv := make([][]byte, n)
+	for i, index := range indices {
+		m[i] = append([]byte(nil), rs.row(index)...)
+		inv[i] = make([]byte, n)
+		inv[i][i] = 1
+	}
+	for col := range n {
+		pivot := col
+		for pivot < n && m[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errors.New("singular matrix")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+		if c := gfInv(m[col][col]); c != 1 {
+			for j := range n {
+				m[col][j] = gfMul(m[col][j], c)
+				inv[col][j] = gfMul(inv[col][j], c)
+			}
+		}
+		for i := range n {
+			if c := m[i][col]; i != col && c != 0 {
+				gfMulAdd(c, m[col], m[i])
+				gfMulAdd(c, inv[col], inv[i])
+			}
+		}
+	}
+
+	present := make([][]byte, n)
+	for i, index := range indices {
+		present[i] = shards[index]
+		if len(present[i]) < size {
+			present[i] = append(append([]byte(nil), present[i]...), make([]byte, size-len(present[i]))...)
+		}
+	}
+	var recovered [][]byte
+	for i := range n {
+		if shards[i] != nil {
+			continue
+		}
+		shard := make([]byte, size)
+		for j, in := range present {
+			gfMulAdd(inv[i][j], in[:size], shard)
+		}
+		recovered = append(recovered, shard)
+	}
+	return recovered, nil
+}
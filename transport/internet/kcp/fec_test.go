@@ -0,0 +1,90 @@
+package kcp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/app/stats"
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	. "github.com/HZ-PRE/XrarCore/transport/internet/kcp"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+)
+
+func TestFECRecovery(t *testing.T) {
+	const dataShards, parityShards = 4, 2
+	encoder := NewFECEncoder(dataShards, parityShards)
+	recovered := new(stats.Counter)
+	decoder := NewFECDecoder(recovered)
+
+	var payloads [][]byte
+	var frames [][]byte
+	for i := range 3 * dataShards {
+		payload := make([]byte, 100+i*10)
+		rand.Read(payload[2:])
+		payloads = append(payloads, payload)
+		frames = append(frames, encoder.Encode(payload)...)
+	}
+	if len(frames) != 3*(dataShards+parityShards) {
+		t.Fatal("unexpected number of frames: ", len(frames))
+	}
+
+	// Drop two data shards of the first group, a data and a parity shard of the second group,
+	// and nothing of the third group.
+	dropped := map[int]bool{0: true, 2: true, 7: true, 10: true}
+	received := make(map[string]bool)
+	for i, frame := range frames {
+		if dropped[i] {
+			continue
+		}
+		for _, payload := range decoder.Decode(frame) {
+			received[string(payload)] = true
+		}
+	}
+	for i, payload := range payloads {
+		if !received[string(payload)] {
+			t.Error("payload ", i, " is not received")
+		}
+	}
+	if v := recovered.Value(); v != 3 {
+		t.Error("recovered: ", v)
+	}
+}
+
+func TestDialAndListenWithFEC(t *testing.T) {
+	listener, err := NewListener(context.Background(), net.LocalHostIP, net.Port(0), &internet.MemoryStreamConfig{
+		ProtocolName:     "mkcp",
+		ProtocolSettings: &Config{},
+	}, func(conn stat.Connection) {
+		go func() {
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+	})
+	common.Must(err)
+	defer listener.Close()
+
+	port := net.Port(listener.Addr().(*net.UDPAddr).Port)
+	conn, err := DialKCP(context.Background(), net.UDPDestination(net.LocalHostIP, port), &internet.MemoryStreamConfig{
+		ProtocolName: "mkcp",
+		ProtocolSettings: &Config{
+			Fec: &FEC{DataShards: 5, ParityShards: 2},
+		},
+	})
+	common.Must(err)
+	defer conn.Close()
+
+	sent := make([]byte, 256*1024)
+	rand.Read(sent)
+	go conn.Write(sent)
+
+	received := make([]byte, len(sent))
+	common.Must2(io.ReadFull(conn, received))
+	if !bytes.Equal(sent, received) {
+		t.Error("unexpected payload")
+	}
+}
@@ -22,9 +22,29 @@ type PacketWriter interface {
 type KCPPacketReader struct {
 	Security cipher.AEAD
 	Header   internet.PacketHeader
+	// FEC, if not nil, decodes FEC frames.
+	FEC *FECDecoder
 }
 
 func (r *KCPPacketReader) Read(b []byte) []Segment {
+	b = r.Open(b)
+	if b == nil {
+		return nil
+	}
+	if r.FEC == nil || !isFECFrame(b) {
+		segments, _ := ReadSegments(b)
+		return segments
+	}
+	var result []Segment
+	for _, payload := range r.FEC.Decode(b) {
+		segments, _ := ReadSegments(payload)
+		result = append(result, segments...)
+	}
+	return result
+}
+
+// Open removes the header and decrypts b. It returns nil if b is invalid.
+func (r *KCPPacketReader) Open(b []byte) []byte {
 	if r.Header != nil {
 		if int32(len(b)) <= r.Header.Size() {
 			return nil
@@ -43,8 +63,17 @@ func (r *KCPPacketReader) Read(b []byte) []Segment {
 		}
 		b = out
 	}
+	return b
+}
+
+// ReadSegments returns the segments in a plain payload, and whether it offers FEC with the
+// returned shard counts.
+func ReadSegments(b []byte) ([]Segment, *FEC) {
 	var result []Segment
 	for len(b) > 0 {
+		if dataShards, parityShards, ok := readFECOffer(b); ok {
+			return result, &FEC{DataShards: uint32(dataShards), ParityShards: uint32(parityShards)}
+		}
 		seg, x := ReadSegment(b)
 		if seg == nil {
 			break
@@ -52,13 +81,15 @@ func (r *KCPPacketReader) Read(b []byte) []Segment {
 		result = append(result, seg)
 		b = x
 	}
-	return result
+	return result, nil
 }
 
 type KCPPacketWriter struct {
 	Header   internet.PacketHeader
 	Security cipher.AEAD
 	Writer   io.Writer
+	// FEC, if not nil, encodes payloads into FEC frames.
+	FEC *FECEncoder
 }
 
 func (w *KCPPacketWriter) Overhead() int {
@@ -69,10 +100,25 @@ func (w *KCPPacketWriter) Overhead() int {
 	if w.Security != nil {
 		overhead += w.Security.Overhead()
 	}
+	if w.FEC != nil {
+		overhead += w.FEC.Overhead()
+	}
 	return overhead
 }
 
 func (w *KCPPacketWriter) Write(b []byte) (int, error) {
+	if w.FEC == nil {
+		return w.write(b)
+	}
+	for _, frame := range w.FEC.Encode(b) {
+		if _, err := w.write(frame); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *KCPPacketWriter) write(b []byte) (int, error) {
 	bb := buf.StackNew()
 	defer bb.Release()
 
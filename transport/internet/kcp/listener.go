@@ -4,18 +4,32 @@ import (
 	"context"
 	"crypto/cipher"
 	gotls "crypto/tls"
+	"encoding/binary"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/HZ-PRE/XrarCore/common"
 	"github.com/HZ-PRE/XrarCore/common/buf"
 	"github.com/HZ-PRE/XrarCore/common/errors"
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/features/stats"
 	"github.com/HZ-PRE/XrarCore/transport/internet"
 	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
 	"github.com/HZ-PRE/XrarCore/transport/internet/tls"
 	"github.com/HZ-PRE/XrarCore/transport/internet/udp"
 )
 
+const (
+	// maxPendingFECDecoders is the number of FEC decoders kept for conversations without sessions.
+	// Sessions start on the first data shards, so the decoders are only for the first frames lost
+	// or received out of order.
+	maxPendingFECDecoders = 32
+	// pendingFECDecoderTimeout is how long an FEC decoder without a session is kept after its last
+	// frame.
+	pendingFECDecoderTimeout = 10 * time.Second
+)
+
 type ConnectionID struct {
 	Remote net.Address
 	Port   net.Port
@@ -25,14 +39,22 @@ type ConnectionID struct {
 // Listener defines a server listening for connections
 type Listener struct {
 	sync.Mutex
-	sessions  map[ConnectionID]*Connection
+	sessions map[ConnectionID]*Connection
+	// decoders are the FEC decoders of sessions, and pending are those of conversations without
+	// sessions, which are limited so that spoofed frames can't grow them without bound.
+	decoders  map[ConnectionID]*FECDecoder
+	pending   map[ConnectionID]*pendingFECDecoder
+	recovered stats.Counter
 	hub       *udp.Hub
 	tlsConfig *gotls.Config
-	config    *Config
-	reader    PacketReader
-	header    internet.PacketHeader
-	security  cipher.AEAD
-	addConn   internet.ConnHandler
+	// clientUsers maps client certificates to users, if tlsConfig verifies them.
+	clientUsers []*tls.ClientCertificateUser
+	acme        io.Closer
+	config      *Config
+	reader      *KCPPacketReader
+	header      internet.PacketHeader
+	security    cipher.AEAD
+	addConn     internet.ConnHandler
 }
 
 func NewListener(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (*Listener, error) {
@@ -52,17 +74,22 @@ func NewListener(ctx context.Context, address net.Address, port net.Port, stream
 			Header:   header,
 			Security: security,
 		},
-		sessions: make(map[ConnectionID]*Connection),
-		config:   kcpSettings,
-		addConn:  addConn,
+		sessions:  make(map[ConnectionID]*Connection),
+		decoders:  make(map[ConnectionID]*FECDecoder),
+		pending:   make(map[ConnectionID]*pendingFECDecoder),
+		recovered: fecRecoveredCounter(ctx),
+		config:    kcpSettings,
+		addConn:   addConn,
 	}
 
 	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.tlsConfig = config.GetTLSConfig()
+		l.tlsConfig, l.acme = config.GetServerTLSConfig()
+		l.clientUsers = config.ClientUsers
 	}
 
 	hub, err := udp.ListenUDP(ctx, address, port, streamSettings, udp.HubCapacity(1024))
 	if err != nil {
+		common.Close(l.acme)
 		return nil, err
 	}
 	l.Lock()
@@ -83,32 +110,56 @@ func (l *Listener) handlePackets() {
 }
 
 func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
-	segments := l.reader.Read(payload.Bytes())
-	payload.Release()
+	defer payload.Release()
 
-	if len(segments) == 0 {
+	b := l.reader.Open(payload.Bytes())
+	if len(b) < 2 {
 		errors.LogInfo(context.Background(), "discarding invalid payload from ", src)
 		return
 	}
 
-	conv := segments[0].Conversation()
-	cmd := segments[0].Command()
-
 	id := ConnectionID{
 		Remote: src.Address,
 		Port:   src.Port,
-		Conv:   conv,
+		Conv:   binary.BigEndian.Uint16(b),
 	}
 
 	l.Lock()
 	defer l.Unlock()
 
+	var segments []Segment
+	var fec *FEC
+	if isFECFrame(b) {
+		decoder := l.fecDecoder(id)
+		dataShards, parityShards := fecShards(b)
+		fec = &FEC{DataShards: uint32(dataShards), ParityShards: uint32(parityShards)}
+		for _, payload := range decoder.Decode(b) {
+			s, _ := ReadSegments(payload)
+			segments = append(segments, s...)
+		}
+		if len(segments) == 0 {
+			// Parity shards, or data shards of other conversations.
+			return
+		}
+	} else {
+		segments, fec = ReadSegments(b)
+		if len(segments) == 0 {
+			errors.LogInfo(context.Background(), "discarding invalid payload from ", src)
+			return
+		}
+	}
+
 	conn, found := l.sessions[id]
 
 	if !found {
-		if cmd == CommandTerminate {
+		pending := l.pending[id]
+		delete(l.pending, id)
+		if segments[0].Command() == CommandTerminate {
 			return
 		}
+		if pending != nil {
+			l.decoders[id] = pending.decoder
+		}
 		writer := &Writer{
 			id:       id,
 			hub:      l.hub,
@@ -120,18 +171,23 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 			Port: int(src.Port),
 		}
 		localAddr := l.hub.Addr()
-		conn = NewConnection(ConnMetadata{
-			LocalAddr:    localAddr,
-			RemoteAddr:   remoteAddr,
-			Conversation: conv,
-		}, &KCPPacketWriter{
+		packetWriter := &KCPPacketWriter{
 			Header:   l.header,
 			Security: l.security,
 			Writer:   writer,
-		}, writer, l.config)
+		}
+		if fec != nil {
+			// Follow the shard counts of the client.
+			packetWriter.FEC = NewFECEncoder(int(fec.DataShards), int(fec.ParityShards))
+		}
+		conn = NewConnection(ConnMetadata{
+			LocalAddr:    localAddr,
+			RemoteAddr:   remoteAddr,
+			Conversation: id.Conv,
+		}, packetWriter, writer, l.config)
 		var netConn stat.Connection = conn
 		if l.tlsConfig != nil {
-			netConn = tls.Server(conn, l.tlsConfig)
+			netConn = tls.ServerWithClientUsers(conn, l.tlsConfig, l.clientUsers)
 		}
 
 		l.addConn(netConn)
@@ -140,15 +196,63 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 	conn.Input(segments)
 }
 
+type pendingFECDecoder struct {
+	decoder  *FECDecoder
+	lastSeen time.Time
+}
+
+// fecDecoder returns the FEC decoder of the conversation. It must be called with the lock held.
+func (l *Listener) fecDecoder(id ConnectionID) *FECDecoder {
+	if decoder, found := l.decoders[id]; found {
+		return decoder
+	}
+	now := time.Now()
+	p, found := l.pending[id]
+	if _, hasSession := l.sessions[id]; hasSession {
+		// The session was started by a frame without FEC, such as the FEC offer of the client.
+		decoder := NewFECDecoder(l.recovered)
+		if found {
+			decoder = p.decoder
+			delete(l.pending, id)
+		}
+		l.decoders[id] = decoder
+		return decoder
+	}
+	if found {
+		p.lastSeen = now
+		return p.decoder
+	}
+	if len(l.pending) >= maxPendingFECDecoders {
+		var oldest ConnectionID
+		var oldestSeen time.Time
+		for pid, p := range l.pending {
+			if now.Sub(p.lastSeen) > pendingFECDecoderTimeout {
+				delete(l.pending, pid)
+			} else if oldestSeen.IsZero() || p.lastSeen.Before(oldestSeen) {
+				oldest, oldestSeen = pid, p.lastSeen
+			}
+		}
+		if len(l.pending) >= maxPendingFECDecoders {
+			delete(l.pending, oldest)
+		}
+	}
+	p = &pendingFECDecoder{decoder: NewFECDecoder(l.recovered), lastSeen: now}
+	l.pending[id] = p
+	return p.decoder
+}
+
 func (l *Listener) Remove(id ConnectionID) {
 	l.Lock()
 	delete(l.sessions, id)
+	delete(l.decoders, id)
+	delete(l.pending, id)
 	l.Unlock()
 }
 
 // Close stops listening on the UDP address. Already Accepted connections are not closed.
 func (l *Listener) Close() error {
 	l.hub.Close()
+	common.Close(l.acme)
 
 	l.Lock()
 	defer l.Unlock()
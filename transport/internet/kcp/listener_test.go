@@ -0,0 +1,116 @@
+package kcp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/HZ-PRE/XrarCore/app/stats"
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+)
+
+func newTestListener() *Listener {
+	l, err := NewListener(context.Background(), net.LocalHostIP, net.Port(0), &internet.MemoryStreamConfig{
+		ProtocolName:     "mkcp",
+		ProtocolSettings: &Config{},
+	}, func(conn stat.Connection) {
+		conn.Close()
+	})
+	common.Must(err)
+	return l
+}
+
+func sendFrame(l *Listener, frame []byte, port net.Port) {
+	var packet bytes.Buffer
+	writer := &KCPPacketWriter{Header: l.header, Security: l.security, Writer: &packet}
+	common.Must2(writer.write(frame))
+	l.OnReceive(buf.FromBytes(packet.Bytes()), net.UDPDestination(net.LocalHostIP, port))
+}
+
+func pingSegment(conv uint16) []byte {
+	seg := &CmdOnlySegment{Conv: conv, Cmd: CommandPing}
+	b := make([]byte, seg.ByteSize())
+	seg.Serialize(b)
+	return b
+}
+
+// sendSpoofedParity sends parity shards from many sources, which fill up the pending decoders.
+func sendSpoofedParity(l *Listener) {
+	for i := 0; i < 1000; i++ {
+		encoder := NewFECEncoder(2, 1)
+		encoder.Encode(pingSegment(uint16(i)))
+		frames := encoder.Encode(pingSegment(uint16(i)))
+		sendFrame(l, frames[1], net.Port(10000+i))
+	}
+}
+
+func TestListenerPendingFECDecoders(t *testing.T) {
+	l := newTestListener()
+	defer l.Close()
+
+	// Spoofed parity shards from many sources never start sessions.
+	sendSpoofedParity(l)
+	l.Lock()
+	if len(l.pending) > maxPendingFECDecoders {
+		t.Error("too many pending decoders: ", len(l.pending))
+	}
+	if len(l.decoders) != 0 {
+		t.Error("decoders without sessions: ", len(l.decoders))
+	}
+	l.Unlock()
+
+	// The pending decoder is kept for the session started by the data shard.
+	encoder := NewFECEncoder(2, 1)
+	encoder.Encode(pingSegment(1))
+	frames := encoder.Encode(pingSegment(1))
+	sendFrame(l, frames[1], net.Port(20000))
+	sendFrame(l, frames[0], net.Port(20000))
+	id := ConnectionID{Remote: net.LocalHostIP, Port: net.Port(20000), Conv: 1}
+	l.Lock()
+	defer l.Unlock()
+	if _, found := l.pending[id]; found {
+		t.Error("pending decoder of the session is not removed")
+	}
+	if _, found := l.decoders[id]; !found {
+		t.Error("decoder of the session is not kept")
+	}
+}
+
+func TestListenerFECAfterOffer(t *testing.T) {
+	l := newTestListener()
+	defer l.Close()
+	recovered := new(stats.Counter)
+	l.recovered = recovered
+
+	// The session is started by the FEC offer, which is a plain payload.
+	sendFrame(l, newFECOffer(2, 1).Encode(pingSegment(1))[0], net.Port(20000))
+	id := ConnectionID{Remote: net.LocalHostIP, Port: net.Port(20000), Conv: 1}
+	l.Lock()
+	if _, found := l.sessions[id]; !found {
+		t.Fatal("session is not started by the offer")
+	}
+	l.Unlock()
+
+	encoder := NewFECEncoder(2, 1)
+	sendFrame(l, encoder.Encode(pingSegment(1))[0], net.Port(20000))
+	l.Lock()
+	if _, found := l.decoders[id]; !found {
+		t.Error("decoder of the session is not kept")
+	}
+	if _, found := l.pending[id]; found {
+		t.Error("decoder of the session is pending")
+	}
+	l.Unlock()
+
+	// The decoder of the session survives spoofed frames, and recovers the lost data shard.
+	sendSpoofedParity(l)
+	frames := encoder.Encode(pingSegment(1))
+	sendFrame(l, frames[1], net.Port(20000))
+	if v := recovered.Value(); v != 1 {
+		t.Error("expect 1 recovered payload, but got ", v)
+	}
+}
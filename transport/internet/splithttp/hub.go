@@ -340,6 +340,7 @@ type Listener struct {
 	h3server   *http3.Server
 	listener   net.Listener
 	h3listener *quic.EarlyListener
+	acme       io.Closer
 	config     *Config
 	addConn    internet.ConnHandler
 	isH3       bool
@@ -363,7 +364,8 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		sessionMu: &sync.Mutex{},
 		sessions:  sync.Map{},
 	}
-	tlsConfig := getTLSConfig(streamSettings)
+	var tlsConfig *gotls.Config
+	tlsConfig, l.acme = getTLSConfig(streamSettings)
 	l.isH3 = len(tlsConfig.NextProtos) == 1 && tlsConfig.NextProtos[0] == "h3"
 
 	var err error
@@ -373,6 +375,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 			Net:  "unix",
 		}, streamSettings.SocketSettings)
 		if err != nil {
+			common.Close(l.acme)
 			return nil, errors.New("failed to listen UNIX domain socket for XHTTP on ", address).Base(err)
 		}
 		errors.LogInfo(ctx, "listening UNIX domain socket for XHTTP on ", address)
@@ -382,10 +385,12 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 			Port: int(port),
 		}, streamSettings.SocketSettings)
 		if err != nil {
+			common.Close(l.acme)
 			return nil, errors.New("failed to listen UDP for XHTTP/3 on ", address, ":", port).Base(err)
 		}
 		l.h3listener, err = quic.ListenEarly(Conn, tlsConfig, nil)
 		if err != nil {
+			common.Close(l.acme)
 			return nil, errors.New("failed to listen QUIC for XHTTP/3 on ", address, ":", port).Base(err)
 		}
 		errors.LogInfo(ctx, "listening QUIC for XHTTP/3 on ", address, ":", port)
@@ -406,6 +411,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 			Port: int(port),
 		}, streamSettings.SocketSettings)
 		if err != nil {
+			common.Close(l.acme)
 			return nil, errors.New("failed to listen TCP for XHTTP on ", address, ":", port).Base(err)
 		}
 		errors.LogInfo(ctx, "listening TCP for XHTTP on ", address, ":", port)
@@ -414,9 +420,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 	// tcp/unix (h1/h2)
 	if l.listener != nil {
 		if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-			if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-				l.listener = gotls.NewListener(l.listener, tlsConfig)
-			}
+			l.listener = gotls.NewListener(l.listener, tlsConfig)
 		}
 		if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
 			l.listener = goreality.NewListener(l.listener, config.GetREALITYConfig())
@@ -457,6 +461,7 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	common.Close(ln.acme)
 	if ln.h3server != nil {
 		if err := ln.h3server.Close(); err != nil {
 			return err
@@ -466,12 +471,12 @@ func (ln *Listener) Close() error {
 	}
 	return errors.New("listener does not have an HTTP/3 server or a net.listener")
 }
-func getTLSConfig(streamSettings *internet.MemoryStreamConfig) *gotls.Config {
+func getTLSConfig(streamSettings *internet.MemoryStreamConfig) (*gotls.Config, io.Closer) {
 	config := tls.ConfigFromStreamSettings(streamSettings)
 	if config == nil {
-		return &gotls.Config{}
+		return &gotls.Config{}, nil
 	}
-	return config.GetTLSConfig()
+	return config.GetServerTLSConfig()
 }
 func init() {
 	common.Must(internet.RegisterTransportListener(protocolName, ListenXH))
@@ -3,6 +3,7 @@ package tcp
 import (
 	"context"
 	gotls "crypto/tls"
+	"io"
 	"strings"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 type Listener struct {
 	listener      net.Listener
 	tlsConfig     *gotls.Config
+	acme          io.Closer
+	clientUsers   []*tls.ClientCertificateUser
 	realityConfig *goreality.Config
 	authConfig    internet.ConnectionAuthenticator
 	config        *Config
@@ -67,13 +70,6 @@ func ListenTCP(ctx context.Context, address net.Address, port net.Port, streamSe
 
 	l.listener = listener
 
-	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.tlsConfig = config.GetTLSConfig()
-	}
-	if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.realityConfig = config.GetREALITYConfig()
-	}
-
 	if tcpSettings.HeaderSettings != nil {
 		headerConfig, err := tcpSettings.HeaderSettings.GetInstance()
 		if err != nil {
@@ -86,6 +82,14 @@ func ListenTCP(ctx context.Context, address net.Address, port net.Port, streamSe
 		l.authConfig = auth
 	}
 
+	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
+		l.tlsConfig, l.acme = config.GetServerTLSConfig()
+		l.clientUsers = config.ClientUsers
+	}
+	if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
+		l.realityConfig = config.GetREALITYConfig()
+	}
+
 	go l.keepAccepting()
 	return l, nil
 }
@@ -106,7 +110,7 @@ func (v *Listener) keepAccepting() {
 		}
 		go func() {
 			if v.tlsConfig != nil {
-				conn = tls.Server(conn, v.tlsConfig)
+				conn = tls.ServerWithClientUsers(conn, v.tlsConfig, v.clientUsers)
 			} else if v.realityConfig != nil {
 				if conn, err = reality.Server(conn, v.realityConfig); err != nil {
 					errors.LogInfo(context.Background(), err.Error())
@@ -128,6 +132,7 @@ func (v *Listener) Addr() net.Addr {
 
 // Close implements internet.Listener.Close.
 func (v *Listener) Close() error {
+	common.Close(v.acme)
 	return v.listener.Close()
 }
 
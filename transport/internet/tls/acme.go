@@ -0,0 +1,624 @@
+package tls
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"golang.org/x/crypto/acme"
+)
+
+// acmeTLSALPNProto is the ALPN protocol of TLS-ALPN-01 challenges (RFC 8737).
+const acmeTLSALPNProto = "acme-tls/1"
+
+const (
+	acmeDefaultRenewBefore = 30 * 24 * time.Hour
+	acmeCheckInterval      = time.Hour
+	acmeRetryInterval      = 10 * time.Minute
+	acmeObtainTimeout      = 10 * time.Minute
+)
+
+var (
+	acmeAccess sync.Mutex
+	// acmeManagers are the running managers, which are shared by the listeners with the same CA,
+	// storage and domains, until all of them are closed.
+	acmeManagers = make(map[string]*acmeManager)
+
+	// http01Tokens maps the paths of pending HTTP-01 challenges to their key authorizations.
+	http01Tokens sync.Map
+
+	http01Access    sync.Mutex
+	http01Listeners = make(map[string]*http01Listener)
+
+	dns01Access sync.RWMutex
+	dns01Hooks  = make(map[string]DNS01Hook)
+)
+
+// DNS01Hook provisions the TXT records of DNS-01 challenges. Present should not return until the
+// record is visible to the CA.
+type DNS01Hook interface {
+	Present(ctx context.Context, fqdn string, value string) error
+	CleanUp(ctx context.Context, fqdn string, value string) error
+}
+
+// RegisterDNS01Hook makes hook available to ACME configs whose dns01_hook is name.
+func RegisterDNS01Hook(name string, hook DNS01Hook) {
+	dns01Access.Lock()
+	defer dns01Access.Unlock()
+	dns01Hooks[name] = hook
+}
+
+func getDNS01Hook(name string) DNS01Hook {
+	dns01Access.RLock()
+	defer dns01Access.RUnlock()
+	if hook, found := dns01Hooks[name]; found {
+		return hook
+	}
+	return commandDNS01Hook(name)
+}
+
+// commandDNS01Hook runs a command as "<command> present|cleanup <fqdn> <value>".
+type commandDNS01Hook string
+
+func (h commandDNS01Hook) run(ctx context.Context, action string, fqdn string, value string) error {
+	args := strings.Fields(string(h))
+	if len(args) == 0 {
+		return errors.New("no DNS-01 hook")
+	}
+	args = append(args, action, fqdn, value)
+	if output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return errors.New("DNS-01 hook failed to ", action, " ", fqdn, ": ", strings.TrimSpace(string(output))).Base(err)
+	}
+	return nil
+}
+
+// Present implements DNS01Hook.
+func (h commandDNS01Hook) Present(ctx context.Context, fqdn string, value string) error {
+	return h.run(ctx, "present", fqdn, value)
+}
+
+// CleanUp implements DNS01Hook.
+func (h commandDNS01Hook) CleanUp(ctx context.Context, fqdn string, value string) error {
+	return h.run(ctx, "cleanup", fqdn, value)
+}
+
+// HTTP01ChallengeResponse returns the key authorization to answer a request to path, if it's a
+// pending HTTP-01 challenge. Inbounds listening on port 80 use it to serve the challenges.
+func HTTP01ChallengeResponse(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/.well-known/acme-challenge/") {
+		return "", false
+	}
+	keyAuth, found := http01Tokens.Load(path)
+	if !found {
+		return "", false
+	}
+	return keyAuth.(string), true
+}
+
+func http01Handler(w http.ResponseWriter, r *http.Request) {
+	keyAuth, found := HTTP01ChallengeResponse(r.URL.Path)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
+
+// http01Listener is a standalone HTTP-01 responder, shared by the managers listening on its address.
+type http01Listener struct {
+	server *http.Server
+	refs   int
+}
+
+// listenHTTP01 starts the standalone HTTP-01 responder on address, once per address. It must be
+// released by releaseHTTP01.
+func listenHTTP01(address string) {
+	http01Access.Lock()
+	defer http01Access.Unlock()
+	if l, found := http01Listeners[address]; found {
+		l.refs++
+		return
+	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           http.HandlerFunc(http01Handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	http01Listeners[address] = &http01Listener{server: server, refs: 1}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errors.LogErrorInner(context.Background(), err, "ACME HTTP-01 responder on ", address, " stopped")
+		}
+	}()
+}
+
+// releaseHTTP01 stops the HTTP-01 responder on address, if it's released by all of its managers.
+func releaseHTTP01(address string) {
+	http01Access.Lock()
+	defer http01Access.Unlock()
+	l, found := http01Listeners[address]
+	if !found {
+		return
+	}
+	l.refs--
+	if l.refs > 0 {
+		return
+	}
+	delete(http01Listeners, address)
+	l.server.Close()
+}
+
+// acmeManager obtains and renews the certificates of an ACME config, and serves them to TLS servers.
+type acmeManager struct {
+	sync.RWMutex
+	config  *AcmeConfig
+	client  *acme.Client
+	domains []string
+	// certs are the issued certificates by domain.
+	certs map[string]*tls.Certificate
+	// challengeCerts are the TLS-ALPN-01 challenge certificates by domain.
+	challengeCerts map[string]*tls.Certificate
+
+	obtainAccess sync.Mutex
+	registered   bool
+
+	// key is the key of the manager in acmeManagers, and refs is the number of its references,
+	// which are guarded by acmeAccess.
+	key  string
+	refs int
+	// ctx is canceled when the manager is stopped.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// acmeReference is a reference to a running manager, which releases the manager on Close.
+type acmeReference struct {
+	*acmeManager
+	once sync.Once
+}
+
+// Close implements io.Closer.
+func (r *acmeReference) Close() error {
+	r.once.Do(r.acmeManager.release)
+	return nil
+}
+
+// acquireACMEManager returns a reference to the running manager for config, starting one if
+// necessary. Managers are shared by TLS configs with the same CA, storage and domains, and are
+// stopped when all the references are closed.
+func acquireACMEManager(config *AcmeConfig) (*acmeReference, error) {
+	key := strings.Join(append([]string{config.DirectoryUrl, config.StoragePath}, config.Domains...), "\x00")
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+	m, found := acmeManagers[key]
+	if !found {
+		var err error
+		m, err = newACMEManager(config)
+		if err != nil {
+			return nil, err
+		}
+		m.key = key
+		m.start()
+		acmeManagers[key] = m
+	}
+	m.refs++
+	return &acmeReference{acmeManager: m}, nil
+}
+
+// release drops a reference to the manager, and stops it if it's the last one.
+func (m *acmeManager) release() {
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+	delete(acmeManagers, m.key)
+	m.cancel()
+	if m.config.Challenge == AcmeConfig_HTTP01 && m.config.Http01Listen != "" {
+		releaseHTTP01(m.config.Http01Listen)
+	}
+}
+
+func newACMEManager(config *AcmeConfig) (*acmeManager, error) {
+	if len(config.Domains) == 0 {
+		return nil, errors.New("no domain for ACME")
+	}
+	if config.StoragePath == "" {
+		return nil, errors.New("no storage path for ACME")
+	}
+	if err := os.MkdirAll(config.StoragePath, 0o700); err != nil {
+		return nil, errors.New("failed to create ACME storage ", config.StoragePath).Base(err)
+	}
+	accountKey, err := loadOrCreateKey(filepath.Join(config.StoragePath, "account.key"))
+	if err != nil {
+		return nil, errors.New("failed to load ACME account key").Base(err)
+	}
+	directoryURL := config.DirectoryUrl
+	if directoryURL == "" {
+		directoryURL = acme.LetsEncryptURL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &acmeManager{
+		ctx:    ctx,
+		cancel: cancel,
+		config: config,
+		client: &acme.Client{
+			Key:          accountKey,
+			DirectoryURL: directoryURL,
+			UserAgent:    "Xray",
+		},
+		certs:          make(map[string]*tls.Certificate),
+		challengeCerts: make(map[string]*tls.Certificate),
+	}
+	for _, domain := range config.Domains {
+		domain = strings.ToLower(domain)
+		m.domains = append(m.domains, domain)
+		cert, err := m.loadCertificate(domain)
+		if err != nil {
+			if !os.IsNotExist(errors.Cause(err)) {
+				errors.LogWarningInner(context.Background(), err, "failed to load stored ACME certificate of ", domain)
+			}
+			continue
+		}
+		m.certs[domain] = cert
+	}
+	return m, nil
+}
+
+// start runs the background renewal, and the HTTP-01 responder if configured, until the manager
+// is released.
+func (m *acmeManager) start() {
+	if m.config.Challenge == AcmeConfig_HTTP01 && m.config.Http01Listen != "" {
+		listenHTTP01(m.config.Http01Listen)
+	}
+	go m.run()
+}
+
+func (m *acmeManager) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		next := acmeCheckInterval
+		for _, domain := range m.domains {
+			if !m.needsRenewal(domain) {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(m.ctx, acmeObtainTimeout)
+			err := m.obtain(ctx, domain)
+			cancel()
+			if m.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				errors.LogErrorInner(context.Background(), err, "failed to obtain certificate of ", domain, " from ACME")
+				next = acmeRetryInterval
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+func (m *acmeManager) renewBefore() time.Duration {
+	if m.config.RenewBefore > 0 {
+		return time.Duration(m.config.RenewBefore) * time.Second
+	}
+	return acmeDefaultRenewBefore
+}
+
+func (m *acmeManager) needsRenewal(domain string) bool {
+	m.RLock()
+	cert := m.certs[domain]
+	m.RUnlock()
+	return cert == nil || time.Until(cert.Leaf.NotAfter) < m.renewBefore()
+}
+
+// certificate returns the issued certificate for serverName, or nil if there isn't one.
+func (m *acmeManager) certificate(serverName string) *tls.Certificate {
+	serverName = strings.ToLower(serverName)
+	m.RLock()
+	defer m.RUnlock()
+	if cert, found := m.certs[serverName]; found {
+		return cert
+	}
+	if index := strings.IndexByte(serverName, '.'); index != -1 {
+		return m.certs["*"+serverName[index:]]
+	}
+	return nil
+}
+
+// defaultCertificate returns the issued certificate of the first domain that has one.
+func (m *acmeManager) defaultCertificate() *tls.Certificate {
+	m.RLock()
+	defer m.RUnlock()
+	for _, domain := range m.domains {
+		if cert, found := m.certs[domain]; found {
+			return cert
+		}
+	}
+	return nil
+}
+
+func (m *acmeManager) challengeCertificate(serverName string) (*tls.Certificate, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if cert, found := m.challengeCerts[strings.ToLower(serverName)]; found {
+		return cert, nil
+	}
+	return nil, errors.New("no pending TLS-ALPN-01 challenge for ", serverName)
+}
+
+func (m *acmeManager) register(ctx context.Context) error {
+	if m.registered {
+		return nil
+	}
+	account := &acme.Account{}
+	if m.config.Email != "" {
+		account.Contact = []string{"mailto:" + m.config.Email}
+	}
+	if _, err := m.client.Register(ctx, account, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return errors.New("failed to register ACME account").Base(err)
+	}
+	m.registered = true
+	return nil
+}
+
+// obtain orders a certificate for domain, stores it and swaps it in.
+func (m *acmeManager) obtain(ctx context.Context, domain string) error {
+	m.obtainAccess.Lock()
+	defer m.obtainAccess.Unlock()
+
+	if err := m.register(ctx); err != nil {
+		return err
+	}
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(domain))
+	if err != nil {
+		return errors.New("failed to create order").Base(err)
+	}
+	for _, url := range order.AuthzURLs {
+		if err := m.authorize(ctx, domain, url); err != nil {
+			return err
+		}
+	}
+	if order, err = m.client.WaitOrder(ctx, order.URI); err != nil {
+		return errors.New("failed to wait for order").Base(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{domain}}, key)
+	if err != nil {
+		return errors.New("failed to create CSR").Base(err)
+	}
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return errors.New("failed to finalize order").Base(err)
+	}
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return errors.New("invalid certificate from ACME").Base(err)
+	}
+	cert := &tls.Certificate{Certificate: der, PrivateKey: key, Leaf: leaf}
+	if err := m.storeCertificate(domain, cert); err != nil {
+		errors.LogWarningInner(ctx, err, "failed to store ACME certificate of ", domain)
+	}
+
+	m.Lock()
+	m.certs[domain] = cert
+	m.Unlock()
+	errors.LogInfo(ctx, "obtained certificate of ", domain, " from ACME, valid until ", leaf.NotAfter)
+	return nil
+}
+
+// authorize solves a challenge of the authorization at url.
+func (m *acmeManager) authorize(ctx context.Context, domain string, url string) error {
+	authz, err := m.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return errors.New("failed to get authorization").Base(err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challengeType string
+	switch m.config.Challenge {
+	case AcmeConfig_HTTP01:
+		challengeType = "http-01"
+	case AcmeConfig_TLS_ALPN01:
+		challengeType = "tls-alpn-01"
+	case AcmeConfig_DNS01:
+		challengeType = "dns-01"
+	}
+	index := slices.IndexFunc(authz.Challenges, func(c *acme.Challenge) bool {
+		return c.Type == challengeType
+	})
+	if index == -1 {
+		return errors.New("ACME CA doesn't offer ", challengeType, " challenge for ", domain)
+	}
+	challenge := authz.Challenges[index]
+
+	cleanup, err := m.prepareChallenge(ctx, domain, challenge)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := m.client.Accept(ctx, challenge); err != nil {
+		return errors.New("failed to accept ", challengeType, " challenge").Base(err)
+	}
+	if _, err := m.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return errors.New("failed to authorize ", domain).Base(err)
+	}
+	return nil
+}
+
+// prepareChallenge sets up the response to challenge, and returns a function to tear it down.
+func (m *acmeManager) prepareChallenge(ctx context.Context, domain string, challenge *acme.Challenge) (func(), error) {
+	switch challenge.Type {
+	case "http-01":
+		keyAuth, err := m.client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return nil, err
+		}
+		path := m.client.HTTP01ChallengePath(challenge.Token)
+		http01Tokens.Store(path, keyAuth)
+		return func() { http01Tokens.Delete(path) }, nil
+	case "tls-alpn-01":
+		cert, err := m.client.TLSALPN01ChallengeCert(challenge.Token, domain)
+		if err != nil {
+			return nil, err
+		}
+		m.Lock()
+		m.challengeCerts[domain] = &cert
+		m.Unlock()
+		return func() {
+			m.Lock()
+			delete(m.challengeCerts, domain)
+			m.Unlock()
+		}, nil
+	default:
+		value, err := m.client.DNS01ChallengeRecord(challenge.Token)
+		if err != nil {
+			return nil, err
+		}
+		fqdn := "_acme-challenge." + strings.TrimPrefix(domain, "*.") + "."
+		hook := getDNS01Hook(m.config.Dns01Hook)
+		if err := hook.Present(ctx, fqdn, value); err != nil {
+			return nil, err
+		}
+		return func() {
+			if err := hook.CleanUp(context.Background(), fqdn, value); err != nil {
+				errors.LogWarningInner(ctx, err, "failed to clean up DNS-01 challenge of ", domain)
+			}
+		}, nil
+	}
+}
+
+func (m *acmeManager) certificatePath(domain string) (string, string) {
+	name := strings.ReplaceAll(domain, "*", "_")
+	return filepath.Join(m.config.StoragePath, name+".crt"), filepath.Join(m.config.StoragePath, name+".key")
+}
+
+func (m *acmeManager) loadCertificate(domain string) (*tls.Certificate, error) {
+	certPath, keyPath := m.certificatePath(domain)
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+func (m *acmeManager) storeCertificate(domain string, cert *tls.Certificate) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return err
+	}
+	var certPEM []byte
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	certPath, keyPath := m.certificatePath(domain)
+	if err := writeFileAtomic(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})); err != nil {
+		return err
+	}
+	return writeFileAtomic(certPath, certPEM)
+}
+
+// loadOrCreateKey loads a PEM encoded private key from path, or generates one and saves it there.
+func loadOrCreateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("invalid key file ", path)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported key in ", path)
+		}
+		return signer, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// applyACME serves the certificates of the ACME manager in config, ahead of the configured ones.
+// It returns the reference to the manager, or nil if there isn't one.
+func (c *Config) applyACME(config *tls.Config) io.Closer {
+	if c.Acme == nil {
+		return nil
+	}
+	m, err := acquireACMEManager(c.Acme)
+	if err != nil {
+		errors.LogErrorInner(context.Background(), err, "failed to start ACME")
+		return nil
+	}
+	getCertificate := config.GetCertificate
+	config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if slices.Contains(hello.SupportedProtos, acmeTLSALPNProto) {
+			return m.challengeCertificate(hello.ServerName)
+		}
+		if cert := m.certificate(hello.ServerName); cert != nil {
+			return cert, nil
+		}
+		if len(c.Certificate) == 0 && !c.RejectUnknownSni {
+			if cert := m.defaultCertificate(); cert != nil {
+				return cert, nil
+			}
+		}
+		return getCertificate(hello)
+	}
+	if c.Acme.Challenge == AcmeConfig_TLS_ALPN01 {
+		config.NextProtos = append(config.NextProtos, acmeTLSALPNProto)
+	}
+	return m
+}
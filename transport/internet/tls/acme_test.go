@@ -0,0 +1,423 @@
+package tls_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	. "github.com/HZ-PRE/XrarCore/transport/internet/tls"
+)
+
+// fakeACME is a minimal RFC 8555 CA. It doesn't verify JWS signatures.
+type fakeACME struct {
+	sync.Mutex
+	*httptest.Server
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	// validate checks the response to a challenge.
+	validate func(challengeType, domain, token string) error
+
+	nextID int
+	orders map[string]*fakeOrder
+	authzs map[string]*fakeAuthz
+}
+
+type fakeOrder struct {
+	status string
+	domain string
+	authz  string
+	cert   []byte
+}
+
+type fakeAuthz struct {
+	status string
+	domain string
+	token  string
+}
+
+func newFakeACME(t *testing.T, validate func(challengeType, domain, token string) error) *fakeACME {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	common.Must(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	common.Must(err)
+	caCert, err := x509.ParseCertificate(der)
+	common.Must(err)
+
+	f := &fakeACME{
+		caCert:   caCert,
+		caKey:    caKey,
+		validate: validate,
+		orders:   make(map[string]*fakeOrder),
+		authzs:   make(map[string]*fakeAuthz),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeACME) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	if r.URL.Path == "/directory" {
+		f.writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   f.URL + "/nonce",
+			"newAccount": f.URL + "/account",
+			"newOrder":   f.URL + "/order",
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		return
+	}
+
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	f.Lock()
+	defer f.Unlock()
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch path[0] {
+	case "account":
+		w.Header().Set("Location", f.URL+"/account/1")
+		f.writeJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+	case "order":
+		if len(path) == 1 {
+			var req struct {
+				Identifiers []struct{ Value string }
+			}
+			common.Must(json.Unmarshal(payload, &req))
+			f.nextID++
+			id := strconv.Itoa(f.nextID)
+			domain := req.Identifiers[0].Value
+			f.authzs[id] = &fakeAuthz{status: "pending", domain: domain, token: "token" + id}
+			f.orders[id] = &fakeOrder{status: "pending", domain: domain, authz: id}
+			w.Header().Set("Location", f.URL+"/order/"+id)
+			f.writeOrder(w, http.StatusCreated, id)
+			return
+		}
+		f.writeOrder(w, http.StatusOK, path[1])
+	case "authz":
+		authz := f.authzs[path[1]]
+		var challenges []map[string]string
+		for _, challengeType := range []string{"http-01", "tls-alpn-01", "dns-01"} {
+			challenges = append(challenges, map[string]string{
+				"type":   challengeType,
+				"url":    f.URL + "/challenge/" + path[1] + "/" + challengeType,
+				"token":  authz.token,
+				"status": authz.status,
+			})
+		}
+		f.writeJSON(w, http.StatusOK, map[string]any{
+			"status":     authz.status,
+			"identifier": map[string]string{"type": "dns", "value": authz.domain},
+			"challenges": challenges,
+		})
+	case "challenge":
+		authz := f.authzs[path[1]]
+		// The validation connects back to the server under test, which must not block on f.
+		f.Unlock()
+		err := f.validate(path[2], authz.domain, authz.token)
+		f.Lock()
+		if err != nil {
+			authz.status = "invalid"
+		} else {
+			authz.status = "valid"
+		}
+		f.writeJSON(w, http.StatusOK, map[string]string{
+			"type":   path[2],
+			"url":    f.URL + r.URL.Path,
+			"token":  authz.token,
+			"status": authz.status,
+		})
+	case "finalize":
+		order := f.orders[path[1]]
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		common.Must(json.Unmarshal(payload, &req))
+		csrDER, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if err != nil || len(csr.DNSNames) != 1 || csr.DNSNames[0] != order.domain {
+			http.Error(w, "bad CSR", http.StatusBadRequest)
+			return
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(f.nextID + 100)),
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, f.caCert, csr.PublicKey, f.caKey)
+		common.Must(err)
+		order.cert = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw})...)
+		order.status = "valid"
+		w.Header().Set("Location", f.URL+"/order/"+path[1])
+		f.writeOrder(w, http.StatusOK, path[1])
+	case "cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(f.orders[path[1]].cert)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeACME) writeOrder(w http.ResponseWriter, status int, id string) {
+	order := f.orders[id]
+	if order.status == "pending" && f.authzs[order.authz].status == "valid" {
+		order.status = "ready"
+	}
+	v := map[string]any{
+		"status":         order.status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": order.domain}},
+		"authorizations": []string{f.URL + "/authz/" + order.authz},
+		"finalize":       f.URL + "/finalize/" + id,
+	}
+	if order.status == "valid" {
+		v["certificate"] = f.URL + "/cert/" + id
+	}
+	f.writeJSON(w, status, v)
+}
+
+func (f *fakeACME) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// waitACMECertificate waits until config serves a certificate issued by ca to domain.
+func waitACMECertificate(t *testing.T, config *gotls.Config, domain string, ca *x509.Certificate) *gotls.Certificate {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		cert, err := config.GetCertificate(&gotls.ClientHelloInfo{ServerName: domain})
+		if err == nil && cert.Leaf != nil && cert.Leaf.CheckSignatureFrom(ca) == nil {
+			if cert.Leaf.VerifyHostname(domain) != nil {
+				t.Fatal("certificate is not for ", domain)
+			}
+			return cert
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("no certificate from ACME for ", domain)
+	return nil
+}
+
+func TestACMEHTTP01(t *testing.T) {
+	const domain = "http01.example.com"
+	listen := freeAddress(t)
+	f := newFakeACME(t, func(challengeType, host, token string) error {
+		if challengeType != "http-01" {
+			return errors.New("unexpected challenge " + challengeType)
+		}
+		resp, err := http.Get("http://" + listen + "/.well-known/acme-challenge/" + token)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if !strings.HasPrefix(string(body), token+".") {
+			return errors.New("wrong key authorization " + string(body))
+		}
+		if keyAuth, ok := HTTP01ChallengeResponse("/.well-known/acme-challenge/" + token); !ok || keyAuth != string(body) {
+			return errors.New("HTTP01ChallengeResponse doesn't match")
+		}
+		return nil
+	})
+
+	storage := t.TempDir()
+	config, acme := (&Config{
+		Acme: &AcmeConfig{
+			DirectoryUrl: f.URL + "/directory",
+			Domains:      []string{domain},
+			StoragePath:  storage,
+			Http01Listen: listen,
+		},
+	}).GetServerTLSConfig()
+	cert := waitACMECertificate(t, config, domain, f.caCert)
+
+	for _, name := range []string{"account.key", domain + ".crt", domain + ".key"} {
+		if _, err := os.Stat(filepath.Join(storage, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, ok := HTTP01ChallengeResponse("/.well-known/acme-challenge/token1"); ok {
+		t.Error("challenge is not cleaned up")
+	}
+
+	// The HTTP-01 responder is stopped with the last listener using it.
+	common.Must(acme.Close())
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		t.Fatal("HTTP-01 responder is not stopped: ", err)
+	}
+	l.Close()
+
+	// Another CA with the same storage must serve the stored certificate right away.
+	stored, storedACME := (&Config{
+		Acme: &AcmeConfig{
+			DirectoryUrl: "http://127.0.0.1:1/directory",
+			Domains:      []string{domain},
+			StoragePath:  storage,
+		},
+	}).GetServerTLSConfig()
+	defer storedACME.Close()
+	storedCert, err := stored.GetCertificate(&gotls.ClientHelloInfo{ServerName: domain})
+	common.Must(err)
+	if storedCert.Leaf.SerialNumber.Cmp(cert.Leaf.SerialNumber) != 0 {
+		t.Error("stored certificate is not loaded")
+	}
+}
+
+func TestACMETLSALPN01(t *testing.T) {
+	const domain = "alpn.example.com"
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	f := newFakeACME(t, func(challengeType, host, token string) error {
+		if challengeType != "tls-alpn-01" {
+			return errors.New("unexpected challenge " + challengeType)
+		}
+		conn, err := gotls.Dial("tcp", listener.Addr().String(), &gotls.Config{
+			ServerName:         host,
+			NextProtos:         []string{"acme-tls/1"},
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		state := conn.ConnectionState()
+		if state.NegotiatedProtocol != "acme-tls/1" {
+			return errors.New("unexpected ALPN " + state.NegotiatedProtocol)
+		}
+		if err := state.PeerCertificates[0].VerifyHostname(host); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	config, acme := (&Config{
+		Acme: &AcmeConfig{
+			DirectoryUrl: f.URL + "/directory",
+			Domains:      []string{domain},
+			StoragePath:  t.TempDir(),
+			Challenge:    AcmeConfig_TLS_ALPN01,
+		},
+	}).GetServerTLSConfig()
+	defer acme.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				gotls.Server(conn, config).Handshake()
+			}()
+		}
+	}()
+	waitACMECertificate(t, config, domain, f.caCert)
+}
+
+type testDNS01Hook struct {
+	sync.Mutex
+	records map[string]string
+	cleaned bool
+}
+
+func (h *testDNS01Hook) Present(ctx context.Context, fqdn string, value string) error {
+	h.Lock()
+	defer h.Unlock()
+	h.records[fqdn] = value
+	return nil
+}
+
+func (h *testDNS01Hook) CleanUp(ctx context.Context, fqdn string, value string) error {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.records, fqdn)
+	h.cleaned = true
+	return nil
+}
+
+func TestACMEDNS01(t *testing.T) {
+	const domain = "*.dns.example.com"
+	hook := &testDNS01Hook{records: make(map[string]string)}
+	RegisterDNS01Hook("test", hook)
+
+	f := newFakeACME(t, func(challengeType, host, token string) error {
+		if challengeType != "dns-01" {
+			return errors.New("unexpected challenge " + challengeType)
+		}
+		hook.Lock()
+		defer hook.Unlock()
+		if hook.records["_acme-challenge.dns.example.com."] == "" {
+			return errors.New("no TXT record")
+		}
+		return nil
+	})
+
+	config, acme := (&Config{
+		Acme: &AcmeConfig{
+			DirectoryUrl: f.URL + "/directory",
+			Domains:      []string{domain},
+			StoragePath:  t.TempDir(),
+			Challenge:    AcmeConfig_DNS01,
+			Dns01Hook:    "test",
+		},
+	}).GetServerTLSConfig()
+	defer acme.Close()
+	waitACMECertificate(t, config, "www.dns.example.com", f.caCert)
+
+	hook.Lock()
+	defer hook.Unlock()
+	if !hook.cleaned || len(hook.records) != 0 {
+		t.Error("DNS-01 record is not cleaned up")
+	}
+}
@@ -0,0 +1,210 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/url"
+	"slices"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/transport/internet/stat"
+)
+
+// ParseClientCRL parses a CRL in PEM or DER, and verifies that it's issued by one of cas.
+func ParseClientCRL(data []byte, cas []*x509.Certificate) ([]*x509.RevocationList, error) {
+	var ders [][]byte
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type == "X509 CRL" {
+				ders = append(ders, block.Bytes)
+			}
+		}
+		if len(ders) == 0 {
+			return nil, errors.New("no X509 CRL in PEM")
+		}
+	} else {
+		ders = [][]byte{data}
+	}
+
+	crls := make([]*x509.RevocationList, 0, len(ders))
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, errors.New("invalid CRL").Base(err)
+		}
+		if !slices.ContainsFunc(cas, func(ca *x509.Certificate) bool {
+			return crl.CheckSignatureFrom(ca) == nil
+		}) {
+			return nil, errors.New("CRL of ", crl.Issuer.String(), " is not signed by any client CA")
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
+
+// ParseClientCAs parses PEM encoded CA certificates for client certificate verification.
+func ParseClientCAs(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate in PEM")
+	}
+	return certs, nil
+}
+
+// isRevoked returns true if any certificate in chain is revoked by crls.
+func isRevoked(chain []*x509.Certificate, crls []*x509.RevocationList) bool {
+	for _, cert := range chain {
+		for _, crl := range crls {
+			if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+				continue
+			}
+			for _, entry := range crl.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// applyClientAuth sets up the verification of client certificates for servers.
+func (c *Config) applyClientAuth(config *tls.Config) {
+	if c.ClientAuth == Config_NO_CLIENT_CERT {
+		return
+	}
+	cas, err := ParseClientCAs(c.ClientCa)
+	if err != nil {
+		// Fail closed, so that a broken CA bundle doesn't make client certificates optional.
+		errors.LogErrorInner(context.Background(), err, "failed to load client CA, all client certificates will be rejected")
+		cas = nil
+	}
+	config.ClientCAs = x509.NewCertPool()
+	for _, ca := range cas {
+		config.ClientCAs.AddCert(ca)
+	}
+	if c.ClientAuth == Config_REQUIRE_AND_VERIFY {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	var crls []*x509.RevocationList
+	for _, data := range c.ClientCrl {
+		list, err := ParseClientCRL(data, cas)
+		if err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to load client CRL")
+			continue
+		}
+		crls = append(crls, list...)
+	}
+	if len(crls) == 0 {
+		return
+	}
+	verifyPeerCertificate := config.VerifyPeerCertificate
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			if isRevoked(chain, crls) {
+				return errors.New("client certificate is revoked")
+			}
+		}
+		if verifyPeerCertificate != nil {
+			return verifyPeerCertificate(rawCerts, verifiedChains)
+		}
+		return nil
+	}
+}
+
+// matches returns true if cert meets all conditions of u.
+func (u *ClientCertificateUser) matches(cert *x509.Certificate) bool {
+	if u.CommonName != "" && cert.Subject.CommonName != u.CommonName {
+		return false
+	}
+	if u.San != "" && !slices.Contains(cert.DNSNames, u.San) && !slices.Contains(cert.EmailAddresses, u.San) &&
+		!slices.ContainsFunc(cert.URIs, func(uri *url.URL) bool { return uri.String() == u.San }) {
+		return false
+	}
+	if len(u.Fingerprint) > 0 {
+		fingerprint := sha256.Sum256(cert.Raw)
+		if !bytes.Equal(fingerprint[:], u.Fingerprint) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientUserEmail returns the email mapped from the verified client certificate, or "" if none.
+func (c *Conn) ClientUserEmail() string {
+	if len(c.clientUsers) == 0 {
+		return ""
+	}
+	state := c.ConnectionState()
+	if !state.HandshakeComplete || len(state.VerifiedChains) == 0 {
+		return ""
+	}
+	leaf := state.VerifiedChains[0][0]
+	for _, u := range c.clientUsers {
+		if u.matches(leaf) {
+			return u.Email
+		}
+	}
+	return ""
+}
+
+// ClientUserEmail returns the email mapped from the verified client certificate of conn, or "" if
+// conn isn't such a TLS connection. Transports that wrap TLS connections pass the email on by
+// implementing ClientUserEmail() too.
+func ClientUserEmail(conn net.Conn) string {
+	if statConn, ok := conn.(*stat.CounterConnection); ok {
+		conn = statConn.Connection
+	}
+	if c, ok := conn.(interface{ ClientUserEmail() string }); ok {
+		return c.ClientUserEmail()
+	}
+	return ""
+}
+
+type clientUsersListener struct {
+	net.Listener
+	config      *tls.Config
+	clientUsers []*ClientCertificateUser
+}
+
+func (l *clientUsersListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return ServerWithClientUsers(conn, l.config, l.clientUsers), nil
+}
+
+// NewListenerWithClientUsers is like crypto/tls.NewListener, but accepts connections of
+// ServerWithClientUsers.
+func NewListenerWithClientUsers(inner net.Listener, config *tls.Config, clientUsers []*ClientCertificateUser) net.Listener {
+	return &clientUsersListener{Listener: inner, config: config, clientUsers: clientUsers}
+}
@@ -0,0 +1,109 @@
+package tls_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/protocol/tls/cert"
+	. "github.com/HZ-PRE/XrarCore/transport/internet/tls"
+)
+
+func clientCertificate(ca *cert.Certificate, commonName string) *cert.Certificate {
+	return cert.MustGenerate(ca, cert.CommonName(commonName), func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+}
+
+// handshakeWithClientCert returns the user email mapped by the server, or the handshake error.
+func handshakeWithClientCert(serverConfig *Config, clientCert *cert.Certificate) (string, error) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	clientConfig := &gotls.Config{
+		ServerName:         "example.com",
+		InsecureSkipVerify: true,
+	}
+	if clientCert != nil {
+		certPEM, keyPEM := clientCert.ToPEM()
+		keyPair, err := gotls.X509KeyPair(certPEM, keyPEM)
+		common.Must(err)
+		clientConfig.Certificates = []gotls.Certificate{keyPair}
+	}
+	go func() {
+		client := gotls.Client(clientConn, clientConfig)
+		if client.Handshake() == nil {
+			// TLS 1.3 servers report client certificate errors after the client handshake.
+			client.Read(make([]byte, 1))
+		}
+		clientConn.Close()
+	}()
+
+	conn := ServerWithClientUsers(serverConn, serverConfig.GetTLSConfig(), serverConfig.ClientUsers).(*Conn)
+	if err := conn.Handshake(); err != nil {
+		return "", err
+	}
+	return conn.ClientUserEmail(), nil
+}
+
+func TestClientAuth(t *testing.T) {
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.CommonName("Device CA"), cert.KeyUsage(x509.KeyUsageCertSign|x509.KeyUsageCRLSign), func(c *x509.Certificate) {
+		c.ExtKeyUsage = nil
+	})
+	caPEM, _ := ca.ToPEM()
+	device1 := clientCertificate(ca, "device1")
+	device2 := clientCertificate(ca, "device2")
+	revoked := clientCertificate(ca, "device1")
+	untrusted := clientCertificate(cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign)), "device1")
+
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	caKey, err := x509.ParsePKCS8PrivateKey(ca.PrivateKey)
+	common.Must(err)
+	revokedCert, err := x509.ParseCertificate(revoked.Certificate)
+	common.Must(err)
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revokedCert.SerialNumber, RevocationTime: time.Now()},
+		},
+	}, caCert, caKey.(crypto.Signer))
+	common.Must(err)
+
+	config := &Config{
+		Certificate: []*Certificate{ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com")))},
+		ClientAuth:  Config_REQUIRE_AND_VERIFY,
+		ClientCa:    caPEM,
+		ClientCrl:   [][]byte{pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl})},
+		ClientUsers: []*ClientCertificateUser{
+			{Email: "device1@example.com", CommonName: "device1"},
+		},
+	}
+
+	if email, err := handshakeWithClientCert(config, device1); err != nil || email != "device1@example.com" {
+		t.Error("expect device1@example.com, but got ", email, " ", err)
+	}
+	if email, err := handshakeWithClientCert(config, device2); err != nil || email != "" {
+		t.Error("expect no user, but got ", email, " ", err)
+	}
+	for name, clientCert := range map[string]*cert.Certificate{"revoked": revoked, "untrusted": untrusted, "missing": nil} {
+		if _, err := handshakeWithClientCert(config, clientCert); err == nil {
+			t.Error("expect ", name, " client certificate to be rejected")
+		}
+	}
+
+	config.ClientAuth = Config_VERIFY_IF_GIVEN
+	if email, err := handshakeWithClientCert(config, nil); err != nil || email != "" {
+		t.Error("expect optional client certificate, but got ", email, " ", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"io"
 	"os"
 	"slices"
 	"strings"
@@ -343,6 +344,12 @@ func (r *RandCarrier) Read(p []byte) (n int, err error) {
 
 // GetTLSConfig converts this Config into tls.Config.
 func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
+	return c.GetTLSConfigContext(context.Background(), opts...)
+}
+
+// GetTLSConfigContext is GetTLSConfig with a context for the lookups it may need, such as that of
+// the ECHConfigList. Dialers should pass the context of the dial.
+func (c *Config) GetTLSConfigContext(ctx context.Context, opts ...Option) *tls.Config {
 	root, err := c.getCertPool()
 	if err != nil {
 		errors.LogErrorInner(context.Background(), err, "failed to load system root certificate")
@@ -436,6 +443,9 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 		}
 	}
 
+	c.applyClientAuth(config)
+	c.applyECH(ctx, config)
+
 	if len(c.MasterKeyLog) > 0 && c.MasterKeyLog != "none" {
 		writer, err := os.OpenFile(c.MasterKeyLog, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
 		if err != nil {
@@ -448,6 +458,14 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 	return config
 }
 
+// GetServerTLSConfig is GetTLSConfig for listeners, which also serves the certificates from ACME if
+// configured. The returned io.Closer, which may be nil, releases the ACME manager, and should be
+// closed with the listener.
+func (c *Config) GetServerTLSConfig(opts ...Option) (*tls.Config, io.Closer) {
+	config := c.GetTLSConfig(opts...)
+	return config, c.applyACME(config)
+}
+
 // Option for building TLS config.
 type Option func(*tls.Config)
 
@@ -7,10 +7,11 @@
 package tls
 
 import (
-	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
-	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 )
 
 const (
@@ -69,6 +70,104 @@ func (Certificate_Usage) EnumDescriptor() ([]byte, []int) {
 	return file_transport_internet_tls_config_proto_rawDescGZIP(), []int{0, 0}
 }
 
+type Config_ClientAuth int32
+
+const (
+	Config_NO_CLIENT_CERT     Config_ClientAuth = 0
+	Config_VERIFY_IF_GIVEN    Config_ClientAuth = 1
+	Config_REQUIRE_AND_VERIFY Config_ClientAuth = 2
+)
+
+// Enum value maps for Config_ClientAuth.
+var (
+	Config_ClientAuth_name = map[int32]string{
+		0: "NO_CLIENT_CERT",
+		1: "VERIFY_IF_GIVEN",
+		2: "REQUIRE_AND_VERIFY",
+	}
+	Config_ClientAuth_value = map[string]int32{
+		"NO_CLIENT_CERT":     0,
+		"VERIFY_IF_GIVEN":    1,
+		"REQUIRE_AND_VERIFY": 2,
+	}
+)
+
+func (x Config_ClientAuth) Enum() *Config_ClientAuth {
+	p := new(Config_ClientAuth)
+	*p = x
+	return p
+}
+
+func (x Config_ClientAuth) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Config_ClientAuth) Descriptor() protoreflect.EnumDescriptor {
+	return file_transport_internet_tls_config_proto_enumTypes[1].Descriptor()
+}
+
+func (Config_ClientAuth) Type() protoreflect.EnumType {
+	return &file_transport_internet_tls_config_proto_enumTypes[1]
+}
+
+func (x Config_ClientAuth) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Config_ClientAuth.Descriptor instead.
+func (Config_ClientAuth) EnumDescriptor() ([]byte, []int) {
+	return file_transport_internet_tls_config_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type AcmeConfig_Challenge int32
+
+const (
+	AcmeConfig_HTTP01     AcmeConfig_Challenge = 0
+	AcmeConfig_TLS_ALPN01 AcmeConfig_Challenge = 1
+	AcmeConfig_DNS01      AcmeConfig_Challenge = 2
+)
+
+// Enum value maps for AcmeConfig_Challenge.
+var (
+	AcmeConfig_Challenge_name = map[int32]string{
+		0: "HTTP01",
+		1: "TLS_ALPN01",
+		2: "DNS01",
+	}
+	AcmeConfig_Challenge_value = map[string]int32{
+		"HTTP01":     0,
+		"TLS_ALPN01": 1,
+		"DNS01":      2,
+	}
+)
+
+func (x AcmeConfig_Challenge) Enum() *AcmeConfig_Challenge {
+	p := new(AcmeConfig_Challenge)
+	*p = x
+	return p
+}
+
+func (x AcmeConfig_Challenge) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AcmeConfig_Challenge) Descriptor() protoreflect.EnumDescriptor {
+	return file_transport_internet_tls_config_proto_enumTypes[2].Descriptor()
+}
+
+func (AcmeConfig_Challenge) Type() protoreflect.EnumType {
+	return &file_transport_internet_tls_config_proto_enumTypes[2]
+}
+
+func (x AcmeConfig_Challenge) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AcmeConfig_Challenge.Descriptor instead.
+func (AcmeConfig_Challenge) EnumDescriptor() ([]byte, []int) {
+	return file_transport_internet_tls_config_proto_rawDescGZIP(), []int{3, 0}
+}
+
 type Certificate struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -203,20 +302,39 @@ type Config struct {
 	Fingerprint      string `protobuf:"bytes,11,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
 	RejectUnknownSni bool   `protobuf:"varint,12,opt,name=reject_unknown_sni,json=rejectUnknownSni,proto3" json:"reject_unknown_sni,omitempty"`
 	// @Document Some certificate chain sha256 hashes.
-	// @Document After normal validation or allow_insecure, if the server's cert chain hash does not match any of these values, the connection will be aborted.
-	// @Critical
+	//@Document After normal validation or allow_insecure, if the server's cert chain hash does not match any of these values, the connection will be aborted.
+	//@Critical
 	PinnedPeerCertificateChainSha256 [][]byte `protobuf:"bytes,13,rep,name=pinned_peer_certificate_chain_sha256,json=pinnedPeerCertificateChainSha256,proto3" json:"pinned_peer_certificate_chain_sha256,omitempty"`
 	// @Document Some certificate public key sha256 hashes.
-	// @Document After normal validation (required), if one of certs in verified chain matches one of these values, the connection will be eventually accepted.
-	// @Critical
+	//@Document After normal validation (required), if one of certs in verified chain matches one of these values, the connection will be eventually accepted.
+	//@Critical
 	PinnedPeerCertificatePublicKeySha256 [][]byte `protobuf:"bytes,14,rep,name=pinned_peer_certificate_public_key_sha256,json=pinnedPeerCertificatePublicKeySha256,proto3" json:"pinned_peer_certificate_public_key_sha256,omitempty"`
 	MasterKeyLog                         string   `protobuf:"bytes,15,opt,name=master_key_log,json=masterKeyLog,proto3" json:"master_key_log,omitempty"`
 	// Lists of string as CurvePreferences values.
 	CurvePreferences []string `protobuf:"bytes,16,rep,name=curve_preferences,json=curvePreferences,proto3" json:"curve_preferences,omitempty"`
 	// @Document Replaces server_name to verify the peer cert.
-	// @Document After allow_insecure (automatically), if the server's cert can't be verified by any of these names, pinned_peer_certificate_chain_sha256 will be tried.
-	// @Critical
+	//@Document After allow_insecure (automatically), if the server's cert can't be verified by any of these names, pinned_peer_certificate_chain_sha256 will be tried.
+	//@Critical
 	VerifyPeerCertInNames []string `protobuf:"bytes,17,rep,name=verify_peer_cert_in_names,json=verifyPeerCertInNames,proto3" json:"verify_peer_cert_in_names,omitempty"`
+	// ECH key sets for the server, as generated by "xray tls ech".
+	EchServerKeys []byte `protobuf:"bytes,18,opt,name=ech_server_keys,json=echServerKeys,proto3" json:"ech_server_keys,omitempty"`
+	// ECHConfigList for the client.
+	EchConfigList []byte `protobuf:"bytes,19,opt,name=ech_config_list,json=echConfigList,proto3" json:"ech_config_list,omitempty"`
+	// Domain whose HTTPS record provides the ECHConfigList for the client. The
+	// record is queried through the DNS feature of the core.
+	EchConfigDomain string `protobuf:"bytes,20,opt,name=ech_config_domain,json=echConfigDomain,proto3" json:"ech_config_domain,omitempty"`
+	// Obtain and renew certificates from an ACME CA.
+	Acme *AcmeConfig `protobuf:"bytes,21,opt,name=acme,proto3" json:"acme,omitempty"`
+	// Whether the server requests and verifies client certificates.
+	ClientAuth Config_ClientAuth `protobuf:"varint,22,opt,name=client_auth,json=clientAuth,proto3,enum=xray.transport.internet.tls.Config_ClientAuth" json:"client_auth,omitempty"`
+	// PEM encoded CA certificates to verify client certificates.
+	ClientCa []byte `protobuf:"bytes,23,opt,name=client_ca,json=clientCa,proto3" json:"client_ca,omitempty"`
+	// PEM or DER encoded CRLs of client_ca. Revoked client certificates are
+	// rejected.
+	ClientCrl [][]byte `protobuf:"bytes,24,rep,name=client_crl,json=clientCrl,proto3" json:"client_crl,omitempty"`
+	// Maps verified client certificates to user emails on RAW (TCP), mKCP and
+	// HTTPUpgrade servers.
+	ClientUsers []*ClientCertificateUser `protobuf:"bytes,25,rep,name=client_users,json=clientUsers,proto3" json:"client_users,omitempty"`
 }
 
 func (x *Config) Reset() {
@@ -361,6 +479,246 @@ func (x *Config) GetVerifyPeerCertInNames() []string {
 	return nil
 }
 
+func (x *Config) GetEchServerKeys() []byte {
+	if x != nil {
+		return x.EchServerKeys
+	}
+	return nil
+}
+
+func (x *Config) GetEchConfigList() []byte {
+	if x != nil {
+		return x.EchConfigList
+	}
+	return nil
+}
+
+func (x *Config) GetEchConfigDomain() string {
+	if x != nil {
+		return x.EchConfigDomain
+	}
+	return ""
+}
+
+func (x *Config) GetAcme() *AcmeConfig {
+	if x != nil {
+		return x.Acme
+	}
+	return nil
+}
+
+func (x *Config) GetClientAuth() Config_ClientAuth {
+	if x != nil {
+		return x.ClientAuth
+	}
+	return Config_NO_CLIENT_CERT
+}
+
+func (x *Config) GetClientCa() []byte {
+	if x != nil {
+		return x.ClientCa
+	}
+	return nil
+}
+
+func (x *Config) GetClientCrl() [][]byte {
+	if x != nil {
+		return x.ClientCrl
+	}
+	return nil
+}
+
+func (x *Config) GetClientUsers() []*ClientCertificateUser {
+	if x != nil {
+		return x.ClientUsers
+	}
+	return nil
+}
+
+// ClientCertificateUser maps client certificates to the user of email. All
+// non-empty conditions must match.
+type ClientCertificateUser struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Email string `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// Subject common name.
+	CommonName string `protobuf:"bytes,2,opt,name=common_name,json=commonName,proto3" json:"common_name,omitempty"`
+	// DNS name, email address or URI in the subject alternative names.
+	San string `protobuf:"bytes,3,opt,name=san,proto3" json:"san,omitempty"`
+	// SHA-256 of the certificate in DER.
+	Fingerprint []byte `protobuf:"bytes,4,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+}
+
+func (x *ClientCertificateUser) Reset() {
+	*x = ClientCertificateUser{}
+	mi := &file_transport_internet_tls_config_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientCertificateUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientCertificateUser) ProtoMessage() {}
+
+func (x *ClientCertificateUser) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_tls_config_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientCertificateUser.ProtoReflect.Descriptor instead.
+func (*ClientCertificateUser) Descriptor() ([]byte, []int) {
+	return file_transport_internet_tls_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClientCertificateUser) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ClientCertificateUser) GetCommonName() string {
+	if x != nil {
+		return x.CommonName
+	}
+	return ""
+}
+
+func (x *ClientCertificateUser) GetSan() string {
+	if x != nil {
+		return x.San
+	}
+	return ""
+}
+
+func (x *ClientCertificateUser) GetFingerprint() []byte {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return nil
+}
+
+type AcmeConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Directory URL of the ACME CA. Let's Encrypt is used if empty.
+	DirectoryUrl string `protobuf:"bytes,1,opt,name=directory_url,json=directoryUrl,proto3" json:"directory_url,omitempty"`
+	// Contact email of the ACME account.
+	Email string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	// Domains to obtain certificates for, one certificate per domain.
+	Domains []string `protobuf:"bytes,3,rep,name=domains,proto3" json:"domains,omitempty"`
+	// Directory where the account key and certificates are stored.
+	StoragePath string               `protobuf:"bytes,4,opt,name=storage_path,json=storagePath,proto3" json:"storage_path,omitempty"`
+	Challenge   AcmeConfig_Challenge `protobuf:"varint,5,opt,name=challenge,proto3,enum=xray.transport.internet.tls.AcmeConfig_Challenge" json:"challenge,omitempty"`
+	// Address of the standalone HTTP-01 responder, e.g. ":80". Leave it empty if
+	// an HTTP inbound on port 80 answers the challenges.
+	Http01Listen string `protobuf:"bytes,6,opt,name=http01_listen,json=http01Listen,proto3" json:"http01_listen,omitempty"`
+	// Name of a registered DNS-01 hook, or a command called as
+	// "<command> present|cleanup <fqdn> <value>".
+	Dns01Hook string `protobuf:"bytes,7,opt,name=dns01_hook,json=dns01Hook,proto3" json:"dns01_hook,omitempty"`
+	// Renew certificates this many seconds before they expire.
+	RenewBefore uint64 `protobuf:"varint,8,opt,name=renew_before,json=renewBefore,proto3" json:"renew_before,omitempty"`
+}
+
+func (x *AcmeConfig) Reset() {
+	*x = AcmeConfig{}
+	mi := &file_transport_internet_tls_config_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcmeConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcmeConfig) ProtoMessage() {}
+
+func (x *AcmeConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_transport_internet_tls_config_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcmeConfig.ProtoReflect.Descriptor instead.
+func (*AcmeConfig) Descriptor() ([]byte, []int) {
+	return file_transport_internet_tls_config_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AcmeConfig) GetDirectoryUrl() string {
+	if x != nil {
+		return x.DirectoryUrl
+	}
+	return ""
+}
+
+func (x *AcmeConfig) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *AcmeConfig) GetDomains() []string {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *AcmeConfig) GetStoragePath() string {
+	if x != nil {
+		return x.StoragePath
+	}
+	return ""
+}
+
+func (x *AcmeConfig) GetChallenge() AcmeConfig_Challenge {
+	if x != nil {
+		return x.Challenge
+	}
+	return AcmeConfig_HTTP01
+}
+
+func (x *AcmeConfig) GetHttp01Listen() string {
+	if x != nil {
+		return x.Http01Listen
+	}
+	return ""
+}
+
+func (x *AcmeConfig) GetDns01Hook() string {
+	if x != nil {
+		return x.Dns01Hook
+	}
+	return ""
+}
+
+func (x *AcmeConfig) GetRenewBefore() uint64 {
+	if x != nil {
+		return x.RenewBefore
+	}
+	return 0
+}
+
 var File_transport_internet_tls_config_proto protoreflect.FileDescriptor
 
 var file_transport_internet_tls_config_proto_rawDesc = []byte{
@@ -392,7 +750,7 @@ var file_transport_internet_tls_config_proto_rawDesc = []byte{
 	0x4e, 0x43, 0x49, 0x50, 0x48, 0x45, 0x52, 0x4d, 0x45, 0x4e, 0x54, 0x10, 0x00, 0x12, 0x14, 0x0a,
 	0x10, 0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46,
 	0x59, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x41, 0x55, 0x54, 0x48, 0x4f, 0x52, 0x49, 0x54, 0x59,
-	0x5f, 0x49, 0x53, 0x53, 0x55, 0x45, 0x10, 0x02, 0x22, 0x9a, 0x06, 0x0a, 0x06, 0x43, 0x6f, 0x6e,
+	0x5f, 0x49, 0x53, 0x53, 0x55, 0x45, 0x10, 0x02, 0x22, 0x86, 0x0a, 0x0a, 0x06, 0x43, 0x6f, 0x6e,
 	0x66, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x69, 0x6e, 0x73,
 	0x65, 0x63, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x61, 0x6c, 0x6c,
 	0x6f, 0x77, 0x49, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x12, 0x4a, 0x0a, 0x0b, 0x63, 0x65,
@@ -442,15 +800,77 @@ var file_transport_internet_tls_config_proto_rawDesc = []byte{
 	0x65, 0x72, 0x69, 0x66, 0x79, 0x5f, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x5f,
 	0x69, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x09, 0x52, 0x15,
 	0x76, 0x65, 0x72, 0x69, 0x66, 0x79, 0x50, 0x65, 0x65, 0x72, 0x43, 0x65, 0x72, 0x74, 0x49, 0x6e,
-	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x42, 0x73, 0x0a, 0x1f, 0x63, 0x6f, 0x6d, 0x2e, 0x78, 0x72, 0x61,
-	0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65,
-	0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c, 0x73, 0x50, 0x01, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x78, 0x74, 0x6c, 0x73, 0x2f, 0x78, 0x72, 0x61, 0x79,
-	0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2f,
-	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2f, 0x74, 0x6c, 0x73, 0xaa, 0x02, 0x1b, 0x58,
-	0x72, 0x61, 0x79, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x49, 0x6e,
-	0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x54, 0x6c, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x65, 0x63, 0x68, 0x5f, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d,
+	0x65, 0x63, 0x68, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x26, 0x0a,
+	0x0f, 0x65, 0x63, 0x68, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x6c, 0x69, 0x73, 0x74,
+	0x18, 0x13, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x65, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x65, 0x63, 0x68, 0x5f, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x5f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0f, 0x65, 0x63, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x44, 0x6f, 0x6d, 0x61, 0x69,
+	0x6e, 0x12, 0x3b, 0x0a, 0x04, 0x61, 0x63, 0x6d, 0x65, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x27, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c, 0x73, 0x2e, 0x41, 0x63,
+	0x6d, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x04, 0x61, 0x63, 0x6d, 0x65, 0x12, 0x4f,
+	0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x61, 0x75, 0x74, 0x68, 0x18, 0x16, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x2e, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c,
+	0x73, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x41,
+	0x75, 0x74, 0x68, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x41, 0x75, 0x74, 0x68, 0x12,
+	0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x61, 0x18, 0x17, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x12, 0x1d, 0x0a, 0x0a,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x72, 0x6c, 0x18, 0x18, 0x20, 0x03, 0x28, 0x0c,
+	0x52, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x72, 0x6c, 0x12, 0x55, 0x0a, 0x0c, 0x63,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x19, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x32, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c, 0x73, 0x2e,
+	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x55, 0x73, 0x65,
+	0x72, 0x73, 0x22, 0x4d, 0x0a, 0x0a, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x41, 0x75, 0x74, 0x68,
+	0x12, 0x12, 0x0a, 0x0e, 0x4e, 0x4f, 0x5f, 0x43, 0x4c, 0x49, 0x45, 0x4e, 0x54, 0x5f, 0x43, 0x45,
+	0x52, 0x54, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x56, 0x45, 0x52, 0x49, 0x46, 0x59, 0x5f, 0x49,
+	0x46, 0x5f, 0x47, 0x49, 0x56, 0x45, 0x4e, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x52, 0x45, 0x51,
+	0x55, 0x49, 0x52, 0x45, 0x5f, 0x41, 0x4e, 0x44, 0x5f, 0x56, 0x45, 0x52, 0x49, 0x46, 0x59, 0x10,
+	0x02, 0x22, 0x82, 0x01, 0x0a, 0x15, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x6d, 0x61, 0x69, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69,
+	0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x61, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x61, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72,
+	0x69, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x66, 0x69, 0x6e, 0x67, 0x65,
+	0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x22, 0xf0, 0x02, 0x0a, 0x0a, 0x41, 0x63, 0x6d, 0x65, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x79, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x55, 0x72, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x6d,
+	0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c,
+	0x12, 0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x50, 0x61, 0x74, 0x68, 0x12, 0x4f, 0x0a,
+	0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x31, 0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x2e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c, 0x73, 0x2e, 0x41,
+	0x63, 0x6d, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65,
+	0x6e, 0x67, 0x65, 0x52, 0x09, 0x63, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x23,
+	0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x30, 0x31, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x74, 0x74, 0x70, 0x30, 0x31, 0x4c, 0x69, 0x73,
+	0x74, 0x65, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x6e, 0x73, 0x30, 0x31, 0x5f, 0x68, 0x6f, 0x6f,
+	0x6b, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x6e, 0x73, 0x30, 0x31, 0x48, 0x6f,
+	0x6f, 0x6b, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x6e, 0x65, 0x77, 0x5f, 0x62, 0x65, 0x66, 0x6f,
+	0x72, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x72, 0x65, 0x6e, 0x65, 0x77, 0x42,
+	0x65, 0x66, 0x6f, 0x72, 0x65, 0x22, 0x32, 0x0a, 0x09, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e,
+	0x67, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x54, 0x54, 0x50, 0x30, 0x31, 0x10, 0x00, 0x12, 0x0e,
+	0x0a, 0x0a, 0x54, 0x4c, 0x53, 0x5f, 0x41, 0x4c, 0x50, 0x4e, 0x30, 0x31, 0x10, 0x01, 0x12, 0x09,
+	0x0a, 0x05, 0x44, 0x4e, 0x53, 0x30, 0x31, 0x10, 0x02, 0x42, 0x74, 0x0a, 0x1f, 0x63, 0x6f, 0x6d,
+	0x2e, 0x78, 0x72, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2e,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x74, 0x6c, 0x73, 0x50, 0x01, 0x5a, 0x31,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x48, 0x5a, 0x2d, 0x50, 0x52,
+	0x45, 0x2f, 0x58, 0x72, 0x61, 0x72, 0x43, 0x6f, 0x72, 0x65, 0x2f, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2f, 0x74, 0x6c,
+	0x73, 0xaa, 0x02, 0x1b, 0x58, 0x72, 0x61, 0x79, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x2e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x2e, 0x54, 0x6c, 0x73, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -465,21 +885,29 @@ func file_transport_internet_tls_config_proto_rawDescGZIP() []byte {
 	return file_transport_internet_tls_config_proto_rawDescData
 }
 
-var file_transport_internet_tls_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_transport_internet_tls_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_transport_internet_tls_config_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_transport_internet_tls_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_transport_internet_tls_config_proto_goTypes = []any{
-	(Certificate_Usage)(0), // 0: xray.transport.internet.tls.Certificate.Usage
-	(*Certificate)(nil),    // 1: xray.transport.internet.tls.Certificate
-	(*Config)(nil),         // 2: xray.transport.internet.tls.Config
+	(Certificate_Usage)(0),        // 0: xray.transport.internet.tls.Certificate.Usage
+	(Config_ClientAuth)(0),        // 1: xray.transport.internet.tls.Config.ClientAuth
+	(AcmeConfig_Challenge)(0),     // 2: xray.transport.internet.tls.AcmeConfig.Challenge
+	(*Certificate)(nil),           // 3: xray.transport.internet.tls.Certificate
+	(*Config)(nil),                // 4: xray.transport.internet.tls.Config
+	(*ClientCertificateUser)(nil), // 5: xray.transport.internet.tls.ClientCertificateUser
+	(*AcmeConfig)(nil),            // 6: xray.transport.internet.tls.AcmeConfig
 }
 var file_transport_internet_tls_config_proto_depIdxs = []int32{
 	0, // 0: xray.transport.internet.tls.Certificate.usage:type_name -> xray.transport.internet.tls.Certificate.Usage
-	1, // 1: xray.transport.internet.tls.Config.certificate:type_name -> xray.transport.internet.tls.Certificate
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 1: xray.transport.internet.tls.Config.certificate:type_name -> xray.transport.internet.tls.Certificate
+	6, // 2: xray.transport.internet.tls.Config.acme:type_name -> xray.transport.internet.tls.AcmeConfig
+	1, // 3: xray.transport.internet.tls.Config.client_auth:type_name -> xray.transport.internet.tls.Config.ClientAuth
+	5, // 4: xray.transport.internet.tls.Config.client_users:type_name -> xray.transport.internet.tls.ClientCertificateUser
+	2, // 5: xray.transport.internet.tls.AcmeConfig.challenge:type_name -> xray.transport.internet.tls.AcmeConfig.Challenge
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_transport_internet_tls_config_proto_init() }
@@ -492,8 +920,8 @@ func file_transport_internet_tls_config_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_transport_internet_tls_config_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   2,
+			NumEnums:      3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
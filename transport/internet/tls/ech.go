@@ -0,0 +1,176 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common/errors"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	"github.com/OmarTariq612/goech"
+	"github.com/miekg/dns"
+	utls "github.com/refraction-networking/utls"
+)
+
+// invalidECHConfigList is an empty ECHConfigList. crypto/tls refuses to handshake with it, so that
+// connections fail instead of falling back to a plaintext SNI when the ECHConfigList can't be fetched.
+var invalidECHConfigList = []byte{0x00, 0x00}
+
+// ParseECHKeys converts ECH key sets, as generated by "xray tls ech", into keys for crypto/tls servers.
+func ParseECHKeys(data []byte) ([]tls.EncryptedClientHelloKey, error) {
+	keySets, err := goech.UnmarshalECHKeySetList(data)
+	if err != nil {
+		return nil, errors.New("invalid ECH keys").Base(err)
+	}
+	if len(keySets) == 0 {
+		return nil, errors.New("no ECH key")
+	}
+	keys := make([]tls.EncryptedClientHelloKey, 0, len(keySets))
+	for _, keySet := range keySets {
+		// An ECHConfigList with a single config is a 2-byte length followed by the config.
+		configList, err := goech.MarshalECHConfigArgs(keySet.ECHConfig)
+		if err != nil {
+			return nil, errors.New("invalid ECH config").Base(err)
+		}
+		privateKey, err := keySet.PrivateKey.MarshalBinary()
+		if err != nil {
+			return nil, errors.New("invalid ECH private key").Base(err)
+		}
+		keys = append(keys, tls.EncryptedClientHelloKey{
+			Config:      configList[2:],
+			PrivateKey:  privateKey,
+			SendAsRetry: true,
+		})
+	}
+	return keys, nil
+}
+
+// ParseECHConfigList validates an ECHConfigList. The "ECH CONFIGS" printed by "xray tls ech" is also an ECHConfigList.
+func ParseECHConfigList(data []byte) ([]byte, error) {
+	configs, err := goech.UnmarshalECHConfigList(data)
+	if err != nil {
+		return nil, errors.New("invalid ECHConfigList").Base(err)
+	}
+	if len(configs) == 0 {
+		return nil, errors.New("empty ECHConfigList")
+	}
+	return data, nil
+}
+
+const (
+	// echConfigListMinTTL is the lower bound for caching ECHConfigLists fetched from DNS.
+	echConfigListMinTTL = time.Minute
+	// echConfigListFailureTTL is how long a failure of fetching an ECHConfigList is cached, so that
+	// connections don't query DNS one by one while it fails.
+	echConfigListFailureTTL = 10 * time.Second
+)
+
+type echConfigListCacheItem struct {
+	configList []byte
+	err        error
+	expire     time.Time
+}
+
+var echConfigListCache struct {
+	sync.Mutex
+	items map[string]*echConfigListCacheItem
+}
+
+// lookupECHConfigList returns the ECHConfigList from the HTTPS record of domain, which is cached for
+// the TTL of the record.
+func lookupECHConfigList(ctx context.Context, domain string) ([]byte, error) {
+	now := time.Now()
+	echConfigListCache.Lock()
+	item, found := echConfigListCache.items[domain]
+	echConfigListCache.Unlock()
+	if found && item.expire.After(now) {
+		return item.configList, item.err
+	}
+
+	configList, ttl, err := queryECHConfigList(ctx, domain)
+	if ctx.Err() != nil {
+		// Don't cache the failure of a canceled dial for other connections.
+		return nil, err
+	}
+	item = &echConfigListCacheItem{configList: configList, err: err}
+	if err != nil {
+		item.expire = now.Add(echConfigListFailureTTL)
+	} else {
+		item.expire = now.Add(max(ttl, echConfigListMinTTL))
+	}
+	echConfigListCache.Lock()
+	if echConfigListCache.items == nil {
+		echConfigListCache.items = make(map[string]*echConfigListCacheItem)
+	}
+	for d, i := range echConfigListCache.items {
+		if !i.expire.After(now) {
+			delete(echConfigListCache.items, d)
+		}
+	}
+	echConfigListCache.items[domain] = item
+	echConfigListCache.Unlock()
+	return configList, err
+}
+
+// queryECHConfigList fetches the ECHConfigList from the HTTPS record of domain, along with the TTL
+// of the record.
+func queryECHConfigList(ctx context.Context, domain string) ([]byte, time.Duration, error) {
+	records, err := internet.LookupRecords(ctx, domain, dns.TypeHTTPS)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rr := range records {
+		https, ok := rr.(*dns.HTTPS)
+		if !ok {
+			continue
+		}
+		for _, kv := range https.Value {
+			if ech, ok := kv.(*dns.SVCBECHConfig); ok {
+				configList, err := ParseECHConfigList(ech.ECH)
+				return configList, time.Duration(https.Hdr.Ttl) * time.Second, err
+			}
+		}
+	}
+	return nil, 0, errors.New("no ECHConfigList in HTTPS record of ", domain)
+}
+
+// applyECH sets up ECH for both servers and clients. The ECHConfigList of clients may be looked up with ctx.
+func (c *Config) applyECH(ctx context.Context, config *tls.Config) {
+	if len(c.EchServerKeys) > 0 {
+		keys, err := ParseECHKeys(c.EchServerKeys)
+		if err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to load ECH keys")
+		} else {
+			config.EncryptedClientHelloKeys = keys
+		}
+	}
+
+	switch {
+	case len(c.EchConfigList) > 0:
+		config.EncryptedClientHelloConfigList = c.EchConfigList
+	case c.EchConfigDomain != "":
+		configList, err := lookupECHConfigList(ctx, c.EchConfigDomain)
+		if err != nil {
+			errors.LogErrorInner(ctx, err, "failed to get ECHConfigList from ", c.EchConfigDomain)
+			configList = invalidECHConfigList
+		}
+		config.EncryptedClientHelloConfigList = configList
+	}
+}
+
+// hasClientECH returns true if the client is configured to use ECH.
+func (c *Config) hasClientECH() bool {
+	return c != nil && (len(c.EchConfigList) > 0 || c.EchConfigDomain != "")
+}
+
+// ClientFingerprint returns the uTLS fingerprint for client handshakes, or nil if crypto/tls should be used.
+// The bundled uTLS has Config.ECHConfigs, but its only ECH extension is GREASE, which fails handshakes
+// with real ECHConfigs, so crypto/tls is always used for ECH, including in place of the default
+// fingerprint. Configs reject fingerprints with ECH, and warn about the default one.
+func (c *Config) ClientFingerprint() *utls.ClientHelloID {
+	if c.hasClientECH() {
+		return nil
+	}
+	return GetFingerprint(c.GetFingerprint())
+}
@@ -0,0 +1,123 @@
+package tls_test
+
+import (
+	"bytes"
+	"context"
+	gotls "crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/protocol/tls/cert"
+	"github.com/HZ-PRE/XrarCore/features/dns"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
+	. "github.com/HZ-PRE/XrarCore/transport/internet/tls"
+	"github.com/OmarTariq612/goech"
+	"github.com/cloudflare/circl/hpke"
+	mdns "github.com/miekg/dns"
+)
+
+func TestECHHandshake(t *testing.T) {
+	keySet, err := goech.GenerateECHKeySet(0, "public.example.com", hpke.KEM_X25519_HKDF_SHA256)
+	common.Must(err)
+	keys, err := keySet.MarshalBinary()
+	common.Must(err)
+	configList, err := keySet.ECHConfig.MarshalBinary()
+	common.Must(err)
+	configList, err = ParseECHConfigList(configList)
+	common.Must(err)
+
+	serverConfig := (&Config{
+		Certificate:   []*Certificate{ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("private.example.com", "public.example.com")))},
+		EchServerKeys: keys,
+	}).GetTLSConfig()
+	if len(serverConfig.EncryptedClientHelloKeys) != 1 {
+		t.Fatal("expect 1 ECH key, but got ", len(serverConfig.EncryptedClientHelloKeys))
+	}
+	clientConfig := (&Config{
+		AllowInsecure: true,
+		ServerName:    "private.example.com",
+		EchConfigList: configList,
+	}).GetTLSConfig()
+	if (&Config{EchConfigList: configList, Fingerprint: "chrome"}).ClientFingerprint() != nil {
+		t.Error("expect no uTLS fingerprint with ECH")
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	serverName := make(chan string, 1)
+	go func() {
+		server := gotls.Server(serverConn, serverConfig)
+		if err := server.Handshake(); err != nil {
+			serverName <- ""
+			return
+		}
+		serverName <- server.ConnectionState().ServerName
+	}()
+
+	client := gotls.Client(clientConn, clientConfig)
+	common.Must(client.Handshake())
+	if !client.ConnectionState().ECHAccepted {
+		t.Error("ECH is not accepted")
+	}
+	if name := <-serverName; name != "private.example.com" {
+		t.Error("unexpected server name: ", name)
+	}
+}
+
+// blockingRecordLookup answers HTTPS queries with an ECHConfigList, but only after release is closed.
+type blockingRecordLookup struct {
+	configList []byte
+	release    chan struct{}
+}
+
+func (*blockingRecordLookup) Type() interface{} { return dns.ClientType() }
+func (*blockingRecordLookup) Start() error      { return nil }
+func (*blockingRecordLookup) Close() error      { return nil }
+
+func (*blockingRecordLookup) LookupIP(domain string, option dns.IPOption) ([]net.IP, error) {
+	return nil, dns.ErrEmptyResponse
+}
+
+func (l *blockingRecordLookup) LookupRecords(ctx context.Context, domain string, qType uint16) ([]mdns.RR, error) {
+	select {
+	case <-l.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []mdns.RR{&mdns.HTTPS{SVCB: mdns.SVCB{
+		Hdr:   mdns.RR_Header{Name: mdns.Fqdn(domain), Rrtype: mdns.TypeHTTPS, Class: mdns.ClassINET, Ttl: 600},
+		Value: []mdns.SVCBKeyValue{&mdns.SVCBECHConfig{ECH: l.configList}},
+	}}}, nil
+}
+
+func TestECHConfigDomainLookupContext(t *testing.T) {
+	keySet, err := goech.GenerateECHKeySet(0, "public.example.com", hpke.KEM_X25519_HKDF_SHA256)
+	common.Must(err)
+	configList, err := keySet.ECHConfig.MarshalBinary()
+	common.Must(err)
+
+	lookup := &blockingRecordLookup{configList: configList, release: make(chan struct{})}
+	internet.InitSystemDialer(lookup, nil)
+	defer internet.InitSystemDialer(nil, nil)
+	config := &Config{EchConfigDomain: "context.example.com"}
+
+	// The lookup ends with the dial, and its failure isn't cached for other dials.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if tlsConfig := config.GetTLSConfigContext(ctx); bytes.Equal(tlsConfig.EncryptedClientHelloConfigList, configList) {
+		t.Error("expect no ECHConfigList from a canceled lookup")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Error("expect the lookup to end with the context, but it took ", d)
+	}
+
+	close(lookup.release)
+	if tlsConfig := config.GetTLSConfigContext(context.Background()); !bytes.Equal(tlsConfig.EncryptedClientHelloConfigList, configList) {
+		t.Error("expect the ECHConfigList from the HTTPS record")
+	}
+}
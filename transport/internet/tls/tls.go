@@ -25,6 +25,8 @@ var _ Interface = (*Conn)(nil)
 
 type Conn struct {
 	*tls.Conn
+	// clientUsers maps client certificates to users on servers.
+	clientUsers []*ClientCertificateUser
 }
 
 const tlsCloseTimeout = 250 * time.Millisecond
@@ -68,6 +70,12 @@ func Server(c net.Conn, config *tls.Config) net.Conn {
 	return &Conn{Conn: tlsConn}
 }
 
+// ServerWithClientUsers is Server with verified client certificates mapped to users by clientUsers.
+func ServerWithClientUsers(c net.Conn, config *tls.Config, clientUsers []*ClientCertificateUser) net.Conn {
+	tlsConn := tls.Server(c, config)
+	return &Conn{Conn: tlsConn, clientUsers: clientUsers}
+}
+
 type UConn struct {
 	*utls.UConn
 }
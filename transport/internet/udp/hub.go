@@ -80,46 +80,76 @@ func (h *Hub) start() {
 	c := h.cache
 	defer close(c)
 
-	oobBytes := make([]byte, 256)
+	readBatch := newUDPBatchReader(h.conn)
+	msgs := make([]internet.UDPMessage, internet.UDPBatchSize)
+	buffers := make([]*buf.Buffer, len(msgs))
+	for i := range msgs {
+		msgs[i].OOB = make([]byte, 256)
+	}
+	defer func() {
+		for _, buffer := range buffers {
+			buffer.Release()
+		}
+	}()
 
 	for {
-		buffer := buf.New()
-		var noob int
-		var addr *net.UDPAddr
-		rawBytes := buffer.Extend(buf.Size)
+		for i, buffer := range buffers {
+			if buffer == nil {
+				buffers[i] = buf.New()
+				msgs[i].Buffer = buffers[i].Extend(buf.Size)
+			}
+		}
 
-		n, noob, _, addr, err := ReadUDPMsg(h.conn, rawBytes, oobBytes)
+		n, err := readBatch(msgs)
 		if err != nil {
 			errors.LogInfoInner(context.Background(), err, "failed to read UDP msg")
-			buffer.Release()
 			break
 		}
-		buffer.Resize(0, int32(n))
 
-		if buffer.IsEmpty() {
-			buffer.Release()
-			continue
-		}
+		for i, msg := range msgs[:n] {
+			if msg.N == 0 {
+				continue
+			}
+			buffer := buffers[i]
+			buffers[i] = nil
+			buffer.Resize(0, int32(msg.N))
 
-		payload := &udp.Packet{
-			Payload: buffer,
-			Source:  net.UDPDestination(net.IPAddress(addr.IP), net.Port(addr.Port)),
-		}
-		if h.recvOrigDest && noob > 0 {
-			payload.Target = RetrieveOriginalDest(oobBytes[:noob])
-			if payload.Target.IsValid() {
-				errors.LogDebug(context.Background(), "UDP original destination: ", payload.Target)
-			} else {
-				errors.LogInfo(context.Background(), "failed to read UDP original destination")
+			payload := &udp.Packet{
+				Payload: buffer,
+				Source:  net.UDPDestination(net.IPAddress(msg.Addr.IP), net.Port(msg.Addr.Port)),
+			}
+			if h.recvOrigDest && msg.NOOB > 0 {
+				payload.Target = RetrieveOriginalDest(msg.OOB[:msg.NOOB])
+				if payload.Target.IsValid() {
+					errors.LogDebug(context.Background(), "UDP original destination: ", payload.Target)
+				} else {
+					errors.LogInfo(context.Background(), "failed to read UDP original destination")
+				}
+			}
+
+			select {
+			case c <- payload:
+			default:
+				buffer.Release()
+				payload.Payload = nil
 			}
 		}
+	}
+}
 
-		select {
-		case c <- payload:
-		default:
-			buffer.Release()
-			payload.Payload = nil
+// udpBatchReader reads datagrams into msgs, and returns the number of them.
+type udpBatchReader func(msgs []internet.UDPMessage) (int, error)
+
+// newUDPMsgReader returns a udpBatchReader that reads one datagram at a time with ReadUDPMsg.
+func newUDPMsgReader(conn *net.UDPConn) udpBatchReader {
+	return func(msgs []internet.UDPMessage) (int, error) {
+		m := &msgs[0]
+		n, noob, _, addr, err := ReadUDPMsg(conn, m.Buffer, m.OOB)
+		if err != nil {
+			return 0, err
 		}
+		m.N, m.NOOB, m.Addr = n, noob, addr
+		return 1, nil
 	}
 }
 
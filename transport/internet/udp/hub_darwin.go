@@ -44,3 +44,7 @@ func ReadUDPMsg(conn *net.UDPConn, payload []byte, oob []byte) (int, int, int, *
 	noob, _ := reader.Read(oob)
 	return nBytes, noob, 0, addr, err
 }
+
+func newUDPBatchReader(conn *net.UDPConn) udpBatchReader {
+	return newUDPMsgReader(conn)
+}
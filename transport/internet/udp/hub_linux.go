@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/HZ-PRE/XrarCore/common/net"
+	"github.com/HZ-PRE/XrarCore/transport/internet"
 	"golang.org/x/sys/unix"
 )
 
@@ -32,3 +33,8 @@ func RetrieveOriginalDest(oob []byte) net.Destination {
 func ReadUDPMsg(conn *net.UDPConn, payload []byte, oob []byte) (int, int, int, *net.UDPAddr, error) {
 	return conn.ReadMsgUDP(payload, oob)
 }
+
+// newUDPBatchReader reads datagrams with recvmmsg and GRO.
+func newUDPBatchReader(conn *net.UDPConn) udpBatchReader {
+	return internet.NewUDPBatchConn(conn, true).ReadBatch
+}
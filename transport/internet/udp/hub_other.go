@@ -15,3 +15,7 @@ func ReadUDPMsg(conn *net.UDPConn, payload []byte, oob []byte) (int, int, int, *
 	nBytes, addr, err := conn.ReadFromUDP(payload)
 	return nBytes, 0, 0, addr, err
 }
+
+func newUDPBatchReader(conn *net.UDPConn) udpBatchReader {
+	return newUDPMsgReader(conn)
+}
@@ -0,0 +1,84 @@
+package internet
+
+import (
+	"github.com/HZ-PRE/XrarCore/common/buf"
+	"github.com/HZ-PRE/XrarCore/common/net"
+)
+
+// UDPBatchSize is the max number of datagrams read or written by one syscall of UDPBatchConn.
+const UDPBatchSize = 64
+
+// UDPMessage is a datagram read or written by UDPBatchConn.
+type UDPMessage struct {
+	// Buffer holds the payload. Reads fill it and set N.
+	Buffer []byte
+	// OOB holds the control messages. Reads fill it if it's not empty and set NOOB.
+	OOB []byte
+	// Addr is the source of reads, and the destination of writes. It's nil for connected sockets.
+	Addr *net.UDPAddr
+	N    int
+	NOOB int
+}
+
+// UDPBatchConn reads and writes datagrams of a UDP socket in batches. On Linux it uses recvmmsg and
+// sendmmsg, and UDP GRO and GSO where the kernel supports them. Elsewhere it moves one datagram per
+// syscall. ReadBatch and WriteBatch may be called concurrently, but neither of them concurrently with
+// itself.
+type UDPBatchConn struct {
+	*net.UDPConn
+	udpBatch
+}
+
+// NewUDPBatchConn creates a UDPBatchConn on conn. gro enables UDP GRO, which takes about 64KB of
+// memory per datagram in a batch, so it's meant for long-lived sockets of listeners.
+func NewUDPBatchConn(conn *net.UDPConn, gro bool) *UDPBatchConn {
+	c := &UDPBatchConn{UDPConn: conn}
+	c.init(gro)
+	return c
+}
+
+func sameUDPAddr(a, b *net.UDPAddr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Port == b.Port && a.IP.Equal(b.IP) && a.Zone == b.Zone
+}
+
+// UDPBatchReader is a buf.Reader of the datagrams read by a UDPBatchConn, with Buffer.UDP set to
+// their sources.
+type UDPBatchReader struct {
+	conn *UDPBatchConn
+	msgs []UDPMessage
+}
+
+// NewUDPBatchReader creates a UDPBatchReader that reads at most size datagrams at once.
+func NewUDPBatchReader(conn *UDPBatchConn, size int) *UDPBatchReader {
+	return &UDPBatchReader{
+		conn: conn,
+		msgs: make([]UDPMessage, size),
+	}
+}
+
+// ReadMultiBuffer implements buf.Reader.
+func (r *UDPBatchReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb := make(buf.MultiBuffer, len(r.msgs))
+	for i := range mb {
+		mb[i] = buf.New()
+		r.msgs[i].Buffer = mb[i].Extend(buf.Size)
+	}
+	n, err := r.conn.ReadBatch(r.msgs)
+	if err != nil {
+		buf.ReleaseMulti(mb)
+		return nil, err
+	}
+	buf.ReleaseMulti(mb[n:])
+	mb = mb[:n]
+	for i, msg := range r.msgs[:n] {
+		mb[i].Resize(0, int32(msg.N))
+		if msg.Addr != nil {
+			source := net.UDPDestination(net.IPAddress(msg.Addr.IP), net.Port(msg.Addr.Port))
+			mb[i].UDP = &source
+		}
+	}
+	return mb, nil
+}
@@ -0,0 +1,274 @@
+//go:build linux
+// +build linux
+
+package internet
+
+import (
+	"encoding/binary"
+	goerrors "errors"
+	"io"
+	"unsafe"
+
+	"github.com/HZ-PRE/XrarCore/common/net"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// udpGROBatchSize is the batch size of reads with GRO, as a coalesced datagram may take 64KB.
+	udpGROBatchSize    = 8
+	udpMaxDatagramSize = 65535
+	udpOOBSize         = 256
+	// udpMaxGSOSegments is the max number of segments that the kernel accepts in a GSO datagram.
+	udpMaxGSOSegments = 64
+	// udpMaxGSOSegmentSize keeps GSO segments within the MTU of Ethernet, as the kernel rejects
+	// segments larger than the path MTU.
+	udpMaxGSOSegmentSize = 1472
+	// udpMaxGSOSize is the max payload of a GSO datagram.
+	udpMaxGSOSize = 65507
+)
+
+// batchPacketConn is implemented by ipv4.PacketConn and ipv6.PacketConn.
+type batchPacketConn interface {
+	ReadBatch(ms []ipv6.Message, flags int) (int, error)
+	WriteBatch(ms []ipv6.Message, flags int) (int, error)
+}
+
+// udpSegment is a segment of a datagram read with GRO.
+type udpSegment struct {
+	buffer []byte
+	oob    []byte
+	addr   *net.UDPAddr
+}
+
+type udpBatch struct {
+	pc  batchPacketConn
+	gro bool
+	gso bool
+
+	rmsgs []ipv6.Message
+	// groBuffers and groOOBs receive coalesced datagrams, which are split into segments.
+	groBuffers [][]byte
+	groOOBs    [][]byte
+	segments   []udpSegment
+	segment    int
+
+	wmsgs []ipv6.Message
+	// wstarts[i] is the index of the first UDPMessage in wmsgs[i].
+	wstarts    []int
+	gsoBuffers [][]byte
+	gsoOOBs    [][]byte
+}
+
+func (c *UDPBatchConn) init(gro bool) {
+	if local, ok := c.LocalAddr().(*net.UDPAddr); ok && local.IP.To4() != nil {
+		c.pc = ipv4.NewPacketConn(c.UDPConn)
+	} else {
+		c.pc = ipv6.NewPacketConn(c.UDPConn)
+	}
+	if rawConn, err := c.SyscallConn(); err == nil {
+		rawConn.Control(func(fd uintptr) {
+			if gro {
+				c.gro = unix.SetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_GRO, 1) == nil
+			}
+			_, err := unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_SEGMENT)
+			c.gso = err == nil
+		})
+	}
+
+	c.rmsgs = newBatchMessages(UDPBatchSize)
+	c.wmsgs = newBatchMessages(UDPBatchSize)
+	c.wstarts = make([]int, UDPBatchSize+1)
+	if c.gro {
+		c.groBuffers = make([][]byte, udpGROBatchSize)
+		c.groOOBs = make([][]byte, udpGROBatchSize)
+		for i := range c.groBuffers {
+			c.groBuffers[i] = make([]byte, udpMaxDatagramSize)
+			c.groOOBs[i] = make([]byte, udpOOBSize)
+		}
+	}
+	if c.gso {
+		c.gsoBuffers = make([][]byte, UDPBatchSize)
+		c.gsoOOBs = make([][]byte, UDPBatchSize)
+		for i := range c.gsoOOBs {
+			c.gsoOOBs[i] = make([]byte, unix.CmsgSpace(2))
+		}
+	}
+}
+
+func newBatchMessages(n int) []ipv6.Message {
+	msgs := make([]ipv6.Message, n)
+	for i := range msgs {
+		msgs[i].Buffers = make([][]byte, 1)
+	}
+	return msgs
+}
+
+// ReadBatch reads datagrams into msgs, and returns the number of them. It reads at least one
+// datagram unless an error occurs.
+func (c *UDPBatchConn) ReadBatch(msgs []UDPMessage) (int, error) {
+	if c.gro {
+		return c.readGRO(msgs)
+	}
+	rmsgs := c.rmsgs[:min(len(msgs), len(c.rmsgs))]
+	for i := range rmsgs {
+		rmsgs[i].Buffers[0] = msgs[i].Buffer
+		rmsgs[i].OOB = msgs[i].OOB
+	}
+	n, err := c.pc.ReadBatch(rmsgs, 0)
+	if err != nil {
+		return 0, err
+	}
+	for i := range n {
+		msgs[i].N, msgs[i].NOOB = rmsgs[i].N, rmsgs[i].NN
+		msgs[i].Addr, _ = rmsgs[i].Addr.(*net.UDPAddr)
+	}
+	return n, nil
+}
+
+func (c *UDPBatchConn) readGRO(msgs []UDPMessage) (int, error) {
+	if c.segment == len(c.segments) {
+		rmsgs := c.rmsgs[:len(c.groBuffers)]
+		for i := range rmsgs {
+			rmsgs[i].Buffers[0] = c.groBuffers[i]
+			rmsgs[i].OOB = c.groOOBs[i]
+		}
+		n, err := c.pc.ReadBatch(rmsgs, 0)
+		if err != nil {
+			return 0, err
+		}
+		c.segments, c.segment = c.segments[:0], 0
+		for _, m := range rmsgs[:n] {
+			addr, _ := m.Addr.(*net.UDPAddr)
+			payload := m.Buffers[0][:m.N]
+			oob := m.OOB[:m.NN]
+			size := groSegmentSize(oob)
+			if size <= 0 || size > len(payload) {
+				size = len(payload)
+			}
+			for {
+				s := min(size, len(payload))
+				c.segments = append(c.segments, udpSegment{buffer: payload[:s], oob: oob, addr: addr})
+				payload = payload[s:]
+				if len(payload) == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	n := 0
+	for ; n < len(msgs) && c.segment < len(c.segments); n++ {
+		s := c.segments[c.segment]
+		c.segment++
+		msgs[n].N = copy(msgs[n].Buffer, s.buffer)
+		msgs[n].NOOB = copy(msgs[n].OOB, s.oob)
+		msgs[n].Addr = s.addr
+	}
+	return n, nil
+}
+
+// groSegmentSize returns the segment size of a datagram coalesced by GRO, or 0 if it isn't.
+func groSegmentSize(oob []byte) int {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for _, msg := range msgs {
+		if msg.Header.Level == unix.SOL_UDP && msg.Header.Type == unix.UDP_GRO && len(msg.Data) >= 4 {
+			return int(int32(binary.NativeEndian.Uint32(msg.Data)))
+		}
+	}
+	return 0
+}
+
+// WriteBatch writes all msgs. Consecutive datagrams of the same size and destination are sent as
+// one GSO datagram if possible.
+func (c *UDPBatchConn) WriteBatch(msgs []UDPMessage) error {
+	gso := c.gso
+	for len(msgs) > 0 {
+		k := c.prepareWrite(msgs, gso)
+		gso = c.gso
+		sent := 0
+		for sent < k {
+			n, err := c.pc.WriteBatch(c.wmsgs[sent:k], 0)
+			sent += n
+			if err == nil && n == 0 {
+				err = io.ErrShortWrite
+			}
+			if err == nil {
+				continue
+			}
+			if sent == k || c.wmsgs[sent].OOB == nil {
+				return err
+			}
+			// Retry the rest without GSO. EIO means that the device doesn't support GSO at all.
+			if goerrors.Is(err, unix.EIO) {
+				c.gso = false
+			}
+			gso = false
+			break
+		}
+		msgs = msgs[c.wstarts[sent]:]
+	}
+	return nil
+}
+
+// prepareWrite fills wmsgs with msgs, and returns the number of wmsgs filled.
+func (c *UDPBatchConn) prepareWrite(msgs []UDPMessage, gso bool) int {
+	k, i := 0, 0
+	for ; i < len(msgs) && k < len(c.wmsgs); k++ {
+		first := &msgs[i]
+		m := &c.wmsgs[k]
+		c.wstarts[k] = i
+		m.Buffers[0] = first.Buffer
+		m.OOB = nil
+		m.Addr = nil
+		if first.Addr != nil {
+			m.Addr = first.Addr
+		}
+		i++
+
+		size := len(first.Buffer)
+		if !gso || size == 0 || size > udpMaxGSOSegmentSize {
+			continue
+		}
+		segments, total := 1, size
+		for i < len(msgs) && segments < udpMaxGSOSegments && sameUDPAddr(msgs[i].Addr, first.Addr) {
+			next := msgs[i].Buffer
+			if len(next) == 0 || len(next) > size || total+len(next) > udpMaxGSOSize {
+				break
+			}
+			if segments == 1 {
+				c.gsoBuffers[k] = append(c.gsoBuffers[k][:0], first.Buffer...)
+			}
+			c.gsoBuffers[k] = append(c.gsoBuffers[k], next...)
+			segments++
+			total += len(next)
+			i++
+			// Only the last segment may be shorter.
+			if len(next) < size {
+				break
+			}
+		}
+		if segments > 1 {
+			m.Buffers[0] = c.gsoBuffers[k]
+			m.OOB = gsoControl(c.gsoOOBs[k], size)
+		}
+	}
+	c.wstarts[k] = i
+	return k
+}
+
+// gsoControl writes the control message of UDP_SEGMENT into b.
+func gsoControl(b []byte, size int) []byte {
+	b = b[:unix.CmsgSpace(2)]
+	clear(b)
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&b[0]))
+	h.Level = unix.SOL_UDP
+	h.Type = unix.UDP_SEGMENT
+	h.SetLen(unix.CmsgLen(2))
+	binary.NativeEndian.PutUint16(b[unix.CmsgLen(0):], uint16(size))
+	return b
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+// +build !linux
+
+package internet
+
+type udpBatch struct{}
+
+func (c *UDPBatchConn) init(gro bool) {}
+
+// ReadBatch reads datagrams into msgs, and returns the number of them. It reads at least one
+// datagram unless an error occurs.
+func (c *UDPBatchConn) ReadBatch(msgs []UDPMessage) (int, error) {
+	m := &msgs[0]
+	n, noob, _, addr, err := c.ReadMsgUDP(m.Buffer, m.OOB)
+	if err != nil {
+		return 0, err
+	}
+	m.N, m.NOOB, m.Addr = n, noob, addr
+	return 1, nil
+}
+
+// WriteBatch writes all msgs.
+func (c *UDPBatchConn) WriteBatch(msgs []UDPMessage) error {
+	for i := range msgs {
+		if _, _, err := c.WriteMsgUDP(msgs[i].Buffer, nil, msgs[i].Addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package internet_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/HZ-PRE/XrarCore/common"
+	"github.com/HZ-PRE/XrarCore/common/net"
+	. "github.com/HZ-PRE/XrarCore/transport/internet"
+)
+
+func listenUDP(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.LocalHostIP.IP()})
+	common.Must(err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUDPBatchConn(t *testing.T) {
+	for _, gro := range []bool{false, true} {
+		sender := NewUDPBatchConn(listenUDP(t), false)
+		receiver := NewUDPBatchConn(listenUDP(t), gro)
+		dest := receiver.LocalAddr().(*net.UDPAddr)
+
+		// Datagrams of the same size may be sent with GSO, and the last one is shorter.
+		var msgs []UDPMessage
+		for i := range 100 {
+			size := 1200
+			if i == 99 {
+				size = 100
+			}
+			msgs = append(msgs, UDPMessage{Buffer: bytes.Repeat([]byte{byte(i)}, size), Addr: dest})
+		}
+		common.Must(sender.WriteBatch(msgs))
+
+		common.Must(receiver.SetReadDeadline(time.Now().Add(5 * time.Second)))
+		received := make([]UDPMessage, 16)
+		for i := range received {
+			received[i].Buffer = make([]byte, 2048)
+		}
+		next := 0
+		for next < len(msgs) {
+			n, err := receiver.ReadBatch(received)
+			if err != nil {
+				t.Fatal("gro=", gro, " received ", next, " datagrams: ", err)
+			}
+			for _, msg := range received[:n] {
+				if !bytes.Equal(msg.Buffer[:msg.N], msgs[next].Buffer) {
+					t.Fatal("gro=", gro, " unexpected datagram ", next, " of ", msg.N, " bytes")
+				}
+				if msg.Addr.Port != sender.LocalAddr().(*net.UDPAddr).Port {
+					t.Error("unexpected source ", msg.Addr)
+				}
+				next++
+			}
+		}
+	}
+}
+
+func TestUDPBatchReader(t *testing.T) {
+	sender := listenUDP(t)
+	receiver := listenUDP(t)
+	for i := range 3 {
+		_, err := sender.WriteTo([]byte{byte(i)}, receiver.LocalAddr())
+		common.Must(err)
+	}
+
+	common.Must(receiver.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	reader := NewUDPBatchReader(NewUDPBatchConn(receiver, false), 8)
+	for i := 0; i < 3; {
+		mb, err := reader.ReadMultiBuffer()
+		common.Must(err)
+		for _, b := range mb {
+			if b.Byte(0) != byte(i) || b.UDP.Port != net.Port(sender.LocalAddr().(*net.UDPAddr).Port) {
+				t.Error("unexpected datagram ", b.Bytes(), " from ", b.UDP)
+			}
+			b.Release()
+			i++
+		}
+	}
+}
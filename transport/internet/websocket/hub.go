@@ -80,6 +80,7 @@ type Listener struct {
 	sync.Mutex
 	server   http.Server
 	listener net.Listener
+	acme     io.Closer
 	config   *Config
 	addConn  internet.ConnHandler
 }
@@ -123,7 +124,8 @@ func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSet
 	}
 
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
+		var tlsConfig *tls.Config
+		if tlsConfig, l.acme = config.GetServerTLSConfig(); tlsConfig != nil {
 			listener = tls.NewListener(listener, tlsConfig)
 		}
 	}
@@ -156,6 +158,7 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	common.Close(ln.acme)
 	return ln.listener.Close()
 }
 